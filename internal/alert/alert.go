@@ -0,0 +1,116 @@
+// Package alert delivers anomaly alerts to notification sinks and remembers
+// which incidents have already been alerted, so that each spike alerts once.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/analyze"
+)
+
+// Sink receives alerts.
+type Sink interface {
+	// Name identifies the sink in error messages.
+	Name() string
+	// Send delivers one alert.
+	Send(ctx context.Context, a analyze.Anomaly) error
+}
+
+// CommandSink runs a shell command for each alert, with the alert as JSON on
+// its stdin and its main fields in DP_ALERT_* environment variables.
+type CommandSink struct {
+	Command string
+}
+
+func (c CommandSink) Name() string { return "command" }
+
+func (c CommandSink) Send(ctx context.Context, a analyze.Anomaly) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"DP_ALERT_KIND="+a.Kind,
+		"DP_ALERT_KEY="+a.Key,
+		"DP_ALERT_TOOL="+a.ToolName,
+		fmt.Sprintf("DP_ALERT_COUNT=%d", a.Count),
+		fmt.Sprintf("DP_ALERT_BASELINE=%.1f", a.Baseline),
+		fmt.Sprintf("DP_ALERT_Z=%.1f", a.Z),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", c.Command, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// FileSink appends each alert to a file as one line of JSON.
+type FileSink struct {
+	Path string
+}
+
+func (f FileSink) Name() string { return "file" }
+
+func (f FileSink) Send(_ context.Context, a analyze.Anomaly) error {
+	line, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WebhookSink POSTs each alert as JSON to a URL. Any 2xx response is
+// success.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client // nil means a client with a 10-second timeout
+}
+
+func (w WebhookSink) Name() string { return "webhook" }
+
+func (w WebhookSink) Send(ctx context.Context, a analyze.Anomaly) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("POST %s: %s: %s", w.URL, resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scbrown/desire-path/internal/analyze"
+)
+
+var spike = analyze.Anomaly{Kind: analyze.AnomalyTool, Key: "read_file", ToolName: "read_file", Count: 20, Baseline: 0.5, StdDev: 1, Z: 19.5}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "alerts.ndjson")
+	sink := FileSink{Path: path}
+	for range 2 {
+		if err := sink.Send(context.Background(), spike); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	var got analyze.Anomaly
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil || got.Key != "read_file" {
+		t.Errorf("line = %s, %v", lines[1], err)
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	sink := CommandSink{Command: `printf '%s %s ' "$DP_ALERT_KEY" "$DP_ALERT_COUNT" > ` + out + ` && cat >> ` + out}
+	if err := sink.Send(context.Background(), spike); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), `read_file 20 {"kind":"tool"`) {
+		t.Errorf("command output = %s", data)
+	}
+
+	err = CommandSink{Command: "echo boom >&2; exit 3"}.Send(context.Background(), spike)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("failing command: err = %v", err)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got analyze.Anomaly
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	t.Cleanup(ts.Close)
+
+	if err := (WebhookSink{URL: ts.URL}).Send(context.Background(), spike); err != nil {
+		t.Fatal(err)
+	}
+	if got.Key != "read_file" || got.Count != 20 {
+		t.Errorf("webhook got %+v", got)
+	}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+	if err := (WebhookSink{URL: down.URL}).Send(context.Background(), spike); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("503 webhook: err = %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/analyze"
+)
+
+// Incident is an anomaly that has been alerted and not yet resolved.
+type Incident struct {
+	Kind     string    `json:"kind"`
+	Key      string    `json:"key"`
+	Alerted  time.Time `json:"alerted"`           // when the incident was first alerted
+	LastSeen time.Time `json:"last_seen"`         // when it was last detected
+	Pending  []string  `json:"pending,omitempty"` // sinks that failed to receive the alert
+
+	opened bool // opened by this run's Update, and not yet delivered
+}
+
+// State records open incidents between runs. An anomaly that matches an open
+// incident is not alerted again; an incident closes once a whole run has
+// gone by without detecting it (see Update).
+type State struct {
+	path      string
+	Incidents map[string]*Incident `json:"incidents"`
+}
+
+func incidentID(kind, key string) string { return kind + ":" + key }
+
+// LoadState reads the state file at path. A missing file is an empty state.
+func LoadState(path string) (*State, error) {
+	st := &State{path: path, Incidents: make(map[string]*Incident)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading alert state: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("parsing alert state %s: %w", path, err)
+	}
+	if st.Incidents == nil {
+		st.Incidents = make(map[string]*Incident)
+	}
+	return st, nil
+}
+
+// Open reports whether a has an open incident.
+func (st *State) Open(a analyze.Anomaly) bool {
+	return st.Incidents[incidentID(a.Kind, a.Key)] != nil
+}
+
+// Update records the anomalies detected at now, by a run every bucket, and
+// returns those that start a new incident, in their original order.
+//
+// Open incidents that have not been detected for more than two buckets are
+// closed first. The next run on schedule comes a bucket and a few seconds
+// after the last, so a single bucket would close every incident before it
+// could be matched; two mean an incident closes only after a whole run
+// without it.
+func (st *State) Update(anomalies []analyze.Anomaly, now time.Time, bucket time.Duration) []analyze.Anomaly {
+	for id, inc := range st.Incidents {
+		if now.Sub(inc.LastSeen) > 2*bucket {
+			delete(st.Incidents, id)
+		}
+	}
+	var fresh []analyze.Anomaly
+	for _, a := range anomalies {
+		id := incidentID(a.Kind, a.Key)
+		if inc := st.Incidents[id]; inc != nil {
+			inc.LastSeen = now
+			continue
+		}
+		st.Incidents[id] = &Incident{Kind: a.Kind, Key: a.Key, Alerted: now, LastSeen: now, opened: true}
+		fresh = append(fresh, a)
+	}
+	return fresh
+}
+
+// Deliver sends a to the sinks its incident is waiting on: every sink for
+// an incident that Update has just opened, otherwise the sinks that failed
+// on an earlier run. Failing sinks are kept pending and retried the next
+// time a is detected, so the ones that succeeded never get it twice. A
+// failing sink doesn't stop the others; the errors are joined.
+func (st *State) Deliver(ctx context.Context, sinks []Sink, a analyze.Anomaly) error {
+	inc := st.Incidents[incidentID(a.Kind, a.Key)]
+	if inc == nil || (!inc.opened && len(inc.Pending) == 0) {
+		return nil
+	}
+	var pending []string
+	var errs []error
+	for _, s := range sinks {
+		if !inc.opened && !slices.Contains(inc.Pending, s.Name()) {
+			continue
+		}
+		if err := s.Send(ctx, a); err != nil {
+			pending = append(pending, s.Name())
+			errs = append(errs, fmt.Errorf("%s alert for %s: %w", s.Name(), a.Key, err))
+		}
+	}
+	inc.opened = false
+	inc.Pending = pending
+	return errors.Join(errs...)
+}
+
+// Save writes the state back to its file, replacing it atomically.
+func (st *State) Save() error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(st.path), 0o755); err != nil {
+		return fmt.Errorf("creating alert state directory: %w", err)
+	}
+	tmp := st.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing alert state: %w", err)
+	}
+	if err := os.Rename(tmp, st.path); err != nil {
+		return fmt.Errorf("writing alert state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/analyze"
+)
+
+func TestStateDedup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	st, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	fp := analyze.Anomaly{Kind: analyze.AnomalyFingerprint, Key: "read_file: unknown tool"}
+
+	if fresh := st.Update([]analyze.Anomaly{spike, fp}, now, time.Hour); len(fresh) != 2 {
+		t.Fatalf("first run: %d fresh, want 2", len(fresh))
+	}
+	if err := st.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reloaded, the same spike ten minutes later is part of the open incident.
+	st, err = LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Open(spike) {
+		t.Error("spike should be open after reload")
+	}
+	if fresh := st.Update([]analyze.Anomaly{spike}, now.Add(10*time.Minute), time.Hour); len(fresh) != 0 {
+		t.Errorf("repeat run: %d fresh, want 0", len(fresh))
+	}
+
+	// The fingerprint was last seen at noon; once a whole hourly run has
+	// missed it, its incident closes and a new spike alerts again. The tool,
+	// seen at 12:10, is still open.
+	fresh := st.Update([]analyze.Anomaly{spike, fp}, now.Add(130*time.Minute), time.Hour)
+	if len(fresh) != 1 || fresh[0].Key != fp.Key {
+		t.Errorf("after quiet period: fresh = %+v, want the fingerprint", fresh)
+	}
+}
+
+func TestStateNextRunOnSchedule(t *testing.T) {
+	st, err := LoadState(filepath.Join(t.TempDir(), "alerts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	if fresh := st.Update([]analyze.Anomaly{spike}, now, time.Hour); len(fresh) != 1 {
+		t.Fatalf("first run: %d fresh, want 1", len(fresh))
+	}
+	// An hourly cron run starts a little more than a bucket later.
+	if fresh := st.Update([]analyze.Anomaly{spike}, now.Add(time.Hour+time.Second), time.Hour); len(fresh) != 0 {
+		t.Errorf("next scheduled run alerted again: %+v", fresh)
+	}
+}
+
+// flakySink fails while down is set, and counts what it receives.
+type flakySink struct {
+	name string
+	down bool
+	got  int
+}
+
+func (f *flakySink) Name() string { return f.name }
+
+func (f *flakySink) Send(context.Context, analyze.Anomaly) error {
+	if f.down {
+		return errors.New("unreachable")
+	}
+	f.got++
+	return nil
+}
+
+func TestStateDeliverRetriesFailedSinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	st, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	file := &flakySink{name: "file"}
+	hook := &flakySink{name: "webhook", down: true}
+	sinks := []Sink{hook, file}
+
+	st.Update([]analyze.Anomaly{spike}, now, time.Hour)
+	err = st.Deliver(ctx, sinks, spike)
+	if err == nil || !strings.Contains(err.Error(), "webhook alert for read_file") {
+		t.Errorf("err = %v", err)
+	}
+	if file.got != 1 {
+		t.Errorf("file sink skipped after webhook failure: got %d", file.got)
+	}
+	if err := st.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Next run: only the webhook is retried.
+	st, err = LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.down = false
+	next := now.Add(time.Hour)
+	if fresh := st.Update([]analyze.Anomaly{spike}, next, time.Hour); len(fresh) != 0 {
+		t.Errorf("retry run: fresh = %+v, want none", fresh)
+	}
+	if err := st.Deliver(ctx, sinks, spike); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if file.got != 1 || hook.got != 1 {
+		t.Errorf("file got %d, webhook got %d; want 1 each", file.got, hook.got)
+	}
+
+	// Delivered everywhere: nothing more is sent.
+	st.Update([]analyze.Anomaly{spike}, next.Add(time.Hour), time.Hour)
+	if err := st.Deliver(ctx, sinks, spike); err != nil || file.got != 1 || hook.got != 1 {
+		t.Errorf("repeat: err %v, file %d, webhook %d", err, file.got, hook.got)
+	}
+}
+
+func TestLoadStateCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(path); err == nil {
+		t.Error("corrupt state should fail to load")
+	}
+}
@@ -0,0 +1,201 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// Anomaly kinds: a spike in one tool's desires, or in one error fingerprint.
+const (
+	AnomalyTool        = "tool"
+	AnomalyFingerprint = "fingerprint"
+)
+
+// Defaults for AnomalyOpts.
+const (
+	DefaultAnomalyBuckets   = 24
+	DefaultAnomalyAlpha     = 0.3
+	DefaultAnomalyThreshold = 3.0
+	DefaultAnomalyMinCount  = 5
+)
+
+// AnomalyOpts controls DetectAnomalies. The current bucket is the Bucket
+// ending at Now; the baseline is the Buckets buckets before it.
+type AnomalyOpts struct {
+	Bucket    time.Duration // Bucket length; 0 means one hour.
+	Buckets   int           // Baseline buckets; 0 means DefaultAnomalyBuckets.
+	Alpha     float64       // EWMA smoothing factor in (0, 1]; 0 means DefaultAnomalyAlpha.
+	Threshold float64       // Minimum z-score; 0 means DefaultAnomalyThreshold.
+	MinCount  int           // Minimum desires in the current bucket; 0 means DefaultAnomalyMinCount.
+	Now       time.Time     // End of the current bucket; zero means now.
+}
+
+// Anomaly is a tool or error fingerprint whose desires in the current bucket
+// are well above its baseline.
+type Anomaly struct {
+	Kind     string    `json:"kind"` // "tool" or "fingerprint"
+	Key      string    `json:"key"`  // tool name, or fingerprint
+	ToolName string    `json:"tool_name"`
+	Count    int       `json:"count"`    // desires in the current bucket
+	Baseline float64   `json:"baseline"` // EWMA of the baseline buckets
+	StdDev   float64   `json:"stddev"`
+	Z        float64   `json:"z"`
+	Start    time.Time `json:"start"` // start of the current bucket
+	End      time.Time `json:"end"`
+}
+
+// DetectAnomalies compares each tool's and each error fingerprint's desire
+// count in the current bucket with an exponentially weighted moving average
+// of the buckets before it. A series is anomalous when its current count is
+// at least MinCount and Threshold standard deviations above the average.
+//
+// The standard deviation is the EWMA deviation of the baseline, but never
+// less than the square root of the average (the spread of a Poisson process
+// at that rate) or 1, so a quiet, steady baseline doesn't turn a handful of
+// failures into an alert. Anomalies are returned highest z-score first.
+func DetectAnomalies(ctx context.Context, s store.Store, opts AnomalyOpts) ([]Anomaly, error) {
+	if opts.Bucket <= 0 {
+		opts.Bucket = time.Hour
+	}
+	if opts.Buckets <= 0 {
+		opts.Buckets = DefaultAnomalyBuckets
+	}
+	if opts.Alpha <= 0 || opts.Alpha > 1 {
+		opts.Alpha = DefaultAnomalyAlpha
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultAnomalyThreshold
+	}
+	if opts.MinCount <= 0 {
+		opts.MinCount = DefaultAnomalyMinCount
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	end := opts.Now.UTC()
+	start := end.Add(-opts.Bucket)
+	from := start.Add(-time.Duration(opts.Buckets) * opts.Bucket)
+
+	desires, err := s.ListDesires(ctx, store.ListOpts{Since: from})
+	if err != nil {
+		return nil, fmt.Errorf("listing desires: %w", err)
+	}
+
+	// counts[key][i] is the count in bucket i: 0..Buckets-1 are the
+	// baseline, oldest first, and Buckets is the current bucket.
+	type series struct {
+		kind, tool string
+		counts     []int
+	}
+	all := make(map[string]*series)
+	add := func(kind, key, tool string, i int) {
+		id := kind + "\x00" + key
+		sr := all[id]
+		if sr == nil {
+			sr = &series{kind: kind, tool: tool, counts: make([]int, opts.Buckets+1)}
+			all[id] = sr
+		}
+		sr.counts[i]++
+	}
+	for _, d := range desires {
+		t := d.Timestamp.UTC()
+		if t.Before(from) || !t.Before(end) {
+			continue
+		}
+		i := int(t.Sub(from) / opts.Bucket)
+		add(AnomalyTool, d.ToolName, d.ToolName, i)
+		add(AnomalyFingerprint, Fingerprint(d.ToolName, d.Error), d.ToolName, i)
+	}
+
+	var out []Anomaly
+	for id, sr := range all {
+		count := sr.counts[opts.Buckets]
+		if count < opts.MinCount {
+			continue
+		}
+		mean, sd := ewma(sr.counts[:opts.Buckets], opts.Alpha)
+		sd = math.Max(sd, math.Max(math.Sqrt(mean), 1))
+		z := (float64(count) - mean) / sd
+		if z < opts.Threshold {
+			continue
+		}
+		out = append(out, Anomaly{
+			Kind:     sr.kind,
+			Key:      id[strings.IndexByte(id, 0)+1:],
+			ToolName: sr.tool,
+			Count:    count,
+			Baseline: mean,
+			StdDev:   sd,
+			Z:        z,
+			Start:    start,
+			End:      end,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Z != out[j].Z {
+			return out[i].Z > out[j].Z
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind > out[j].Kind // tool before fingerprint
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
+
+// ewma returns the exponentially weighted moving average and standard
+// deviation of xs, oldest first, with smoothing factor alpha.
+func ewma(xs []int, alpha float64) (mean, sd float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	mean = float64(xs[0])
+	var variance float64
+	for _, x := range xs[1:] {
+		diff := float64(x) - mean
+		incr := alpha * diff
+		mean += incr
+		variance = (1 - alpha) * (variance + diff*incr)
+	}
+	return mean, math.Sqrt(variance)
+}
+
+// maxFingerprintLen caps the normalized error part of a fingerprint.
+const maxFingerprintLen = 80
+
+// Fingerprint normalization patterns, applied in order.
+var fingerprintPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`"), "<str>"},
+	{regexp.MustCompile(`(?:~|\.{1,2})?(?:/[^\s:,;)]+)+`), "<path>"},
+	{regexp.MustCompile(`\b0x[0-9a-f]+\b|\b[0-9a-f]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// Fingerprint groups desires whose errors differ only in detail: it is the
+// tool name and the error message with quoted strings, paths, hex IDs, and
+// numbers replaced by placeholders, e.g. "Read: file not found: <path>".
+func Fingerprint(toolName, errorMsg string) string {
+	msg := strings.ToLower(errorMsg)
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		msg = msg[:i]
+	}
+	for _, p := range fingerprintPatterns {
+		msg = p.re.ReplaceAllString(msg, p.repl)
+	}
+	msg = strings.TrimSpace(msg)
+	if len(msg) > maxFingerprintLen {
+		msg = strings.ToValidUTF8(msg[:maxFingerprintLen], "")
+	}
+	return toolName + ": " + msg
+}
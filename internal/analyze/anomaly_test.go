@@ -0,0 +1,91 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		tool, err, want string
+	}{
+		{"Read", "File does not exist: /home/u/src/main.go", "Read: file does not exist: <path>"},
+		{"Read", "File does not exist: ./cmd/dp/main.go", "Read: file does not exist: <path>"},
+		{"Bash", "exit status 127\nstderr follows", "Bash: exit status <n>"},
+		{"mcp__db__query", `relation "users" does not exist`, "mcp__db__query: relation <str> does not exist"},
+		{"Task", "agent 3f9a0c12e4 timed out after 300s", "Task: agent <hex> timed out after <n>s"},
+		{"Edit", "  old_string   not found  ", "Edit: old_string not found"},
+	}
+	for _, tt := range tests {
+		if got := Fingerprint(tt.tool, tt.err); got != tt.want {
+			t.Errorf("Fingerprint(%q, %q) = %q, want %q", tt.tool, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	var desires []model.Desire
+	add := func(tool, errMsg string, at time.Time) {
+		desires = append(desires, model.Desire{
+			ID: fmt.Sprintf("d%d", len(desires)), ToolName: tool, Error: errMsg, Timestamp: at,
+		})
+	}
+	// Grep fails 6 times an hour, steadily, and 7 times in the last hour.
+	for h := 1; h <= 24; h++ {
+		for i := range 6 {
+			add("Grep", "no matches", now.Add(-time.Duration(h)*time.Hour-time.Duration(i)*time.Minute))
+		}
+	}
+	for i := range 7 {
+		add("Grep", "no matches", now.Add(-time.Duration(i+1)*time.Minute))
+	}
+	// read_file failed once a few hours ago, then 20 times in the last hour.
+	add("read_file", "unknown tool", now.Add(-5*time.Hour))
+	for i := range 20 {
+		add("read_file", fmt.Sprintf("open /tmp/f%d: no such file", i), now.Add(-time.Duration(i+1)*time.Minute))
+	}
+	// Too old, and in the future: both ignored.
+	add("web_fetch", "x", now.Add(-48*time.Hour))
+	add("web_fetch", "x", now.Add(time.Minute))
+
+	got, err := DetectAnomalies(context.Background(), &mockStore{desires: desires}, AnomalyOpts{Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d anomalies, want 2: %+v", len(got), got)
+	}
+	// The fingerprint is brand new, so it scores above the tool, which had
+	// one earlier failure.
+	if got[0].Kind != AnomalyFingerprint || got[0].Key != "read_file: open <path>: no such file" || got[0].ToolName != "read_file" {
+		t.Errorf("first anomaly = %+v, want read_file fingerprint", got[0])
+	}
+	if got[1].Kind != AnomalyTool || got[1].Key != "read_file" || got[1].Count != 20 {
+		t.Errorf("second anomaly = %+v, want tool read_file", got[1])
+	}
+	if got[1].Z < DefaultAnomalyThreshold || !got[1].Start.Equal(now.Add(-time.Hour)) {
+		t.Errorf("anomaly z = %.1f, start = %s", got[1].Z, got[1].Start)
+	}
+
+	// A higher minimum count silences it.
+	got, err = DetectAnomalies(context.Background(), &mockStore{desires: desires}, AnomalyOpts{Now: now, MinCount: 21})
+	if err != nil || len(got) != 0 {
+		t.Errorf("MinCount 21: %+v, %v", got, err)
+	}
+}
+
+func TestEWMA(t *testing.T) {
+	mean, sd := ewma([]int{4, 4, 4, 4}, 0.3)
+	if mean != 4 || sd != 0 {
+		t.Errorf("steady: mean = %v, sd = %v", mean, sd)
+	}
+	mean, sd = ewma([]int{0, 10, 0, 10}, 0.5)
+	if mean <= 0 || mean >= 10 || sd == 0 {
+		t.Errorf("alternating: mean = %v, sd = %v", mean, sd)
+	}
+}
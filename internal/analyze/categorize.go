@@ -18,6 +18,18 @@ var envNeedPatterns = []*regexp.Regexp{
 	regexp.MustCompile(`(?i)not installed`),
 }
 
+// timeoutPatterns matches error messages from calls that ran out of time.
+// They avoid a bare "timeout", which also shows up in errors about an
+// unknown --timeout flag.
+var timeoutPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)timed out`),
+	regexp.MustCompile(`(?i)\btimeout (?:exceeded|expired|reached|after)\b`),
+	regexp.MustCompile(`(?i)\b(?:exceeded|reached) (?:the )?timeout\b`),
+	regexp.MustCompile(`(?i)deadline exceeded`),
+	regexp.MustCompile(`\bETIMEDOUT\b`),
+	regexp.MustCompile(`exit (?:code |status )?124\b`), // timeout(1)
+}
+
 // Command extraction patterns, precompiled for reuse.
 var (
 	reShellNotFound  = regexp.MustCompile(`(?:bash|sh|/bin/\w+):\s+(\S+):\s+(?:command )?not found`)
@@ -28,12 +40,26 @@ var (
 // CategorizeDesire returns the category for a desire based on its error
 // message and tool context. Returns empty string if no category matches.
 func CategorizeDesire(toolName, errorMsg string, toolInput json.RawMessage) string {
+	if IsTimeout(errorMsg) {
+		return model.CategoryTimeout
+	}
 	if isEnvNeed(toolName, errorMsg) {
 		return model.CategoryEnvNeed
 	}
 	return ""
 }
 
+// IsTimeout reports whether errorMsg says the call ran out of time. Any tool
+// can time out, so unlike env-need this doesn't depend on the tool.
+func IsTimeout(errorMsg string) bool {
+	for _, pat := range timeoutPatterns {
+		if pat.MatchString(errorMsg) {
+			return true
+		}
+	}
+	return false
+}
+
 // isEnvNeed detects "command not found" style errors from Bash tool calls.
 func isEnvNeed(toolName, errorMsg string) bool {
 	if toolName != "Bash" {
@@ -86,6 +86,29 @@ func TestCategorizeDesire_EnvNeed(t *testing.T) {
 	}
 }
 
+func TestCategorizeDesire_Timeout(t *testing.T) {
+	tests := []struct {
+		tool    string
+		errMsg  string
+		wantCat string
+	}{
+		{"Bash", "Command timed out after 2m 0.0s", model.CategoryTimeout},
+		{"WebFetch", "Request timed out", model.CategoryTimeout},
+		{"mcp__db__query", "context deadline exceeded", model.CategoryTimeout},
+		{"Bash", "exit status 124", model.CategoryTimeout},
+		{"WebFetch", "connect ETIMEDOUT 10.0.0.1:443", model.CategoryTimeout},
+		{"Task", "agent exceeded the timeout", model.CategoryTimeout},
+		// A hallucinated flag is not a timeout.
+		{"Bash", "error: unknown option '--timeout'", ""},
+		{"Bash", "exit status 127", model.CategoryEnvNeed},
+	}
+	for _, tt := range tests {
+		if got := CategorizeDesire(tt.tool, tt.errMsg, nil); got != tt.wantCat {
+			t.Errorf("CategorizeDesire(%q, %q) = %q, want %q", tt.tool, tt.errMsg, got, tt.wantCat)
+		}
+	}
+}
+
 func TestEnvNeedCommand(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,228 @@
+package analyze
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// Defaults for MineOpts.
+const (
+	DefaultMineConfidence  = 0.5
+	DefaultMineMinSteps    = 3
+	DefaultMineMaxSteps    = 8
+	DefaultMineMaxPatterns = 5000
+)
+
+// MineOpts controls MineTurnPatterns.
+type MineOpts struct {
+	MinSupport    int     // Minimum distinct sessions; 0 means MinPatternSessions.
+	MinConfidence float64 // Minimum confidence in (0, 1]; 0 means DefaultMineConfidence.
+	MinSteps      int     // Shortest pattern reported; 0 means DefaultMineMinSteps.
+	MaxSteps      int     // Longest pattern mined; 0 means DefaultMineMaxSteps.
+	MaxPatterns   int     // Frequent patterns mined before stopping; 0 means DefaultMineMaxPatterns.
+}
+
+// MinedPattern is a recurring sub-workflow: tool steps that appear in this
+// order, not necessarily adjacent, in turns across several sessions.
+type MinedPattern struct {
+	Steps    []string `json:"steps"`
+	Pattern  string   `json:"pattern"`  // steps joined with " → "
+	Sessions int      `json:"sessions"` // support: distinct sessions with a matching turn
+	Turns    int      `json:"turns"`
+	// Confidence is the share of sessions matching all steps but the last
+	// that go on to the last step.
+	Confidence float64 `json:"confidence"`
+}
+
+// MineTurnPatterns finds frequent tool subsequences in turns with
+// PrefixSpan. Runs of the same tool are collapsed first, so "Read → Read →
+// Edit" and "Read → Edit" are the same workflow, and steps may be separated
+// by other calls, so near-identical turns cluster where exact patterns
+// (TurnPatternStats) don't.
+//
+// Support is counted in distinct sessions; turns without a session count as
+// a session each. Only closed patterns are returned: a pattern is dropped
+// when a longer one containing it has the same support and also meets
+// MinConfidence. Results are ordered by support, then length, then
+// confidence.
+//
+// Long, varied turns can hold exponentially many frequent subsequences, so
+// mining stops after MaxPatterns of them and the result is then partial.
+func MineTurnPatterns(turns []store.TurnRow, opts MineOpts) []MinedPattern {
+	opts = opts.withDefaults()
+	db := make([]mineSeq, 0, len(turns))
+	for _, t := range turns {
+		session := t.SessionID
+		if session == "" {
+			session = "\x00" + t.TurnID
+		}
+		db = append(db, mineSeq{session: session, items: collapseRuns(strings.Split(t.Tools, " → "))})
+	}
+
+	root := make([]projection, len(db))
+	for i := range db {
+		root[i] = projection{seq: i}
+	}
+	var frequent []MinedPattern
+	m := miner{db: db, opts: opts}
+	m.grow(nil, root, 0, func(p MinedPattern) { frequent = append(frequent, p) })
+
+	// Closedness is judged among confident patterns only, so a pattern is
+	// never dropped in favour of a longer one that isn't reported.
+	var confident []MinedPattern
+	for _, p := range frequent {
+		if p.Confidence >= opts.MinConfidence {
+			confident = append(confident, p)
+		}
+	}
+	var mined []MinedPattern
+	for _, p := range confident {
+		if len(p.Steps) < opts.MinSteps || !isClosed(p, confident) {
+			continue
+		}
+		mined = append(mined, p)
+	}
+	sort.SliceStable(mined, func(i, j int) bool {
+		a, b := mined[i], mined[j]
+		if a.Sessions != b.Sessions {
+			return a.Sessions > b.Sessions
+		}
+		if len(a.Steps) != len(b.Steps) {
+			return len(a.Steps) > len(b.Steps)
+		}
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		return a.Pattern < b.Pattern
+	})
+	return mined
+}
+
+func (o MineOpts) withDefaults() MineOpts {
+	if o.MinSupport <= 0 {
+		o.MinSupport = MinPatternSessions
+	}
+	if o.MinConfidence <= 0 {
+		o.MinConfidence = DefaultMineConfidence
+	}
+	if o.MinSteps <= 0 {
+		o.MinSteps = DefaultMineMinSteps
+	}
+	if o.MaxSteps <= 0 {
+		o.MaxSteps = DefaultMineMaxSteps
+	}
+	if o.MaxPatterns <= 0 {
+		o.MaxPatterns = DefaultMineMaxPatterns
+	}
+	return o
+}
+
+// mineSeq is one turn's collapsed tool sequence.
+type mineSeq struct {
+	session string
+	items   []string
+}
+
+// projection is the suffix of a sequence after a prefix's first match.
+type projection struct {
+	seq, pos int
+}
+
+type miner struct {
+	db    []mineSeq
+	opts  MineOpts
+	found int // frequent patterns emitted so far
+}
+
+// grow extends prefix by every step frequent in its projected database,
+// emitting each extension and recursing until MaxSteps, or until
+// MaxPatterns have been emitted. support is the prefix's own session count,
+// used for confidence.
+func (m *miner) grow(prefix []string, proj []projection, support int, emit func(MinedPattern)) {
+	// For each next step, project every sequence at its first occurrence.
+	next := make(map[string][]projection)
+	for _, p := range proj {
+		items := m.db[p.seq].items
+		seen := make(map[string]bool)
+		for i := p.pos; i < len(items); i++ {
+			if seen[items[i]] {
+				continue
+			}
+			seen[items[i]] = true
+			next[items[i]] = append(next[items[i]], projection{seq: p.seq, pos: i + 1})
+		}
+	}
+
+	steps := make([]string, 0, len(next))
+	for step := range next {
+		steps = append(steps, step)
+	}
+	sort.Strings(steps)
+	for _, step := range steps {
+		ext := next[step]
+		sessions := make(map[string]bool)
+		for _, p := range ext {
+			sessions[m.db[p.seq].session] = true
+		}
+		if len(sessions) < m.opts.MinSupport {
+			continue
+		}
+		if m.found >= m.opts.MaxPatterns {
+			return
+		}
+		m.found++
+		pattern := append(append([]string(nil), prefix...), step)
+		confidence := 1.0
+		if support > 0 {
+			confidence = math.Round(float64(len(sessions))/float64(support)*1000) / 1000
+		}
+		emit(MinedPattern{
+			Steps:      pattern,
+			Pattern:    strings.Join(pattern, " → "),
+			Sessions:   len(sessions),
+			Turns:      len(ext),
+			Confidence: confidence,
+		})
+		if len(pattern) < m.opts.MaxSteps {
+			m.grow(pattern, ext, len(sessions), emit)
+		}
+	}
+}
+
+// isClosed reports whether no longer pattern in all contains p with the
+// same support.
+func isClosed(p MinedPattern, all []MinedPattern) bool {
+	for _, q := range all {
+		if len(q.Steps) > len(p.Steps) && q.Sessions == p.Sessions && isSubsequence(p.Steps, q.Steps) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSubsequence reports whether sub appears in seq in order, not
+// necessarily adjacent.
+func isSubsequence(sub, seq []string) bool {
+	i := 0
+	for _, s := range seq {
+		if i < len(sub) && sub[i] == s {
+			i++
+		}
+	}
+	return i == len(sub)
+}
+
+// collapseRuns replaces each run of the same step with a single one.
+func collapseRuns(steps []string) []string {
+	var out []string
+	for _, s := range steps {
+		if s == "" || (len(out) > 0 && out[len(out)-1] == s) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package analyze
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func TestMineTurnPatterns(t *testing.T) {
+	turns := []store.TurnRow{
+		{TurnID: "a:0", SessionID: "a", Tools: "Grep → Read → Read → Edit → Bash"},
+		{TurnID: "b:0", SessionID: "b", Tools: "Glob → Grep → Read → Edit"},
+		{TurnID: "c:0", SessionID: "c", Tools: "Grep → Read → Write → Edit → Bash"},
+		{TurnID: "c:1", SessionID: "c", Tools: "Grep → Read → Edit"},
+		{TurnID: "d:0", SessionID: "d", Tools: "Bash → Bash → Bash"},
+	}
+
+	mined := MineTurnPatterns(turns, MineOpts{MinSupport: 2})
+	var patterns []string
+	for _, m := range mined {
+		patterns = append(patterns, m.Pattern)
+	}
+	// "Grep → Read → Edit" is in every session but d; "Grep → Read →
+	// Edit → Bash" in a and c. Shorter patterns with the same support,
+	// like "Read → Edit", are not closed.
+	want := []string{"Grep → Read → Edit", "Grep → Read → Edit → Bash"}
+	if !reflect.DeepEqual(patterns, want) {
+		t.Fatalf("patterns = %q, want %q", patterns, want)
+	}
+
+	top := mined[0]
+	if top.Sessions != 3 || top.Turns != 4 || top.Confidence != 1 {
+		t.Errorf("top = %+v", top)
+	}
+	if !reflect.DeepEqual(top.Steps, []string{"Grep", "Read", "Edit"}) {
+		t.Errorf("Steps = %q", top.Steps)
+	}
+	if got := mined[1].Confidence; got != 0.667 {
+		t.Errorf("confidence of Bash after Grep → Read → Edit = %v, want 0.667", got)
+	}
+}
+
+func TestMineTurnPatternsThresholds(t *testing.T) {
+	turns := []store.TurnRow{
+		{TurnID: "a:0", SessionID: "a", Tools: "Grep → Read → Edit → Bash"},
+		{TurnID: "b:0", SessionID: "b", Tools: "Grep → Read → Edit → Bash"},
+		{TurnID: "c:0", SessionID: "c", Tools: "Grep → Read → Edit"},
+		{TurnID: "c:1", SessionID: "c", Tools: "Grep → Read → Edit"},
+	}
+
+	// Two turns of one session count once.
+	if mined := MineTurnPatterns(turns, MineOpts{MinSupport: 4}); len(mined) != 0 {
+		t.Errorf("MinSupport 4: got %+v", mined)
+	}
+
+	// Bash follows Grep → Read → Edit in 2 of 3 sessions.
+	mined := MineTurnPatterns(turns, MineOpts{MinSupport: 2, MinConfidence: 0.7})
+	if len(mined) != 1 || mined[0].Pattern != "Grep → Read → Edit" {
+		t.Errorf("MinConfidence 0.7: got %+v", mined)
+	}
+
+	// Every ordered pair: three in all sessions, three ending in Bash.
+	mined = MineTurnPatterns(turns, MineOpts{MinSupport: 2, MinSteps: 2, MaxSteps: 2})
+	if len(mined) != 6 {
+		t.Errorf("MaxSteps 2: got %+v", mined)
+	}
+	mined = MineTurnPatterns(turns, MineOpts{MinSupport: 2, MinSteps: 5})
+	if len(mined) != 0 {
+		t.Errorf("MinSteps 5: got %+v", mined)
+	}
+}
+
+func TestMineTurnPatternsMaxPatterns(t *testing.T) {
+	turns := []store.TurnRow{
+		{TurnID: "a:0", SessionID: "a", Tools: "Grep → Read → Edit"},
+		{TurnID: "b:0", SessionID: "b", Tools: "Grep → Read → Edit"},
+	}
+	if mined := MineTurnPatterns(turns, MineOpts{MinSupport: 2}); len(mined) != 1 {
+		t.Fatalf("uncapped: %+v, want Grep → Read → Edit", mined)
+	}
+	// Mining stops at "Edit", before any pattern is long enough.
+	if mined := MineTurnPatterns(turns, MineOpts{MinSupport: 2, MaxPatterns: 1}); len(mined) != 0 {
+		t.Errorf("MaxPatterns 1: %+v, want none", mined)
+	}
+}
+
+func TestMineTurnPatternsNoSession(t *testing.T) {
+	turns := []store.TurnRow{
+		{TurnID: "t1", Tools: "Grep → Read → Edit"},
+		{TurnID: "t2", Tools: "Grep → Read → Edit"},
+		{TurnID: "t3", Tools: "Grep → Read → Edit"},
+	}
+	mined := MineTurnPatterns(turns, MineOpts{})
+	if len(mined) != 1 || mined[0].Sessions != 3 {
+		t.Errorf("turns without a session should each count: %+v", mined)
+	}
+}
+
+func TestCollapseRuns(t *testing.T) {
+	got := collapseRuns([]string{"Read", "Read", "Edit", "Read", "Read", ""})
+	if want := []string{"Read", "Edit", "Read"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("collapseRuns = %q, want %q", got, want)
+	}
+}
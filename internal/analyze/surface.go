@@ -16,6 +16,10 @@ import (
 // must appear in before it gets surfaced as a desire path.
 const MinPatternSessions = 3
 
+// MaxMinedDesires caps how many sub-workflows SurfaceMinedPatternDesires
+// records in one run.
+const MaxMinedDesires = 10
+
 // SurfaceTurnPatternDesires detects recurring turn patterns and creates Desire
 // records for patterns that appear 3+ times across sessions with turn length
 // exceeding the threshold. Returns the newly created desires.
@@ -28,18 +32,9 @@ func SurfaceTurnPatternDesires(ctx context.Context, s store.Store, threshold int
 	if err != nil {
 		return nil, fmt.Errorf("querying turn patterns: %w", err)
 	}
-
-	// Load existing turn-pattern desires for dedup.
-	existing, err := s.ListDesires(ctx, store.ListOpts{Category: model.CategoryTurnPattern})
+	seen, err := surfacedPatterns(ctx, s)
 	if err != nil {
-		return nil, fmt.Errorf("listing existing turn-pattern desires: %w", err)
-	}
-	seen := make(map[string]bool, len(existing))
-	for _, d := range existing {
-		p := extractPattern(d.Metadata)
-		if p != "" {
-			seen[p] = true
-		}
+		return nil, err
 	}
 
 	var created []model.Desire
@@ -75,10 +70,104 @@ func SurfaceTurnPatternDesires(ctx context.Context, s store.Store, threshold int
 		created = append(created, d)
 		seen[p.Pattern] = true
 	}
+	return created, nil
+}
+
+// SurfaceMinedPatternDesires records sub-workflows found by
+// MineTurnPatterns as turn-pattern desires, when they span more sessions
+// than any exact pattern (of turns of at least threshold calls) containing
+// them: that is, when mining clusters turns the exact patterns keep apart.
+// At most MaxMinedDesires are recorded per run, in mined's order. Returns
+// the newly created desires.
+//
+// Mining is too costly to run on every ingest, so this runs on request
+// ('dp turns --mine --surface'). Like SurfaceTurnPatternDesires, it skips
+// patterns that already have a turn-pattern desire.
+func SurfaceMinedPatternDesires(ctx context.Context, s store.Store, mined []MinedPattern, threshold int) ([]model.Desire, error) {
+	patterns, err := s.TurnPatternStats(ctx, store.TurnOpts{MinLength: threshold})
+	if err != nil {
+		return nil, fmt.Errorf("querying turn patterns: %w", err)
+	}
+	seen, err := surfacedPatterns(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []model.Desire
+	for _, m := range mined {
+		if len(created) == MaxMinedDesires {
+			break
+		}
+		if seen[m.Pattern] || coveredByPattern(m, patterns) {
+			continue
+		}
+
+		meta, _ := json.Marshal(map[string]any{
+			"pattern":    m.Pattern,
+			"mined":      true,
+			"turns":      m.Turns,
+			"sessions":   m.Sessions,
+			"confidence": m.Confidence,
+		})
 
+		d := model.Desire{
+			ID:       uuid.New().String(),
+			ToolName: m.Steps[0],
+			Error: fmt.Sprintf("Recurring sub-workflow: %s (in %d turns across %d sessions, %.0f%% confidence)",
+				m.Pattern, m.Turns, m.Sessions, m.Confidence*100),
+			Category:  model.CategoryTurnPattern,
+			Source:    "transcript-analysis",
+			Timestamp: time.Now(),
+			Metadata:  meta,
+		}
+
+		if err := s.RecordDesire(ctx, d); err != nil {
+			return nil, fmt.Errorf("recording turn-pattern desire: %w", err)
+		}
+		created = append(created, d)
+		seen[m.Pattern] = true
+	}
 	return created, nil
 }
 
+// surfacedPatterns returns the patterns that already have a turn-pattern
+// desire.
+func surfacedPatterns(ctx context.Context, s store.Store) (map[string]bool, error) {
+	existing, err := s.ListDesires(ctx, store.ListOpts{Category: model.CategoryTurnPattern})
+	if err != nil {
+		return nil, fmt.Errorf("listing existing turn-pattern desires: %w", err)
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, d := range existing {
+		p := extractPattern(d.Metadata)
+		if p != "" {
+			seen[p] = true
+		}
+	}
+	return seen, nil
+}
+
+// coveredByPattern reports whether an exact turn pattern containing m's
+// steps already spans at least as many sessions.
+func coveredByPattern(m MinedPattern, patterns []store.TurnPattern) bool {
+	for _, p := range patterns {
+		if p.Sessions < m.Sessions {
+			continue
+		}
+		var steps []string
+		for _, step := range strings.Split(p.Pattern, " → ") {
+			if idx := strings.Index(step, "{"); idx >= 0 {
+				step = step[:idx]
+			}
+			steps = append(steps, step)
+		}
+		if isSubsequence(m.Steps, steps) {
+			return true
+		}
+	}
+	return false
+}
+
 // firstTool extracts the first tool name from an abstract pattern like
 // "Grep → Read{2+} → Edit", returning "Grep".
 func firstTool(pattern string) string {
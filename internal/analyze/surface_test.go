@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,9 +17,16 @@ import (
 // mockStore implements store.Store for surface testing. Only the methods
 // used by SurfaceTurnPatternDesires need real implementations.
 type mockStore struct {
-	patterns []store.TurnPattern
-	desires  []model.Desire
-	recorded []model.Desire
+	patterns  []store.TurnPattern
+	turns     []store.TurnRow
+	turnCalls int // ListTurns calls
+	desires   []model.Desire
+	recorded  []model.Desire
+}
+
+func (m *mockStore) ListTurns(_ context.Context, _ store.TurnOpts) ([]store.TurnRow, error) {
+	m.turnCalls++
+	return m.turns, nil
 }
 
 func (m *mockStore) TurnPatternStats(_ context.Context, _ store.TurnOpts) ([]store.TurnPattern, error) {
@@ -45,14 +54,12 @@ func (m *mockStore) RecordDesire(_ context.Context, d model.Desire) error {
 
 // Unused Store methods — satisfy the interface.
 func (m *mockStore) GetPaths(context.Context, store.PathOpts) ([]model.Path, error)            { return nil, nil }
+func (m *mockStore) PathTrends(context.Context, store.TrendOpts) (*store.TrendResult, error) { return nil, nil }
 func (m *mockStore) SetAlias(context.Context, model.Alias) error                               { return nil }
-func (m *mockStore) GetAlias(context.Context, string, string, string, string, string) (*model.Alias, error) {
-	return nil, nil
-}
+func (m *mockStore) GetAlias(context.Context, model.AliasKey) (*model.Alias, error) { return nil, nil }
+func (m *mockStore) GetToolAliases(context.Context, string) ([]model.Alias, error) { return nil, nil }
 func (m *mockStore) GetAliases(context.Context) ([]model.Alias, error)                            { return nil, nil }
-func (m *mockStore) DeleteAlias(context.Context, string, string, string, string, string) (bool, error) {
-	return false, nil
-}
+func (m *mockStore) DeleteAlias(context.Context, model.AliasKey) (bool, error) { return false, nil }
 func (m *mockStore) GetRulesForTool(context.Context, string) ([]model.Alias, error)               { return nil, nil }
 func (m *mockStore) Stats(context.Context) (store.Stats, error)                                   { return store.Stats{}, nil }
 func (m *mockStore) InspectPath(context.Context, store.InspectOpts) (*store.InspectResult, error) { return nil, nil }
@@ -63,11 +70,12 @@ func (m *mockStore) ListInvocations(context.Context, store.InvocationOpts) ([]mo
 func (m *mockStore) InvocationStats(context.Context) (store.InvocationStatsResult, error) {
 	return store.InvocationStatsResult{}, nil
 }
-func (m *mockStore) ListTurns(context.Context, store.TurnOpts) ([]store.TurnRow, error)        { return nil, nil }
 func (m *mockStore) ToolTurnStats(context.Context, store.TurnOpts) ([]store.ToolTurnStat, error) { return nil, nil }
 func (m *mockStore) DetectAndRecordRecovery(context.Context, model.Invocation) error             { return nil }
-func (m *mockStore) ListRecoveries(context.Context, time.Time, int) ([]model.Recovery, error)    { return nil, nil }
+func (m *mockStore) ListRecoveries(context.Context, store.RecoveryOpts) ([]model.Recovery, error)    { return nil, nil }
 func (m *mockStore) RecoveryStats(context.Context) ([]model.RecoveryStat, error)                 { return nil, nil }
+func (m *mockStore) TrackRetryChain(context.Context, model.Invocation, json.RawMessage) error    { return nil }
+func (m *mockStore) ListRetryChains(context.Context, store.ChainOpts) ([]model.RetryChain, error) { return nil, nil }
 func (m *mockStore) SetDocMapping(context.Context, model.DocMapping) error                       { return nil }
 func (m *mockStore) GetDocMappings(context.Context) ([]model.DocMapping, error)                  { return nil, nil }
 func (m *mockStore) DeleteDocMapping(context.Context, string) (bool, error)                      { return false, nil }
@@ -76,6 +84,37 @@ func (m *mockStore) IncrementDocMatchCount(context.Context, string) error
 func (m *mockStore) StrugglingTools(context.Context, store.StrugglingOpts) ([]model.StrugglingTool, error) {
 	return nil, nil
 }
+func (m *mockStore) RecordIntervention(context.Context, model.Intervention) error { return nil }
+func (m *mockStore) InterventionStats(context.Context, time.Time) ([]model.InterventionStat, error) {
+	return nil, nil
+}
+func (m *mockStore) CreateToken(context.Context, model.APIToken) error { return nil }
+func (m *mockStore) ListTokens(context.Context) ([]model.APIToken, error) {
+	return nil, nil
+}
+func (m *mockStore) RevokeToken(context.Context, string) (bool, error) { return false, nil }
+func (m *mockStore) AuthenticateToken(context.Context, string) (*model.APIToken, error) {
+	return nil, nil
+}
+func (m *mockStore) SourceToolCounts(context.Context) ([]store.SourceToolCount, error) {
+	return nil, nil
+}
+func (m *mockStore) RecordDesires(ctx context.Context, ds []model.Desire) error {
+	for _, d := range ds {
+		if err := m.RecordDesire(ctx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (m *mockStore) RecordInvocations(ctx context.Context, invs []model.Invocation) error {
+	for _, inv := range invs {
+		if err := m.RecordInvocation(ctx, inv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 func (m *mockStore) Close() error                                                                { return nil }
 
 func TestSurfaceTurnPatternDesires_CreatesDesires(t *testing.T) {
@@ -204,6 +243,78 @@ func TestSurfaceTurnPatternDesires_SkipsExistingPattern(t *testing.T) {
 	}
 }
 
+func TestSurfaceMinedPatternDesires(t *testing.T) {
+	// Three sessions run the same workflow with different detours, so no
+	// exact pattern reaches the session threshold.
+	ms := &mockStore{
+		patterns: []store.TurnPattern{
+			{Pattern: "Grep → Read → Bash → Edit", Count: 1, AvgLength: 4, Sessions: 1},
+			{Pattern: "Grep → Glob → Read{2+} → Edit", Count: 1, AvgLength: 5, Sessions: 1},
+			{Pattern: "Grep → Read → Edit → Bash", Count: 1, AvgLength: 4, Sessions: 1},
+		},
+		turns: []store.TurnRow{
+			{TurnID: "s1:0", SessionID: "s1", Tools: "Grep → Read → Bash → Edit"},
+			{TurnID: "s2:0", SessionID: "s2", Tools: "Grep → Glob → Read → Read → Edit"},
+			{TurnID: "s3:0", SessionID: "s3", Tools: "Grep → Read → Edit → Bash"},
+		},
+	}
+
+	// Ingest surfaces exact patterns only; mining is left to
+	// 'dp turns --mine --surface'.
+	created, err := SurfaceTurnPatternDesires(context.Background(), ms, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != 0 || ms.turnCalls != 0 {
+		t.Fatalf("SurfaceTurnPatternDesires mined: %d desires, %d ListTurns calls", len(created), ms.turnCalls)
+	}
+
+	mined := MineTurnPatterns(ms.turns, MineOpts{})
+	created, err = SurfaceMinedPatternDesires(context.Background(), ms, mined, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != 1 {
+		t.Fatalf("expected 1 mined desire, got %d: %+v", len(created), created)
+	}
+	d := created[0]
+	if got := extractPattern(d.Metadata); got != "Grep → Read → Edit" {
+		t.Errorf("pattern = %q, want %q", got, "Grep → Read → Edit")
+	}
+	if d.ToolName != "Grep" {
+		t.Errorf("ToolName = %q, want Grep", d.ToolName)
+	}
+	want := "Recurring sub-workflow: Grep → Read → Edit (in 3 turns across 3 sessions, 100% confidence)"
+	if d.Error != want {
+		t.Errorf("Error = %q, want %q", d.Error, want)
+	}
+
+	// The mined pattern is deduplicated like an exact one.
+	created, err = SurfaceMinedPatternDesires(context.Background(), ms, mined, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != 0 {
+		t.Errorf("expected 0 desires on second call, got %d", len(created))
+	}
+}
+
+func TestSurfaceMinedPatternDesires_Capped(t *testing.T) {
+	ms := &mockStore{}
+	var mined []MinedPattern
+	for i := 0; i < MaxMinedDesires+5; i++ {
+		steps := []string{"Grep", "Read", "Tool" + strconv.Itoa(i)}
+		mined = append(mined, MinedPattern{Steps: steps, Pattern: strings.Join(steps, " → "), Sessions: 3, Turns: 3, Confidence: 1})
+	}
+	created, err := SurfaceMinedPatternDesires(context.Background(), ms, mined, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != MaxMinedDesires {
+		t.Errorf("created %d desires, want at most %d per run", len(created), MaxMinedDesires)
+	}
+}
+
 func TestFirstTool(t *testing.T) {
 	tests := []struct {
 		pattern string
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/alert"
+	"github.com/scbrown/desire-path/internal/analyze"
+	"github.com/scbrown/desire-path/internal/config"
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	alertsBucket    string
+	alertsBaseline  int
+	alertsThreshold float64
+	alertsMinCount  int
+	alertsNotify    bool
+)
+
+// alertsCmd lists tools and error fingerprints that are spiking above their
+// baseline, and optionally notifies the configured sinks.
+var alertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Show tools and errors spiking above their baseline",
+	Long: `Alerts compares each tool's desires, and each error fingerprint's, in the
+latest bucket (the last hour by default) with an exponentially weighted moving
+average of the --baseline buckets before it. A series alerts when its count is
+at least --min-count and --threshold standard deviations above the average.
+
+An error fingerprint is the tool name and error message with quoted strings,
+paths, hex IDs, and numbers replaced by placeholders, so one failure mode
+counts as one series however its details vary.
+
+With --notify, alerts that are not part of an open incident are sent to the
+configured sinks (alert_command, alert_file, alert_webhook) and recorded in
+alerts.json next to the database. An incident stays open, and is not alerted
+again, until it has not been detected for two buckets, that is, until a whole
+run on the bucket's schedule has missed it. Run dp alerts --notify from cron
+or a systemd timer, once per bucket, to be told when a path spikes.`,
+	Example: `  dp alerts
+  dp alerts --bucket day --baseline 14
+  dp alerts --threshold 4 --min-count 10
+  dp alerts --notify
+  dp alerts --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runAlerts(context.Background(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	alertsCmd.Flags().StringVar(&alertsBucket, "bucket", store.BucketHour, "bucket size: hour, day, or week")
+	alertsCmd.Flags().IntVar(&alertsBaseline, "baseline", analyze.DefaultAnomalyBuckets, "baseline buckets before the current one")
+	alertsCmd.Flags().Float64Var(&alertsThreshold, "threshold", 0, "minimum z-score (default alert_threshold, or 3)")
+	alertsCmd.Flags().IntVar(&alertsMinCount, "min-count", 0, "minimum desires in the current bucket (default alert_min_count, or 5)")
+	alertsCmd.Flags().BoolVar(&alertsNotify, "notify", false, "send new alerts to the configured sinks")
+	rootCmd.AddCommand(alertsCmd)
+}
+
+// runAlerts detects anomalies, notifies sinks if --notify is set, and writes
+// the anomalies to w.
+func runAlerts(ctx context.Context, w io.Writer) error {
+	bucket, err := store.BucketSize(alertsBucket)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadFrom(configPath)
+	if err != nil {
+		return err
+	}
+	opts := analyze.AnomalyOpts{
+		Bucket:    bucket,
+		Buckets:   alertsBaseline,
+		Threshold: cfg.AlertThreshold,
+		MinCount:  cfg.AlertMinCount,
+		Now:       time.Now(),
+	}
+	if alertsThreshold > 0 {
+		opts.Threshold = alertsThreshold
+	}
+	if alertsMinCount > 0 {
+		opts.MinCount = alertsMinCount
+	}
+
+	var sinks []alert.Sink
+	if alertsNotify {
+		sinks = alertSinks(cfg)
+		if len(sinks) == 0 {
+			return fmt.Errorf("no alert sinks configured; set alert_command, alert_file, or alert_webhook with dp config set")
+		}
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer s.Close()
+
+	anomalies, err := analyze.DetectAnomalies(ctx, s, opts)
+	if err != nil {
+		return fmt.Errorf("detect anomalies: %w", err)
+	}
+
+	state, err := alert.LoadState(alertStatePath())
+	if err != nil {
+		return err
+	}
+	open := make([]bool, len(anomalies))
+	for i, a := range anomalies {
+		open[i] = state.Open(a)
+	}
+
+	var notifyErr error
+	if alertsNotify {
+		fresh := state.Update(anomalies, opts.Now, bucket)
+		var errs []error
+		for _, a := range anomalies {
+			// Sends new alerts, and retries those a sink failed to take.
+			if err := state.Deliver(ctx, sinks, a); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		notifyErr = errors.Join(errs...)
+		if err := state.Save(); err != nil {
+			return err
+		}
+		if !jsonOutput && len(fresh) > 0 {
+			fmt.Fprintf(w, "Sent %d new alert(s) to %d sink(s).\n\n", len(fresh), len(sinks))
+		}
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if anomalies == nil {
+			anomalies = []analyze.Anomaly{}
+		}
+		if err := enc.Encode(anomalies); err != nil {
+			return err
+		}
+		return notifyErr
+	}
+	if len(anomalies) == 0 {
+		fmt.Fprintln(w, "No anomalies.")
+		return notifyErr
+	}
+	tbl := NewTable(w, "KIND", "KEY", "NOW", "BASELINE", "Z", "STATUS")
+	for i, a := range anomalies {
+		status := "new"
+		if open[i] {
+			status = "open"
+		}
+		tbl.Row(
+			a.Kind,
+			truncate(a.Key, 60),
+			fmt.Sprintf("%d", a.Count),
+			fmt.Sprintf("%.1f", a.Baseline),
+			fmt.Sprintf("%.1f", a.Z),
+			status,
+		)
+	}
+	tbl.Flush()
+	return notifyErr
+}
+
+// alertSinks returns the notification sinks set in cfg.
+func alertSinks(cfg *config.Config) []alert.Sink {
+	var sinks []alert.Sink
+	if cfg.AlertCommand != "" {
+		sinks = append(sinks, alert.CommandSink{Command: cfg.AlertCommand})
+	}
+	if cfg.AlertFile != "" {
+		sinks = append(sinks, alert.FileSink{Path: cfg.AlertFile})
+	}
+	if cfg.AlertWebhook != "" {
+		sinks = append(sinks, alert.WebhookSink{URL: cfg.AlertWebhook})
+	}
+	return sinks
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/analyze"
+	"github.com/scbrown/desire-path/internal/config"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func resetAlertsFlags(t *testing.T) {
+	t.Helper()
+	alertsBucket = store.BucketHour
+	alertsBaseline = analyze.DefaultAnomalyBuckets
+	alertsThreshold = 0
+	alertsMinCount = 0
+	alertsNotify = false
+	jsonOutput = false
+	configPath = config.Path()
+}
+
+func TestAlertsNotifyOnce(t *testing.T) {
+	dir := t.TempDir()
+	db := filepath.Join(dir, "test.db")
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := range 12 {
+		d := model.Desire{ID: fmt.Sprintf("d%d", i), ToolName: "read_file", Error: "unknown tool", Timestamp: time.Now().Add(-time.Duration(i+1) * time.Minute)}
+		if err := s.RecordDesire(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	resetAlertsFlags(t)
+	t.Cleanup(func() { resetAlertsFlags(t) })
+	dbPath = db
+	configPath = filepath.Join(dir, "config.toml")
+	sinkFile := filepath.Join(dir, "alerts.ndjson")
+	cfg := &config.Config{AlertFile: sinkFile}
+	if err := cfg.SaveTo(configPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := runAlerts(ctx, &buf); err != nil {
+		t.Fatalf("runAlerts: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "read_file") || !strings.Contains(out, "new") {
+		t.Errorf("listing:\n%s", out)
+	}
+	if _, err := os.Stat(sinkFile); err == nil {
+		t.Error("alerts were sent without --notify")
+	}
+
+	alertsNotify = true
+	for range 2 {
+		buf.Reset()
+		if err := runAlerts(ctx, &buf); err != nil {
+			t.Fatalf("runAlerts --notify: %v", err)
+		}
+	}
+	data, err := os.ReadFile(sinkFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One alert for the tool and one for its error fingerprint, sent once.
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("sink got %d alerts, want 2:\n%s", n, data)
+	}
+	if out := buf.String(); !strings.Contains(out, "open") || strings.Contains(out, "Sent") {
+		t.Errorf("second run:\n%s", out)
+	}
+}
+
+func TestAlertsNotifyWithoutSinks(t *testing.T) {
+	resetAlertsFlags(t)
+	t.Cleanup(func() { resetAlertsFlags(t) })
+	dbPath = filepath.Join(t.TempDir(), "test.db")
+	configPath = filepath.Join(t.TempDir(), "config.toml")
+	alertsNotify = true
+
+	err := runAlerts(context.Background(), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "no alert sinks") {
+		t.Errorf("err = %v, want no alert sinks", err)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/scbrown/desire-path/internal/model"
 	"github.com/spf13/cobra"
@@ -21,7 +23,14 @@ var (
 	aliasParam   string   // --param
 	aliasRegex   bool     // --regex
 	aliasRecipe  bool     // --recipe
+	aliasDeny    bool     // --deny
 	aliasMessage string   // --message
+
+	// Scope flags.
+	aliasScopeCWD    string // --scope-cwd
+	aliasScopeRemote string // --scope-remote
+	aliasScopeSource string // --scope-source
+	aliasExpires     string // --expires
 )
 
 var aliasCmd = &cobra.Command{
@@ -47,6 +56,11 @@ Advanced / MCP tools (--tool + --param):
   dp alias --tool MyMCPTool --param input_path "/old/path" "/new/path"
   dp alias --tool Bash --param command --regex "curl -k" "curl --cacert cert.pem"
 
+Deny (block the call and tell the agent why, never rewrite):
+  dp alias --deny "rm -rf /" --message "Never delete the filesystem root"
+  dp alias --deny --regex 'curl[^|]*\|\s*(ba)?sh' --message "Download, inspect, then run"
+  dp alias --deny --tool WebFetch --param url 'internal\.corp' --message "Internal URLs are off limits"
+
 Recipe (whole-command replacement with a script):
   dp alias --recipe "gt await-signal" 'while true; do
     status=$(gt mol status 2>&1)
@@ -54,15 +68,29 @@ Recipe (whole-command replacement with a script):
     sleep 5
   done'
 
-Delete (specify same flags to identify the rule):
+Scope (any rule; all given scopes must match for the rule to apply):
+  dp alias --cmd make --replace just --scope-cwd ~/src/app
+  dp alias --cmd make --replace just --scope-remote github.com/acme/app
+  dp alias read_file Read --scope-source claude-code
+  dp alias --deny "git push" --expires 7d --message "Release freeze"
+
+Scoped variants of the same rule are separate rules, so each repo can have
+its own replacement. Where several apply, the most specific one wins:
+  dp alias --cmd make --replace just --scope-remote github.com/acme/app
+  dp alias --cmd make --replace task --scope-remote github.com/acme/tools
+
+Delete (specify same flags, including scope, to identify the rule):
   dp alias --delete read_file
+  dp alias --delete --cmd make --replace just --scope-remote github.com/acme/app
   dp alias --delete --cmd scp --flag r
   dp alias --delete --cmd grep --replace rg
-  dp alias --delete --recipe "gt await-signal"`,
+  dp alias --delete --recipe "gt await-signal"
+  dp alias --delete --deny "rm -rf /"`,
 	Example: `  dp alias read_file Read
   dp alias --cmd scp --flag r R
   dp alias --cmd grep --replace rg --message "Use ripgrep"
   dp alias --recipe "gt await-signal" 'while true; do ...; done'
+  dp alias --deny "git push --force" --message "Force-push is not allowed"
   dp alias --delete read_file`,
 	RunE: runAlias,
 }
@@ -87,7 +115,12 @@ func init() {
 	aliasCmd.Flags().StringVar(&aliasParam, "param", "", "parameter name to correct (requires --tool)")
 	aliasCmd.Flags().BoolVar(&aliasRegex, "regex", false, "treat FROM as a regex pattern (requires --tool/--param)")
 	aliasCmd.Flags().BoolVar(&aliasRecipe, "recipe", false, "whole-command replacement with a script (FROM is a command prefix)")
+	aliasCmd.Flags().BoolVar(&aliasDeny, "deny", false, "block matching calls instead of rewriting them (FROM is a command prefix, or a regex with --regex/--tool)")
 	aliasCmd.Flags().StringVar(&aliasMessage, "message", "", "custom message shown when correction fires")
+	aliasCmd.Flags().StringVar(&aliasScopeCWD, "scope-cwd", "", "only apply when the working directory (or a parent) matches this glob")
+	aliasCmd.Flags().StringVar(&aliasScopeRemote, "scope-remote", "", "only apply when the git origin URL contains this string")
+	aliasCmd.Flags().StringVar(&aliasScopeSource, "scope-source", "", "only apply for this source (e.g. claude-code)")
+	aliasCmd.Flags().StringVar(&aliasExpires, "expires", "", "stop applying after a duration (e.g. 7d, 12h) or date (RFC3339 or YYYY-MM-DD)")
 	rootCmd.AddCommand(aliasCmd)
 	rootCmd.AddCommand(aliasesCmd)
 }
@@ -119,7 +152,7 @@ func buildAlias(args []string) (model.Alias, error) {
 	if aliasReplace != "" && aliasCmd_ == "" {
 		return a, fmt.Errorf("--replace requires --cmd")
 	}
-	if aliasRegex && aliasTool == "" {
+	if aliasRegex && aliasTool == "" && !aliasDeny {
 		return a, fmt.Errorf("--regex requires --tool/--param")
 	}
 	if (aliasTool != "" && aliasParam == "") || (aliasTool == "" && aliasParam != "") {
@@ -131,8 +164,47 @@ func buildAlias(args []string) (model.Alias, error) {
 	if aliasRecipe && (aliasCmd_ != "" || aliasTool != "" || aliasParam != "" || len(aliasFlag) > 0 || aliasReplace != "" || aliasRegex) {
 		return a, fmt.Errorf("--recipe is mutually exclusive with --cmd/--tool/--param/--flag/--replace/--regex")
 	}
+	if aliasDeny && (aliasCmd_ != "" || len(aliasFlag) > 0 || aliasReplace != "" || aliasRecipe) {
+		return a, fmt.Errorf("--deny is mutually exclusive with --cmd/--flag/--replace/--recipe")
+	}
 
 	a.Message = aliasMessage
+	a.ScopeCWD = aliasScopeCWD
+	a.ScopeRemote = aliasScopeRemote
+	a.ScopeSource = aliasScopeSource
+	if aliasExpires != "" {
+		exp, err := parseExpires(aliasExpires, time.Now())
+		if err != nil {
+			return a, fmt.Errorf("--expires: %w", err)
+		}
+		a.ExpiresAt = &exp
+	}
+
+	// Mode 7: --deny (policy block, checked before corrections)
+	if aliasDeny {
+		if len(args) != 1 {
+			return a, fmt.Errorf("--deny requires exactly one positional argument: the pattern to block")
+		}
+		a.From = args[0]
+		a.MatchKind = "deny"
+		if aliasTool != "" {
+			// Tool+param deny: FROM is a regex against that parameter.
+			a.Tool = aliasTool
+			a.Param = aliasParam
+		} else {
+			a.Tool = "Bash"
+			a.Param = "command"
+			if !aliasRegex {
+				a.Command = extractCommand(args[0])
+			}
+		}
+		if a.Command == "" {
+			if _, err := regexp.Compile(a.From); err != nil {
+				return a, fmt.Errorf("invalid deny regex %q: %w", a.From, err)
+			}
+		}
+		return a, nil
+	}
 
 	// Mode 1: --cmd with --flag
 	if aliasCmd_ != "" && len(aliasFlag) > 0 {
@@ -258,6 +330,22 @@ func buildAlias(args []string) (model.Alias, error) {
 	return a, nil
 }
 
+// parseExpires accepts a duration from now (e.g. "7d", "12h") or an
+// absolute RFC3339 timestamp or date.
+func parseExpires(s string, now time.Time) (time.Time, error) {
+	if d, err := parseDuration(s); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("duration must be positive")
+		}
+		return now.Add(d).UTC(), nil
+	}
+	t, err := parseSince(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a duration (e.g. 7d, 12h) or date: %w", err)
+	}
+	return t.UTC(), nil
+}
+
 // truncateTo collapses newlines to spaces and truncates to maxLen with "..." suffix.
 func truncateTo(s string, maxLen int) string {
 	s = strings.ReplaceAll(s, "\n", " ")
@@ -302,6 +390,8 @@ func setAlias(a model.Alias) error {
 	}
 	if a.IsToolNameAlias() {
 		fmt.Printf("Alias set: %s -> %s\n", a.From, a.To)
+	} else if a.IsDeny() {
+		fmt.Printf("Deny rule set: %s (%s:%s)\n", a.From, a.Tool, a.Param)
 	} else {
 		fmt.Printf("Rule set: %s %s -> %s (%s)\n", a.Command, a.From, a.To, a.MatchKind)
 	}
@@ -315,7 +405,7 @@ func deleteAlias(a model.Alias) error {
 	}
 	defer s.Close()
 
-	deleted, err := s.DeleteAlias(context.Background(), a.From, a.Tool, a.Param, a.Command, a.MatchKind)
+	deleted, err := s.DeleteAlias(context.Background(), a.Key())
 	if err != nil {
 		return fmt.Errorf("delete alias: %w", err)
 	}
@@ -358,13 +448,14 @@ func listAliases() error {
 		return nil
 	}
 
-	tbl := NewTable(os.Stdout, "FROM", "TO", "TYPE", "COMMAND", "CREATED")
+	now := time.Now()
+	tbl := NewTable(os.Stdout, "FROM", "TO", "TYPE", "COMMAND", "SCOPE", "CREATED")
 	for _, a := range aliases {
 		kind := "alias"
 		if a.MatchKind != "" {
 			kind = a.MatchKind
 		}
-		tbl.Row(a.From, truncateTo(a.To, 40), kind, a.Command, a.CreatedAt.Format("2006-01-02 15:04:05"))
+		tbl.Row(a.From, truncateTo(a.To, 40), kind, a.Command, formatAliasScope(a, now), a.CreatedAt.Format("2006-01-02 15:04:05"))
 	}
 	return tbl.Flush()
 }
@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	impactWindow    string // --window
+	impactMinBefore int    // --min-before
+	impactMinDrop   int    // --min-drop
+)
+
+// Impact verdicts.
+const (
+	impactEffective = "effective" // failures dropped by at least --min-drop
+	impactNoEffect  = "no-effect" // failures did not drop enough
+	impactNoData    = "no-data"   // too few failures before the alias to measure
+	impactTooNew    = "too-new"   // created less than an hour ago
+)
+
+// aliasImpact compares a rule's matching failures in equal windows before
+// and after it was created.
+type aliasImpact struct {
+	Rule           string    `json:"rule"`
+	CreatedAt      time.Time `json:"created_at"`
+	WindowHours    float64   `json:"window_hours"`
+	Before         int       `json:"failures_before"`
+	After          int       `json:"failures_after"`
+	SessionsBefore int       `json:"sessions_before"`
+	SessionsAfter  int       `json:"sessions_after"`
+	CallsBefore    int       `json:"calls_before"` // all invocations in the window
+	CallsAfter     int       `json:"calls_after"`
+	RateBefore     *float64  `json:"rate_before,omitempty"` // failures per 1000 calls
+	RateAfter      *float64  `json:"rate_after,omitempty"`
+	Change         *float64  `json:"change,omitempty"` // relative change, -1 to +Inf
+	Verdict        string    `json:"verdict"`
+}
+
+var aliasImpactCmd = &cobra.Command{
+	Use:   "impact",
+	Short: "Compare failures before and after each alias was created",
+	Long: `Impact measures whether each alias and rule reduced the failures it
+targets. For every rule it counts matching desires in two equal windows,
+before and after the rule's creation time: the window is the time since
+creation, capped at --window.
+
+Tool-name aliases match desires for their From name; parameter, command,
+and deny rules match desires whose tool input they would have rewritten
+or blocked. Failure counts are normalised by the total number of
+invocations recorded in each window, so a quieter week after the alias
+doesn't pass for an improvement. Without invocation data, raw counts are
+compared.
+
+Verdicts:
+
+  effective  failures dropped by at least --min-drop percent
+  no-effect  failures dropped by less, or rose
+  no-data    fewer than --min-before failures before the rule
+  too-new    created less than an hour ago
+
+Rules with no measurable effect are flagged so they can be revisited:
+the alias may point at the wrong tool, or pave-check may not be installed.`,
+	Example: `  dp aliases impact
+  dp aliases impact --window 7d
+  dp aliases impact --min-drop 75 --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		window, err := parseDuration(impactWindow)
+		if err != nil {
+			return fmt.Errorf("invalid --window value %q: %w", impactWindow, err)
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return fmt.Errorf("open store: %w", err)
+		}
+		defer s.Close()
+
+		impacts, err := aliasImpacts(context.Background(), s, window, time.Now())
+		if err != nil {
+			return err
+		}
+		w := cmd.OutOrStdout()
+		if jsonOutput {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(impacts)
+		}
+		printAliasImpacts(w, impacts)
+		return nil
+	},
+}
+
+func init() {
+	aliasImpactCmd.Flags().StringVar(&impactWindow, "window", "14d", "longest window to compare on each side of the creation time")
+	aliasImpactCmd.Flags().IntVar(&impactMinBefore, "min-before", 3, "fewest failures before the rule needed to judge it")
+	aliasImpactCmd.Flags().IntVar(&impactMinDrop, "min-drop", 50, "percentage drop in the failure rate that counts as effective")
+	aliasesCmd.AddCommand(aliasImpactCmd)
+}
+
+// aliasImpacts measures every alias in s at now. The returned slice is never
+// nil.
+func aliasImpacts(ctx context.Context, s store.Store, maxWindow time.Duration, now time.Time) ([]aliasImpact, error) {
+	aliases, err := s.GetAliases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get aliases: %w", err)
+	}
+	impacts := []aliasImpact{}
+	if len(aliases) == 0 {
+		return impacts, nil
+	}
+
+	// Load everything from the earliest window start once.
+	from := now
+	for _, a := range aliases {
+		if start := a.CreatedAt.Add(-maxWindow); start.Before(from) {
+			from = start
+		}
+	}
+	desires, err := s.ListDesires(ctx, store.ListOpts{Since: from})
+	if err != nil {
+		return nil, fmt.Errorf("list desires: %w", err)
+	}
+	invs, err := s.ListInvocations(ctx, store.InvocationOpts{Since: from})
+	if err != nil {
+		return nil, fmt.Errorf("list invocations: %w", err)
+	}
+
+	for _, a := range aliases {
+		impacts = append(impacts, measureAlias(a, desires, invs, maxWindow, now))
+	}
+	sort.SliceStable(impacts, func(i, j int) bool {
+		return impacts[i].Before+impacts[i].After > impacts[j].Before+impacts[j].After
+	})
+	return impacts, nil
+}
+
+// measureAlias compares a's matching desires in the windows before and
+// after its creation.
+func measureAlias(a model.Alias, desires []model.Desire, invs []model.Invocation, maxWindow time.Duration, now time.Time) aliasImpact {
+	window := now.Sub(a.CreatedAt)
+	if window > maxWindow {
+		window = maxWindow
+	}
+	im := aliasImpact{Rule: lintRuleName(a), CreatedAt: a.CreatedAt, WindowHours: math.Round(window.Hours()*10) / 10}
+	if window < time.Hour {
+		im.Verdict = impactTooNew
+		return im
+	}
+	start, end := a.CreatedAt.Add(-window), a.CreatedAt.Add(window)
+
+	// inWindow returns -1 for before, 1 for after, and 0 outside both.
+	inWindow := func(t time.Time) int {
+		switch {
+		case t.Before(start) || !t.Before(end):
+			return 0
+		case t.Before(a.CreatedAt):
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	sessBefore, sessAfter := make(map[string]bool), make(map[string]bool)
+	for _, d := range desires {
+		side := inWindow(d.Timestamp)
+		if side == 0 {
+			continue
+		}
+		if a.IsToolNameAlias() {
+			if d.ToolName != a.From {
+				continue
+			}
+		} else if !ruleMatchesDesire(a, d) {
+			continue
+		}
+		if side < 0 {
+			im.Before++
+			sessBefore[d.SessionID] = true
+		} else {
+			im.After++
+			sessAfter[d.SessionID] = true
+		}
+	}
+	im.SessionsBefore, im.SessionsAfter = len(sessBefore), len(sessAfter)
+	for _, inv := range invs {
+		switch inWindow(inv.Timestamp) {
+		case -1:
+			im.CallsBefore++
+		case 1:
+			im.CallsAfter++
+		}
+	}
+
+	before, after := float64(im.Before), float64(im.After)
+	if im.CallsBefore > 0 && im.CallsAfter > 0 {
+		rb := 1000 * before / float64(im.CallsBefore)
+		ra := 1000 * after / float64(im.CallsAfter)
+		im.RateBefore, im.RateAfter = &rb, &ra
+		before, after = rb, ra
+	}
+	if im.Before < impactMinBefore || im.Before == 0 {
+		im.Verdict = impactNoData
+		return im
+	}
+	change := (after - before) / before
+	im.Change = &change
+	if change <= -float64(impactMinDrop)/100 {
+		im.Verdict = impactEffective
+	} else {
+		im.Verdict = impactNoEffect
+	}
+	return im
+}
+
+func printAliasImpacts(w io.Writer, impacts []aliasImpact) {
+	if len(impacts) == 0 {
+		fmt.Fprintln(w, "No aliases configured.")
+		return
+	}
+	tbl := NewTable(w, "RULE", "WINDOW", "FAILURES", "SESSIONS", "PER 1K CALLS", "CHANGE", "VERDICT")
+	flagged := 0
+	for _, im := range impacts {
+		rates, change := "-", "-"
+		if im.RateBefore != nil {
+			rates = fmt.Sprintf("%.1f → %.1f", *im.RateBefore, *im.RateAfter)
+		}
+		if im.Change != nil {
+			change = fmt.Sprintf("%+.0f%%", *im.Change*100)
+		}
+		verdict := im.Verdict
+		if verdict == impactNoEffect {
+			verdict += " !"
+			flagged++
+		}
+		tbl.Row(
+			truncateTo(im.Rule, 40),
+			formatWindow(im.WindowHours),
+			fmt.Sprintf("%d → %d", im.Before, im.After),
+			fmt.Sprintf("%d → %d", im.SessionsBefore, im.SessionsAfter),
+			rates,
+			change,
+			verdict,
+		)
+	}
+	tbl.Flush()
+	if flagged > 0 {
+		fmt.Fprintf(w, "\n%d rule(s) with no measurable effect.\n", flagged)
+	}
+}
+
+// formatWindow shows a window length in days, or hours when under two days.
+func formatWindow(hours float64) string {
+	if hours >= 48 {
+		return fmt.Sprintf("%.0fd", hours/24)
+	}
+	return fmt.Sprintf("%.0fh", hours)
+}
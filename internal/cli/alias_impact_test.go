@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func TestMeasureAlias(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-48 * time.Hour)
+	at := func(h int) time.Time { return created.Add(time.Duration(h) * time.Hour) }
+
+	var desires []model.Desire
+	var invs []model.Invocation
+	add := func(tool, session, input string, ts time.Time) {
+		d := model.Desire{ToolName: tool, SessionID: session, Timestamp: ts}
+		if input != "" {
+			d.ToolInput = json.RawMessage(input)
+		}
+		desires = append(desires, d)
+	}
+	// read_file: 6 failures before, 1 after.
+	for i := 0; i < 6; i++ {
+		add("read_file", "s"+itoa(i%3), "", at(-1-i))
+	}
+	add("read_file", "s9", "", at(5))
+	// scp -r: 4 before, 4 after.
+	for i := 0; i < 4; i++ {
+		add("Bash", "s1", `{"command":"scp -r a b"}`, at(-2-i))
+		add("Bash", "s2", `{"command":"scp -r a b"}`, at(2+i))
+	}
+	add("Bash", "s1", `{"command":"scp a b"}`, at(-1)) // doesn't match the flag rule
+	add("read_file", "s1", "", at(-100))               // outside the window
+	// Equal call volume on both sides.
+	for i := 0; i < 100; i++ {
+		invs = append(invs, model.Invocation{Timestamp: at(-1 - i%40)}, model.Invocation{Timestamp: at(i % 40)})
+	}
+
+	readFile := measureAlias(model.Alias{From: "read_file", To: "Read", CreatedAt: created}, desires, invs, 14*24*time.Hour, now)
+	if readFile.Before != 6 || readFile.After != 1 || readFile.SessionsBefore != 3 || readFile.SessionsAfter != 1 {
+		t.Errorf("read_file counts = %+v", readFile)
+	}
+	if readFile.CallsBefore != 100 || readFile.CallsAfter != 100 || readFile.RateBefore == nil || *readFile.RateBefore != 60 {
+		t.Errorf("read_file volume = %+v", readFile)
+	}
+	if readFile.Verdict != impactEffective {
+		t.Errorf("read_file verdict = %q, want %q", readFile.Verdict, impactEffective)
+	}
+	if readFile.WindowHours != 48 {
+		t.Errorf("window = %v, want 48h (time since creation)", readFile.WindowHours)
+	}
+
+	scp := measureAlias(model.Alias{From: "r", To: "R", Tool: "Bash", Param: "command", Command: "scp", MatchKind: "flag", CreatedAt: created}, desires, invs, 14*24*time.Hour, now)
+	if scp.Before != 4 || scp.After != 4 || scp.Verdict != impactNoEffect {
+		t.Errorf("scp = %+v, want 4 → 4 no-effect", scp)
+	}
+
+	rare := measureAlias(model.Alias{From: "write_file", To: "Write", CreatedAt: created}, desires, invs, 14*24*time.Hour, now)
+	if rare.Verdict != impactNoData || rare.Change != nil {
+		t.Errorf("write_file = %+v, want no-data", rare)
+	}
+
+	fresh := measureAlias(model.Alias{From: "read_file", To: "Read", CreatedAt: now.Add(-10 * time.Minute)}, desires, invs, 14*24*time.Hour, now)
+	if fresh.Verdict != impactTooNew {
+		t.Errorf("fresh verdict = %q, want %q", fresh.Verdict, impactTooNew)
+	}
+}
+
+func TestMeasureAliasNormalisesByVolume(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-24 * time.Hour)
+
+	// Failures halve after the alias, but so does overall traffic.
+	var desires []model.Desire
+	var invs []model.Invocation
+	for i := 0; i < 8; i++ {
+		desires = append(desires, model.Desire{ToolName: "read_file", Timestamp: created.Add(-time.Duration(i+1) * time.Hour)})
+		invs = append(invs, model.Invocation{Timestamp: created.Add(-time.Duration(i+1) * time.Hour)})
+	}
+	for i := 0; i < 4; i++ {
+		desires = append(desires, model.Desire{ToolName: "read_file", Timestamp: created.Add(time.Duration(i+1) * time.Hour)})
+		invs = append(invs, model.Invocation{Timestamp: created.Add(time.Duration(i+1) * time.Hour)})
+	}
+
+	a := model.Alias{From: "read_file", To: "Read", CreatedAt: created}
+	im := measureAlias(a, desires, invs, 14*24*time.Hour, now)
+	if im.Change == nil || *im.Change != 0 || im.Verdict != impactNoEffect {
+		t.Errorf("normalised = %+v, want 0%% change and no-effect", im)
+	}
+
+	// Without invocations the raw counts are compared.
+	im = measureAlias(a, desires, nil, 14*24*time.Hour, now)
+	if im.RateBefore != nil || im.Change == nil || *im.Change != -0.5 || im.Verdict != impactEffective {
+		t.Errorf("raw = %+v, want -50%% and effective", im)
+	}
+}
+
+func TestAliasesImpactCmd(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := s.SetAlias(ctx, model.Alias{From: "read_file", To: "Read"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	dbPath = db
+	jsonOutput = false
+	t.Cleanup(func() { jsonOutput = false })
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"aliases", "impact", "--db", db})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "read_file") || !strings.Contains(out, impactTooNew) {
+		t.Errorf("expected a too-new read_file row, got:\n%s", out)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"aliases", "impact", "--db", db, "--json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var impacts []aliasImpact
+	if err := json.Unmarshal(buf.Bytes(), &impacts); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(impacts) != 1 || impacts[0].Verdict != impactTooNew {
+		t.Errorf("impacts = %+v", impacts)
+	}
+}
@@ -0,0 +1,431 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/config"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintFile   string // --file
+	lintDays   int    // --days
+	lintStrict bool   // --strict
+)
+
+// Lint severities.
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+)
+
+// lintIssue is a single problem found by 'dp aliases lint'.
+type lintIssue struct {
+	Severity string `json:"severity"` // "error" or "warning"
+	Check    string `json:"check"`    // "cycle", "chain", "unknown-target", "bad-regex", "overlap", "dead"
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+var aliasLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check aliases for conflicts, cycles, and dead rules",
+	Long: `Lint the alias table (or an alias pack with --file) and report:
+
+  cycle           tool-name aliases that loop back on themselves
+  chain           tool-name aliases whose target is itself aliased (a→b, b→c)
+  unknown-target  tool-name aliases pointing at a tool that isn't known
+  bad-regex       regex rules whose pattern does not compile
+  overlap         regex/literal/deny rules shadowed by another rule on the
+                  same tool, parameter, and command
+  dead            rules with no matching desires in the last --days days;
+                  rules created within the window are not checked yet
+
+Known tools are the known_tools config key (or the Claude Code built-ins
+when unset) plus every tool seen in a successful invocation. Set --days 0
+to skip the dead-rule check.
+
+A correction rule that fires prevents the desire it was written for, so
+a "dead" finding is a hint to review the rule, not proof it is unused.
+
+Exits non-zero when an error is found, so it can gate a pre-commit hook
+on shared alias packs. With --strict, warnings fail too.`,
+	Example: `  dp aliases lint
+  dp aliases lint --days 7
+  dp aliases lint --file crew-aliases.toml --days 0 --strict
+  dp aliases lint --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		issues, err := runAliasLint(context.Background())
+		if err != nil {
+			return err
+		}
+		w := cmd.OutOrStdout()
+		if jsonOutput {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(issues); err != nil {
+				return err
+			}
+		} else {
+			printLintIssues(w, issues)
+		}
+		if lintStrict && len(issues) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("aliases lint: %d issue(s) found", len(issues))
+		}
+		if n := lintErrors(issues); n > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("aliases lint: %d error(s) found", n)
+		}
+		return nil
+	},
+}
+
+func init() {
+	aliasLintCmd.Flags().StringVar(&lintFile, "file", "", "lint an alias pack (TOML or JSON) instead of the alias table")
+	aliasLintCmd.Flags().IntVar(&lintDays, "days", 30, "flag rules with no matching desires in this many days (0 disables)")
+	aliasLintCmd.Flags().BoolVar(&lintStrict, "strict", false, "exit non-zero on warnings too")
+	aliasesCmd.AddCommand(aliasLintCmd)
+}
+
+// runAliasLint loads the aliases and the data needed to judge them, then
+// runs every check. The returned slice is never nil.
+func runAliasLint(ctx context.Context) ([]lintIssue, error) {
+	s, err := openStore()
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+	defer s.Close()
+
+	var aliases []model.Alias
+	if lintFile != "" {
+		collection, err := readAliasCollection(lintFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, ea := range collection.Aliases {
+			aliases = append(aliases, toModel(ea))
+		}
+	} else {
+		aliases, err = s.GetAliases(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get aliases: %w", err)
+		}
+	}
+
+	known, err := lintKnownTools(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []lintIssue{}
+	issues = append(issues, lintToolAliases(aliases, known)...)
+	issues = append(issues, lintRegexes(aliases)...)
+	issues = append(issues, lintOverlaps(aliases)...)
+
+	if lintDays > 0 {
+		since := time.Now().AddDate(0, 0, -lintDays)
+		desires, err := s.ListDesires(ctx, store.ListOpts{Since: since})
+		if err != nil {
+			return nil, fmt.Errorf("list desires: %w", err)
+		}
+		issues = append(issues, lintDeadRules(aliases, desires, since, lintDays)...)
+	}
+	return issues, nil
+}
+
+// lintErrors counts the issues of error severity.
+func lintErrors(issues []lintIssue) int {
+	n := 0
+	for _, is := range issues {
+		if is.Severity == lintError {
+			n++
+		}
+	}
+	return n
+}
+
+// lintKnownTools returns the set of tool names an alias may point at.
+func lintKnownTools(ctx context.Context, s store.Store) (map[string]bool, error) {
+	known := make(map[string]bool)
+	names := defaultKnownTools
+	if cfg, _ := config.LoadFrom(configPath); cfg != nil && len(cfg.KnownTools) > 0 {
+		names = cfg.KnownTools
+	}
+	for _, n := range names {
+		known[strings.TrimSpace(n)] = true
+	}
+
+	counts, err := s.SourceToolCounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("tool counts: %w", err)
+	}
+	for _, c := range counts {
+		if c.Invocations > c.InvocationErrors {
+			known[c.ToolName] = true
+		}
+	}
+	return known, nil
+}
+
+// lintToolAliases checks tool-name aliases for cycles, chains, and unknown targets.
+func lintToolAliases(aliases []model.Alias, known map[string]bool) []lintIssue {
+	next := make(map[string]string)
+	var froms []string
+	for _, a := range aliases {
+		if a.IsToolNameAlias() {
+			next[a.From] = a.To
+			froms = append(froms, a.From)
+		}
+	}
+	sort.Strings(froms)
+
+	var issues []lintIssue
+	reported := make(map[string]bool) // cycle members already reported
+	for _, from := range froms {
+		path := []string{from}
+		seen := map[string]bool{from: true}
+		cur := next[from]
+		cycle := false
+		for {
+			path = append(path, cur)
+			if seen[cur] {
+				cycle = true
+				break
+			}
+			to, ok := next[cur]
+			if !ok {
+				break
+			}
+			seen[cur] = true
+			cur = to
+		}
+
+		rule := from + " → " + next[from]
+		switch {
+		case cycle:
+			if reported[from] {
+				continue
+			}
+			for _, n := range path {
+				reported[n] = true
+			}
+			issues = append(issues, lintIssue{
+				Severity: lintError,
+				Check:    "cycle",
+				Rule:     rule,
+				Message:  "alias cycle: " + strings.Join(path, " → "),
+			})
+		case len(path) > 2:
+			final := path[len(path)-1]
+			issues = append(issues, lintIssue{
+				Severity: lintWarning,
+				Check:    "chain",
+				Rule:     rule,
+				Message:  fmt.Sprintf("alias chain %s; point %s directly at %s", strings.Join(path, " → "), from, final),
+			})
+		case !known[next[from]]:
+			issues = append(issues, lintIssue{
+				Severity: lintError,
+				Check:    "unknown-target",
+				Rule:     rule,
+				Message:  fmt.Sprintf("%q is not a known tool (add it to known_tools if it is real)", next[from]),
+			})
+		}
+	}
+	return issues
+}
+
+// lintRegexes reports regex rules whose pattern does not compile.
+func lintRegexes(aliases []model.Alias) []lintIssue {
+	var issues []lintIssue
+	for _, a := range aliases {
+		if !lintIsRegex(a) {
+			continue
+		}
+		if _, err := regexp.Compile(a.From); err != nil {
+			issues = append(issues, lintIssue{
+				Severity: lintError,
+				Check:    "bad-regex",
+				Rule:     lintRuleName(a),
+				Message:  err.Error(),
+			})
+		}
+	}
+	return issues
+}
+
+// lintOverlaps reports pattern rules that can never fire on their own because
+// another rule on the same tool, parameter, and command matches everything
+// they match.
+func lintOverlaps(aliases []model.Alias) []lintIssue {
+	var rules []model.Alias
+	for _, a := range aliases {
+		switch {
+		case a.MatchKind == "literal", lintIsRegex(a):
+			rules = append(rules, a)
+		}
+	}
+
+	var issues []lintIssue
+	for i, a := range rules {
+		for j, b := range rules {
+			if i == j || a.Tool != b.Tool || a.Param != b.Param || a.Command != b.Command {
+				continue
+			}
+			if !lintCovers(a, b) || !lintShadows(a, b) {
+				continue
+			}
+			// Two literals with identical patterns shadow each other; report once.
+			if lintCovers(b, a) && lintShadows(b, a) && j < i {
+				continue
+			}
+			issues = append(issues, lintIssue{
+				Severity: lintWarning,
+				Check:    "overlap",
+				Rule:     lintRuleName(b),
+				Message:  fmt.Sprintf("overlaps with %s, which matches everything this rule does", lintRuleName(a)),
+			})
+		}
+	}
+	return issues
+}
+
+// lintCovers reports whether rule a applies wherever rule b does, judged by
+// their scopes: a must be unscoped or scoped exactly like b.
+func lintCovers(a, b model.Alias) bool {
+	return scopeSpecificity(a) == 0 ||
+		(a.ScopeCWD == b.ScopeCWD && a.ScopeRemote == b.ScopeRemote && a.ScopeSource == b.ScopeSource)
+}
+
+// lintShadows reports whether rule a matches every value rule b's pattern
+// is written to catch, judged by testing a against b's literal text.
+func lintShadows(a, b model.Alias) bool {
+	if lintIsRegex(b) {
+		// A regex's pattern text is only a fair sample when it is plain text.
+		if regexp.QuoteMeta(b.From) != b.From {
+			return false
+		}
+	}
+	if lintIsRegex(a) {
+		re, err := regexp.Compile(a.From)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(b.From)
+	}
+	return strings.Contains(b.From, a.From)
+}
+
+// lintDeadRules reports rules that matched none of the given desires,
+// which cover the days before now back to since. Rules created after since
+// have not been around for the whole window and are skipped.
+func lintDeadRules(aliases []model.Alias, desires []model.Desire, since time.Time, days int) []lintIssue {
+	var issues []lintIssue
+	for _, a := range aliases {
+		if a.CreatedAt.After(since) {
+			continue
+		}
+		if a.IsToolNameAlias() {
+			if lintToolDesired(a.From, desires) {
+				continue
+			}
+		} else if lintIsRegex(a) && !lintCompiles(a.From) {
+			continue // already reported as bad-regex
+		} else if lintRuleDesired(a, desires) {
+			continue
+		}
+		issues = append(issues, lintIssue{
+			Severity: lintWarning,
+			Check:    "dead",
+			Rule:     lintRuleName(a),
+			Message:  fmt.Sprintf("no matching desires in the last %d days", days),
+		})
+	}
+	return issues
+}
+
+func lintToolDesired(tool string, desires []model.Desire) bool {
+	for _, d := range desires {
+		if d.ToolName == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// lintRuleDesired reports whether a parameter or deny rule would have fired on
+// any desire, using the same matching as pave-check.
+func lintRuleDesired(a model.Alias, desires []model.Desire) bool {
+	for _, d := range desires {
+		if ruleMatchesDesire(a, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleMatchesDesire reports whether a parameter or deny rule would have
+// fired on d's tool input.
+func ruleMatchesDesire(a model.Alias, d model.Desire) bool {
+	if d.ToolName != a.Tool || len(d.ToolInput) == 0 {
+		return false
+	}
+	var input map[string]interface{}
+	if err := json.Unmarshal(d.ToolInput, &input); err != nil {
+		return false
+	}
+	value, ok := input[a.Param].(string)
+	if !ok {
+		return false
+	}
+	if a.IsDeny() {
+		return matchDenyRule(value, a)
+	}
+	_, _, applied := applyRule(value, a)
+	return applied
+}
+
+// lintIsRegex reports whether a rule's From is a regular expression.
+func lintIsRegex(a model.Alias) bool {
+	return a.MatchKind == "regex" || (a.IsDeny() && a.Command == "")
+}
+
+func lintCompiles(pattern string) bool {
+	_, err := regexp.Compile(pattern)
+	return err == nil
+}
+
+// lintRuleName is a compact, human-readable identifier for a rule.
+func lintRuleName(a model.Alias) string {
+	if a.IsToolNameAlias() {
+		return a.From + " → " + a.To
+	}
+	scope := a.Tool + "." + a.Param
+	if a.Command != "" {
+		scope = a.Command
+	}
+	return fmt.Sprintf("%s[%s] %s", a.MatchKind, scope, a.From)
+}
+
+func printLintIssues(w io.Writer, issues []lintIssue) {
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "No issues found.")
+		return
+	}
+	tbl := NewTable(w, "SEVERITY", "CHECK", "RULE", "MESSAGE")
+	for _, is := range issues {
+		tbl.Row(is.Severity, is.Check, truncateTo(is.Rule, 40), is.Message)
+	}
+	tbl.Flush()
+}
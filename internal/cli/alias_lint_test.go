@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func lintChecks(issues []lintIssue) map[string]int {
+	m := make(map[string]int)
+	for _, is := range issues {
+		m[is.Check]++
+	}
+	return m
+}
+
+func TestLintToolAliases(t *testing.T) {
+	known := map[string]bool{"Read": true, "Grep": true}
+	aliases := []model.Alias{
+		{From: "read_file", To: "Read"},     // ok
+		{From: "cat_file", To: "read_file"}, // chain → Read
+		{From: "a", To: "b"},                // cycle a → b → a
+		{From: "b", To: "a"},
+		{From: "search", To: "Serch"}, // unknown target
+	}
+
+	issues := lintToolAliases(aliases, known)
+	got := lintChecks(issues)
+	if got["chain"] != 1 || got["cycle"] != 1 || got["unknown-target"] != 1 || len(issues) != 3 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+	for _, is := range issues {
+		switch is.Check {
+		case "chain":
+			if !strings.Contains(is.Message, "cat_file → read_file → Read") {
+				t.Errorf("chain message = %q", is.Message)
+			}
+		case "cycle":
+			if is.Severity != lintError {
+				t.Errorf("cycle severity = %q, want error", is.Severity)
+			}
+		}
+	}
+}
+
+func TestLintKnownTools(t *testing.T) {
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+	now := time.Now()
+	for _, inv := range []model.Invocation{
+		{ID: "i1", Source: "test", ToolName: "MyTool", Timestamp: now},
+		{ID: "i2", Source: "test", ToolName: "MyTool", IsError: true, Timestamp: now},
+		{ID: "i3", Source: "test", ToolName: "Broken", IsError: true, Timestamp: now},
+	} {
+		if err := s.RecordInvocation(ctx, inv); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configPath = filepath.Join(t.TempDir(), "config.toml")
+	known, err := lintKnownTools(ctx, s)
+	if err != nil {
+		t.Fatalf("lintKnownTools: %v", err)
+	}
+	if !known["MyTool"] || !known["Read"] {
+		t.Errorf("expected MyTool and the built-ins to be known, got %v", known)
+	}
+	if known["Broken"] {
+		t.Error("a tool that only ever failed should not be known")
+	}
+}
+
+func TestLintRegexesAndOverlaps(t *testing.T) {
+	aliases := []model.Alias{
+		{From: "curl -k", To: "curl --cacert c.pem", Tool: "Bash", Param: "command", MatchKind: "regex"},
+		{From: "curl", Tool: "Bash", Param: "command", MatchKind: "deny"}, // shadows the regex above
+		{From: "(", To: "x", Tool: "Bash", Param: "command", MatchKind: "regex"},
+		{From: "curl", To: "wget", Tool: "WebFetch", Param: "url", MatchKind: "regex"}, // different tool
+	}
+
+	bad := lintRegexes(aliases)
+	if len(bad) != 1 || bad[0].Check != "bad-regex" {
+		t.Fatalf("bad regex issues = %+v", bad)
+	}
+
+	overlaps := lintOverlaps(aliases)
+	if len(overlaps) != 1 {
+		t.Fatalf("overlap issues = %+v", overlaps)
+	}
+	if !strings.Contains(overlaps[0].Rule, "curl -k") || !strings.Contains(overlaps[0].Message, "deny") {
+		t.Errorf("overlap = %+v", overlaps[0])
+	}
+}
+
+func TestLintOverlapsScopedVariants(t *testing.T) {
+	aliases := []model.Alias{
+		{From: "make", To: "just", Tool: "Bash", Param: "command", MatchKind: "literal", ScopeCWD: "/src/app"},
+		{From: "make", To: "task", Tool: "Bash", Param: "command", MatchKind: "literal", ScopeCWD: "/src/tools"},
+		{From: "make", To: "gmake", Tool: "Bash", Param: "command", MatchKind: "literal"},
+	}
+
+	// Variants in different scopes never apply together; only the unscoped
+	// rule overlaps, and only with the scoped ones.
+	if issues := lintOverlaps(aliases); len(issues) != 2 {
+		t.Fatalf("overlap issues = %+v", issues)
+	}
+	if issues := lintOverlaps(aliases[:2]); len(issues) != 0 {
+		t.Fatalf("overlap issues between scoped variants = %+v", issues)
+	}
+}
+
+func TestLintDeadRules(t *testing.T) {
+	aliases := []model.Alias{
+		{From: "read_file", To: "Read"},
+		{From: "write_file", To: "Write"},
+		{From: "r", To: "R", Tool: "Bash", Param: "command", Command: "scp", MatchKind: "flag"},
+		{From: "grep", To: "rg", Tool: "Bash", Param: "command", Command: "grep", MatchKind: "command"},
+		{From: "cat_file", To: "Read", CreatedAt: time.Now()}, // too new to judge
+	}
+	desires := []model.Desire{
+		{ToolName: "read_file"},
+		{ToolName: "Bash", ToolInput: json.RawMessage(`{"command":"scp -r a b"}`)},
+	}
+
+	issues := lintDeadRules(aliases, desires, time.Now().AddDate(0, 0, -30), 30)
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 dead rules, got %+v", issues)
+	}
+	if !strings.Contains(issues[0].Rule, "write_file") || !strings.Contains(issues[1].Rule, "grep") {
+		t.Errorf("unexpected dead rules: %+v", issues)
+	}
+}
+
+func TestAliasesLintCmd(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := s.SetAlias(ctx, model.Alias{From: "read_file", To: "Read"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordDesire(ctx, model.Desire{ID: "d1", ToolName: "read_file", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	dbPath = db
+	jsonOutput = false
+	lintFile = ""
+	lintDays = 30
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"aliases", "lint", "--db", db})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("clean table should lint ok: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "No issues found.") {
+		t.Errorf("expected no issues, got:\n%s", buf.String())
+	}
+
+	// A pack with a cycle fails.
+	pack := filepath.Join(t.TempDir(), "pack.toml")
+	data := "[meta]\nversion = 1\ncount = 2\n\n[[aliases]]\nfrom = \"a\"\nto = \"b\"\n\n[[aliases]]\nfrom = \"b\"\nto = \"a\"\n"
+	if err := os.WriteFile(pack, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	rootCmd.SetArgs([]string{"aliases", "lint", "--db", db, "--file", pack, "--days", "0"})
+	err = rootCmd.Execute()
+	lintFile = ""
+	lintDays = 30
+	if err == nil || !strings.Contains(err.Error(), "error(s) found") {
+		t.Fatalf("expected lint failure, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "cycle") {
+		t.Errorf("expected cycle in output, got:\n%s", buf.String())
+	}
+
+	// A pack with only warnings passes, unless --strict.
+	data = "[meta]\nversion = 1\ncount = 2\n\n[[aliases]]\nfrom = \"a\"\nto = \"b\"\n\n[[aliases]]\nfrom = \"b\"\nto = \"Read\"\n"
+	if err := os.WriteFile(pack, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	rootCmd.SetArgs([]string{"aliases", "lint", "--db", db, "--file", pack, "--days", "0"})
+	err = rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("warnings alone should lint ok: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "chain") {
+		t.Errorf("expected chain in output, got:\n%s", buf.String())
+	}
+	rootCmd.SetArgs([]string{"aliases", "lint", "--db", db, "--file", pack, "--days", "0", "--strict"})
+	err = rootCmd.Execute()
+	lintFile = ""
+	lintDays = 30
+	lintStrict = false
+	if err == nil || !strings.Contains(err.Error(), "issue(s) found") {
+		t.Fatalf("expected --strict lint failure, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// aliasScope describes where a tool call happens, for matching against
+// alias scope fields. The git remote is resolved lazily from CWD the first
+// time a remote-scoped alias is checked.
+type aliasScope struct {
+	CWD    string
+	Source string
+	Now    time.Time
+
+	remote         string
+	remoteResolved bool
+}
+
+// applies reports whether alias a is active in this scope. Unscoped aliases
+// always apply; an alias with several scope fields must satisfy all of them.
+func (sc *aliasScope) applies(a model.Alias) bool {
+	if a.Expired(sc.Now) {
+		return false
+	}
+	if a.ScopeSource != "" && a.ScopeSource != sc.Source {
+		return false
+	}
+	if a.ScopeCWD != "" && !matchCWDScope(a.ScopeCWD, sc.CWD) {
+		return false
+	}
+	if a.ScopeRemote != "" {
+		if !sc.remoteResolved {
+			sc.remote = gitRemoteURL(sc.CWD)
+			sc.remoteResolved = true
+		}
+		if sc.remote == "" || !strings.Contains(sc.remote, a.ScopeRemote) {
+			return false
+		}
+	}
+	return true
+}
+
+// filter returns the aliases that apply in this scope and those that don't.
+// When several scoped variants of the same rule apply, only the most
+// specific one is kept; the others are returned with those that don't
+// apply.
+func (sc *aliasScope) filter(aliases []model.Alias) (in, out []model.Alias) {
+	kept := make(map[model.AliasKey]int) // unscoped key -> index in in
+	for _, a := range aliases {
+		if !sc.applies(a) {
+			out = append(out, a)
+			continue
+		}
+		k := a.Key().Unscoped()
+		i, ok := kept[k]
+		switch {
+		case !ok:
+			kept[k] = len(in)
+			in = append(in, a)
+		case scopeSpecificity(a) > scopeSpecificity(in[i]):
+			out = append(out, in[i])
+			in[i] = a
+		default:
+			out = append(out, a)
+		}
+	}
+	return in, out
+}
+
+// scopeSpecificity counts the scope fields set on a.
+func scopeSpecificity(a model.Alias) int {
+	n := 0
+	for _, f := range []string{a.ScopeCWD, a.ScopeRemote, a.ScopeSource} {
+		if f != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// matchCWDScope reports whether cwd, or any of its parent directories,
+// matches the glob pattern. A scope of "/src/app" therefore covers
+// "/src/app/internal" too. A leading "~/" expands to the home directory.
+func matchCWDScope(pattern, cwd string) bool {
+	if cwd == "" {
+		return false
+	}
+	if strings.HasPrefix(pattern, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			pattern = filepath.Join(home, pattern[2:])
+		}
+	}
+	pattern = filepath.Clean(pattern)
+	dir := filepath.Clean(cwd)
+	for {
+		if ok, _ := filepath.Match(pattern, dir); ok {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// gitRemoteURL returns the origin remote URL of the repository containing
+// dir, or "" if there is none. It reads .git/config directly so pave-check
+// never shells out.
+func gitRemoteURL(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	root, err := findGitRoot(dir)
+	if err != nil {
+		return ""
+	}
+	gitDir := filepath.Join(root, ".git")
+	if fi, err := os.Stat(gitDir); err == nil && !fi.IsDir() {
+		// Worktree or submodule: .git is a file pointing at the real dir.
+		data, err := os.ReadFile(gitDir)
+		if err != nil {
+			return ""
+		}
+		ref := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+		if !filepath.IsAbs(ref) {
+			ref = filepath.Join(root, ref)
+		}
+		gitDir = ref
+		// Worktrees keep config in the common dir.
+		if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+			c := strings.TrimSpace(string(common))
+			if !filepath.IsAbs(c) {
+				c = filepath.Join(gitDir, c)
+			}
+			gitDir = c
+		}
+	}
+
+	f, err := os.Open(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	inOrigin := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "[") {
+			inOrigin = line == `[remote "origin"]`
+			continue
+		}
+		if !inOrigin {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(k) == "url" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// formatAliasScope renders an alias's scope for table output.
+func formatAliasScope(a model.Alias, now time.Time) string {
+	var parts []string
+	if a.ScopeCWD != "" {
+		parts = append(parts, "cwd="+a.ScopeCWD)
+	}
+	if a.ScopeRemote != "" {
+		parts = append(parts, "remote="+a.ScopeRemote)
+	}
+	if a.ScopeSource != "" {
+		parts = append(parts, "source="+a.ScopeSource)
+	}
+	if a.ExpiresAt != nil {
+		if a.Expired(now) {
+			parts = append(parts, "expired")
+		} else {
+			parts = append(parts, "until="+a.ExpiresAt.Local().Format("2006-01-02"))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func TestMatchCWDScope(t *testing.T) {
+	tests := []struct {
+		pattern, cwd string
+		want         bool
+	}{
+		{"/src/app", "/src/app", true},
+		{"/src/app", "/src/app/internal/cli", true},
+		{"/src/app", "/src/application", false},
+		{"/src/*", "/src/other/pkg", true},
+		{"/work/*/api", "/work/acme/api/cmd", true},
+		{"/work/*/api", "/work/acme/web", false},
+		{"/src/app", "", false},
+	}
+	for _, tt := range tests {
+		if got := matchCWDScope(tt.pattern, tt.cwd); got != tt.want {
+			t.Errorf("matchCWDScope(%q, %q) = %v, want %v", tt.pattern, tt.cwd, got, tt.want)
+		}
+	}
+}
+
+func TestGitRemoteURL(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := `[core]
+	bare = false
+[remote "upstream"]
+	url = git@github.com:other/fork.git
+[remote "origin"]
+	url = git@github.com:acme/app.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+`
+	if err := os.WriteFile(filepath.Join(root, ".git", "config"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "pkg")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := gitRemoteURL(sub); got != "git@github.com:acme/app.git" {
+		t.Errorf("gitRemoteURL = %q", got)
+	}
+	if got := gitRemoteURL(t.TempDir()); got != "" {
+		t.Errorf("expected no remote outside a repo, got %q", got)
+	}
+}
+
+func TestAliasScopeApplies(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	sc := &aliasScope{CWD: "/src/app", Source: "claude-code", Now: now, remoteResolved: true, remote: "git@github.com:acme/app.git"}
+	tests := []struct {
+		name string
+		a    model.Alias
+		want bool
+	}{
+		{"unscoped", model.Alias{From: "x"}, true},
+		{"matching cwd", model.Alias{ScopeCWD: "/src/*"}, true},
+		{"other cwd", model.Alias{ScopeCWD: "/other"}, false},
+		{"matching source", model.Alias{ScopeSource: "claude-code"}, true},
+		{"other source", model.Alias{ScopeSource: "cursor"}, false},
+		{"matching remote", model.Alias{ScopeRemote: "acme/app"}, true},
+		{"other remote", model.Alias{ScopeRemote: "acme/web"}, false},
+		{"not yet expired", model.Alias{ExpiresAt: &future}, true},
+		{"expired", model.Alias{ExpiresAt: &past}, false},
+		{"all must match", model.Alias{ScopeCWD: "/src/app", ScopeSource: "cursor"}, false},
+	}
+	for _, tt := range tests {
+		if got := sc.applies(tt.a); got != tt.want {
+			t.Errorf("%s: applies = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/spf13/cobra"
+)
+
+var (
+	aliasTestTool   string // --tool
+	aliasTestInput  string // --input
+	aliasTestCWD    string // --cwd
+	aliasTestSource string // --source
+)
+
+var aliasTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Simulate pave-check against a sample tool call",
+	Long: `Run the same checks as the pave-check hook against a sample tool call
+and report what would happen, without recording anything.
+
+Pass the tool name with --tool and the tool input as a JSON object with
+--input. Without --input, a full PreToolUse hook payload is read from
+stdin (--tool, if given, overrides its tool_name).
+
+Scoped aliases are matched against --cwd (default: the payload's cwd, or
+the current directory) and --source (default: claude-code).
+
+The report shows which rules matched, the resulting updatedInput, the
+additionalContext text, and whether the hook would exit 2. Nothing is
+written to the database and no hook-format JSON is printed.`,
+	Example: `  dp aliases test --tool Bash --input '{"command":"scp -r a b"}'
+  dp aliases test --tool read_file --input '{}'
+  echo '{"tool_name":"Bash","tool_input":{"command":"grep -rn x ."}}' | dp aliases test
+  dp aliases test --tool Bash --input '{"command":"rm -rf /"}' --json
+  dp aliases test --tool Bash --input '{"command":"make"}' --cwd ~/src/app`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAliasTest(os.Stdin, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	aliasTestCmd.Flags().StringVar(&aliasTestTool, "tool", "", "tool name to simulate (e.g. Bash)")
+	aliasTestCmd.Flags().StringVar(&aliasTestInput, "input", "", "tool input as a JSON object (default: read hook payload from stdin)")
+	aliasTestCmd.Flags().StringVar(&aliasTestCWD, "cwd", "", "working directory for scoped aliases (default: payload cwd or current directory)")
+	aliasTestCmd.Flags().StringVar(&aliasTestSource, "source", "claude-code", "source name for source-scoped aliases")
+	aliasesCmd.AddCommand(aliasTestCmd)
+}
+
+// aliasTestResult is the JSON structure for 'dp aliases test' output.
+type aliasTestResult struct {
+	ToolName          string                 `json:"tool_name"`
+	ToolInput         map[string]interface{} `json:"tool_input"`
+	RulesChecked      int                    `json:"rules_checked"`
+	Matched           []model.Alias          `json:"matched"`
+	OutOfScope        []model.Alias          `json:"out_of_scope,omitempty"`
+	Decision          string                 `json:"decision"` // "allow", "block", "deny", "correct"
+	ExitCode          int                    `json:"exit_code"`
+	Message           string                 `json:"message,omitempty"`
+	UpdatedInput      map[string]interface{} `json:"updated_input,omitempty"`
+	AdditionalContext string                 `json:"additional_context,omitempty"`
+}
+
+// runAliasTest builds a hook payload from flags or r, evaluates it against
+// the current rules, and writes a report to w.
+func runAliasTest(r io.Reader, w io.Writer) error {
+	payload, err := aliasTestPayload(r)
+	if err != nil {
+		return err
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer s.Close()
+
+	if aliasTestCWD != "" {
+		payload.CWD = aliasTestCWD
+	}
+	if payload.CWD == "" {
+		payload.CWD, _ = os.Getwd()
+	}
+	scope := &aliasScope{CWD: payload.CWD, Source: aliasTestSource, Now: time.Now()}
+
+	v, err := evaluatePave(context.Background(), s, payload, scope)
+	if err != nil {
+		return err
+	}
+
+	res := aliasTestResult{
+		ToolName:     payload.ToolName,
+		ToolInput:    payload.ToolInput,
+		RulesChecked: len(v.rules),
+		Matched:      []model.Alias{},
+		OutOfScope:   v.outOfScope,
+		Decision:     "allow",
+	}
+	switch {
+	case v.block != nil:
+		res.Matched = append(res.Matched, *v.block)
+		res.Decision = model.InterventionBlock
+		res.ExitCode = 2
+		res.Message = v.blockMsg
+	case v.deny != nil:
+		res.Matched = append(res.Matched, *v.deny)
+		res.Decision = model.InterventionDeny
+		res.Message = v.output.HookSpecificOutput.PermissionDecisionReason
+	case v.output != nil:
+		for _, c := range v.corrections {
+			res.Matched = append(res.Matched, c.rules...)
+		}
+		res.Decision = model.InterventionCorrect
+		res.UpdatedInput = v.output.HookSpecificOutput.UpdatedInput
+		res.AdditionalContext = v.output.HookSpecificOutput.AdditionalContext
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	printAliasTestResult(w, res)
+	return nil
+}
+
+// aliasTestPayload assembles the simulated hook payload.
+func aliasTestPayload(r io.Reader) (hookPayload, error) {
+	var p hookPayload
+	if aliasTestInput != "" {
+		if aliasTestTool == "" {
+			return p, fmt.Errorf("--input requires --tool")
+		}
+		if err := json.Unmarshal([]byte(aliasTestInput), &p.ToolInput); err != nil {
+			return p, fmt.Errorf("parse --input: %w", err)
+		}
+		p.ToolName = aliasTestTool
+		return p, nil
+	}
+
+	if f, ok := r.(*os.File); ok && isTTY(f) {
+		return p, fmt.Errorf("provide --tool and --input, or pipe a hook payload on stdin")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return p, fmt.Errorf("read stdin: %w", err)
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("parse payload: %w", err)
+	}
+	if aliasTestTool != "" {
+		p.ToolName = aliasTestTool
+	}
+	if p.ToolName == "" {
+		return p, fmt.Errorf("payload has no tool_name (use --tool)")
+	}
+	return p, nil
+}
+
+func printAliasTestResult(w io.Writer, res aliasTestResult) {
+	color := isTTY(w)
+
+	fmt.Fprintf(w, "Tool:           %s\n", res.ToolName)
+	fmt.Fprintf(w, "Rules checked:  %d\n", res.RulesChecked)
+
+	fmt.Fprintln(w)
+	if len(res.Matched) == 0 {
+		fmt.Fprintln(w, "No rules matched.")
+	} else {
+		fmt.Fprintln(w, bold("Matched rules:", color))
+		for _, a := range res.Matched {
+			if a.IsToolNameAlias() {
+				fmt.Fprintf(w, "  %-8s %-8s %s → %s\n", "alias", "", a.From, a.To)
+				continue
+			}
+			fmt.Fprintf(w, "  %-8s %-8s %s\n", a.MatchKind, a.Command, formatRuleDescription(a))
+		}
+	}
+
+	if len(res.OutOfScope) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, bold("Skipped (out of scope):", color))
+		now := time.Now()
+		for _, a := range res.OutOfScope {
+			kind := "alias"
+			if a.MatchKind != "" {
+				kind = a.MatchKind
+			}
+			fmt.Fprintf(w, "  %-8s %-8s %s  [%s]\n", kind, a.Command, lintRuleName(a), formatAliasScope(a, now))
+		}
+	}
+
+	fmt.Fprintln(w)
+	switch res.Decision {
+	case model.InterventionBlock:
+		fmt.Fprintf(w, "Result:         block (exit 2)\n")
+		fmt.Fprintf(w, "Message:        %s\n", res.Message)
+	case model.InterventionDeny:
+		fmt.Fprintf(w, "Result:         deny (exit 0, permissionDecision \"deny\")\n")
+		fmt.Fprintf(w, "Reason:         %s\n", res.Message)
+	case model.InterventionCorrect:
+		fmt.Fprintf(w, "Result:         correct (exit 0, permissionDecision \"allow\")\n")
+		fmt.Fprintln(w, "updatedInput:")
+		keys := make([]string, 0, len(res.UpdatedInput))
+		for k := range res.UpdatedInput {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "  %s: %v\n", k, res.UpdatedInput[k])
+		}
+		fmt.Fprintf(w, "additionalContext:\n  %s\n", strings.ReplaceAll(res.AdditionalContext, "\n", "\n  "))
+	default:
+		fmt.Fprintf(w, "Result:         allow (exit 0, no output)\n")
+	}
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func seedAliasTestDB(t *testing.T, aliases ...model.Alias) string {
+	t.Helper()
+	db := filepath.Join(t.TempDir(), "test.db")
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range aliases {
+		if err := s.SetAlias(context.Background(), a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+	dbPath = db
+	jsonOutput = false
+	aliasTestTool = ""
+	aliasTestInput = ""
+	aliasTestCWD = ""
+	aliasTestSource = "claude-code"
+	t.Cleanup(func() {
+		aliasTestTool = ""
+		aliasTestInput = ""
+		aliasTestCWD = ""
+		jsonOutput = false
+	})
+	return db
+}
+
+func TestAliasTestFlagCorrection(t *testing.T) {
+	seedAliasTestDB(t, model.Alias{
+		From: "r", To: "R", Tool: "Bash", Param: "command", Command: "scp", MatchKind: "flag",
+	})
+	aliasTestTool = "Bash"
+	aliasTestInput = `{"command":"scp -r a b"}`
+
+	var buf bytes.Buffer
+	if err := runAliasTest(strings.NewReader(""), &buf); err != nil {
+		t.Fatalf("runAliasTest: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"Rules checked:  1", "flag", "Result:         correct", "command: scp -R a b", "Corrected:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hookSpecificOutput") {
+		t.Errorf("output should not be in hook format:\n%s", out)
+	}
+}
+
+func TestAliasTestBlockFromStdin(t *testing.T) {
+	db := seedAliasTestDB(t, model.Alias{From: "read_file", To: "Read"})
+	jsonOutput = true
+
+	var buf bytes.Buffer
+	payload := `{"tool_name":"read_file","tool_input":{"file_path":"/tmp/x"}}`
+	if err := runAliasTest(strings.NewReader(payload), &buf); err != nil {
+		t.Fatalf("runAliasTest: %v", err)
+	}
+
+	var res aliasTestResult
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, buf.String())
+	}
+	if res.Decision != "block" || res.ExitCode != 2 {
+		t.Errorf("got decision=%q exit=%d, want block/2", res.Decision, res.ExitCode)
+	}
+	if len(res.Matched) != 1 || res.Matched[0].To != "Read" {
+		t.Errorf("matched = %+v", res.Matched)
+	}
+
+	// Simulation must not record anything.
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ivs, err := s.InterventionStats(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ivs) != 0 {
+		t.Errorf("expected no interventions recorded, got %+v", ivs)
+	}
+}
+
+func TestAliasTestDenyAndNoMatch(t *testing.T) {
+	seedAliasTestDB(t, model.Alias{
+		From: "rm -rf /", Tool: "Bash", Param: "command", Command: "rm", MatchKind: "deny", Message: "nope",
+	})
+	jsonOutput = true
+	aliasTestTool = "Bash"
+
+	tests := []struct {
+		input    string
+		decision string
+		message  string
+	}{
+		{`{"command":"rm -rf /"}`, "deny", "nope"},
+		{`{"command":"ls -la"}`, "allow", ""},
+	}
+	for _, tt := range tests {
+		aliasTestInput = tt.input
+		var buf bytes.Buffer
+		if err := runAliasTest(strings.NewReader(""), &buf); err != nil {
+			t.Fatalf("runAliasTest(%s): %v", tt.input, err)
+		}
+		var res aliasTestResult
+		if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if res.Decision != tt.decision || res.Message != tt.message || res.ExitCode != 0 {
+			t.Errorf("%s: got %+v", tt.input, res)
+		}
+	}
+}
+
+func TestAliasTestValidation(t *testing.T) {
+	seedAliasTestDB(t)
+
+	aliasTestInput = `{"command":"ls"}`
+	if err := runAliasTest(strings.NewReader(""), &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "--input requires --tool") {
+		t.Errorf("expected --tool error, got %v", err)
+	}
+
+	aliasTestTool = "Bash"
+	aliasTestInput = `not json`
+	if err := runAliasTest(strings.NewReader(""), &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "parse --input") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+
+	aliasTestTool = ""
+	aliasTestInput = ""
+	if err := runAliasTest(strings.NewReader(`{"tool_input":{}}`), &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "no tool_name") {
+		t.Errorf("expected tool_name error, got %v", err)
+	}
+}
+
+func TestAliasTestCommandPath(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"aliases", "test"})
+	if err != nil || cmd != aliasTestCmd {
+		t.Errorf("dp aliases test resolved to %v, %v", cmd, err)
+	}
+	// "test" stays free as the FROM of a tool-name alias.
+	cmd, args, err := rootCmd.Find([]string{"alias", "test", "Bash"})
+	if err != nil || cmd != aliasCmd || len(args) != 2 {
+		t.Errorf("dp alias test Bash resolved to %v %v, %v", cmd, args, err)
+	}
+}
@@ -24,7 +24,12 @@ func resetAliasFlags(t *testing.T) {
 	aliasParam = ""
 	aliasRegex = false
 	aliasRecipe = false
+	aliasDeny = false
 	aliasMessage = ""
+	aliasScopeCWD = ""
+	aliasScopeRemote = ""
+	aliasScopeSource = ""
+	aliasExpires = ""
 }
 
 func TestAliasCmdSet(t *testing.T) {
@@ -379,7 +384,7 @@ func TestAliasCmdFlagCorrection(t *testing.T) {
 	}
 	defer s.Close()
 
-	alias, err := s.GetAlias(context.Background(), "r", "Bash", "command", "scp", "flag")
+	alias, err := s.GetAlias(context.Background(), model.AliasKey{From: "r", Tool: "Bash", Param: "command", Command: "scp", MatchKind: "flag"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -425,7 +430,7 @@ func TestAliasCmdReplace(t *testing.T) {
 	}
 	defer s.Close()
 
-	alias, err := s.GetAlias(context.Background(), "grep", "Bash", "command", "grep", "command")
+	alias, err := s.GetAlias(context.Background(), model.AliasKey{From: "grep", Tool: "Bash", Param: "command", Command: "grep", MatchKind: "command"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -456,7 +461,7 @@ func TestAliasCmdLiteral(t *testing.T) {
 	}
 	defer s.Close()
 
-	alias, err := s.GetAlias(context.Background(), "user@host:", "Bash", "command", "scp", "literal")
+	alias, err := s.GetAlias(context.Background(), model.AliasKey{From: "user@host:", Tool: "Bash", Param: "command", Command: "scp", MatchKind: "literal"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -484,7 +489,7 @@ func TestAliasCmdToolParam(t *testing.T) {
 	}
 	defer s.Close()
 
-	alias, err := s.GetAlias(context.Background(), "/old", "MyMCP", "input_path", "", "literal")
+	alias, err := s.GetAlias(context.Background(), model.AliasKey{From: "/old", Tool: "MyMCP", Param: "input_path", MatchKind: "literal"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -512,7 +517,7 @@ func TestAliasCmdToolParamRegex(t *testing.T) {
 	}
 	defer s.Close()
 
-	alias, err := s.GetAlias(context.Background(), "curl -k", "Bash", "command", "", "regex")
+	alias, err := s.GetAlias(context.Background(), model.AliasKey{From: "curl -k", Tool: "Bash", Param: "command", MatchKind: "regex"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -647,7 +652,7 @@ func TestAliasCmdRecipeCreate(t *testing.T) {
 	}
 	defer s.Close()
 
-	alias, err := s.GetAlias(context.Background(), "gt await-signal", "Bash", "command", "gt", "recipe")
+	alias, err := s.GetAlias(context.Background(), model.AliasKey{From: "gt await-signal", Tool: "Bash", Param: "command", Command: "gt", MatchKind: "recipe"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -681,7 +686,7 @@ func TestAliasCmdRecipeWithMessage(t *testing.T) {
 	}
 	defer s.Close()
 
-	alias, err := s.GetAlias(context.Background(), "bd list --wisp", "Bash", "command", "bd", "recipe")
+	alias, err := s.GetAlias(context.Background(), model.AliasKey{From: "bd list --wisp", Tool: "Bash", Param: "command", Command: "bd", MatchKind: "recipe"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -801,6 +806,111 @@ func TestAliasCmdRecipeValidation(t *testing.T) {
 	}
 }
 
+func TestAliasCmdDenyCreate(t *testing.T) {
+	resetAliasFlags(t)
+	db := filepath.Join(t.TempDir(), "test.db")
+	dbPath = db
+
+	rootCmd.SetArgs([]string{"alias", "--db", db, "--deny", "rm -rf /", "--message", "Never delete the filesystem root"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	alias, err := s.GetAlias(context.Background(), model.AliasKey{From: "rm -rf /", Tool: "Bash", Param: "command", Command: "rm", MatchKind: "deny"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alias == nil {
+		t.Fatal("expected deny rule, got nil")
+	}
+	if alias.To != "" {
+		t.Errorf("got to=%q, want empty", alias.To)
+	}
+	if alias.Message != "Never delete the filesystem root" {
+		t.Errorf("got message=%q", alias.Message)
+	}
+}
+
+func TestAliasCmdDenyRegexAndToolParam(t *testing.T) {
+	resetAliasFlags(t)
+	aliasDeny = true
+	aliasRegex = true
+	a, err := buildAlias([]string{`curl[^|]*\|\s*sh`})
+	if err != nil {
+		t.Fatalf("build regex deny: %v", err)
+	}
+	if a.Tool != "Bash" || a.Param != "command" || a.Command != "" || a.MatchKind != "deny" {
+		t.Errorf("unexpected regex deny rule: %+v", a)
+	}
+
+	resetAliasFlags(t)
+	aliasDeny = true
+	aliasTool = "WebFetch"
+	aliasParam = "url"
+	a, err = buildAlias([]string{`internal\.corp`})
+	resetAliasFlags(t)
+	if err != nil {
+		t.Fatalf("build tool deny: %v", err)
+	}
+	if a.Tool != "WebFetch" || a.Param != "url" || a.Command != "" || a.MatchKind != "deny" {
+		t.Errorf("unexpected tool deny rule: %+v", a)
+	}
+}
+
+func TestAliasCmdDenyValidation(t *testing.T) {
+	resetAliasFlags(t)
+	t.Cleanup(func() { resetAliasFlags(t) })
+	db := filepath.Join(t.TempDir(), "test.db")
+	dbPath = db
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "deny and cmd mutual exclusion",
+			args: []string{"alias", "--db", db, "--deny", "--cmd", "rm", "rm -rf /"},
+			want: "--deny is mutually exclusive",
+		},
+		{
+			name: "deny and recipe mutual exclusion",
+			args: []string{"alias", "--db", db, "--deny", "--recipe", "a", "b"},
+			want: "mutually exclusive",
+		},
+		{
+			name: "deny with two args",
+			args: []string{"alias", "--db", db, "--deny", "a", "b"},
+			want: "--deny requires exactly one positional argument",
+		},
+		{
+			name: "deny with invalid regex",
+			args: []string{"alias", "--db", db, "--deny", "--regex", "("},
+			want: "invalid deny regex",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetAliasFlags(t)
+			rootCmd.SetArgs(tt.args)
+			err := rootCmd.Execute()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q should contain %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
 func TestExtractCommand(t *testing.T) {
 	tests := []struct {
 		input string
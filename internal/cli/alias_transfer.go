@@ -41,9 +41,18 @@ type ExportedAlias struct {
 	Command   string `json:"command,omitempty" toml:"command,omitempty"`
 	MatchKind string `json:"match_kind,omitempty" toml:"match_kind,omitempty"`
 	Message   string `json:"message,omitempty" toml:"message,omitempty"`
+
+	ScopeCWD    string `json:"scope_cwd,omitempty" toml:"scope_cwd,omitempty"`
+	ScopeRemote string `json:"scope_remote,omitempty" toml:"scope_remote,omitempty"`
+	ScopeSource string `json:"scope_source,omitempty" toml:"scope_source,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty" toml:"expires_at,omitempty"` // RFC3339
 }
 
 func toExported(a model.Alias) ExportedAlias {
+	var expires string
+	if a.ExpiresAt != nil {
+		expires = a.ExpiresAt.UTC().Format(time.RFC3339)
+	}
 	return ExportedAlias{
 		From:      a.From,
 		To:        a.To,
@@ -52,10 +61,19 @@ func toExported(a model.Alias) ExportedAlias {
 		Command:   a.Command,
 		MatchKind: a.MatchKind,
 		Message:   a.Message,
+
+		ScopeCWD:    a.ScopeCWD,
+		ScopeRemote: a.ScopeRemote,
+		ScopeSource: a.ScopeSource,
+		ExpiresAt:   expires,
 	}
 }
 
 func toModel(e ExportedAlias) model.Alias {
+	var expires *time.Time
+	if t, err := time.Parse(time.RFC3339, e.ExpiresAt); err == nil {
+		expires = &t
+	}
 	return model.Alias{
 		From:      e.From,
 		To:        e.To,
@@ -64,6 +82,11 @@ func toModel(e ExportedAlias) model.Alias {
 		Command:   e.Command,
 		MatchKind: e.MatchKind,
 		Message:   e.Message,
+
+		ScopeCWD:    e.ScopeCWD,
+		ScopeRemote: e.ScopeRemote,
+		ScopeSource: e.ScopeSource,
+		ExpiresAt:   expires,
 	}
 }
 
@@ -192,15 +215,14 @@ func runAliasExport(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runAliasImport(cmd *cobra.Command, args []string) error {
-	filePath := args[0]
-
+// readAliasCollection parses a TOML or JSON alias collection file.
+func readAliasCollection(filePath string) (AliasCollection, error) {
+	var collection AliasCollection
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("read file: %w", err)
+		return collection, fmt.Errorf("read file: %w", err)
 	}
 
-	var collection AliasCollection
 	ext := strings.ToLower(filepath.Ext(filePath))
 	switch ext {
 	case ".toml":
@@ -211,12 +233,20 @@ func runAliasImport(cmd *cobra.Command, args []string) error {
 		// Try TOML first, fall back to JSON.
 		if err2 := toml.Unmarshal(data, &collection); err2 != nil {
 			if err3 := json.Unmarshal(data, &collection); err3 != nil {
-				return fmt.Errorf("cannot parse %s as TOML or JSON", filePath)
+				return collection, fmt.Errorf("cannot parse %s as TOML or JSON", filePath)
 			}
 		}
 	}
 	if err != nil {
-		return fmt.Errorf("parse %s: %w", filePath, err)
+		return collection, fmt.Errorf("parse %s: %w", filePath, err)
+	}
+	return collection, nil
+}
+
+func runAliasImport(cmd *cobra.Command, args []string) error {
+	collection, err := readAliasCollection(args[0])
+	if err != nil {
+		return err
 	}
 
 	if len(collection.Aliases) == 0 {
@@ -238,7 +268,7 @@ func runAliasImport(cmd *cobra.Command, args []string) error {
 	var imported, skipped, overwritten int
 
 	for _, ea := range collection.Aliases {
-		existing, err := s.GetAlias(ctx, ea.From, ea.Tool, ea.Param, ea.Command, ea.MatchKind)
+		existing, err := s.GetAlias(ctx, toModel(ea).Key())
 		if err != nil {
 			return fmt.Errorf("check existing alias %q: %w", ea.From, err)
 		}
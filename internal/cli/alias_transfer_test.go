@@ -187,3 +187,49 @@ func TestAliasImportSkipExisting(t *testing.T) {
 		t.Errorf("aliases = %d, want 1 (skip should prevent duplicate)", len(aliases))
 	}
 }
+
+func TestAliasImportRoundTripScope(t *testing.T) {
+	resetAliasFlags(t)
+	t.Cleanup(func() { resetAliasFlags(t) })
+	tmp := t.TempDir()
+	dbSrc := filepath.Join(tmp, "src.db")
+	dbDst := filepath.Join(tmp, "dst.db")
+	exportFile := filepath.Join(tmp, "aliases.toml")
+
+	rootCmd.SetArgs([]string{"--db", dbSrc, "alias", "--cmd", "make", "--replace", "just",
+		"--scope-cwd", "/src/app", "--scope-remote", "github.com/acme/app", "--scope-source", "claude-code", "--expires", "2099-01-01"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("alias set: %v", err)
+	}
+	resetAliasFlags(t)
+
+	rootCmd.SetArgs([]string{"--db", dbSrc, "aliases", "export", "-o", exportFile})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	rootCmd.SetArgs([]string{"--db", dbDst, "aliases", "import", exportFile})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	s, err := openStoreAt(dbDst)
+	if err != nil {
+		t.Fatalf("open dst store: %v", err)
+	}
+	defer s.Close()
+
+	aliases, err := s.GetAliases(t.Context())
+	if err != nil {
+		t.Fatalf("get aliases: %v", err)
+	}
+	if len(aliases) != 1 {
+		t.Fatalf("imported aliases = %d, want 1", len(aliases))
+	}
+	a := aliases[0]
+	if a.ScopeCWD != "/src/app" || a.ScopeRemote != "github.com/acme/app" || a.ScopeSource != "claude-code" {
+		t.Errorf("scope not carried through: %+v", a)
+	}
+	if a.ExpiresAt == nil || a.ExpiresAt.Year() != 2099 {
+		t.Errorf("expires_at = %v, want 2099-01-01", a.ExpiresAt)
+	}
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareBy       string
+	compareSince    string
+	compareTool     string
+	compareMinCalls int
+)
+
+// unknownModel labels calls whose source didn't report a model.
+const unknownModel = "(unknown)"
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare failure rates across models",
+	Long: `Compare reports the failure rate of each tool under each model, from
+recorded invocations. Use it to tell which model upgrade introduced new
+failures, such as calls to tools that don't exist.
+
+A tool marked "only this model" was never called by any other model: with
+a high failure rate, that is usually a hallucinated tool name.
+
+The model comes from the source payload (Cursor) or the session transcript
+(Claude Code). Calls without one are grouped as (unknown).`,
+	Example: `  dp compare --by model
+  dp compare --by model --since 7d
+  dp compare --by model --tool Bash
+  dp compare --by model --min-calls 10 --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if compareBy != "model" {
+			return fmt.Errorf("unsupported --by %q (use model)", compareBy)
+		}
+		s, err := openStore()
+		if err != nil {
+			return fmt.Errorf("open store: %w", err)
+		}
+		defer s.Close()
+
+		opts := store.InvocationOpts{ToolName: compareTool}
+		if compareSince != "" {
+			d, err := parseDuration(compareSince)
+			if err != nil {
+				return fmt.Errorf("invalid --since value %q: %w", compareSince, err)
+			}
+			opts.Since = time.Now().Add(-d)
+		}
+		invs, err := s.ListInvocations(context.Background(), opts)
+		if err != nil {
+			return fmt.Errorf("list invocations: %w", err)
+		}
+
+		r := buildModelComparison(invs, compareMinCalls)
+		if jsonOutput {
+			return writeComparisonJSON(os.Stdout, r)
+		}
+		writeComparisonTable(os.Stdout, r)
+		return nil
+	},
+}
+
+func init() {
+	compareCmd.Flags().StringVar(&compareBy, "by", "model", "dimension to compare (model)")
+	compareCmd.Flags().StringVar(&compareSince, "since", "", "only include calls within this duration (e.g., 24h, 7d)")
+	compareCmd.Flags().StringVar(&compareTool, "tool", "", "only compare this tool")
+	compareCmd.Flags().IntVar(&compareMinCalls, "min-calls", 1, "hide tool rows with fewer calls")
+	rootCmd.AddCommand(compareCmd)
+}
+
+// modelStats counts one model's calls and failures.
+type modelStats struct {
+	Model       string  `json:"model"`
+	Calls       int     `json:"calls"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+}
+
+// toolModelStats counts one tool's calls and failures under one model.
+type toolModelStats struct {
+	ToolName string `json:"tool_name"`
+	modelStats
+	OnlyModel bool `json:"only_model"` // no other model called the tool
+}
+
+// modelComparison is the report of dp compare --by model.
+type modelComparison struct {
+	Models []modelStats     `json:"models"` // by calls, most first
+	Tools  []toolModelStats `json:"tools"`  // by tool, then failure rate, highest first
+}
+
+// buildModelComparison groups invs by model and by tool and model. Tool rows
+// with fewer than minCalls calls are left out; the model totals count them.
+func buildModelComparison(invs []model.Invocation, minCalls int) *modelComparison {
+	byModel := make(map[string]*modelStats)
+	type key struct{ tool, model string }
+	byTool := make(map[key]*toolModelStats)
+	toolModels := make(map[string]int) // distinct models that called each tool
+	for _, inv := range invs {
+		m := inv.Model
+		if m == "" {
+			m = unknownModel
+		}
+		ms := byModel[m]
+		if ms == nil {
+			ms = &modelStats{Model: m}
+			byModel[m] = ms
+		}
+		k := key{inv.ToolName, m}
+		ts := byTool[k]
+		if ts == nil {
+			ts = &toolModelStats{ToolName: inv.ToolName, modelStats: modelStats{Model: m}}
+			byTool[k] = ts
+			toolModels[inv.ToolName]++
+		}
+		ms.Calls++
+		ts.Calls++
+		if inv.IsError {
+			ms.Failures++
+			ts.Failures++
+		}
+	}
+
+	r := &modelComparison{Models: []modelStats{}, Tools: []toolModelStats{}}
+	for _, ms := range byModel {
+		ms.FailureRate = float64(ms.Failures) / float64(ms.Calls)
+		r.Models = append(r.Models, *ms)
+	}
+	sort.Slice(r.Models, func(i, j int) bool {
+		a, b := r.Models[i], r.Models[j]
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return a.Model < b.Model
+	})
+	for _, ts := range byTool {
+		if ts.Calls < minCalls {
+			continue
+		}
+		ts.FailureRate = float64(ts.Failures) / float64(ts.Calls)
+		ts.OnlyModel = len(byModel) > 1 && toolModels[ts.ToolName] == 1
+		r.Tools = append(r.Tools, *ts)
+	}
+	sort.Slice(r.Tools, func(i, j int) bool {
+		a, b := r.Tools[i], r.Tools[j]
+		if a.ToolName != b.ToolName {
+			return a.ToolName < b.ToolName
+		}
+		if a.FailureRate != b.FailureRate {
+			return a.FailureRate > b.FailureRate
+		}
+		return a.Model < b.Model
+	})
+	return r
+}
+
+func writeComparisonJSON(w io.Writer, r *modelComparison) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func writeComparisonTable(w io.Writer, r *modelComparison) {
+	if len(r.Models) == 0 {
+		fmt.Fprintln(w, "No invocations found.")
+		return
+	}
+	color := isTTY(w)
+	fmt.Fprintln(w, bold("By model:", color))
+	tbl := NewTable(w, "MODEL", "CALLS", "FAILURES", "FAIL%")
+	for _, m := range r.Models {
+		tbl.Row(m.Model, itoa(m.Calls), itoa(m.Failures), fmt.Sprintf("%.1f%%", m.FailureRate*100))
+	}
+	tbl.Flush()
+
+	if len(r.Tools) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, bold("By tool and model:", color))
+	tbl = NewTable(w, "TOOL", "MODEL", "CALLS", "FAILURES", "FAIL%", "NOTE")
+	for _, t := range r.Tools {
+		note := ""
+		if t.OnlyModel {
+			note = "only this model"
+		}
+		tbl.Row(t.ToolName, t.Model, itoa(t.Calls), itoa(t.Failures), fmt.Sprintf("%.1f%%", t.FailureRate*100), note)
+	}
+	tbl.Flush()
+}
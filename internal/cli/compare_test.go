@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func TestBuildModelComparison(t *testing.T) {
+	invs := []model.Invocation{
+		{ToolName: "Read", Model: "old"},
+		{ToolName: "Read", Model: "old"},
+		{ToolName: "Read", Model: "new", IsError: true},
+		{ToolName: "Read", Model: "new"},
+		{ToolName: "read_file", Model: "new", IsError: true},
+		{ToolName: "read_file", Model: "new", IsError: true},
+		{ToolName: "Bash"},
+	}
+	r := buildModelComparison(invs, 1)
+
+	wantModels := []modelStats{
+		{Model: "new", Calls: 4, Failures: 3, FailureRate: 0.75},
+		{Model: "old", Calls: 2},
+		{Model: unknownModel, Calls: 1},
+	}
+	if len(r.Models) != len(wantModels) {
+		t.Fatalf("Models = %+v", r.Models)
+	}
+	for i, want := range wantModels {
+		if r.Models[i] != want {
+			t.Errorf("Models[%d] = %+v, want %+v", i, r.Models[i], want)
+		}
+	}
+
+	var rows []string
+	for _, tm := range r.Tools {
+		rows = append(rows, tm.ToolName+"/"+tm.Model)
+		switch tm.ToolName {
+		case "read_file":
+			if !tm.OnlyModel || tm.FailureRate != 1 {
+				t.Errorf("read_file = %+v, want only-model with 100%% failures", tm)
+			}
+		case "Read":
+			if tm.OnlyModel {
+				t.Errorf("Read is called by two models: %+v", tm)
+			}
+		}
+	}
+	if got := strings.Join(rows, " "); got != "Bash/(unknown) Read/new Read/old read_file/new" {
+		t.Errorf("tool rows = %s", got)
+	}
+
+	if r := buildModelComparison(invs, 2); len(r.Tools) != 3 || r.Models[0].Calls != 4 {
+		t.Errorf("--min-calls 2 should hide only Bash: %+v", r.Tools)
+	}
+}
+
+func TestWriteComparisonTable(t *testing.T) {
+	var buf bytes.Buffer
+	writeComparisonTable(&buf, buildModelComparison([]model.Invocation{
+		{ToolName: "Read", Model: "old"},
+		{ToolName: "read_file", Model: "new", IsError: true},
+	}, 1))
+	out := buf.String()
+	for _, want := range []string{"By model:", "By tool and model:", "read_file", "100.0%", "only this model"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	writeComparisonTable(&buf, buildModelComparison(nil, 1))
+	if !strings.Contains(buf.String(), "No invocations found.") {
+		t.Errorf("unexpected empty output: %q", buf.String())
+	}
+}
@@ -70,12 +70,22 @@ func showConfig(cfg *config.Config) error {
 		val, _ := cfg.Get(key)
 		if val == "" {
 			val = "(not set)"
+		} else if key == "remote_token" {
+			val = maskToken(val)
 		}
 		fmt.Fprintf(w, "%s\t%s\n", key, val)
 	}
 	return w.Flush()
 }
 
+// maskToken hides all but the start of a secret for table display.
+func maskToken(tok string) string {
+	if len(tok) <= 8 {
+		return "********"
+	}
+	return tok[:7] + "…"
+}
+
 func getConfig(cfg *config.Config, key string) error {
 	val, err := cfg.Get(key)
 	if err != nil {
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/config"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// toolCost is the tokens spent on one tool's failed calls. A retry is a
+// failure that follows another failure of the same tool in the same turn,
+// with no success in between.
+type toolCost struct {
+	ToolName    string  `json:"tool_name"`
+	Failures    int     `json:"failures"`
+	Retries     int     `json:"retries"`
+	Tokens      int     `json:"tokens"`       // all failures, retries included
+	RetryTokens int     `json:"retry_tokens"` // retries only
+	Cost        float64 `json:"cost_usd"`
+	RetryCost   float64 `json:"retry_cost_usd"`
+}
+
+// costReport estimates the tokens and dollars spent on calls, and how much
+// of it went to failed calls and their retries.
+type costReport struct {
+	Since           time.Time         `json:"since,omitempty"`
+	Price           config.TokenPrice `json:"price_per_mtok"`
+	Calls           int               `json:"calls"`
+	CallsWithTokens int               `json:"calls_with_tokens"` // calls matched to a transcript
+	Tokens          int               `json:"tokens"`
+	Cost            float64           `json:"cost_usd"`
+	FailedTokens    int               `json:"failed_tokens"`
+	FailedCost      float64           `json:"failed_cost_usd"`
+	RetryTokens     int               `json:"retry_tokens"`
+	RetryCost       float64           `json:"retry_cost_usd"`
+	Tools           []toolCost        `json:"tools"` // by cost, highest first
+}
+
+// loadCostReport reads the invocations recorded since since and prices
+// them with the configured token prices.
+func loadCostReport(ctx context.Context, s store.Store, since time.Time) (*costReport, error) {
+	cfg, err := config.LoadFrom(configPath)
+	if err != nil {
+		return nil, err
+	}
+	invs, err := s.ListInvocations(ctx, store.InvocationOpts{Since: since})
+	if err != nil {
+		return nil, fmt.Errorf("list invocations: %w", err)
+	}
+	r := buildCostReport(invs, cfg.TokenPriceFor)
+	r.Since = since
+	return r, nil
+}
+
+// buildCostReport totals the tokens of invs and prices each call at the
+// price priceFor gives its model.
+func buildCostReport(invs []model.Invocation, priceFor func(model string) config.TokenPrice) *costReport {
+	r := &costReport{Price: priceFor(""), Tools: []toolCost{}}
+	sort.SliceStable(invs, func(i, j int) bool { return invs[i].Timestamp.Before(invs[j].Timestamp) })
+
+	type turnKey struct{ session, turn, tool string }
+	failing := make(map[turnKey]bool) // the last call of the tool in the turn failed
+	byTool := make(map[string]*toolCost)
+	for _, inv := range invs {
+		tokens := inv.InputTokens + inv.OutputTokens + inv.CacheReadTokens + inv.CacheWriteTokens
+		cost := priceFor(inv.Model).Cost(inv.InputTokens, inv.OutputTokens, inv.CacheReadTokens, inv.CacheWriteTokens)
+		r.Calls++
+		if tokens > 0 {
+			r.CallsWithTokens++
+		}
+		r.Tokens += tokens
+		r.Cost += cost
+
+		k := turnKey{inv.InstanceID, inv.TurnID, inv.ToolName}
+		retry := k.session != "" && failing[k]
+		if k.session != "" {
+			failing[k] = inv.IsError
+		}
+		if !inv.IsError {
+			continue
+		}
+
+		tc := byTool[inv.ToolName]
+		if tc == nil {
+			tc = &toolCost{ToolName: inv.ToolName}
+			byTool[inv.ToolName] = tc
+		}
+		tc.Failures++
+		tc.Tokens += tokens
+		tc.Cost += cost
+		r.FailedTokens += tokens
+		r.FailedCost += cost
+		if retry {
+			tc.Retries++
+			tc.RetryTokens += tokens
+			tc.RetryCost += cost
+			r.RetryTokens += tokens
+			r.RetryCost += cost
+		}
+	}
+
+	for _, tc := range byTool {
+		tc.Cost, tc.RetryCost = roundCents(tc.Cost), roundCents(tc.RetryCost)
+		r.Tools = append(r.Tools, *tc)
+	}
+	sort.Slice(r.Tools, func(i, j int) bool {
+		a, b := r.Tools[i], r.Tools[j]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		if a.Tokens != b.Tokens {
+			return a.Tokens > b.Tokens
+		}
+		if a.Failures != b.Failures {
+			return a.Failures > b.Failures
+		}
+		return a.ToolName < b.ToolName
+	})
+	r.Cost, r.FailedCost, r.RetryCost = roundCents(r.Cost), roundCents(r.FailedCost), roundCents(r.RetryCost)
+	return r
+}
+
+// roundCents rounds a dollar amount to a hundredth of a cent.
+func roundCents(d float64) float64 {
+	return math.Round(d*1e4) / 1e4
+}
+
+// formatDollars shows a dollar amount with cents, or more precision below
+// ten cents.
+func formatDollars(d float64) string {
+	if d != 0 && d < 0.1 {
+		return fmt.Sprintf("$%.4f", d)
+	}
+	return fmt.Sprintf("$%.2f", d)
+}
+
+// formatTokens shows a token count compactly: 950, 12.3k, 4.5M.
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// percentOf returns part as a percentage of whole, or 0 if whole is 0.
+func percentOf(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return 100 * float64(part) / float64(whole)
+}
+
+func printCostReport(w io.Writer, r *costReport, top int) {
+	color := isTTY(w)
+	fmt.Fprintf(w, "Calls:              %d (%d with token data)\n", r.Calls, r.CallsWithTokens)
+	if r.CallsWithTokens == 0 {
+		fmt.Fprintln(w, "\nNo token data recorded yet. Tokens are read from Claude Code transcripts when calls are ingested.")
+		return
+	}
+	fmt.Fprintf(w, "Tokens:             %s (%s)\n", formatTokens(r.Tokens), formatDollars(r.Cost))
+	fmt.Fprintf(w, "Failed calls:       %s (%s, %.1f%% of tokens)\n",
+		formatTokens(r.FailedTokens), formatDollars(r.FailedCost), percentOf(r.FailedTokens, r.Tokens))
+	fmt.Fprintf(w, "  of which retries: %s (%s)\n", formatTokens(r.RetryTokens), formatDollars(r.RetryCost))
+
+	if len(r.Tools) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, bold("Failed calls by tool:", color))
+	tbl := NewTable(w, "TOOL", "FAILURES", "RETRIES", "TOKENS", "COST", "RETRY_COST")
+	for i, tc := range r.Tools {
+		if top > 0 && i == top {
+			break
+		}
+		tbl.Row(
+			tc.ToolName,
+			itoa(tc.Failures),
+			itoa(tc.Retries),
+			formatTokens(tc.Tokens),
+			formatDollars(tc.Cost),
+			formatDollars(tc.RetryCost),
+		)
+	}
+	tbl.Flush()
+	fmt.Fprintf(w, "\nDefault prices per million tokens: input $%g, output $%g, cache read $%g, cache write $%g (token_prices).\n",
+		r.Price.Input, r.Price.Output, r.Price.CacheRead, r.Price.CacheWrite)
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/config"
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func TestBuildCostReport(t *testing.T) {
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	inv := func(id, session, turn, tool string, isErr bool, offset, output int) model.Invocation {
+		return model.Invocation{
+			ID: id, InstanceID: session, TurnID: turn, ToolName: tool, IsError: isErr,
+			Timestamp: base.Add(time.Duration(offset) * time.Second), OutputTokens: output,
+		}
+	}
+	invs := []model.Invocation{
+		// Listed newest first, as the store returns them.
+		inv("i6", "s2", "s2:0", "Bash", true, 6, 1000),
+		inv("i5", "s1", "s1:1", "Bash", true, 5, 1000),
+		inv("i4", "s1", "s1:0", "Bash", false, 4, 1000),
+		inv("i3", "s1", "s1:0", "Bash", true, 3, 2000),
+		inv("i2", "s1", "s1:0", "Read", false, 2, 1000),
+		inv("i1", "s1", "s1:0", "Bash", true, 1, 4000),
+		inv("i0", "s1", "s1:0", "Read", false, 0, 0),
+	}
+	// $1 per thousand output tokens.
+	r := buildCostReport(invs, func(string) config.TokenPrice { return config.TokenPrice{Output: 1000} })
+
+	if r.Calls != 7 || r.CallsWithTokens != 6 || r.Tokens != 10000 || r.Cost != 10 {
+		t.Errorf("totals = %d calls, %d with tokens, %d tokens, $%v", r.Calls, r.CallsWithTokens, r.Tokens, r.Cost)
+	}
+	// i3 retries i1 in the same turn; i5 is in a new turn, and i6 in
+	// another session.
+	if r.FailedTokens != 8000 || r.FailedCost != 8 || r.RetryTokens != 2000 || r.RetryCost != 2 {
+		t.Errorf("failed = %d ($%v), retries = %d ($%v)", r.FailedTokens, r.FailedCost, r.RetryTokens, r.RetryCost)
+	}
+	if len(r.Tools) != 1 {
+		t.Fatalf("expected 1 tool, got %+v", r.Tools)
+	}
+	want := toolCost{ToolName: "Bash", Failures: 4, Retries: 1, Tokens: 8000, RetryTokens: 2000, Cost: 8, RetryCost: 2}
+	if r.Tools[0] != want {
+		t.Errorf("tool = %+v, want %+v", r.Tools[0], want)
+	}
+}
+
+func TestBuildCostReportPricesByModel(t *testing.T) {
+	invs := []model.Invocation{
+		{ID: "a", ToolName: "Read", Model: "big", OutputTokens: 1000},
+		{ID: "b", ToolName: "Read", Model: "small", OutputTokens: 1000},
+	}
+	prices := map[string]config.TokenPrice{"big": {Output: 10_000}, "": {Output: 1000}}
+	r := buildCostReport(invs, func(m string) config.TokenPrice {
+		if p, ok := prices[m]; ok {
+			return p
+		}
+		return prices[""]
+	})
+	if r.Cost != 11 || r.Price != prices[""] {
+		t.Errorf("Cost = $%v, Price = %+v; want $11 at the default price", r.Cost, r.Price)
+	}
+}
+
+func TestPrintCostReport(t *testing.T) {
+	r := &costReport{
+		Price:           config.DefaultTokenPrice,
+		Calls:           10,
+		CallsWithTokens: 8,
+		Tokens:          250_000,
+		Cost:            1.5,
+		FailedTokens:    50_000,
+		FailedCost:      0.3,
+		RetryTokens:     20_000,
+		RetryCost:       0.05,
+		Tools:           []toolCost{{ToolName: "Bash", Failures: 3, Retries: 1, Tokens: 50_000, RetryTokens: 20_000, Cost: 0.3, RetryCost: 0.05}},
+	}
+	var buf bytes.Buffer
+	printCostReport(&buf, r, 10)
+	out := buf.String()
+	for _, want := range []string{
+		"Calls:              10 (8 with token data)",
+		"Tokens:             250.0k ($1.50)",
+		"Failed calls:       50.0k ($0.30, 20.0% of tokens)",
+		"of which retries: 20.0k ($0.0500)",
+		"RETRY_COST",
+		"input $3, output $15",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	printCostReport(&buf, &costReport{Calls: 3}, 10)
+	if !strings.Contains(buf.String(), "No token data recorded yet.") {
+		t.Errorf("unexpected output without tokens:\n%s", buf.String())
+	}
+}
+
+func TestPathCosts(t *testing.T) {
+	paths := []model.Path{{Pattern: "Bash", Count: 4}, {Pattern: "read_file", Count: 2}}
+	r := &costReport{Tools: []toolCost{{ToolName: "Bash", Retries: 1, Tokens: 8000, Cost: 8}}}
+	costs := pathCosts(paths, r)
+	if len(costs) != 2 || costs[0].Tokens != 8000 || costs[0].Cost != 8 || costs[1].Tokens != 0 {
+		t.Errorf("costs = %+v", costs)
+	}
+
+	var buf bytes.Buffer
+	writePathsCostTable(&buf, costs)
+	if !strings.Contains(buf.String(), "8.0k") || !strings.Contains(buf.String(), "$8.00") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
+
+func TestBuildCostReportToolsByCost(t *testing.T) {
+	// Grep fails with fewer tokens than Read, but on a pricier model.
+	invs := []model.Invocation{
+		{ID: "a", ToolName: "Read", IsError: true, Model: "small", OutputTokens: 2000},
+		{ID: "b", ToolName: "Grep", IsError: true, Model: "big", OutputTokens: 1000},
+	}
+	r := buildCostReport(invs, func(m string) config.TokenPrice {
+		if m == "big" {
+			return config.TokenPrice{Output: 10_000}
+		}
+		return config.TokenPrice{Output: 1000}
+	})
+	if len(r.Tools) != 2 || r.Tools[0].ToolName != "Grep" || r.Tools[1].ToolName != "Read" {
+		t.Errorf("tools = %+v, want Grep ($10) before Read ($2)", r.Tools)
+	}
+}
@@ -18,6 +18,7 @@ var (
 	exportFormat string
 	exportSince  string
 	exportType   string
+	exportModel  string
 )
 
 var exportCmd = &cobra.Command{
@@ -35,7 +36,8 @@ Use --type to select what to export: desires (default) or invocations.`,
   dp export --format json --since 2024-01-01T00:00:00Z | jq '.tool_name'
   dp export --type invocations
   dp export --type invocations --format csv > invocations.csv
-  dp export --type invocations --since 2024-01-01`,
+  dp export --type invocations --since 2024-01-01
+  dp export --type invocations --model cursor-fast`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s, err := openStore()
 		if err != nil {
@@ -63,11 +65,12 @@ func init() {
 	exportCmd.Flags().StringVar(&exportFormat, "format", "json", "output format: json or csv")
 	exportCmd.Flags().StringVar(&exportSince, "since", "", "only export records after this time (RFC3339 or YYYY-MM-DD)")
 	exportCmd.Flags().StringVar(&exportType, "type", "desires", "data type to export: desires or invocations")
+	exportCmd.Flags().StringVar(&exportModel, "model", "", "only export records made by this model")
 	rootCmd.AddCommand(exportCmd)
 }
 
 func exportDesires(s store.Store, format string) error {
-	var opts store.ListOpts
+	opts := store.ListOpts{Model: exportModel}
 	if exportSince != "" {
 		t, err := parseSince(exportSince)
 		if err != nil {
@@ -92,7 +95,7 @@ func exportDesires(s store.Store, format string) error {
 }
 
 func exportInvocations(s store.Store, format string) error {
-	var opts store.InvocationOpts
+	opts := store.InvocationOpts{Model: exportModel}
 	if exportSince != "" {
 		t, err := parseSince(exportSince)
 		if err != nil {
@@ -27,6 +27,7 @@ Shows all matching desires aggregated with:
   - Frequency over time (text histogram by day)
   - Most common tool_input values
   - Most common error messages
+  - Retry chains (attempts, time to recovery, and the fixes that worked)
 
 The pattern argument is an exact tool name by default. Use % as a wildcard
 for broader matching (e.g., "read%" matches read_file, read_dir, etc.).`,
@@ -155,4 +156,25 @@ func writeInspectText(w io.Writer, r *store.InspectResult) {
 			fmt.Fprintf(w, "  %4d  %s\n", e.Count, truncate(e.Name, maxName))
 		}
 	}
+
+	// Retry chains.
+	if c := r.Chains; c != nil {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, bold("Retry chains:", color))
+		fmt.Fprintf(w, "  %d total: %d recovered, %d abandoned, %d open\n", c.Total, c.Recovered, c.Abandoned, c.Open)
+		fmt.Fprintf(w, "  Avg attempts:         %.1f\n", c.AvgAttempts)
+		if c.Recovered > 0 {
+			fmt.Fprintf(w, "  Avg time to recovery: %s\n", formatElapsed(c.AvgRecoveryMs))
+		}
+		if len(c.TopFixes) > 0 {
+			maxName := width - 10
+			if maxName < 30 {
+				maxName = 30
+			}
+			fmt.Fprintln(w, "  Fixes that worked:")
+			for _, f := range c.TopFixes {
+				fmt.Fprintf(w, "  %4d  %s\n", f.Count, truncate(f.Name, maxName))
+			}
+		}
+	}
 }
@@ -37,6 +37,7 @@ func resetFlags(t *testing.T) {
 	t.Helper()
 	jsonOutput = false
 	exportType = "desires"
+	exportModel = ""
 	recordSource = ""
 	ingestSource = ""
 }
@@ -222,3 +223,30 @@ func TestInspectCmdRequiresArg(t *testing.T) {
 		t.Fatal("expected error for missing argument")
 	}
 }
+
+func TestWriteInspectTextChains(t *testing.T) {
+	r := &store.InspectResult{
+		Pattern: "Bash",
+		Total:   3,
+		Chains: &store.ChainSummary{
+			Total: 4, Recovered: 2, Abandoned: 1, Open: 1,
+			AvgAttempts:   1.5,
+			AvgRecoveryMs: 8400,
+			TopFixes:      []store.NameCount{{Name: `command: "scp -r" → "scp -R"`, Count: 2}},
+		},
+	}
+	var buf bytes.Buffer
+	writeInspectText(&buf, r)
+	out := buf.String()
+	for _, want := range []string{
+		"Retry chains:",
+		"4 total: 2 recovered, 1 abandoned, 1 open",
+		"Avg attempts:         1.5",
+		"Avg time to recovery: 8.4s",
+		`2  command: "scp -r" → "scp -R"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
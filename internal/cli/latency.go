@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/analyze"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// defaultSlow is the p95 at or above which a failing tool is reported as
+// slow and failing.
+const defaultSlow = 10 * time.Second
+
+// toolLatency is how long one tool's calls took. Percentiles cover the
+// calls with a known duration.
+type toolLatency struct {
+	ToolName    string  `json:"tool_name"`
+	Calls       int     `json:"calls"`
+	Timed       int     `json:"timed"` // calls with a known duration
+	Failures    int     `json:"failures"`
+	Timeouts    int     `json:"timeouts"`
+	FailureRate float64 `json:"failure_rate"`
+	P50Ms       int64   `json:"p50_ms"`
+	P95Ms       int64   `json:"p95_ms"`
+	P99Ms       int64   `json:"p99_ms"`
+	MaxMs       int64   `json:"max_ms"`
+	FailedMs    int64   `json:"failed_ms"` // time spent in failed calls
+}
+
+// latencyReport summarises tool call durations per tool.
+type latencyReport struct {
+	Since    time.Time     `json:"since,omitempty"`
+	Calls    int           `json:"calls"`
+	Timed    int           `json:"timed"` // calls with a known duration
+	Timeouts int           `json:"timeouts"`
+	SlowMs   int64         `json:"slow_ms"`
+	Tools    []toolLatency `json:"tools"` // by p95, slowest first
+	// SlowFailing lists the tools with failures whose p95 is at least
+	// SlowMs, by time spent in failed calls.
+	SlowFailing []toolLatency `json:"slow_and_failing"`
+}
+
+// loadLatencyReport reads the invocations recorded since since and
+// summarises their durations.
+func loadLatencyReport(ctx context.Context, s store.Store, since time.Time, slow time.Duration) (*latencyReport, error) {
+	invs, err := s.ListInvocations(ctx, store.InvocationOpts{Since: since})
+	if err != nil {
+		return nil, fmt.Errorf("list invocations: %w", err)
+	}
+	r := buildLatencyReport(invs, slow)
+	r.Since = since
+	return r, nil
+}
+
+// buildLatencyReport computes per-tool latency percentiles for invs. A
+// failed call counts as a timeout when its error says so.
+func buildLatencyReport(invs []model.Invocation, slow time.Duration) *latencyReport {
+	r := &latencyReport{SlowMs: slow.Milliseconds(), Tools: []toolLatency{}, SlowFailing: []toolLatency{}}
+	byTool := make(map[string]*toolLatency)
+	durations := make(map[string][]int64)
+	for _, inv := range invs {
+		tl := byTool[inv.ToolName]
+		if tl == nil {
+			tl = &toolLatency{ToolName: inv.ToolName}
+			byTool[inv.ToolName] = tl
+		}
+		r.Calls++
+		tl.Calls++
+		ms := int64(inv.DurationMs)
+		if ms > 0 {
+			r.Timed++
+			tl.Timed++
+			durations[inv.ToolName] = append(durations[inv.ToolName], ms)
+		}
+		if !inv.IsError {
+			continue
+		}
+		tl.Failures++
+		tl.FailedMs += ms
+		if analyze.IsTimeout(inv.Error) {
+			tl.Timeouts++
+			r.Timeouts++
+		}
+	}
+
+	for name, tl := range byTool {
+		if tl.Timed == 0 && tl.Timeouts == 0 {
+			continue
+		}
+		tl.FailureRate = float64(tl.Failures) / float64(tl.Calls)
+		ds := durations[name]
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		tl.P50Ms, tl.P95Ms, tl.P99Ms = percentile(ds, 50), percentile(ds, 95), percentile(ds, 99)
+		if len(ds) > 0 {
+			tl.MaxMs = ds[len(ds)-1]
+		}
+		r.Tools = append(r.Tools, *tl)
+		if tl.Failures > 0 && tl.Timed > 0 && tl.P95Ms >= r.SlowMs {
+			r.SlowFailing = append(r.SlowFailing, *tl)
+		}
+	}
+	sort.Slice(r.Tools, func(i, j int) bool {
+		a, b := r.Tools[i], r.Tools[j]
+		if a.P95Ms != b.P95Ms {
+			return a.P95Ms > b.P95Ms
+		}
+		return a.ToolName < b.ToolName
+	})
+	sort.Slice(r.SlowFailing, func(i, j int) bool {
+		a, b := r.SlowFailing[i], r.SlowFailing[j]
+		if a.FailedMs != b.FailedMs {
+			return a.FailedMs > b.FailedMs
+		}
+		return a.ToolName < b.ToolName
+	})
+	return r
+}
+
+// percentile returns the nearest-rank pth percentile of sorted, or 0 if it
+// is empty.
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// formatLatency shows a duration in milliseconds, or "-" when unknown.
+func formatLatency(ms int64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return formatElapsed(ms)
+}
+
+func printLatencyReport(w io.Writer, r *latencyReport, top int) {
+	color := isTTY(w)
+	fmt.Fprintf(w, "Calls:              %d (%d with duration)\n", r.Calls, r.Timed)
+	fmt.Fprintf(w, "Timeouts:           %d\n", r.Timeouts)
+	if r.Timed == 0 {
+		fmt.Fprintln(w, "\nNo durations recorded yet. Durations come from Cursor payloads and from Claude Code transcripts when calls are ingested.")
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, bold("Latency by tool:", color))
+	tbl := NewTable(w, "TOOL", "CALLS", "P50", "P95", "P99", "MAX", "FAIL%", "TIMEOUTS")
+	for i, tl := range r.Tools {
+		if top > 0 && i == top {
+			break
+		}
+		tbl.Row(
+			tl.ToolName,
+			itoa(tl.Calls),
+			formatLatency(tl.P50Ms),
+			formatLatency(tl.P95Ms),
+			formatLatency(tl.P99Ms),
+			formatLatency(tl.MaxMs),
+			fmt.Sprintf("%.1f%%", tl.FailureRate*100),
+			itoa(tl.Timeouts),
+		)
+	}
+	tbl.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, bold(fmt.Sprintf("Slow and failing (p95 ≥ %s):", formatElapsed(r.SlowMs)), color))
+	if len(r.SlowFailing) == 0 {
+		fmt.Fprintln(w, "  none")
+		return
+	}
+	tbl = NewTable(w, "TOOL", "FAILURES", "FAIL%", "TIMEOUTS", "P95", "TIME_IN_FAILURES")
+	for _, tl := range r.SlowFailing {
+		tbl.Row(
+			tl.ToolName,
+			itoa(tl.Failures),
+			fmt.Sprintf("%.1f%%", tl.FailureRate*100),
+			itoa(tl.Timeouts),
+			formatLatency(tl.P95Ms),
+			formatLatency(tl.FailedMs),
+		)
+	}
+	tbl.Flush()
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func TestBuildLatencyReport(t *testing.T) {
+	var invs []model.Invocation
+	// Read: 100 fast calls, 1..100ms.
+	for i := 1; i <= 100; i++ {
+		invs = append(invs, model.Invocation{ToolName: "Read", DurationMs: i})
+	}
+	// Bash: slow, with a timeout and another failure.
+	invs = append(invs,
+		model.Invocation{ToolName: "Bash", DurationMs: 2000},
+		model.Invocation{ToolName: "Bash", DurationMs: 120_000, IsError: true, Error: "Command timed out after 2m 0.0s"},
+		model.Invocation{ToolName: "Bash", DurationMs: 15_000, IsError: true, Error: "exit status 1"},
+		model.Invocation{ToolName: "Bash"}, // no duration
+	)
+	// Glob: never timed.
+	invs = append(invs, model.Invocation{ToolName: "Glob"})
+
+	r := buildLatencyReport(invs, 10*time.Second)
+	if r.Calls != 105 || r.Timed != 103 || r.Timeouts != 1 || r.SlowMs != 10_000 {
+		t.Errorf("totals = %d calls, %d timed, %d timeouts, slow %dms", r.Calls, r.Timed, r.Timeouts, r.SlowMs)
+	}
+	if len(r.Tools) != 2 {
+		t.Fatalf("tools = %+v, want Bash and Read", r.Tools)
+	}
+	bash, read := r.Tools[0], r.Tools[1]
+	want := toolLatency{
+		ToolName: "Bash", Calls: 4, Timed: 3, Failures: 2, Timeouts: 1, FailureRate: 0.5,
+		P50Ms: 15_000, P95Ms: 120_000, P99Ms: 120_000, MaxMs: 120_000, FailedMs: 135_000,
+	}
+	if bash != want {
+		t.Errorf("Bash = %+v, want %+v", bash, want)
+	}
+	if read.P50Ms != 50 || read.P95Ms != 95 || read.P99Ms != 99 || read.MaxMs != 100 {
+		t.Errorf("Read percentiles = %d/%d/%d/%d, want 50/95/99/100", read.P50Ms, read.P95Ms, read.P99Ms, read.MaxMs)
+	}
+	if len(r.SlowFailing) != 1 || r.SlowFailing[0].ToolName != "Bash" {
+		t.Errorf("SlowFailing = %+v, want Bash", r.SlowFailing)
+	}
+
+	if r := buildLatencyReport(invs, 5*time.Minute); len(r.SlowFailing) != 0 {
+		t.Errorf("nothing is slow at 5m: %+v", r.SlowFailing)
+	}
+}
+
+func TestPrintLatencyReport(t *testing.T) {
+	r := buildLatencyReport([]model.Invocation{
+		{ToolName: "Bash", DurationMs: 30_000, IsError: true, Error: "Command timed out after 30s"},
+		{ToolName: "Read", DurationMs: 12},
+	}, 10*time.Second)
+	var buf bytes.Buffer
+	printLatencyReport(&buf, r, 10)
+	out := buf.String()
+	for _, want := range []string{
+		"Calls:              2 (2 with duration)",
+		"Timeouts:           1",
+		"P95",
+		"12ms",
+		"Slow and failing (p95 ≥ 10.0s):",
+		"TIME_IN_FAILURES",
+		"30.0s",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	printLatencyReport(&buf, buildLatencyReport(nil, defaultSlow), 10)
+	if !strings.Contains(buf.String(), "No durations recorded yet.") {
+		t.Errorf("unexpected empty output: %q", buf.String())
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("empty = %d, want 0", got)
+	}
+	if got := percentile([]int64{7}, 99); got != 7 {
+		t.Errorf("single = %d, want 7", got)
+	}
+	if got := percentile([]int64{1, 2, 3, 4}, 50); got != 2 {
+		t.Errorf("p50 of 4 = %d, want 2", got)
+	}
+}
@@ -19,6 +19,7 @@ var (
 	listSource   string
 	listTool     string
 	listCategory string
+	listModel    string
 	listLimit    int
 )
 
@@ -32,6 +33,7 @@ Results are ordered by timestamp, newest first.`,
   dp list --source claude-code --limit 20
   dp list --tool read_file --since 24h
   dp list --category env-need
+  dp list --model claude-sonnet-4-5
   dp list --json`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s, err := openStore()
@@ -44,6 +46,7 @@ Results are ordered by timestamp, newest first.`,
 			Source:   listSource,
 			ToolName: listTool,
 			Category: listCategory,
+			Model:    listModel,
 			Limit:    listLimit,
 		}
 
@@ -96,6 +99,7 @@ func init() {
 	listCmd.Flags().StringVar(&listSource, "source", "", "filter by source")
 	listCmd.Flags().StringVar(&listTool, "tool", "", "filter by tool name")
 	listCmd.Flags().StringVar(&listCategory, "category", "", "filter by category (e.g., env-need)")
+	listCmd.Flags().StringVar(&listModel, "model", "", "filter by the model that made the call")
 	listCmd.Flags().IntVar(&listLimit, "limit", 50, "maximum number of results")
 	rootCmd.AddCommand(listCmd)
 }
@@ -18,6 +18,8 @@ var (
 	pathsTop   int
 	pathsSince string
 	pathsTurns bool
+	pathsSort  string
+	pathsCost  bool
 )
 
 // pathsCmd displays aggregated desire paths ranked by frequency.
@@ -26,9 +28,20 @@ var pathsCmd = &cobra.Command{
 	Short: "Show aggregated paths ranked by frequency",
 	Long: `Paths displays aggregated desire patterns ranked by how often they occur.
 Each row represents a unique tool name that has been recorded as a failed call,
-along with its frequency count, first/last occurrence, and any configured alias.`,
+along with its frequency count, first/last occurrence, and any configured alias.
+
+The IMPACT column weighs each path by the sessions it wasted rather than its
+raw count: every session that hit it scores one, plus a bonus for long retry
+chains, decayed by half every week since the session's last failure. Use
+--sort impact to rank by it, and the impact_* config keys to tune it.
+
+Use --cost to add the tokens and estimated dollars spent on each path's
+failed calls, and on retries of them within the same turn (see dp stats
+--cost).`,
 	Example: `  dp paths
   dp paths --top 10
+  dp paths --sort impact
+  dp paths --cost
   dp paths --since 2026-02-01T00:00:00Z
   dp paths --turns
   dp paths --json`,
@@ -43,7 +56,14 @@ along with its frequency count, first/last occurrence, and any configured alias.
 			return runPathsTurns(s)
 		}
 
-		opts := store.PathOpts{Top: pathsTop}
+		if pathsSort != store.PathSortCount && pathsSort != store.PathSortImpact {
+			return fmt.Errorf("invalid --sort value %q: must be count or impact", pathsSort)
+		}
+		cfg, err := config.LoadFrom(configPath)
+		if err != nil {
+			return err
+		}
+		opts := store.PathOpts{Top: pathsTop, Sort: pathsSort, Weights: impactWeights(cfg), Score: true}
 		if pathsSince != "" {
 			t, err := time.Parse(time.RFC3339, pathsSince)
 			if err != nil {
@@ -57,6 +77,21 @@ along with its frequency count, first/last occurrence, and any configured alias.
 			return fmt.Errorf("get paths: %w", err)
 		}
 
+		if pathsCost {
+			r, err := loadCostReport(context.Background(), s, opts.Since)
+			if err != nil {
+				return err
+			}
+			costs := pathCosts(paths, r)
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(costs)
+			}
+			writePathsCostTable(os.Stdout, costs)
+			return nil
+		}
+
 		if jsonOutput {
 			return writePathsJSON(os.Stdout, paths)
 		}
@@ -68,10 +103,28 @@ along with its frequency count, first/last occurrence, and any configured alias.
 func init() {
 	pathsCmd.Flags().IntVar(&pathsTop, "top", 20, "maximum number of paths to display")
 	pathsCmd.Flags().StringVar(&pathsSince, "since", "", "only include desires after this time (RFC3339)")
+	pathsCmd.Flags().StringVar(&pathsSort, "sort", store.PathSortCount, "rank by count or impact")
+	pathsCmd.Flags().BoolVar(&pathsCost, "cost", false, "show tokens and estimated dollars spent on each path's failed calls")
 	pathsCmd.Flags().BoolVar(&pathsTurns, "turns", false, "show per-tool turn statistics (AVG_TURN_LEN, LONG_TURN_%)")
 	rootCmd.AddCommand(pathsCmd)
 }
 
+// impactWeights returns the impact score weights, with any set in cfg
+// replacing the defaults.
+func impactWeights(cfg *config.Config) store.ImpactWeights {
+	w := store.DefaultImpactWeights
+	if cfg.ImpactSessionWeight != nil {
+		w.Sessions = *cfg.ImpactSessionWeight
+	}
+	if cfg.ImpactRetryWeight != nil {
+		w.Retries = *cfg.ImpactRetryWeight
+	}
+	if cfg.ImpactHalfLifeDays > 0 {
+		w.HalfLifeDays = cfg.ImpactHalfLifeDays
+	}
+	return w
+}
+
 // writePathsJSON writes paths as a JSON array to w.
 func writePathsJSON(w io.Writer, paths []model.Path) error {
 	enc := json.NewEncoder(w)
@@ -84,12 +137,14 @@ func writePathsJSON(w io.Writer, paths []model.Path) error {
 
 // writePathsTable writes paths as an aligned text table to w.
 func writePathsTable(w io.Writer, paths []model.Path) {
-	tbl := NewTable(w, "RANK", "PATTERN", "COUNT", "FIRST_SEEN", "LAST_SEEN", "ALIAS")
+	tbl := NewTable(w, "RANK", "PATTERN", "COUNT", "SESSIONS", "IMPACT", "FIRST_SEEN", "LAST_SEEN", "ALIAS")
 	for i, p := range paths {
 		tbl.Row(
 			fmt.Sprintf("%d", i+1),
 			p.Pattern,
 			fmt.Sprintf("%d", p.Count),
+			fmt.Sprintf("%d", p.Sessions),
+			fmt.Sprintf("%.1f", p.Impact),
 			p.FirstSeen.UTC().Format(time.RFC3339),
 			p.LastSeen.UTC().Format(time.RFC3339),
 			p.AliasTo,
@@ -98,6 +153,56 @@ func writePathsTable(w io.Writer, paths []model.Path) {
 	tbl.Flush()
 }
 
+// pathCost is a path with the tokens spent on its failed calls.
+type pathCost struct {
+	model.Path
+	Retries     int     `json:"retries"`
+	Tokens      int     `json:"tokens"`
+	RetryTokens int     `json:"retry_tokens"`
+	Cost        float64 `json:"cost_usd"`
+	RetryCost   float64 `json:"retry_cost_usd"`
+}
+
+// pathCosts joins paths with the per-tool costs in r. The result is never
+// nil.
+func pathCosts(paths []model.Path, r *costReport) []pathCost {
+	byTool := make(map[string]toolCost, len(r.Tools))
+	for _, tc := range r.Tools {
+		byTool[tc.ToolName] = tc
+	}
+	costs := make([]pathCost, 0, len(paths))
+	for _, p := range paths {
+		tc := byTool[p.Pattern]
+		costs = append(costs, pathCost{
+			Path:        p,
+			Retries:     tc.Retries,
+			Tokens:      tc.Tokens,
+			RetryTokens: tc.RetryTokens,
+			Cost:        tc.Cost,
+			RetryCost:   tc.RetryCost,
+		})
+	}
+	return costs
+}
+
+// writePathsCostTable writes paths with their costs as a text table to w.
+func writePathsCostTable(w io.Writer, costs []pathCost) {
+	tbl := NewTable(w, "RANK", "PATTERN", "COUNT", "RETRIES", "TOKENS", "COST", "RETRY_COST", "ALIAS")
+	for i, c := range costs {
+		tbl.Row(
+			fmt.Sprintf("%d", i+1),
+			c.Pattern,
+			fmt.Sprintf("%d", c.Count),
+			fmt.Sprintf("%d", c.Retries),
+			formatTokens(c.Tokens),
+			formatDollars(c.Cost),
+			formatDollars(c.RetryCost),
+			c.AliasTo,
+		)
+	}
+	tbl.Flush()
+}
+
 // runPathsTurns displays per-tool turn statistics.
 func runPathsTurns(s store.Store) error {
 	cfg, _ := config.LoadFrom(configPath)
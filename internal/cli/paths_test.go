@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/config"
 	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
 )
 
 func TestWritePathsTable(t *testing.T) {
@@ -141,3 +143,30 @@ func TestWritePathsTableTimestampFormat(t *testing.T) {
 		t.Errorf("expected RFC3339 timestamp, got: %s", out)
 	}
 }
+
+func TestWritePathsTableImpact(t *testing.T) {
+	paths := []model.Path{{ID: "spread", Pattern: "spread", Count: 3, Sessions: 3, Impact: 2.846}}
+
+	var buf bytes.Buffer
+	writePathsTable(&buf, paths)
+	out := buf.String()
+	if !strings.Contains(out, "SESSIONS") || !strings.Contains(out, "IMPACT") {
+		t.Errorf("missing SESSIONS/IMPACT headers:\n%s", out)
+	}
+	if !strings.Contains(out, "2.8") {
+		t.Errorf("missing impact score:\n%s", out)
+	}
+}
+
+func TestImpactWeights(t *testing.T) {
+	if got := impactWeights(&config.Config{}); got != store.DefaultImpactWeights {
+		t.Errorf("empty config = %+v, want defaults", got)
+	}
+
+	zero, two := 0.0, 2.0
+	got := impactWeights(&config.Config{ImpactSessionWeight: &two, ImpactRetryWeight: &zero, ImpactHalfLifeDays: 3})
+	want := store.ImpactWeights{Sessions: 2, Retries: 0, HalfLifeDays: 3}
+	if got != want {
+		t.Errorf("impactWeights = %+v, want %+v", got, want)
+	}
+}
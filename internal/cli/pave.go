@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/scbrown/desire-path/internal/model"
 	"github.com/scbrown/desire-path/internal/source"
@@ -17,6 +18,9 @@ var (
 	paveHook     bool
 	paveAgentsMD bool
 	paveAppend   string
+	paveWrite    string
+	paveProject  bool
+	paveStale    bool
 	paveSettings string
 )
 
@@ -35,6 +39,12 @@ var paveCmd = &cobra.Command{
                are wrong before it tries them. Output goes to stdout by default,
                or use --append to write directly to a file.
 
+               Use --write FILE to keep the rules in a managed block delimited by
+               <!-- dp:begin --> and <!-- dp:end -->. Re-running replaces the
+               block in place instead of duplicating it. --project targets the
+               AGENTS.md / CLAUDE.md files at the current git root, and --check
+               exits non-zero when a managed block is stale (for CI).
+
 Belt and suspenders: --hook is reactive (catches mistakes), --agents-md is
 preventive (stops them before they happen). Use both for maximum coverage.`,
 	Example: `  # Install the PreToolUse intercept hook
@@ -44,17 +54,37 @@ preventive (stops them before they happen). Use both for maximum coverage.`,
   dp pave --agents-md
 
   # Append rules to an existing AGENTS.md file
-  dp pave --agents-md --append AGENTS.md`,
+  dp pave --agents-md --append AGENTS.md
+
+  # Maintain a managed block in AGENTS.md (idempotent)
+  dp pave --agents-md --write AGENTS.md
+
+  # Update the project's AGENTS.md / CLAUDE.md at the git root
+  dp pave --agents-md --project
+
+  # Fail in CI if the managed block is out of date
+  dp pave --agents-md --project --check`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if !paveHook && !paveAgentsMD {
 			return fmt.Errorf("specify --hook or --agents-md (or both)")
 		}
+		if (paveWrite != "" || paveProject || paveStale) && !paveAgentsMD {
+			return fmt.Errorf("--write, --project, and --check require --agents-md")
+		}
+		if paveAppend != "" && (paveWrite != "" || paveProject) {
+			return fmt.Errorf("--append is mutually exclusive with --write/--project")
+		}
+		if paveStale && paveWrite == "" && !paveProject {
+			return fmt.Errorf("--check requires --write or --project")
+		}
 		if paveHook {
 			if err := runPaveHook(); err != nil {
 				return err
 			}
 		}
 		if paveAgentsMD {
+			// A stale --check is a result, not a usage mistake.
+			cmd.SilenceUsage = paveStale
 			if err := runPaveAgentsMD(); err != nil {
 				return err
 			}
@@ -67,6 +97,9 @@ func init() {
 	paveCmd.Flags().BoolVar(&paveHook, "hook", false, "install PreToolUse intercept hook")
 	paveCmd.Flags().BoolVar(&paveAgentsMD, "agents-md", false, "generate AGENTS.md rules from aliases")
 	paveCmd.Flags().StringVar(&paveAppend, "append", "", "append generated rules to this file (with --agents-md)")
+	paveCmd.Flags().StringVar(&paveWrite, "write", "", "maintain a managed dp block in this file (with --agents-md)")
+	paveCmd.Flags().BoolVar(&paveProject, "project", false, "maintain the managed block in AGENTS.md / CLAUDE.md at the git root")
+	paveCmd.Flags().BoolVar(&paveStale, "check", false, "exit non-zero if the managed block is stale instead of writing it")
 	paveCmd.Flags().StringVar(&paveSettings, "settings", "", "path to settings file (default: ~/.claude/settings.json)")
 	rootCmd.AddCommand(paveCmd)
 }
@@ -129,6 +162,7 @@ func runPaveHook() error {
 
 // runPaveAgentsMD generates AGENTS.md rules from alias data.
 // Tool-name aliases get a "Tool Name Corrections" section.
+// Deny rules get a "Forbidden Commands" section.
 // Command correction rules get a "Command Corrections" section grouped by command.
 func runPaveAgentsMD() error {
 	s, err := openStore()
@@ -142,7 +176,23 @@ func runPaveAgentsMD() error {
 		return fmt.Errorf("get aliases: %w", err)
 	}
 
-	if len(aliases) == 0 {
+	var targets []string
+	if paveWrite != "" {
+		targets = append(targets, paveWrite)
+	}
+	if paveProject {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("get working directory: %w", err)
+		}
+		files, err := discoverProjectFiles(wd)
+		if err != nil {
+			return fmt.Errorf("discover project files: %w", err)
+		}
+		targets = append(targets, files...)
+	}
+
+	if len(aliases) == 0 && len(targets) == 0 {
 		if jsonOutput {
 			enc := json.NewEncoder(os.Stdout)
 			enc.SetIndent("", "  ")
@@ -155,16 +205,79 @@ func runPaveAgentsMD() error {
 		return nil
 	}
 
-	// Split into tool-name aliases and command correction rules.
-	var toolAliases, cmdRules []model.Alias
+	// Expired rules no longer apply, so don't advertise them.
+	now := time.Now()
+	active := aliases[:0]
 	for _, a := range aliases {
-		if a.IsToolNameAlias() {
+		if !a.Expired(now) {
+			active = append(active, a)
+		}
+	}
+	aliases = active
+
+	toolAliases, denyRules, cmdRules := splitAliases(aliases)
+	output := renderAgentsMD(toolAliases, denyRules, cmdRules)
+
+	if len(targets) > 0 {
+		return writeManagedBlocks(targets, output, len(aliases))
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		lines := make([]string, 0, len(aliases))
+		for _, a := range toolAliases {
+			lines = append(lines, fmt.Sprintf("Do NOT call `%s`. Use `%s` instead.", a.From, a.To))
+		}
+		for _, r := range denyRules {
+			lines = append(lines, formatRuleDescription(r))
+		}
+		for _, r := range cmdRules {
+			lines = append(lines, formatRuleDescription(r))
+		}
+		return enc.Encode(map[string]interface{}{
+			"status": "generated",
+			"rules":  lines,
+			"count":  len(aliases),
+		})
+	}
+
+	if paveAppend != "" {
+		f, err := os.OpenFile(paveAppend, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", paveAppend, err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString("\n" + output); err != nil {
+			return fmt.Errorf("write %s: %w", paveAppend, err)
+		}
+		fmt.Fprintf(os.Stdout, "Appended %d rules to %s\n", len(aliases), paveAppend)
+		return nil
+	}
+
+	fmt.Print(output)
+	return nil
+}
+
+// splitAliases separates tool-name aliases, deny rules, and command
+// correction rules, preserving order.
+func splitAliases(aliases []model.Alias) (toolAliases, denyRules, cmdRules []model.Alias) {
+	for _, a := range aliases {
+		switch {
+		case a.IsToolNameAlias():
 			toolAliases = append(toolAliases, a)
-		} else {
+		case a.IsDeny():
+			denyRules = append(denyRules, a)
+		default:
 			cmdRules = append(cmdRules, a)
 		}
 	}
 
+	return toolAliases, denyRules, cmdRules
+}
+
+// renderAgentsMD renders the markdown rule sections for AGENTS.md.
+func renderAgentsMD(toolAliases, denyRules, cmdRules []model.Alias) string {
 	var sb strings.Builder
 
 	// Tool name corrections section.
@@ -177,6 +290,16 @@ func runPaveAgentsMD() error {
 		sb.WriteString("\n")
 	}
 
+	// Forbidden commands section.
+	if len(denyRules) > 0 {
+		sb.WriteString("# Forbidden Commands\n\n")
+		sb.WriteString("The following calls are BLOCKED and will be refused:\n\n")
+		for _, r := range denyRules {
+			sb.WriteString("- " + formatRuleDescription(r) + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	// Command corrections section, grouped by command.
 	if len(cmdRules) > 0 {
 		sb.WriteString("# Command Corrections\n\n")
@@ -221,39 +344,73 @@ func runPaveAgentsMD() error {
 		}
 	}
 
-	output := sb.String()
+	return sb.String()
+}
 
-	if jsonOutput {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		lines := make([]string, 0, len(aliases))
-		for _, a := range toolAliases {
-			lines = append(lines, fmt.Sprintf("Do NOT call `%s`. Use `%s` instead.", a.From, a.To))
+// writeManagedBlocks replaces the dp block in each target with rules, or with
+// --check reports which targets are stale without writing.
+func writeManagedBlocks(targets []string, rules string, count int) error {
+	block := renderManagedBlock(rules)
+
+	var changed []string
+	isChanged := make(map[string]bool)
+	for _, path := range targets {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("read %s: %w", path, err)
 		}
-		for _, r := range cmdRules {
-			lines = append(lines, formatRuleDescription(r))
+		updated := upsertManagedBlock(string(data), block)
+		if updated == string(data) {
+			continue
+		}
+		changed = append(changed, path)
+		isChanged[path] = true
+		if paveStale {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
 		}
-		return enc.Encode(map[string]interface{}{
-			"status": "generated",
-			"rules":  lines,
-			"count":  len(aliases),
-		})
 	}
 
-	if paveAppend != "" {
-		f, err := os.OpenFile(paveAppend, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
-		if err != nil {
-			return fmt.Errorf("open %s: %w", paveAppend, err)
+	status := "updated"
+	switch {
+	case len(changed) == 0:
+		status = "up_to_date"
+	case paveStale:
+		status = "stale"
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if changed == nil {
+			changed = []string{}
 		}
-		defer f.Close()
-		if _, err := f.WriteString("\n" + output); err != nil {
-			return fmt.Errorf("write %s: %w", paveAppend, err)
+		if err := enc.Encode(map[string]interface{}{
+			"status":  status,
+			"files":   targets,
+			"changed": changed,
+			"count":   count,
+		}); err != nil {
+			return err
+		}
+	} else {
+		for _, path := range targets {
+			switch {
+			case !isChanged[path]:
+				fmt.Fprintf(os.Stdout, "%s is up to date\n", path)
+			case paveStale:
+				fmt.Fprintf(os.Stdout, "%s is stale (re-run without --check to update)\n", path)
+			default:
+				fmt.Fprintf(os.Stdout, "Wrote %d rules to %s\n", count, path)
+			}
 		}
-		fmt.Fprintf(os.Stdout, "Appended %d rules to %s\n", len(aliases), paveAppend)
-		return nil
 	}
 
-	fmt.Print(output)
+	if paveStale && len(changed) > 0 {
+		return fmt.Errorf("managed block is stale in %s", strings.Join(changed, ", "))
+	}
 	return nil
 }
 
@@ -275,6 +432,19 @@ func formatRuleDescription(r model.Alias) string {
 		} else {
 			desc = fmt.Sprintf("Do NOT use `%s` — it does not exist and will be rewritten automatically.", r.From)
 		}
+	case "deny":
+		target := "run"
+		if r.Command == "" {
+			target = "match pattern"
+		}
+		if r.Tool != "Bash" || r.Param != "command" {
+			target = fmt.Sprintf("pass %s.%s matching", r.Tool, r.Param)
+		}
+		desc = fmt.Sprintf("Do NOT %s `%s`.", target, r.From)
+		if r.Message != "" {
+			return desc + " " + r.Message
+		}
+		return desc
 	default:
 		desc = fmt.Sprintf("`%s` → `%s`", r.From, r.To)
 	}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Markers delimiting the dp-managed block in AGENTS.md / CLAUDE.md files.
+// Everything between them is owned by 'dp pave --agents-md --write' and is
+// replaced on every run; everything outside them is left untouched.
+const (
+	managedBlockBegin = "<!-- dp:begin -->"
+	managedBlockEnd   = "<!-- dp:end -->"
+)
+
+// projectAgentsFiles are the instruction files looked for at the git root.
+var projectAgentsFiles = []string{"AGENTS.md", "CLAUDE.md"}
+
+// renderManagedBlock wraps generated rules in the dp markers.
+func renderManagedBlock(rules string) string {
+	return managedBlockBegin + "\n" + strings.TrimRight(rules, "\n") + "\n" + managedBlockEnd + "\n"
+}
+
+// upsertManagedBlock returns content with its dp block replaced by block.
+// If content has no complete block, block is appended after a blank line.
+func upsertManagedBlock(content, block string) string {
+	if end := strings.Index(content, managedBlockEnd); end >= 0 {
+		// Pair the end marker with the nearest begin marker before it, so a
+		// stray unterminated begin earlier in the file is left alone.
+		if begin := strings.LastIndex(content[:end], managedBlockBegin); begin >= 0 {
+			end += len(managedBlockEnd)
+			// Swallow the newline after the end marker; block supplies its own.
+			if end < len(content) && content[end] == '\n' {
+				end++
+			}
+			return content[:begin] + block + content[end:]
+		}
+	}
+	if content == "" {
+		return block
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "\n" + block
+}
+
+// findGitRoot walks up from dir to the nearest directory containing .git
+// (a directory, or a file for worktrees and submodules).
+func findGitRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("not inside a git repository")
+		}
+		dir = parent
+	}
+}
+
+// discoverProjectFiles returns the instruction files at the git root of dir.
+// Existing AGENTS.md / CLAUDE.md files are all returned; if neither exists,
+// AGENTS.md is returned so it gets created.
+func discoverProjectFiles(dir string) ([]string, error) {
+	root, err := findGitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range projectAgentsFiles {
+		p := filepath.Join(root, name)
+		if _, err := os.Stat(p); err == nil {
+			files = append(files, p)
+		}
+	}
+	if len(files) == 0 {
+		files = append(files, filepath.Join(root, projectAgentsFiles[0]))
+	}
+	return files, nil
+}
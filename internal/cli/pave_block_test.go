@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func TestUpsertManagedBlock(t *testing.T) {
+	block := renderManagedBlock("# Rules\n\n- one\n")
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty file", "", block},
+		{"append", "# Project\n\nNotes.", "# Project\n\nNotes.\n\n" + block},
+		{
+			"replace in place",
+			"# Project\n\n" + managedBlockBegin + "\nold rules\n" + managedBlockEnd + "\n\n## Footer\n",
+			"# Project\n\n" + block + "\n## Footer\n",
+		},
+		{
+			"unterminated block is appended after",
+			"intro\n" + managedBlockBegin + "\nhalf\n",
+			"intro\n" + managedBlockBegin + "\nhalf\n\n" + block,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := upsertManagedBlock(tt.content, block)
+			if got != tt.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tt.want)
+			}
+			// Idempotent: a second pass changes nothing.
+			if again := upsertManagedBlock(got, block); again != got {
+				t.Errorf("not idempotent:\n%q", again)
+			}
+		})
+	}
+}
+
+func TestDiscoverProjectFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := discoverProjectFiles(sub)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(root, "AGENTS.md") {
+		t.Errorf("expected default AGENTS.md at root, got %v", files)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "CLAUDE.md"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err = discoverProjectFiles(sub)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(root, "CLAUDE.md") {
+		t.Errorf("expected existing CLAUDE.md, got %v", files)
+	}
+
+	if _, err := discoverProjectFiles(t.TempDir()); err == nil {
+		t.Error("expected error outside a git repository")
+	}
+}
+
+func TestPaveAgentsMDWriteAndCheck(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetAlias(context.Background(), model.Alias{From: "read_file", To: "Read"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	outFile := filepath.Join(t.TempDir(), "AGENTS.md")
+	if err := os.WriteFile(outFile, []byte("# My Project\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dbPath = db
+	jsonOutput = false
+	paveHook = false
+	paveAppend = ""
+	defer func() {
+		paveAgentsMD = false
+		paveWrite = ""
+		paveStale = false
+	}()
+
+	run := func(args ...string) error {
+		paveAgentsMD = false
+		paveWrite = ""
+		paveStale = false
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		rootCmd.SetArgs(append([]string{"pave", "--db", db, "--agents-md"}, args...))
+		err := rootCmd.Execute()
+		w.Close()
+		os.Stdout = old
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		return err
+	}
+
+	// Writing twice leaves exactly one block.
+	for i := 0; i < 2; i++ {
+		if err := run("--write", outFile); err != nil {
+			t.Fatalf("write #%d: %v", i+1, err)
+		}
+	}
+	data, _ := os.ReadFile(outFile)
+	content := string(data)
+	if strings.Count(content, managedBlockBegin) != 1 || strings.Count(content, "read_file") != 1 {
+		t.Errorf("expected a single managed block, got:\n%s", content)
+	}
+	if !strings.HasPrefix(content, "# My Project\n") {
+		t.Errorf("existing content should be preserved, got:\n%s", content)
+	}
+
+	if err := run("--write", outFile, "--check"); err != nil {
+		t.Errorf("check on fresh file: %v", err)
+	}
+
+	// A new alias makes the file stale; --check fails without writing.
+	s, err = store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetAlias(context.Background(), model.Alias{From: "search_files", To: "Grep"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	err = run("--write", outFile, "--check")
+	if err == nil || !strings.Contains(err.Error(), "stale") {
+		t.Fatalf("expected stale error, got %v", err)
+	}
+	after, _ := os.ReadFile(outFile)
+	if string(after) != content {
+		t.Error("--check must not modify the file")
+	}
+}
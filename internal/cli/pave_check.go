@@ -8,15 +8,19 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/scbrown/desire-path/internal/cmdparse"
 	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
 	"github.com/spf13/cobra"
 )
 
 // paveCheckCmd is the fast PreToolUse hook handler.
 // Phase 1: blocks hallucinated tool names (exit 2 + stderr).
-// Phase 2: rewrites tool parameters via updatedInput (exit 0 + JSON stdout).
+// Phase 2: refuses calls matching policy deny rules (exit 0 + deny JSON stdout).
+// Phase 3: rewrites tool parameters via updatedInput (exit 0 + JSON stdout).
 var paveCheckCmd = &cobra.Command{
 	Use:    "pave-check",
 	Short:  "PreToolUse hook: check tool name and correct parameters (internal)",
@@ -26,12 +30,18 @@ var paveCheckCmd = &cobra.Command{
 	},
 }
 
+// paveCheckSource is the source name matched against alias source scopes.
+var paveCheckSource string
+
 func init() {
+	paveCheckCmd.Flags().StringVar(&paveCheckSource, "source", "claude-code", "source name for source-scoped aliases")
 	rootCmd.AddCommand(paveCheckCmd)
 }
 
 // hookPayload is the PreToolUse hook JSON from Claude Code.
 type hookPayload struct {
+	SessionID string                 `json:"session_id"`
+	CWD       string                 `json:"cwd"`
 	ToolName  string                 `json:"tool_name"`
 	ToolInput map[string]interface{} `json:"tool_input"`
 }
@@ -42,14 +52,16 @@ type hookOutput struct {
 }
 
 type hookSpecific struct {
-	PermissionDecision string                 `json:"permissionDecision"`
-	UpdatedInput       map[string]interface{} `json:"updatedInput,omitempty"`
-	AdditionalContext  string                 `json:"additionalContext,omitempty"`
+	PermissionDecision       string                 `json:"permissionDecision"`
+	PermissionDecisionReason string                 `json:"permissionDecisionReason,omitempty"`
+	UpdatedInput             map[string]interface{} `json:"updatedInput,omitempty"`
+	AdditionalContext        string                 `json:"additionalContext,omitempty"`
 }
 
-// runPaveCheck reads a hook payload from r, performs two phases of checking:
+// runPaveCheck reads a hook payload from r, performs three phases of checking:
 // 1. Tool-name alias → exit 2 (block)
-// 2. Parameter correction rules → exit 0 with updatedInput JSON on stdout
+// 2. Policy deny rules → exit 0 with permissionDecision "deny" on stdout
+// 3. Parameter correction rules → exit 0 with updatedInput JSON on stdout
 func runPaveCheck(r io.Reader) error {
 	data, err := io.ReadAll(r)
 	if err != nil {
@@ -73,51 +85,175 @@ func runPaveCheck(r io.Reader) error {
 	defer s.Close()
 
 	ctx := context.Background()
+	scope := &aliasScope{CWD: payload.CWD, Source: paveCheckSource, Now: time.Now()}
+	v, err := evaluatePave(ctx, s, payload, scope)
+	if err != nil {
+		return nil // lookup error → allow
+	}
+
+	switch {
+	case v.block != nil:
+		recordIntervention(ctx, s, payload, model.InterventionBlock, *v.block)
+		fmt.Fprint(os.Stderr, v.blockMsg)
+		os.Exit(2)
+		return nil // unreachable
+	case v.deny != nil:
+		recordIntervention(ctx, s, payload, model.InterventionDeny, *v.deny)
+	default:
+		for _, c := range v.corrections {
+			for _, r := range c.rules {
+				recordIntervention(ctx, s, payload, model.InterventionCorrect, r)
+			}
+		}
+	}
+	if v.output == nil {
+		return nil // nothing to say → allow
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	return enc.Encode(v.output)
+}
+
+// paveVerdict is the outcome of evaluating a hook payload against the rule
+// set. At most one of block, deny, or corrections is populated.
+type paveVerdict struct {
+	rules       []model.Alias // parameter rules considered for the tool
+	outOfScope  []model.Alias // aliases and rules skipped by scope, expiry, or a more specific variant
+	block       *model.Alias  // tool-name alias that blocks the call
+	blockMsg    string        // stderr message for a block
+	deny        *model.Alias  // deny rule that refuses the call
+	corrections []correction  // parameter corrections to apply
+	output      *hookOutput   // JSON to emit on stdout, nil to allow silently
+}
+
+// evaluatePave runs the three pave-check phases without side effects.
+// Aliases that don't apply in scope are skipped. It is shared by the hook
+// handler and 'dp aliases test'.
+func evaluatePave(ctx context.Context, s store.Store, payload hookPayload, scope *aliasScope) (paveVerdict, error) {
+	var v paveVerdict
 
 	// Phase 1: Tool-name alias check (block).
-	alias, err := s.GetAlias(ctx, payload.ToolName, "", "", "", "")
+	variants, err := s.GetToolAliases(ctx, payload.ToolName)
 	if err != nil {
-		return nil // lookup error → allow
+		return v, fmt.Errorf("get alias: %w", err)
 	}
-	if alias != nil {
-		msg := fmt.Sprintf("%s is not a valid tool. Use %s instead.", payload.ToolName, alias.To)
+	aliases, skipped := scope.filter(variants)
+	v.outOfScope = append(v.outOfScope, skipped...)
+	if len(aliases) > 0 {
+		alias := &aliases[0]
+		v.block = alias
+		v.blockMsg = fmt.Sprintf("%s is not a valid tool. Use %s instead.", payload.ToolName, alias.To)
 		if alias.Message != "" {
-			msg = alias.Message
+			v.blockMsg = alias.Message
 		}
-		fmt.Fprint(os.Stderr, msg)
-		os.Exit(2)
-		return nil // unreachable
+		return v, nil
+	}
+
+	all, err := s.GetRulesForTool(ctx, payload.ToolName)
+	if err != nil {
+		return v, fmt.Errorf("get rules: %w", err)
+	}
+	rules, skipped := scope.filter(all)
+	v.rules = rules
+	v.outOfScope = append(v.outOfScope, skipped...)
+	if len(rules) == 0 {
+		return v, nil
 	}
 
-	// Phase 2: Parameter correction rules.
-	rules, err := s.GetRulesForTool(ctx, payload.ToolName)
-	if err != nil || len(rules) == 0 {
-		return nil // no rules or error → allow
+	// Phase 2: Policy deny rules. These run before corrections so a
+	// destructive command is never rewritten into something that passes.
+	if rule := matchDenyRules(payload.ToolInput, rules); rule != nil {
+		v.deny = rule
+		v.output = &hookOutput{
+			HookSpecificOutput: hookSpecific{
+				PermissionDecision:       "deny",
+				PermissionDecisionReason: denyReason(*rule),
+			},
+		}
+		return v, nil
 	}
 
-	corrections := applyRules(payload.ToolInput, rules)
-	if len(corrections) == 0 {
-		return nil // no corrections needed → allow
+	// Phase 3: Parameter correction rules.
+	v.corrections = applyRules(payload.ToolInput, rules)
+	if len(v.corrections) == 0 {
+		return v, nil // no corrections needed → allow
 	}
 
 	// Build updatedInput with all corrections applied.
 	updatedInput := make(map[string]interface{})
 	var contextParts []string
-	for _, c := range corrections {
+	for _, c := range v.corrections {
 		updatedInput[c.param] = c.newValue
 		contextParts = append(contextParts, c.description)
 	}
 
-	out := hookOutput{
+	v.output = &hookOutput{
 		HookSpecificOutput: hookSpecific{
 			PermissionDecision: "allow",
 			UpdatedInput:       updatedInput,
 			AdditionalContext:  "Corrected: " + strings.Join(contextParts, "; "),
 		},
 	}
+	return v, nil
+}
 
-	enc := json.NewEncoder(os.Stdout)
-	return enc.Encode(out)
+// recordIntervention persists a pave-check action. Best-effort: a failed
+// write never changes the hook's decision.
+func recordIntervention(ctx context.Context, s store.Store, p hookPayload, kind string, rule model.Alias) {
+	_ = s.RecordIntervention(ctx, model.Intervention{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		ToolName:  p.ToolName,
+		Rule:      rule.From,
+		MatchKind: rule.MatchKind,
+		SessionID: p.SessionID,
+		CWD:       p.CWD,
+		Timestamp: time.Now(),
+	})
+}
+
+// matchDenyRules returns the first deny rule matching the tool input, or nil.
+func matchDenyRules(toolInput map[string]interface{}, rules []model.Alias) *model.Alias {
+	for i, rule := range rules {
+		if !rule.IsDeny() {
+			continue
+		}
+		str, ok := toolInput[rule.Param].(string)
+		if !ok {
+			continue
+		}
+		if matchDenyRule(str, rule) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// matchDenyRule reports whether value matches a single deny rule. Rules with
+// a Command match a command prefix within any pipeline segment; rules without
+// one treat From as a regex against the whole value.
+func matchDenyRule(value string, rule model.Alias) bool {
+	if rule.Command != "" {
+		for _, seg := range cmdparse.Parse(value) {
+			if seg.Command == rule.Command && matchRecipePrefix(seg.Raw, rule.From) {
+				return true
+			}
+		}
+		return false
+	}
+	re, err := regexp.Compile(rule.From)
+	if err != nil {
+		return false // bad regex → skip
+	}
+	return re.MatchString(value)
+}
+
+// denyReason returns the message shown to the agent when a deny rule fires.
+func denyReason(rule model.Alias) string {
+	if rule.Message != "" {
+		return rule.Message
+	}
+	return fmt.Sprintf("`%s` is blocked by policy.", rule.From)
 }
 
 // correction represents a single parameter correction.
@@ -125,6 +261,7 @@ type correction struct {
 	param       string
 	newValue    string
 	description string
+	rules       []model.Alias // rules that contributed to this correction
 }
 
 // applyRules applies all matching rules to the tool input and returns corrections.
@@ -157,6 +294,7 @@ func applyRules(toolInput map[string]interface{}, rules []model.Alias) []correct
 				param:       paramName,
 				newValue:    corrected,
 				description: desc,
+				rules:       []model.Alias{rule},
 			})
 		}
 	}
@@ -170,14 +308,17 @@ func applyRules(toolInput map[string]interface{}, rules []model.Alias) []correct
 	// Collect descriptions.
 	result := make([]correction, 0, len(final))
 	for _, c := range final {
-		// Merge descriptions from all corrections for this param.
+		// Merge descriptions and rules from all corrections for this param.
 		var descs []string
+		var rules []model.Alias
 		for _, cc := range corrections {
 			if cc.param == c.param {
 				descs = append(descs, cc.description)
+				rules = append(rules, cc.rules...)
 			}
 		}
 		c.description = strings.Join(descs, "; ")
+		c.rules = rules
 		result = append(result, c)
 	}
 
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/scbrown/desire-path/internal/model"
 	"github.com/scbrown/desire-path/internal/store"
@@ -40,7 +41,7 @@ func TestPaveCheckMatchingAlias(t *testing.T) {
 	}
 	defer s2.Close()
 
-	alias, err := s2.GetAlias(context.Background(), "read_file", "", "", "", "")
+	alias, err := s2.GetAlias(context.Background(), model.AliasKey{From: "read_file"})
 	if err != nil {
 		t.Fatalf("get alias: %v", err)
 	}
@@ -1103,3 +1104,250 @@ func TestPaveCheckRecipeNoMatch(t *testing.T) {
 		t.Errorf("expected no output (passthrough) for non-matching command, got: %s", output)
 	}
 }
+
+func TestPaveCheckDenyCommand(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := s.SetAlias(ctx, model.Alias{
+		From: "git push --force", Tool: "Bash", Param: "command", Command: "git", MatchKind: "deny",
+		Message: "Force-push is not allowed; open a PR instead.",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// A correction on the same command must not run once the call is denied.
+	if err := s.SetAlias(ctx, model.Alias{
+		From: "--force", To: "--force-with-lease", Tool: "Bash", Param: "command", Command: "git", MatchKind: "flag",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	dbPath = db
+
+	payload := `{"session_id":"s1","cwd":"/repo","tool_name":"Bash","tool_input":{"command":"cd /repo && git push --force origin main"}}`
+	stdin := strings.NewReader(payload)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runPaveCheck(stdin)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("runPaveCheck: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	var result hookOutput
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("unmarshal: %v\noutput: %s", err, buf.String())
+	}
+	if result.HookSpecificOutput.PermissionDecision != "deny" {
+		t.Errorf("expected deny, got %q", result.HookSpecificOutput.PermissionDecision)
+	}
+	if result.HookSpecificOutput.PermissionDecisionReason != "Force-push is not allowed; open a PR instead." {
+		t.Errorf("unexpected reason: %q", result.HookSpecificOutput.PermissionDecisionReason)
+	}
+	if result.HookSpecificOutput.UpdatedInput != nil {
+		t.Errorf("expected no updatedInput, got %v", result.HookSpecificOutput.UpdatedInput)
+	}
+
+	s2, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+	stats, err := s2.InterventionStats(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Kind != model.InterventionDeny || stats[0].Count != 1 {
+		t.Errorf("expected one deny intervention, got %+v", stats)
+	}
+}
+
+func TestPaveCheckDenyRegexNoMatch(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetAlias(context.Background(), model.Alias{
+		From: `^/etc/`, Tool: "Write", Param: "file_path", MatchKind: "deny",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	dbPath = db
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runPaveCheck(strings.NewReader(`{"tool_name":"Write","tool_input":{"file_path":"/tmp/x"}}`))
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("runPaveCheck: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got: %s", buf.String())
+	}
+
+	if !matchDenyRule("/etc/passwd", model.Alias{From: `^/etc/`, MatchKind: "deny"}) {
+		t.Error("expected regex deny rule to match /etc/passwd")
+	}
+}
+
+func TestPaveAgentsMDDeny(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetAlias(context.Background(), model.Alias{
+		From: "rm -rf /", Tool: "Bash", Param: "command", Command: "rm", MatchKind: "deny",
+		Message: "Never delete the root filesystem.",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	dbPath = db
+	jsonOutput = false
+	paveHook = false
+	paveAgentsMD = true
+	paveAppend = ""
+	defer func() { paveAgentsMD = false }()
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd.SetArgs([]string{"pave", "--db", db, "--agents-md"})
+	if err := rootCmd.Execute(); err != nil {
+		w.Close()
+		os.Stdout = old
+		t.Fatalf("execute: %v", err)
+	}
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	out := buf.String()
+
+	if !strings.Contains(out, "# Forbidden Commands") {
+		t.Errorf("expected Forbidden Commands section, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Do NOT run `rm -rf /`. Never delete the root filesystem.") {
+		t.Errorf("expected deny line, got:\n%s", out)
+	}
+	if strings.Contains(out, "# Command Corrections") {
+		t.Errorf("deny rules should not appear under Command Corrections:\n%s", out)
+	}
+}
+
+func TestPaveCheckScopedRule(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetAlias(context.Background(), model.Alias{
+		From: "make", To: "just", Tool: "Bash", Param: "command", Command: "make", MatchKind: "command",
+		ScopeCWD: "/src/app",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	dbPath = db
+	paveCheckSource = "claude-code"
+
+	run := func(cwd string) string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := runPaveCheck(strings.NewReader(`{"cwd":"` + cwd + `","tool_name":"Bash","tool_input":{"command":"make test"}}`))
+		w.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("runPaveCheck: %v", err)
+		}
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		return buf.String()
+	}
+
+	if out := run("/src/app/internal"); !strings.Contains(out, "just test") {
+		t.Errorf("expected correction inside scope, got: %s", out)
+	}
+	if out := run("/src/other"); out != "" {
+		t.Errorf("expected no output outside scope, got: %s", out)
+	}
+}
+
+func TestPaveCheckScopedVariants(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []model.Alias{
+		{From: "make", To: "just", Tool: "Bash", Param: "command", Command: "make", MatchKind: "command"},
+		{From: "make", To: "task", Tool: "Bash", Param: "command", Command: "make", MatchKind: "command", ScopeCWD: "/src/tools"},
+		{From: "read_file", To: "Read"},
+		{From: "read_file", To: "View", ScopeCWD: "/src/tools"},
+	} {
+		if err := s.SetAlias(context.Background(), a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer s.Close()
+
+	eval := func(cwd, tool, command string) paveVerdict {
+		t.Helper()
+		payload := hookPayload{CWD: cwd, ToolName: tool, ToolInput: map[string]interface{}{"command": command}}
+		v, err := evaluatePave(context.Background(), s, payload, &aliasScope{CWD: cwd, Now: time.Now()})
+		if err != nil {
+			t.Fatalf("evaluatePave: %v", err)
+		}
+		return v
+	}
+
+	// The scoped variant is more specific, so it wins inside its scope.
+	if v := eval("/src/tools", "Bash", "make test"); len(v.corrections) != 1 || !strings.Contains(v.corrections[0].newValue, "task test") {
+		t.Errorf("in scope: got corrections %+v", v.corrections)
+	}
+	if v := eval("/src/app", "Bash", "make test"); len(v.corrections) != 1 || !strings.Contains(v.corrections[0].newValue, "just test") {
+		t.Errorf("out of scope: got corrections %+v", v.corrections)
+	}
+	if v := eval("/src/tools", "read_file", ""); v.block == nil || v.block.To != "View" {
+		t.Errorf("in scope: got block %+v", v.block)
+	}
+	if v := eval("/src/app", "read_file", ""); v.block == nil || v.block.To != "Read" {
+		t.Errorf("out of scope: got block %+v", v.block)
+	}
+}
@@ -4,28 +4,43 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"text/tabwriter"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
 	"github.com/spf13/cobra"
 )
 
 var (
-	recoverySince string
-	recoveryLimit int
-	recoveryStats bool
+	recoverySince   string
+	recoveryLimit   int
+	recoveryStats   bool
+	recoveryChains  bool   // --chains
+	recoveryOutcome string // --outcome
+	recoveryTool    string // --tool
 )
 
 var recoveriesCmd = &cobra.Command{
 	Use:   "recoveries",
 	Short: "Show recovery events (previously-failing tools that succeeded)",
 	Long: `List recovery events where a tool that was previously failing started
-succeeding again. Use --stats for aggregated counts per tool.`,
+succeeding again. Use --stats for aggregated counts per tool.
+
+Use --chains to list retry chains instead: runs of consecutive failures of
+the same intent within one session and turn, ending in a success
+(recovered) or not (abandoned, once the turn moves on or the session goes
+idle for 30 minutes). Each chain shows its number of failed attempts, the
+time from the first failure to the recovery, and the input change that
+made the call succeed.`,
 	Example: `  dp recoveries
   dp recoveries --since 7d
   dp recoveries --stats
-  dp recoveries --stats --json`,
+  dp recoveries --stats --json
+  dp recoveries --chains
+  dp recoveries --chains --outcome abandoned --tool Bash`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s, err := openStore()
 		if err != nil {
@@ -34,6 +49,10 @@ succeeding again. Use --stats for aggregated counts per tool.`,
 		defer s.Close()
 		ctx := context.Background()
 
+		if recoveryChains {
+			return runRecoveryChains(ctx, cmd.OutOrStdout(), s)
+		}
+
 		if recoveryStats {
 			stats, err := s.RecoveryStats(ctx)
 			if err != nil {
@@ -65,7 +84,7 @@ succeeding again. Use --stats for aggregated counts per tool.`,
 			since = time.Now().Add(-d)
 		}
 
-		recoveries, err := s.ListRecoveries(ctx, since, recoveryLimit)
+		recoveries, err := s.ListRecoveries(ctx, store.RecoveryOpts{Since: since, Limit: recoveryLimit})
 		if err != nil {
 			return fmt.Errorf("list recoveries: %w", err)
 		}
@@ -94,5 +113,87 @@ func init() {
 	recoveriesCmd.Flags().StringVar(&recoverySince, "since", "", "show recoveries since duration (e.g. 7d, 24h)")
 	recoveriesCmd.Flags().IntVar(&recoveryLimit, "limit", 50, "maximum results")
 	recoveriesCmd.Flags().BoolVar(&recoveryStats, "stats", false, "show aggregated recovery counts per tool")
+	recoveriesCmd.Flags().BoolVar(&recoveryChains, "chains", false, "show retry chains with attempts and time to recovery")
+	recoveriesCmd.Flags().StringVar(&recoveryOutcome, "outcome", "", "with --chains, only show chains with this outcome (recovered, abandoned, open)")
+	recoveriesCmd.Flags().StringVar(&recoveryTool, "tool", "", "with --chains, only show chains of this tool")
 	rootCmd.AddCommand(recoveriesCmd)
 }
+
+// runRecoveryChains lists retry chains matching the command's flags.
+func runRecoveryChains(ctx context.Context, w io.Writer, s store.Store) error {
+	opts := store.ChainOpts{ToolName: recoveryTool, Outcome: recoveryOutcome, Limit: recoveryLimit}
+	switch recoveryOutcome {
+	case "", model.ChainRecovered, model.ChainAbandoned, model.ChainOpen:
+	default:
+		return fmt.Errorf("invalid --outcome value %q: must be recovered, abandoned, or open", recoveryOutcome)
+	}
+	if recoverySince != "" {
+		d, err := parseDuration(recoverySince)
+		if err != nil {
+			return fmt.Errorf("invalid --since value %q: %w", recoverySince, err)
+		}
+		opts.Since = time.Now().Add(-d)
+	}
+
+	chains, err := s.ListRetryChains(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("list retry chains: %w", err)
+	}
+	if jsonOutput {
+		if chains == nil {
+			chains = []model.RetryChain{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(chains)
+	}
+	writeChainsTable(w, chains)
+	return nil
+}
+
+func writeChainsTable(w io.Writer, chains []model.RetryChain) {
+	if len(chains) == 0 {
+		fmt.Fprintln(w, "No retry chains found.")
+		return
+	}
+	tbl := NewTable(w, "STARTED", "SESSION", "INTENT", "ATTEMPTS", "OUTCOME", "ELAPSED", "FIX")
+	for _, c := range chains {
+		session := c.SessionID
+		if len(session) > 8 {
+			session = session[:8]
+		}
+		elapsed, fix := "-", "-"
+		if c.Outcome == model.ChainRecovered {
+			elapsed = formatElapsed(c.ElapsedMs)
+			if c.InputDiff != "" {
+				fix = truncateTo(c.InputDiff, 60)
+			}
+		}
+		tbl.Row(
+			c.StartedAt.Local().Format("2006-01-02 15:04"),
+			session,
+			truncateTo(c.Intent, 30),
+			itoa(c.Attempts),
+			c.Outcome,
+			elapsed,
+			fix,
+		)
+	}
+	tbl.Flush()
+}
+
+// formatElapsed shows a duration in milliseconds with one unit of precision
+// suited to its size: 850ms, 12.3s, 4m05s, 2h10m.
+func formatElapsed(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", ms)
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
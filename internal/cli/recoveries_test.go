@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func TestRecoveriesChainsCmd(t *testing.T) {
+	resetFlags(t)
+	t.Cleanup(func() { recoveryChains, recoveryOutcome, recoveryTool = false, "", "" })
+	db := filepath.Join(t.TempDir(), "test.db")
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour).UTC()
+	calls := []struct {
+		id    string
+		fail  bool
+		input string
+	}{
+		{"a", true, `{"command":"scp -r a host:"}`},
+		{"b", true, `{"command":"scp -r a host:"}`},
+		{"c", false, `{"command":"scp -r a host:/tmp"}`},
+	}
+	for i, c := range calls {
+		inv := model.Invocation{ID: c.id, Source: "claude-code", InstanceID: "session-12345678", ToolName: "Bash", IsError: c.fail, Timestamp: base.Add(time.Duration(i) * 2 * time.Second)}
+		if err := s.TrackRetryChain(ctx, inv, json.RawMessage(c.input)); err != nil {
+			t.Fatalf("TrackRetryChain: %v", err)
+		}
+	}
+	s.Close()
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetErr(&buf)
+	rootCmd.SetArgs([]string{"recoveries", "--chains", "--db", db})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"INTENT", "session-", "Bash:scp", "recovered", "4.0s", `command: "scp -r a host:" → "scp -r a host:/tmp"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"recoveries", "--chains", "--outcome", "abandoned", "--json", "--db", db})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Errorf("abandoned chains = %s, want []", got)
+	}
+
+	rootCmd.SetArgs([]string{"recoveries", "--chains", "--outcome", "gone", "--db", db})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected an error for an invalid --outcome")
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		ms   int64
+		want string
+	}{
+		{850, "850ms"},
+		{12345, "12.3s"},
+		{245000, "4m05s"},
+		{7800000, "2h10m"},
+	}
+	for _, tt := range tests {
+		if got := formatElapsed(tt.ms); got != tt.want {
+			t.Errorf("formatElapsed(%d) = %q, want %q", tt.ms, got, tt.want)
+		}
+	}
+}
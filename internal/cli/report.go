@@ -0,0 +1,425 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/analyze"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportSince  string
+	reportFormat string
+	reportTop    int
+	reportOutput string
+)
+
+// reportCmd assembles a digest of the recent desire-path activity.
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate a digest report of recent activity",
+	Long: `Report puts together a digest of the last --since (7 days by default):
+top new paths, biggest movers against the window before, recoveries,
+struggling tools without doc mappings, missing commands (env-needs), new
+turn-pattern desires, how often each alias caught a call, and suggested
+next aliases.
+
+The markdown format pastes into chat or an issue; the HTML format is a single
+self-contained page with inline styles and no external assets. --json prints
+the underlying data.`,
+	Example: `  dp report
+  dp report --since 30d --top 5
+  dp report --format html -o report.html
+  dp report --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if reportFormat != "markdown" && reportFormat != "html" {
+			return fmt.Errorf("--format must be markdown or html, got %q", reportFormat)
+		}
+		window, err := parseDuration(reportSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since value %q: %w", reportSince, err)
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return fmt.Errorf("open store: %w", err)
+		}
+		defer s.Close()
+
+		rep, err := buildReport(context.Background(), s, window, time.Now(), reportTop)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if reportOutput != "" {
+			f, err := os.Create(reportOutput)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		switch {
+		case jsonOutput:
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(rep)
+		case reportFormat == "html":
+			err = writeReportHTML(w, rep)
+		default:
+			err = writeReportMarkdown(w, rep)
+		}
+		if err != nil {
+			return err
+		}
+		if reportOutput != "" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %s\n", reportOutput)
+		}
+		return nil
+	},
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&reportSince, "since", "7d", "report window (e.g., 24h, 7d, 30d)")
+	reportCmd.Flags().StringVar(&reportFormat, "format", "markdown", "output format: markdown or html")
+	reportCmd.Flags().IntVar(&reportTop, "top", 10, "maximum rows per section")
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "write the report to this file instead of stdout")
+	rootCmd.AddCommand(reportCmd)
+}
+
+// report is the data behind dp report.
+type report struct {
+	Since        time.Time              `json:"since"`
+	Until        time.Time              `json:"until"`
+	Window       string                 `json:"window"` // e.g. "7 days"
+	Desires      int                    `json:"desires"`
+	Paths        int                    `json:"paths"`
+	NewPaths     []store.PathTrend      `json:"new_paths"`
+	Movers       []store.PathTrend      `json:"movers"`
+	Recoveries   []reportCount          `json:"recoveries"`
+	Struggling   []model.StrugglingTool `json:"struggling"` // without doc mappings
+	EnvNeeds     []EnvNeed              `json:"env_needs"`
+	TurnPatterns []reportTurnPattern    `json:"turn_patterns"`
+	Aliases      []reportAlias          `json:"aliases"`
+	Suggestions  []reportSuggestion     `json:"suggestions"`
+}
+
+// reportCount is a name with a count, such as recoveries per tool.
+type reportCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// reportTurnPattern is a turn-pattern desire surfaced during the window.
+type reportTurnPattern struct {
+	Pattern   string    `json:"pattern"`
+	Sessions  int       `json:"sessions"`
+	AvgLength float64   `json:"avg_length"`
+	Surfaced  time.Time `json:"surfaced"`
+}
+
+// reportAlias is how a tool-name alias fared during the window: Caught
+// counts pave-check interventions on its name, Missed the desires still
+// recorded for it.
+type reportAlias struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Caught int    `json:"caught"`
+	Missed int    `json:"missed"`
+}
+
+// reportSuggestion is an unaliased path with its closest known tool.
+type reportSuggestion struct {
+	Pattern string  `json:"pattern"`
+	Count   int     `json:"count"`
+	To      string  `json:"to"`
+	Score   float64 `json:"score"`
+}
+
+// buildReport gathers the report for the window ending at now, keeping at
+// most top rows per section.
+func buildReport(ctx context.Context, s store.Store, window time.Duration, now time.Time, top int) (*report, error) {
+	// Compare the window with the one before it in whole days, or whole
+	// hours for windows under two days.
+	trend := store.TrendOpts{Bucket: store.BucketDay, Buckets: int(window / (24 * time.Hour)), Until: now}
+	if window < 48*time.Hour {
+		trend = store.TrendOpts{Bucket: store.BucketHour, Buckets: int(window / time.Hour), Until: now}
+	}
+	if trend.Buckets < 1 {
+		trend.Buckets = 1
+	}
+	size, _ := store.BucketSize(trend.Bucket)
+	since := now.Add(-time.Duration(trend.Buckets) * size)
+	rep := &report{Since: since.UTC(), Until: now.UTC(), Window: fmt.Sprintf("%d %ss", trend.Buckets, trend.Bucket)}
+	if trend.Buckets == 1 {
+		rep.Window = "1 " + trend.Bucket
+	}
+
+	paths, err := s.GetPaths(ctx, store.PathOpts{Since: since})
+	if err != nil {
+		return nil, fmt.Errorf("get paths: %w", err)
+	}
+	rep.Paths = len(paths)
+	for _, p := range paths {
+		rep.Desires += p.Count
+	}
+
+	trends, err := s.PathTrends(ctx, trend)
+	if err != nil {
+		return nil, fmt.Errorf("path trends: %w", err)
+	}
+	for _, p := range trends.Paths {
+		switch p.Status {
+		case store.TrendNew:
+			rep.NewPaths = append(rep.NewPaths, p)
+		case store.TrendUp, store.TrendDown, store.TrendGone:
+			rep.Movers = append(rep.Movers, p)
+		}
+	}
+	sort.SliceStable(rep.NewPaths, func(i, j int) bool { return rep.NewPaths[i].Current > rep.NewPaths[j].Current })
+	rep.NewPaths = head(rep.NewPaths, top)
+	// Biggest change first, in either direction, so the section keeps both
+	// the paths that took off and the ones that went quiet.
+	sort.SliceStable(rep.Movers, func(i, j int) bool {
+		return absInt(rep.Movers[i].Delta) > absInt(rep.Movers[j].Delta)
+	})
+	rep.Movers = head(rep.Movers, top)
+
+	recs, err := s.ListRecoveries(ctx, store.RecoveryOpts{Since: since})
+	if err != nil {
+		return nil, fmt.Errorf("list recoveries: %w", err)
+	}
+	recByTool := make(map[string]int)
+	for _, r := range recs {
+		recByTool[r.ToolName]++
+	}
+	rep.Recoveries = head(sortedCounts(recByTool), top)
+
+	struggling, err := s.StrugglingTools(ctx, store.StrugglingOpts{Since: since})
+	if err != nil {
+		return nil, fmt.Errorf("struggling tools: %w", err)
+	}
+	for _, t := range struggling {
+		if !t.HasDoc {
+			rep.Struggling = append(rep.Struggling, t)
+		}
+	}
+	rep.Struggling = head(rep.Struggling, top)
+
+	envDesires, err := s.ListDesires(ctx, store.ListOpts{Since: since, Category: model.CategoryEnvNeed})
+	if err != nil {
+		return nil, fmt.Errorf("list env-need desires: %w", err)
+	}
+	rep.EnvNeeds = head(aggregateEnvNeeds(inCategory(envDesires, model.CategoryEnvNeed)), top)
+
+	turnDesires, err := s.ListDesires(ctx, store.ListOpts{Since: since, Category: model.CategoryTurnPattern})
+	if err != nil {
+		return nil, fmt.Errorf("list turn-pattern desires: %w", err)
+	}
+	for _, d := range inCategory(turnDesires, model.CategoryTurnPattern) {
+		var meta struct {
+			Pattern   string  `json:"pattern"`
+			Sessions  int     `json:"sessions"`
+			AvgLength float64 `json:"avg_length"`
+		}
+		if json.Unmarshal(d.Metadata, &meta) != nil || meta.Pattern == "" {
+			continue
+		}
+		rep.TurnPatterns = append(rep.TurnPatterns, reportTurnPattern{
+			Pattern: meta.Pattern, Sessions: meta.Sessions, AvgLength: meta.AvgLength, Surfaced: d.Timestamp,
+		})
+	}
+	rep.TurnPatterns = head(rep.TurnPatterns, top)
+
+	if err := reportAliases(ctx, s, rep, paths, since, top); err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// reportAliases fills in alias effectiveness and suggested next aliases.
+func reportAliases(ctx context.Context, s store.Store, rep *report, paths []model.Path, since time.Time, top int) error {
+	aliases, err := s.GetAliases(ctx)
+	if err != nil {
+		return fmt.Errorf("get aliases: %w", err)
+	}
+	ivs, err := s.InterventionStats(ctx, since)
+	if err != nil {
+		return fmt.Errorf("intervention stats: %w", err)
+	}
+	caught := make(map[string]int)
+	for _, iv := range ivs {
+		caught[iv.ToolName] += iv.Count
+	}
+	missed := make(map[string]int)
+	for _, p := range paths {
+		missed[p.Pattern] = p.Count
+	}
+	for _, a := range aliases {
+		if a.Tool != "" {
+			continue // correction rules are counted per tool, not per rule
+		}
+		rep.Aliases = append(rep.Aliases, reportAlias{From: a.From, To: a.To, Caught: caught[a.From], Missed: missed[a.From]})
+	}
+	sort.SliceStable(rep.Aliases, func(i, j int) bool {
+		return rep.Aliases[i].Caught+rep.Aliases[i].Missed > rep.Aliases[j].Caught+rep.Aliases[j].Missed
+	})
+	rep.Aliases = head(rep.Aliases, top)
+
+	known, err := lintKnownTools(ctx, s)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(known))
+	for n := range known {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, p := range paths {
+		if p.AliasTo != "" || known[p.Pattern] {
+			continue
+		}
+		if sugg := analyze.Suggest(p.Pattern, names); len(sugg) > 0 {
+			rep.Suggestions = append(rep.Suggestions, reportSuggestion{Pattern: p.Pattern, Count: p.Count, To: sugg[0].Name, Score: sugg[0].Score})
+		}
+		if top > 0 && len(rep.Suggestions) == top {
+			break
+		}
+	}
+	return nil
+}
+
+// inCategory returns the desires in category. The remote store doesn't send
+// ListOpts.Category to the server, so the filter is applied again here.
+func inCategory(desires []model.Desire, category string) []model.Desire {
+	var out []model.Desire
+	for _, d := range desires {
+		if d.Category == category {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// sortedCounts returns counts as reportCounts, highest first.
+func sortedCounts(counts map[string]int) []reportCount {
+	out := make([]reportCount, 0, len(counts))
+	for name, n := range counts {
+		out = append(out, reportCount{Name: name, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// head returns at most the first n items; n <= 0 means all.
+func head[T any](items []T, n int) []T {
+	if n > 0 && len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// writeReportMarkdown writes rep as a markdown document.
+func writeReportMarkdown(w io.Writer, rep *report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Desire path report: last %s\n\n", rep.Window)
+	fmt.Fprintf(&b, "%s to %s · %d desires across %d paths\n", rep.Since.Format("2006-01-02 15:04"), rep.Until.Format("2006-01-02 15:04 MST"), rep.Desires, rep.Paths)
+
+	for _, sec := range reportSections(rep) {
+		fmt.Fprintf(&b, "\n## %s\n\n", sec.Title)
+		if len(sec.Rows) == 0 {
+			fmt.Fprintf(&b, "_%s_\n", sec.Empty)
+			continue
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(sec.Headers, " | "))
+		fmt.Fprintf(&b, "|%s\n", strings.Repeat("---|", len(sec.Headers)))
+		for _, row := range sec.Rows {
+			cells := make([]string, len(row))
+			for i, c := range row {
+				cells[i] = markdownCell(c)
+			}
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes pipes, which would otherwise end a table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// reportSection is one titled table of the report, shared by the markdown
+// and HTML renderers.
+type reportSection struct {
+	Title   string
+	Empty   string
+	Headers []string
+	Rows    [][]string
+}
+
+func reportSections(rep *report) []reportSection {
+	newPaths := reportSection{Title: "Top new paths", Empty: "No new paths.", Headers: []string{"Path", "Desires", "Trend"}}
+	for _, p := range rep.NewPaths {
+		newPaths.Rows = append(newPaths.Rows, []string{code(p.Pattern), itoa(p.Current), sparkline(p.Counts)})
+	}
+	movers := reportSection{Title: "Biggest movers", Empty: "No paths changed.", Headers: []string{"Path", "Now", "Before", "Change", "Trend"}}
+	for _, p := range rep.Movers {
+		movers.Rows = append(movers.Rows, []string{code(p.Pattern), itoa(p.Current), itoa(p.Previous), fmt.Sprintf("%+d", p.Delta), sparkline(p.Counts)})
+	}
+	recoveries := reportSection{Title: "Recoveries", Empty: "No recoveries.", Headers: []string{"Tool", "Recoveries"}}
+	for _, r := range rep.Recoveries {
+		recoveries.Rows = append(recoveries.Rows, []string{code(r.Name), itoa(r.Count)})
+	}
+	struggling := reportSection{Title: "Struggling tools without docs", Empty: "Every struggling tool has a doc mapping.", Headers: []string{"Tool", "Failures", "Calls", "Failure rate", "Sessions"}}
+	for _, t := range rep.Struggling {
+		struggling.Rows = append(struggling.Rows, []string{code(t.ToolName), itoa(t.Failures), itoa(t.Total), fmt.Sprintf("%.0f%%", t.FailureRate*100), itoa(t.Sessions)})
+	}
+	envNeeds := reportSection{Title: "Missing commands", Empty: "No missing commands.", Headers: []string{"Command", "Failures"}}
+	for _, n := range rep.EnvNeeds {
+		envNeeds.Rows = append(envNeeds.Rows, []string{code(n.Command), itoa(n.Count)})
+	}
+	turns := reportSection{Title: "New turn patterns", Empty: "No new turn patterns.", Headers: []string{"Pattern", "Sessions", "Avg calls"}}
+	for _, t := range rep.TurnPatterns {
+		turns.Rows = append(turns.Rows, []string{code(t.Pattern), itoa(t.Sessions), fmt.Sprintf("%.1f", t.AvgLength)})
+	}
+	aliases := reportSection{Title: "Alias effectiveness", Empty: "No aliases configured.", Headers: []string{"Alias", "Target", "Caught", "Still failing"}}
+	for _, a := range rep.Aliases {
+		aliases.Rows = append(aliases.Rows, []string{code(a.From), code(a.To), itoa(a.Caught), itoa(a.Missed)})
+	}
+	suggestions := reportSection{Title: "Suggested next aliases", Empty: "No suggestions.", Headers: []string{"Path", "Desires", "Suggested target", "Score", "Command"}}
+	for _, sg := range rep.Suggestions {
+		suggestions.Rows = append(suggestions.Rows, []string{code(sg.Pattern), itoa(sg.Count), code(sg.To), fmt.Sprintf("%.2f", sg.Score), code("dp alias " + sg.Pattern + " " + sg.To)})
+	}
+	return []reportSection{newPaths, movers, recoveries, struggling, envNeeds, turns, aliases, suggestions}
+}
+
+// code marks s as code. Both renderers recognize the backticks.
+func code(s string) string { return "`" + s + "`" }
+
+func itoa(n int) string { return fmt.Sprintf("%d", n) }
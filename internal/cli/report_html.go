@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"html/template"
+	"io"
+	"strings"
+)
+
+// reportHTML is the self-contained page dp report --format html writes:
+// styles are inline and nothing is loaded from elsewhere.
+var reportHTML = template.Must(template.New("report").Funcs(template.FuncMap{
+	"cell": reportHTMLCell,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Desire path report: last {{.Report.Window}}</title>
+<style>
+body { font: 14px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 960px; margin: 2em auto; padding: 0 1em; }
+h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+h2 { font-size: 1.15em; margin-top: 1.8em; border-bottom: 1px solid #d0d7de; padding-bottom: 0.3em; }
+.meta { color: #656d76; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 4px 10px; border-bottom: 1px solid #eaeef2; }
+th { font-weight: 600; background: #f6f8fa; }
+td:not(:first-child) { white-space: nowrap; }
+code { font: 12px ui-monospace, SFMono-Regular, Menlo, monospace; background: #f6f8fa; padding: 1px 4px; border-radius: 4px; }
+.empty { color: #656d76; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>Desire path report: last {{.Report.Window}}</h1>
+<p class="meta">{{.Report.Since.Format "2006-01-02 15:04"}} to {{.Report.Until.Format "2006-01-02 15:04 MST"}} · {{.Report.Desires}} desires across {{.Report.Paths}} paths</p>
+{{range .Sections}}
+<h2>{{.Title}}</h2>
+{{if .Rows}}<table>
+<tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{cell .}}</td>{{end}}</tr>
+{{end}}</table>
+{{else}}<p class="empty">{{.Empty}}</p>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// reportHTMLCell renders a report cell, showing backtick-quoted text as code.
+func reportHTMLCell(s string) template.HTML {
+	if len(s) >= 2 && strings.HasPrefix(s, "`") && strings.HasSuffix(s, "`") {
+		return template.HTML("<code>" + template.HTMLEscapeString(s[1:len(s)-1]) + "</code>")
+	}
+	return template.HTML(template.HTMLEscapeString(s))
+}
+
+// writeReportHTML writes rep as a standalone HTML page.
+func writeReportHTML(w io.Writer, rep *report) error {
+	return reportHTML.Execute(w, struct {
+		Report   *report
+		Sections []reportSection
+	}{rep, reportSections(rep)})
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func reportTestStore(t *testing.T, now time.Time) store.Store {
+	t.Helper()
+	s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	ctx := context.Background()
+	day := 24 * time.Hour
+	var n int
+	desire := func(tool, category string, ago time.Duration, meta string) {
+		n++
+		d := model.Desire{ID: fmt.Sprintf("d%d", n), ToolName: tool, Error: "bash: cargo-insta: command not found", Category: category, Timestamp: now.Add(-ago)}
+		if meta != "" {
+			d.Metadata = json.RawMessage(meta)
+		}
+		if err := s.RecordDesire(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 3 {
+		desire("read_file", "", day, "") // new this week
+	}
+	desire("web_fetch", "", 2*day, "")
+	desire("grep_search", "", 8*day, "") // only last week: gone
+	desire("Bash", model.CategoryEnvNeed, 2*day, "")
+	desire("Grep", model.CategoryTurnPattern, day, `{"pattern":"Grep → Read{3+}","sessions":4,"avg_length":6.5}`)
+	if err := s.SetAlias(ctx, model.Alias{From: "file_read", To: "Read"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordIntervention(ctx, model.Intervention{ID: "iv1", Kind: "block", ToolName: "file_read", Rule: "file_read", Timestamp: now.Add(-day)}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestBuildReport(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	s := reportTestStore(t, now)
+
+	rep, err := buildReport(context.Background(), s, 7*24*time.Hour, now, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Window != "7 days" || !rep.Since.Equal(now.Add(-7*24*time.Hour)) {
+		t.Errorf("window = %q since %s", rep.Window, rep.Since)
+	}
+	if len(rep.NewPaths) == 0 || rep.NewPaths[0].Pattern != "read_file" || rep.NewPaths[0].Current != 3 {
+		t.Errorf("new paths = %+v", rep.NewPaths)
+	}
+	if len(rep.Movers) != 1 || rep.Movers[0].Pattern != "grep_search" || rep.Movers[0].Status != store.TrendGone {
+		t.Errorf("movers = %+v", rep.Movers)
+	}
+	if len(rep.EnvNeeds) != 1 || rep.EnvNeeds[0].Command != "cargo-insta" {
+		t.Errorf("env needs = %+v", rep.EnvNeeds)
+	}
+	if len(rep.TurnPatterns) != 1 || rep.TurnPatterns[0].Sessions != 4 {
+		t.Errorf("turn patterns = %+v", rep.TurnPatterns)
+	}
+	if len(rep.Aliases) != 1 || rep.Aliases[0].Caught != 1 || rep.Aliases[0].Missed != 0 {
+		t.Errorf("aliases = %+v", rep.Aliases)
+	}
+	if len(rep.Suggestions) == 0 || rep.Suggestions[0].Pattern != "web_fetch" || rep.Suggestions[0].To != "WebFetch" {
+		t.Errorf("suggestions = %+v", rep.Suggestions)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	rep, err := buildReport(context.Background(), reportTestStore(t, now), 7*24*time.Hour, now, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var md bytes.Buffer
+	if err := writeReportMarkdown(&md, rep); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Desire path report: last 7 days",
+		"## Top new paths",
+		"| `read_file` | 3 |",
+		"`dp alias web_fetch WebFetch`",
+		"_No recoveries._",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := writeReportHTML(&html, rep); err != nil {
+		t.Fatal(err)
+	}
+	out := html.String()
+	for _, want := range []string{"<!DOCTYPE html>", "<style>", "<code>Grep → Read{3+}</code>", "No recoveries."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<link") || strings.Contains(out, "<script src") {
+		t.Error("html report should not load external assets")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/scbrown/desire-path/internal/config"
 	"github.com/scbrown/desire-path/internal/store"
@@ -12,10 +13,12 @@ import (
 )
 
 var (
-	dbPath     string
-	jsonOutput bool
-	storeMode  string
-	remoteURL  string
+	dbPath      string
+	jsonOutput  bool
+	storeMode   string
+	remoteURL   string
+	remoteToken string
+	workspace   string
 )
 
 func defaultDBPath() string {
@@ -26,6 +29,35 @@ func defaultDBPath() string {
 	return filepath.Join(home, ".dp", "desires.db")
 }
 
+// spoolDir returns where remote-mode writes are queued while the server is
+// unreachable: a "spool" directory next to the database (~/.dp/spool by
+// default).
+func spoolDir() string {
+	return filepath.Join(filepath.Dir(dbPath), "spool")
+}
+
+var spoolNoticeOnce sync.Once
+
+// spoolNotice tells the user, once per run, that writes are being spooled
+// rather than sent.
+func spoolNotice(err error) {
+	spoolNoticeOnce.Do(func() {
+		fmt.Fprintf(os.Stderr, "dp: server unreachable, spooled write to %s (run 'dp sync' to send): %v\n", spoolDir(), err)
+	})
+}
+
+// workspacesDir returns where dp serve keeps the databases of its named
+// workspaces: a "workspaces" directory next to the database.
+func workspacesDir() string {
+	return filepath.Join(filepath.Dir(dbPath), "workspaces")
+}
+
+// alertStatePath returns where dp alerts records open incidents: alerts.json
+// next to the database.
+func alertStatePath() string {
+	return filepath.Join(filepath.Dir(dbPath), "alerts.json")
+}
+
 // rootCmd is the top-level dp command.
 var rootCmd = &cobra.Command{
 	Use:   "dp",
@@ -68,6 +100,12 @@ machine-readable output.`,
 		if cfg.RemoteURL != "" && remoteURL == "" {
 			remoteURL = cfg.RemoteURL
 		}
+		if cfg.RemoteToken != "" && remoteToken == "" {
+			remoteToken = cfg.RemoteToken
+		}
+		if cfg.Workspace != "" && workspace == "" {
+			workspace = cfg.Workspace
+		}
 	},
 }
 
@@ -77,14 +115,37 @@ func init() {
 }
 
 // openStore returns a store.Store based on the current configuration.
-// When store_mode is "remote", it returns a RemoteStore pointing at remote_url.
+// When store_mode is "remote", it returns a RemoteStore pointing at remote_url
+// and authenticating with remote_token, which spools writes to spoolDir()
+// while the server is unreachable. Both remote modes use the server's
+// workspace named by the workspace config key.
+// When store_mode is "hybrid", it returns a HybridStore that writes to the
+// local SQLite database and replicates to remote_url.
 // Otherwise it opens the local SQLite database.
 func openStore() (store.Store, error) {
-	if storeMode == "remote" {
+	switch storeMode {
+	case "remote":
 		if remoteURL == "" {
 			return nil, fmt.Errorf("store_mode is \"remote\" but remote_url is not set; use: dp config set remote_url <url>")
 		}
-		return store.NewRemote(remoteURL), nil
+		rs := store.NewRemote(remoteURL)
+		rs.SetToken(remoteToken)
+		rs.SetWorkspace(workspace)
+		rs.SetSpool(store.NewSpool(spoolDir()))
+		rs.SetSpoolNotice(spoolNotice)
+		return rs, nil
+	case "hybrid":
+		if remoteURL == "" {
+			return nil, fmt.Errorf("store_mode is \"hybrid\" but remote_url is not set; use: dp config set remote_url <url>")
+		}
+		local, err := store.New(dbPath)
+		if err != nil {
+			return nil, err
+		}
+		rs := store.NewRemote(remoteURL)
+		rs.SetToken(remoteToken)
+		rs.SetWorkspace(workspace)
+		return store.NewHybrid(local, rs), nil
 	}
 	return store.New(dbPath)
 }
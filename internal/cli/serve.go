@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/scbrown/desire-path/internal/model"
 	"github.com/scbrown/desire-path/internal/server"
 	"github.com/scbrown/desire-path/internal/store"
 	"github.com/spf13/cobra"
@@ -27,7 +28,17 @@ invocations, paths, aliases, stats, and inspection. A health check is available
 at /api/v1/health.
 
 Use dp config to set store_mode=remote and remote_url to point other dp instances
-at this server instead of a local database.`,
+at this server instead of a local database.
+
+Authentication: create API tokens with 'dp serve token create'. Once any
+active token exists, every request except the health check must send
+"Authorization: Bearer <token>"; clients set it with dp config remote_token.
+Until then the server accepts unauthenticated requests and warns at startup.
+
+Workspaces: teams that share a server but not their tools and aliases each
+get a workspace, created with 'dp serve workspace create'. A workspace has
+its own database and is served under /api/v1/w/<workspace>/; clients pick
+one with dp config workspace. Requests without a workspace use --db.`,
 	Example: `  # Start server on default port
   dp serve
 
@@ -35,7 +46,15 @@ at this server instead of a local database.`,
   dp serve --addr :9090
 
   # Start with a specific database
-  dp serve --db /path/to/desires.db --addr localhost:7273`,
+  dp serve --db /path/to/desires.db --addr localhost:7273
+
+  # Require tokens, then point a client at the server
+  dp serve token create --name alice-laptop --scope write
+  dp config set remote_token dp_...
+
+  # Give a team its own workspace
+  dp serve workspace create team-a
+  dp config set workspace team-a`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s, err := store.New(dbPath)
 		if err != nil {
@@ -44,6 +63,7 @@ at this server instead of a local database.`,
 		defer s.Close()
 
 		srv := server.New(s)
+		srv.SetWorkspaces(store.NewWorkspaces(workspacesDir()))
 
 		// Listen first so we can report the actual address.
 		ln, err := net.Listen("tcp", serveAddr)
@@ -52,6 +72,9 @@ at this server instead of a local database.`,
 		}
 
 		fmt.Fprintf(os.Stderr, "dp serve listening on %s\n", ln.Addr())
+		if tokens, err := s.ListTokens(context.Background()); err == nil && !hasActiveToken(tokens) {
+			fmt.Fprintln(os.Stderr, "warning: no API tokens; accepting unauthenticated requests (see 'dp serve token create')")
+		}
 
 		// Graceful shutdown on SIGINT/SIGTERM.
 		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -72,6 +95,16 @@ at this server instead of a local database.`,
 	},
 }
 
+// hasActiveToken reports whether any token has not been revoked.
+func hasActiveToken(tokens []model.APIToken) bool {
+	for _, t := range tokens {
+		if t.RevokedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	serveCmd.Flags().StringVar(&serveAddr, "addr", ":7273", "address to listen on (host:port)")
 	rootCmd.AddCommand(serveCmd)
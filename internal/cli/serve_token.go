@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/server"
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenScope string // --scope
+	tokenName  string // --name
+	tokenWS    string // --workspace
+)
+
+var serveTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens for dp serve",
+	Long: `Create, list, and revoke the bearer tokens that dp serve accepts.
+
+Tokens are stored hashed in the server's local database (--db), so these
+commands must run on the server host. Once any active token exists, every
+request except /api/v1/health must carry "Authorization: Bearer <token>".
+
+Scopes:
+  read   GET requests
+  write  GET and POST requests (recording, ingest, setting aliases)
+  admin  everything, including DELETE
+
+The token's name is recorded as client_id on the desires, invocations, and
+interventions it writes. A token created with --workspace can only use that
+workspace (see 'dp serve workspace').`,
+}
+
+var serveTokenCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an API token and print it once",
+	Example: `  dp serve token create --name alice-laptop --scope write
+  dp serve token create --name dashboard --scope read --json
+  dp serve token create --name team-a-ci --workspace team-a`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		t, secret, err := server.NewToken(tokenName, tokenScope)
+		if err != nil {
+			return err
+		}
+		if tokenWS != "" && tokenWS != model.DefaultWorkspace {
+			st, err := store.NewWorkspaces(workspacesDir()).Open(tokenWS)
+			if err != nil {
+				return fmt.Errorf("--workspace: %w (see 'dp serve workspace create')", err)
+			}
+			st.Close()
+		}
+		t.Workspace = tokenWS
+		s, err := store.New(dbPath)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer s.Close()
+		if err := s.CreateToken(context.Background(), t); err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if jsonOutput {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				model.APIToken
+				Token string `json:"token"`
+			}{t, secret})
+		}
+		fmt.Fprintf(w, "Created %s token %q (id %s).\n\n", t.Scope, t.Name, t.ID)
+		fmt.Fprintf(w, "  %s\n\n", secret)
+		fmt.Fprintln(w, "This is the only time the token is shown. On the client, run:")
+		fmt.Fprintf(w, "  dp config set remote_token %s\n", secret)
+		return nil
+	},
+}
+
+var serveTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API tokens",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.New(dbPath)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer s.Close()
+		tokens, err := s.ListTokens(context.Background())
+		if err != nil {
+			return err
+		}
+		w := cmd.OutOrStdout()
+		if jsonOutput {
+			if tokens == nil {
+				tokens = []model.APIToken{}
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(tokens)
+		}
+		printTokens(w, tokens)
+		return nil
+	},
+}
+
+var serveTokenRevokeCmd = &cobra.Command{
+	Use:     "revoke <id>",
+	Short:   "Revoke an API token",
+	Example: `  dp serve token revoke 3f2a9c1e-...`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.New(dbPath)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer s.Close()
+		ok, err := s.RevokeToken(context.Background(), args[0])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("no active token with id %q", args[0])
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Revoked token %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	host, _ := os.Hostname()
+	serveTokenCreateCmd.Flags().StringVar(&tokenName, "name", host, "client identity recorded on rows this token writes")
+	serveTokenCreateCmd.Flags().StringVar(&tokenScope, "scope", model.ScopeWrite, "token scope: read, write, or admin")
+	serveTokenCreateCmd.Flags().StringVar(&tokenWS, "workspace", "", "limit the token to this workspace (default: any)")
+	serveTokenCmd.AddCommand(serveTokenCreateCmd, serveTokenListCmd, serveTokenRevokeCmd)
+	serveCmd.AddCommand(serveTokenCmd)
+}
+
+func printTokens(w io.Writer, tokens []model.APIToken) {
+	if len(tokens) == 0 {
+		fmt.Fprintln(w, "No API tokens. dp serve is accepting unauthenticated requests.")
+		return
+	}
+	tbl := NewTable(w, "ID", "NAME", "SCOPE", "WORKSPACE", "CREATED", "LAST USED", "STATUS")
+	for _, t := range tokens {
+		lastUsed := "never"
+		if t.LastUsed != nil {
+			lastUsed = t.LastUsed.Local().Format("2006-01-02 15:04")
+		}
+		status := "active"
+		if t.RevokedAt != nil {
+			status = "revoked"
+		}
+		ws := t.Workspace
+		if ws == "" {
+			ws = "all"
+		}
+		tbl.Row(t.ID, t.Name, t.Scope, ws, t.CreatedAt.Local().Format("2006-01-02 15:04"), lastUsed, status)
+	}
+	tbl.Flush()
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/scbrown/desire-path/internal/server"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func TestServeTokenCreateListRevoke(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+	dbPath = db
+	jsonOutput = false
+	t.Cleanup(func() {
+		tokenName = ""
+		tokenScope = "write"
+	})
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	defer rootCmd.SetOut(nil)
+
+	rootCmd.SetArgs([]string{"serve", "token", "create", "--db", db, "--name", "alice-laptop", "--scope", "read"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("token create: %v", err)
+	}
+	secret := regexp.MustCompile(`dp_[0-9a-f]{64}`).FindString(buf.String())
+	if secret == "" {
+		t.Fatalf("no token in output:\n%s", buf.String())
+	}
+
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := s.AuthenticateToken(context.Background(), server.HashToken(secret))
+	s.Close()
+	if err != nil || tok == nil || tok.Name != "alice-laptop" || tok.Scope != "read" {
+		t.Fatalf("stored token = %+v, %v", tok, err)
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"serve", "token", "list", "--db", db})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("token list: %v", err)
+	}
+	if !strings.Contains(buf.String(), "alice-laptop") || strings.Contains(buf.String(), secret) {
+		t.Errorf("list output:\n%s", buf.String())
+	}
+
+	rootCmd.SetArgs([]string{"serve", "token", "revoke", "--db", db, tok.ID})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("token revoke: %v", err)
+	}
+	rootCmd.SetArgs([]string{"serve", "token", "revoke", "--db", db, tok.ID})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("revoking twice should fail")
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"serve", "token", "create", "--db", db, "--name", "x", "--scope", "root"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
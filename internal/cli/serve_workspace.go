@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var serveWorkspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Manage the workspaces served by dp serve",
+	Long: `Create and list the workspaces dp serve hosts.
+
+Each workspace is a separate SQLite database in a "workspaces" directory
+next to the server's database (--db), so workspaces share no desires,
+invocations, aliases, or doc mappings. The server's own database is the
+"default" workspace. A running server picks up new workspaces without a
+restart.
+
+Clients choose a workspace with dp config workspace; the API serves it under
+/api/v1/w/<workspace>/. Limit a token to one workspace with
+'dp serve token create --workspace'.`,
+}
+
+var serveWorkspaceCreateCmd = &cobra.Command{
+	Use:     "create <name>",
+	Short:   "Create a workspace",
+	Example: `  dp serve workspace create team-a`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws := store.NewWorkspaces(workspacesDir())
+		if err := ws.Create(args[0]); err != nil {
+			return err
+		}
+		w := cmd.OutOrStdout()
+		fmt.Fprintf(w, "Created workspace %q.\n", args[0])
+		fmt.Fprintln(w, "On the client, run:")
+		fmt.Fprintf(w, "  dp config set workspace %s\n", args[0])
+		return nil
+	},
+}
+
+var serveWorkspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List workspaces",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		named, err := store.NewWorkspaces(workspacesDir()).List()
+		if err != nil {
+			return err
+		}
+		def := store.WorkspaceInfo{Name: model.DefaultWorkspace, Path: dbPath}
+		if info, err := os.Stat(dbPath); err == nil {
+			def.Bytes = info.Size()
+		}
+		all := append([]store.WorkspaceInfo{def}, named...)
+
+		w := cmd.OutOrStdout()
+		if jsonOutput {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(all)
+		}
+		return printWorkspaces(w, all)
+	},
+}
+
+func init() {
+	serveWorkspaceCmd.AddCommand(serveWorkspaceCreateCmd, serveWorkspaceListCmd)
+	serveCmd.AddCommand(serveWorkspaceCmd)
+}
+
+func printWorkspaces(w io.Writer, all []store.WorkspaceInfo) error {
+	tbl := NewTable(w, "NAME", "PATH", "BYTES")
+	for _, ws := range all {
+		tbl.Row(ws.Name, ws.Path, fmt.Sprintf("%d", ws.Bytes))
+	}
+	return tbl.Flush()
+}
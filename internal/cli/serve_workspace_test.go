@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/scbrown/desire-path/internal/server"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func TestServeWorkspaceCreateList(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+	dbPath = db
+	jsonOutput = false
+	t.Cleanup(func() {
+		tokenName = ""
+		tokenScope = "write"
+		tokenWS = ""
+	})
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	defer rootCmd.SetOut(nil)
+
+	rootCmd.SetArgs([]string{"serve", "workspace", "create", "--db", db, "team-a"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workspace create: %v", err)
+	}
+	rootCmd.SetArgs([]string{"serve", "workspace", "create", "--db", db, "team-a"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("creating a workspace twice should fail")
+	}
+	rootCmd.SetArgs([]string{"serve", "workspace", "create", "--db", db, "Team A"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error for invalid workspace name")
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"serve", "workspace", "list", "--db", db})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workspace list: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "default") || !strings.Contains(out, "team-a") ||
+		!strings.Contains(out, filepath.Join(filepath.Dir(db), "workspaces", "team-a.db")) {
+		t.Errorf("list output:\n%s", out)
+	}
+
+	rootCmd.SetArgs([]string{"serve", "token", "create", "--db", db, "--name", "ci", "--workspace", "team-b"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected error for a token limited to a missing workspace")
+	}
+	buf.Reset()
+	rootCmd.SetArgs([]string{"serve", "token", "create", "--db", db, "--name", "ci", "--workspace", "team-a"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("token create: %v", err)
+	}
+	secret := regexp.MustCompile(`dp_[0-9a-f]{64}`).FindString(buf.String())
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := s.AuthenticateToken(context.Background(), server.HashToken(secret))
+	s.Close()
+	if err != nil || tok == nil || tok.Workspace != "team-a" {
+		t.Fatalf("stored token = %+v, %v", tok, err)
+	}
+}
@@ -6,12 +6,21 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"time"
 
+	"github.com/scbrown/desire-path/internal/model"
 	"github.com/scbrown/desire-path/internal/store"
 	"github.com/spf13/cobra"
 )
 
-var showInvocations bool
+var (
+	showInvocations   bool
+	showInterventions bool
+	showCost          bool
+	showLatency       bool
+	statsSince        string
+	statsSlow         string
+)
 
 var statsCmd = &cobra.Command{
 	Use:   "stats",
@@ -21,10 +30,31 @@ unique tool names, top sources, most common desires, date range,
 and recent activity counts.
 
 Use --invocations to display invocation statistics instead: total
-invocations, unique tools, top sources, top tools, and time windows.`,
+invocations, unique tools, top sources, top tools, and time windows.
+
+Use --interventions to display how often pave-check intervened
+(blocked, denied, or corrected a call), grouped by kind and tool.
+
+Use --cost to estimate the tokens and dollars spent on calls, and how much
+went to failed calls and their retries. Tokens come from the usage of the
+assistant message that made each call, read from Claude Code transcripts
+at ingest; dollars use the token_prices config key. Limit the window with
+--since.
+
+Use --latency to show how long each tool's calls take (p50, p95, p99)
+and how many of them timed out, followed by the tools that are both slow
+and failing: their p95 is at least --slow and some of their calls fail.
+Durations come from Cursor payloads and Claude Code transcripts.
+
+If writes are waiting in the offline spool (see dp sync), their count is
+shown as well.`,
 	Example: `  dp stats
   dp stats --invocations
-  dp stats --invocations --json`,
+  dp stats --invocations --json
+  dp stats --interventions
+  dp stats --cost --since 30d
+  dp stats --latency --since 7d
+  dp stats --latency --slow 30s`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s, err := openStore()
 		if err != nil {
@@ -32,6 +62,59 @@ invocations, unique tools, top sources, top tools, and time windows.`,
 		}
 		defer s.Close()
 
+		var since time.Time
+		if statsSince != "" {
+			d, err := parseDuration(statsSince)
+			if err != nil {
+				return fmt.Errorf("invalid --since value %q: %w", statsSince, err)
+			}
+			since = time.Now().Add(-d)
+		}
+
+		if showLatency {
+			slow, err := parseDuration(statsSlow)
+			if err != nil {
+				return fmt.Errorf("invalid --slow value %q: %w", statsSlow, err)
+			}
+			r, err := loadLatencyReport(context.Background(), s, since, slow)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(r)
+			}
+			printLatencyReport(os.Stdout, r, 10)
+			return nil
+		}
+
+		if showCost {
+			r, err := loadCostReport(context.Background(), s, since)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(r)
+			}
+			printCostReport(os.Stdout, r, 10)
+			return nil
+		}
+
+		if showInterventions {
+			ivs, err := s.InterventionStats(context.Background(), time.Time{})
+			if err != nil {
+				return fmt.Errorf("get intervention stats: %w", err)
+			}
+			if jsonOutput {
+				return printInterventionStatsJSON(ivs)
+			}
+			printInterventionStatsTable(ivs)
+			return nil
+		}
+
 		if showInvocations {
 			ist, err := s.InvocationStats(context.Background())
 			if err != nil {
@@ -53,19 +136,41 @@ invocations, unique tools, top sources, top tools, and time windows.`,
 			return printStatsJSON(st)
 		}
 		printStatsText(st)
+		if n := spooledWrites(); n > 0 {
+			fmt.Println()
+			fmt.Printf("Spooled writes:     %d (not yet sent; run 'dp sync')\n", n)
+		}
 		return nil
 	},
 }
 
 func init() {
 	statsCmd.Flags().BoolVar(&showInvocations, "invocations", false, "show invocation statistics instead of desire statistics")
+	statsCmd.Flags().BoolVar(&showInterventions, "interventions", false, "show pave-check intervention counts instead of desire statistics")
+	statsCmd.Flags().BoolVar(&showCost, "cost", false, "estimate tokens and dollars spent on failed calls and retries")
+	statsCmd.Flags().BoolVar(&showLatency, "latency", false, "show p50/p95/p99 call duration per tool, and slow and failing tools")
+	statsCmd.Flags().StringVar(&statsSince, "since", "", "with --cost or --latency, only include calls within this duration (e.g. 7d, 24h)")
+	statsCmd.Flags().StringVar(&statsSlow, "slow", defaultSlow.String(), "with --latency, p95 at which a failing tool counts as slow (e.g. 10s, 2m)")
 	rootCmd.AddCommand(statsCmd)
 }
 
 func printStatsJSON(st store.Stats) error {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
-	return enc.Encode(st)
+	return enc.Encode(struct {
+		store.Stats
+		Spooled int `json:"spooled,omitempty"`
+	}{st, spooledWrites()})
+}
+
+// spooledWrites returns how many remote-mode writes are waiting in the
+// offline spool. Errors count as none: this is informational only.
+func spooledWrites() int {
+	st, err := store.NewSpool(spoolDir()).Status()
+	if err != nil {
+		return 0
+	}
+	return st.Entries
 }
 
 func printStatsText(st store.Stats) {
@@ -171,3 +276,24 @@ func printInvocationStatsText(ist store.InvocationStatsResult) {
 		}
 	}
 }
+
+func printInterventionStatsJSON(ivs []model.InterventionStat) error {
+	if ivs == nil {
+		ivs = []model.InterventionStat{}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ivs)
+}
+
+func printInterventionStatsTable(ivs []model.InterventionStat) {
+	if len(ivs) == 0 {
+		fmt.Println("No interventions recorded.")
+		return
+	}
+	tbl := NewTable(os.Stdout, "KIND", "TOOL", "COUNT", "LAST SEEN")
+	for _, iv := range ivs {
+		tbl.Row(iv.Kind, iv.ToolName, fmt.Sprintf("%d", iv.Count), iv.LastSeen.Format("2006-01-02 15:04"))
+	}
+	tbl.Flush()
+}
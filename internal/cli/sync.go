@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncStatus   bool          // --status
+	syncWatch    bool          // --watch
+	syncInterval time.Duration // --interval
+	syncAudit    bool          // --audit
+)
+
+// aliasAuditLimit is how many alias audit entries dp sync --audit shows.
+const aliasAuditLimit = 50
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Send spooled writes, or replicate to the team server in hybrid mode",
+	Long: `In remote mode (store_mode=remote), desires, invocations, pave-check
+interventions, and recovery and retry-chain updates that cannot reach the
+server are appended to a local spool (~/.dp/spool, next to the database)
+instead of being lost. They are sent automatically after the next
+successful write; dp sync sends them now.
+
+Spooled writes are replayed in the order they were recorded. The server
+ignores records it already has, so replaying is safe even if a write
+reached it before the connection dropped. If the server is still
+unreachable, dp sync stops and leaves the rest spooled.
+
+In local mode, dp sync writes the spool into the local database.
+
+In hybrid mode (store_mode=hybrid), everything is written to the local
+database and dp sync replicates it to the team server: desires,
+invocations, and interventions recorded since the last sync are pushed,
+aliases are reconciled in both directions, and doc mappings are pulled.
+An alias changed on both sides goes to whichever change was made last;
+every alias change sync makes is kept in an audit trail, shown by
+--audit. Use --watch to keep syncing every --interval.
+
+Use --status to show the spool size without sending anything.`,
+	Example: `  dp sync
+  dp sync --status
+  dp sync --status --json
+  dp sync --watch --interval 30s
+  dp sync --audit`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		w := cmd.OutOrStdout()
+		sp := store.NewSpool(spoolDir())
+		if syncStatus {
+			st, err := sp.Status()
+			if err != nil {
+				return fmt.Errorf("spool status: %w", err)
+			}
+			return printSpoolStatus(w, st)
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return fmt.Errorf("open store: %w", err)
+		}
+		defer s.Close()
+
+		hs, hybrid := s.(*store.HybridStore)
+		if (syncWatch || syncAudit) && !hybrid {
+			return fmt.Errorf("--watch and --audit require store_mode \"hybrid\"")
+		}
+		if syncAudit {
+			entries, err := hs.AliasLog(context.Background(), aliasAuditLimit)
+			if err != nil {
+				return err
+			}
+			return printAliasLog(w, entries)
+		}
+		if syncWatch {
+			if syncInterval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return watchSync(ctx, w, cmd.ErrOrStderr(), hs, sp)
+		}
+		if hybrid {
+			res, err := syncHybrid(context.Background(), hs, sp)
+			if err != nil {
+				return fmt.Errorf("sync: %w", err)
+			}
+			return printHybridSync(w, res)
+		}
+
+		ctx := context.Background()
+		var res store.ReplayResult
+		if rs, ok := s.(*store.RemoteStore); ok {
+			res, err = rs.Sync(ctx)
+		} else {
+			res, err = sp.Replay(ctx, s)
+		}
+		st, stErr := sp.Status()
+		if err != nil {
+			return fmt.Errorf("sync stopped after %d writes (%d still spooled): %w", res.Replayed, st.Entries, err)
+		}
+		if stErr != nil {
+			return fmt.Errorf("spool status: %w", stErr)
+		}
+
+		if jsonOutput {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				store.ReplayResult
+				Remaining int `json:"remaining"`
+			}{res, st.Entries})
+		}
+		fmt.Fprintf(w, "Sent %d spooled writes.\n", res.Replayed)
+		if res.Skipped > 0 {
+			fmt.Fprintf(w, "Skipped %d unreadable lines.\n", res.Skipped)
+		}
+		if st.Entries > 0 {
+			// Another dp process was replaying at the same time.
+			fmt.Fprintf(w, "%d writes are still spooled in %s.\n", st.Entries, st.Dir)
+		}
+		return nil
+	},
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncStatus, "status", false, "show the spool size without sending anything")
+	syncCmd.Flags().BoolVar(&syncWatch, "watch", false, "hybrid mode: keep syncing until interrupted")
+	syncCmd.Flags().DurationVar(&syncInterval, "interval", time.Minute, "time between syncs with --watch")
+	syncCmd.Flags().BoolVar(&syncAudit, "audit", false, "hybrid mode: show recent alias changes made by sync")
+	rootCmd.AddCommand(syncCmd)
+}
+
+// hybridSyncResult is what one hybrid-mode sync did.
+type hybridSyncResult struct {
+	Spooled int `json:"spooled"` // spooled writes moved into the local database
+	store.SyncResult
+}
+
+// changed reports whether the sync moved anything.
+func (r hybridSyncResult) changed() bool {
+	return r.Spooled > 0 || r.SyncResult != store.SyncResult{}
+}
+
+// syncHybrid moves anything left in the spool (from an earlier remote-mode
+// setup) into the local database, then replicates with the server.
+func syncHybrid(ctx context.Context, hs *store.HybridStore, sp *store.Spool) (hybridSyncResult, error) {
+	var res hybridSyncResult
+	replayed, err := sp.Replay(ctx, hs)
+	res.Spooled = replayed.Replayed
+	if err != nil {
+		return res, err
+	}
+	res.SyncResult, err = hs.Sync(ctx)
+	return res, err
+}
+
+func printHybridSync(w io.Writer, res hybridSyncResult) error {
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	if res.Spooled > 0 {
+		fmt.Fprintf(w, "Moved %d spooled writes into the local database.\n", res.Spooled)
+	}
+	fmt.Fprintf(w, "Pushed %d desires, %d invocations, %d interventions.\n",
+		res.Desires, res.Invocations, res.Interventions)
+	fmt.Fprintf(w, "Aliases: %d pulled, %d pushed, %d deleted on the server, %d removed locally, %d conflicts.\n",
+		res.AliasesPulled, res.AliasesPushed, res.AliasesDeleted, res.AliasesRemoved, res.Conflicts)
+	fmt.Fprintf(w, "Pulled %d doc mappings.\n", res.DocMappings)
+	if res.Conflicts > 0 {
+		fmt.Fprintln(w, "Run 'dp sync --audit' to see how alias conflicts were resolved.")
+	}
+	return nil
+}
+
+// watchSync syncs every syncInterval until ctx is cancelled, reporting
+// syncs that moved something. A failed sync is reported and retried at the
+// next interval.
+func watchSync(ctx context.Context, w, errw io.Writer, hs *store.HybridStore, sp *store.Spool) error {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		res, err := syncHybrid(ctx, hs, sp)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(errw, "%s sync failed: %v\n", time.Now().Format(time.TimeOnly), err)
+		case !res.changed():
+		case jsonOutput:
+			if err := json.NewEncoder(w).Encode(res); err != nil {
+				return err
+			}
+		default:
+			fmt.Fprintf(w, "%s pushed %d desires, %d invocations, %d interventions; aliases %d pulled, %d pushed, %d conflicts; %d doc mappings pulled\n",
+				time.Now().Format(time.TimeOnly), res.Desires, res.Invocations, res.Interventions,
+				res.AliasesPulled, res.AliasesPushed, res.Conflicts, res.DocMappings)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// printAliasLog prints alias audit entries, newest first.
+func printAliasLog(w io.Writer, entries []model.AliasSync) error {
+	if jsonOutput {
+		if entries == nil {
+			entries = []model.AliasSync{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No alias changes synced yet.")
+		return nil
+	}
+	tbl := NewTable(w, "TIME", "ACTION", "FROM", "TOOL", "LOCAL", "REMOTE")
+	for _, e := range entries {
+		action := e.Action
+		if e.Winner != "" {
+			action += " (" + e.Winner + " won)"
+		}
+		tbl.Row(e.Timestamp.Local().Format("2006-01-02 15:04:05"), action, e.From, e.Tool,
+			aliasTarget(e.Local), aliasTarget(e.Remote))
+	}
+	return tbl.Flush()
+}
+
+// aliasTarget shows what an audited alias version mapped to.
+func aliasTarget(a *model.Alias) string {
+	if a == nil {
+		return "-"
+	}
+	return truncateTo(a.To, 30)
+}
+
+// printSpoolStatus reports how many writes are waiting in the spool.
+func printSpoolStatus(w io.Writer, st store.SpoolStatus) error {
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	}
+	if st.Entries == 0 {
+		fmt.Fprintf(w, "Spool is empty (%s).\n", st.Dir)
+		return nil
+	}
+	fmt.Fprintf(w, "%d spooled writes in %d files (%d bytes) in %s.\n", st.Entries, st.Files, st.Bytes, st.Dir)
+	fmt.Fprintln(w, "Run 'dp sync' to send them.")
+	return nil
+}
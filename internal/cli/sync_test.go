@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/server"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func TestSyncReplaysSpoolLocally(t *testing.T) {
+	db := setupTestDB(t)
+	dbPath = db
+	jsonOutput = false
+	t.Cleanup(func() { syncStatus = false })
+
+	// Spool two desires through a remote store whose server is gone.
+	ts := httptest.NewServer(nil)
+	ts.Close()
+	rs := store.NewRemote(ts.URL)
+	rs.SetSpool(store.NewSpool(spoolDir()))
+	for _, id := range []string{"d-1", "d-2"} {
+		d := model.Desire{ID: id, ToolName: "read_file", Error: "unknown tool", Timestamp: time.Now()}
+		if err := rs.RecordDesire(context.Background(), d); err != nil {
+			t.Fatalf("RecordDesire: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	defer rootCmd.SetOut(nil)
+
+	rootCmd.SetArgs([]string{"sync", "--db", db, "--status"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("sync --status: %v", err)
+	}
+	if !strings.Contains(buf.String(), "2 spooled writes in 1 files") {
+		t.Errorf("status output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	syncStatus = false
+	rootCmd.SetArgs([]string{"sync", "--db", db, "--json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	jsonOutput = false
+	var res struct {
+		Replayed  int `json:"replayed"`
+		Remaining int `json:"remaining"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if res.Replayed != 2 || res.Remaining != 0 {
+		t.Errorf("result = %+v, want 2 replayed, 0 remaining", res)
+	}
+
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	desires, err := s.ListDesires(context.Background(), store.ListOpts{})
+	if err != nil || len(desires) != 2 {
+		t.Errorf("local desires = %d (%v), want 2", len(desires), err)
+	}
+}
+
+func TestSyncHybrid(t *testing.T) {
+	db := setupTestDB(t)
+	dbPath = db
+	jsonOutput = false
+	team, err := store.New(filepath.Join(t.TempDir(), "team.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer team.Close()
+	ts := httptest.NewServer(server.New(team).Handler())
+	defer ts.Close()
+
+	storeMode, remoteURL = "hybrid", ts.URL
+	t.Cleanup(func() { storeMode, remoteURL = "", "" })
+
+	// Writes land in the local database only.
+	s, err := openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := s.RecordDesire(ctx, model.Desire{ID: "d-1", ToolName: "read_file", Error: "unknown tool", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("RecordDesire: %v", err)
+	}
+	if err := s.SetAlias(ctx, model.Alias{From: "read_file", To: "Read"}); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	s.Close()
+	if st, _ := team.Stats(ctx); st.TotalDesires != 0 {
+		t.Fatalf("team server has %d desires before sync", st.TotalDesires)
+	}
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"sync", "--db", db})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Pushed 1 desires") || !strings.Contains(buf.String(), "1 pushed") {
+		t.Errorf("sync output:\n%s", buf.String())
+	}
+	if st, _ := team.Stats(ctx); st.TotalDesires != 1 {
+		t.Errorf("team server has %d desires after sync, want 1", st.TotalDesires)
+	}
+	if a, _ := team.GetAlias(ctx, model.AliasKey{From: "read_file"}); a == nil {
+		t.Error("alias not pushed to the team server")
+	}
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"sync", "--db", db, "--audit"})
+	t.Cleanup(func() { syncAudit = false })
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("sync --audit: %v", err)
+	}
+	if !strings.Contains(buf.String(), "push") || !strings.Contains(buf.String(), "read_file") {
+		t.Errorf("audit output:\n%s", buf.String())
+	}
+}
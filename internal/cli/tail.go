@@ -0,0 +1,363 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/events"
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tailFollow   bool          // -f/--follow
+	tailLines    int           // -n/--lines
+	tailTool     string        // --tool
+	tailSource   string        // --source
+	tailSession  string        // --session
+	tailTypes    []string      // --type
+	tailInterval time.Duration // --interval
+)
+
+var tailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Show recent activity, optionally following it live",
+	Long: `Show the most recent desires, invocations, and recoveries, oldest first.
+
+With -f, keep running and print new events as they are recorded. In remote
+mode (store_mode=remote) events are streamed from the server's
+/api/v1/events endpoint as they happen, reconnecting with backoff if the
+stream drops; locally the SQLite database is polled every --interval.
+
+Filter with --tool, --source, --session, and --type (desire, invocation,
+recovery; repeatable). With --json, each event is printed as one JSON
+object per line.`,
+	Example: `  dp tail
+  dp tail -f
+  dp tail -f --type desire --source claude-code
+  dp tail -f --session 3b9c... --json | jq .tool_name`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, t := range tailTypes {
+			switch t {
+			case events.TypeDesire, events.TypeInvocation, events.TypeRecovery:
+			default:
+				return fmt.Errorf("--type must be desire, invocation, or recovery, got %q", t)
+			}
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return runTail(ctx, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	tailCmd.Flags().BoolVarP(&tailFollow, "follow", "f", false, "keep running and print new events as they arrive")
+	tailCmd.Flags().IntVarP(&tailLines, "lines", "n", 10, "number of recent events to show first")
+	tailCmd.Flags().StringVar(&tailTool, "tool", "", "only show events for this tool")
+	tailCmd.Flags().StringVar(&tailSource, "source", "", "only show events from this source")
+	tailCmd.Flags().StringVar(&tailSession, "session", "", "only show events from this session")
+	tailCmd.Flags().StringSliceVar(&tailTypes, "type", nil, "only show these event types (desire, invocation, recovery)")
+	tailCmd.Flags().DurationVar(&tailInterval, "interval", time.Second, "poll interval for local follow mode")
+	rootCmd.AddCommand(tailCmd)
+}
+
+// runTail prints the recent backlog and, with --follow, new events until
+// ctx is cancelled.
+func runTail(ctx context.Context, w io.Writer) error {
+	s, err := openStore()
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer s.Close()
+
+	f := events.Filter{Types: tailTypes, ToolName: tailTool, Source: tailSource, SessionID: tailSession}
+	p := &tailPrinter{w: w}
+
+	start := time.Now()
+	recent, err := pollEvents(ctx, s, f, time.Time{}, tailLines)
+	if err != nil {
+		return err
+	}
+	if len(recent) > tailLines {
+		recent = recent[len(recent)-tailLines:]
+	}
+	if err := p.print(recent); err != nil {
+		return err
+	}
+	if !tailFollow {
+		if len(recent) == 0 && !jsonOutput {
+			fmt.Fprintln(w, "No events.")
+		}
+		return nil
+	}
+
+	rs, ok := s.(*store.RemoteStore)
+	if hs, isHybrid := s.(*store.HybridStore); isHybrid {
+		rs, ok = hs.Remote(), true // follow the whole team, not just this machine
+	}
+	if ok {
+		return followRemote(ctx, rs, f, start, recent, p)
+	}
+	return followLocal(ctx, s, f, start, recent, p)
+}
+
+// Reconnect delays for remote follow mode. The delay doubles after each
+// failed attempt, up to tailReconnectMax.
+var (
+	tailReconnectMin = time.Second
+	tailReconnectMax = 30 * time.Second
+)
+
+// followRemote streams events from the server. When the stream drops or
+// the server is unavailable it reconnects with exponential backoff, first
+// printing the events recorded while it was away. Errors the server won't
+// get over by itself, such as a rejected token, end the follow.
+func followRemote(ctx context.Context, rs *store.RemoteStore, f events.Filter, start time.Time, backlog []events.Event, p *tailPrinter) error {
+	cursor := start
+	seen := make(map[string]time.Time)
+	show := func(evs []events.Event) error {
+		var fresh []events.Event
+		for _, e := range evs {
+			if _, ok := seen[e.ID()]; ok {
+				continue
+			}
+			seen[e.ID()] = e.Timestamp
+			fresh = append(fresh, e)
+			if e.Timestamp.After(cursor) {
+				cursor = e.Timestamp
+			}
+		}
+		for id, ts := range seen {
+			if ts.Before(cursor) {
+				delete(seen, id)
+			}
+		}
+		return p.print(fresh)
+	}
+	if err := show(backlog); err != nil {
+		return err
+	}
+
+	delay := tailReconnectMin
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			evs, err := pollEvents(ctx, rs, f, cursor, 0)
+			if err == nil {
+				err = show(evs)
+			}
+			if err != nil && !store.Unavailable(err) {
+				return err
+			}
+		}
+
+		var printErr error
+		connected := time.Now()
+		err := rs.StreamEvents(ctx, f, func(e events.Event) error {
+			delay = tailReconnectMin // the connection works
+			printErr = show([]events.Event{e})
+			return printErr
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if printErr != nil {
+			return printErr
+		}
+		if err != nil && !store.Unavailable(err) {
+			return err
+		}
+		if time.Since(connected) > tailReconnectMax {
+			delay = tailReconnectMin
+		}
+
+		reason := "closed by the server"
+		if err != nil {
+			reason = "lost: " + err.Error()
+		}
+		fmt.Fprintf(os.Stderr, "dp tail: event stream %s; reconnecting in %s\n", reason, delay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, tailReconnectMax)
+	}
+}
+
+// followLocal polls the store for events recorded at or after start,
+// skipping any already printed in the backlog.
+func followLocal(ctx context.Context, s store.Store, f events.Filter, start time.Time, backlog []events.Event, p *tailPrinter) error {
+	cursor := start
+	seen := make(map[string]time.Time)
+	for _, e := range backlog {
+		seen[e.ID()] = e.Timestamp
+		if e.Timestamp.After(cursor) {
+			cursor = e.Timestamp
+		}
+	}
+
+	ticker := time.NewTicker(tailInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		evs, err := pollEvents(ctx, s, f, cursor, 0)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		var fresh []events.Event
+		for _, e := range evs {
+			if _, ok := seen[e.ID()]; ok {
+				continue
+			}
+			seen[e.ID()] = e.Timestamp
+			fresh = append(fresh, e)
+			if e.Timestamp.After(cursor) {
+				cursor = e.Timestamp
+			}
+		}
+		// Rows at the cursor itself are re-read next time; forget older ones.
+		for id, ts := range seen {
+			if ts.Before(cursor) {
+				delete(seen, id)
+			}
+		}
+		if err := p.print(fresh); err != nil {
+			return err
+		}
+	}
+}
+
+// pollEvents reads desires, invocations, and recoveries at or after since
+// (all time when zero), keeps those matching f, and returns them oldest
+// first. A positive limit caps how many rows of each kind are read.
+func pollEvents(ctx context.Context, s store.Store, f events.Filter, since time.Time, limit int) ([]events.Event, error) {
+	var out []events.Event
+	want := func(t string) bool {
+		return events.Filter{Types: f.Types}.Match(events.Event{Type: t})
+	}
+
+	if want(events.TypeDesire) {
+		desires, err := s.ListDesires(ctx, store.ListOpts{Since: since, Source: f.Source, ToolName: f.ToolName, Limit: limit})
+		if err != nil {
+			return nil, fmt.Errorf("list desires: %w", err)
+		}
+		for _, d := range desires {
+			out = append(out, events.FromDesire(d))
+		}
+	}
+	if want(events.TypeInvocation) {
+		invs, err := s.ListInvocations(ctx, store.InvocationOpts{Since: since, Source: f.Source, InstanceID: f.SessionID, ToolName: f.ToolName, Limit: limit})
+		if err != nil {
+			return nil, fmt.Errorf("list invocations: %w", err)
+		}
+		for _, inv := range invs {
+			out = append(out, events.FromInvocation(inv))
+		}
+	}
+	// Recoveries carry no source or session, so those filters exclude them.
+	if want(events.TypeRecovery) && f.Source == "" && f.SessionID == "" {
+		recs, err := s.ListRecoveries(ctx, store.RecoveryOpts{Since: since, Limit: limit})
+		if err != nil {
+			return nil, fmt.Errorf("list recoveries: %w", err)
+		}
+		for _, r := range recs {
+			out = append(out, events.FromRecovery(r, nil))
+		}
+	}
+
+	kept := out[:0]
+	for _, e := range out {
+		if f.Match(e) {
+			kept = append(kept, e)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Timestamp.Before(kept[j].Timestamp) })
+	return kept, nil
+}
+
+// tailPrinter writes events as table rows or JSON lines. Rows are padded to
+// fixed widths so that batches flushed separately still line up.
+type tailPrinter struct {
+	w          io.Writer
+	headerDone bool
+}
+
+func (p *tailPrinter) print(evs []events.Event) error {
+	if jsonOutput {
+		enc := json.NewEncoder(p.w)
+		for _, e := range evs {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(evs) == 0 {
+		return nil
+	}
+	var tbl *Table
+	if !p.headerDone {
+		tbl = NewTable(p.w, tailRow("TIME", "TYPE", "SOURCE", "SESSION", "TOOL", "DETAIL")...)
+		p.headerDone = true
+	} else {
+		tbl = NewTable(p.w)
+	}
+	for _, e := range evs {
+		session := e.SessionID
+		if len(session) > 8 {
+			session = session[:8]
+		}
+		tbl.Row(tailRow(
+			e.Timestamp.Local().Format("15:04:05"),
+			e.Type,
+			e.Source,
+			session,
+			e.ToolName,
+			tailDetail(e),
+		)...)
+	}
+	return tbl.Flush()
+}
+
+// tailRow pads the fixed-width columns of a tail row.
+func tailRow(ts, typ, source, session, tool, detail string) []string {
+	return []string{
+		fmt.Sprintf("%-8s", ts),
+		fmt.Sprintf("%-10s", typ),
+		fmt.Sprintf("%-12s", truncateTo(source, 12)),
+		fmt.Sprintf("%-8s", session),
+		fmt.Sprintf("%-20s", truncateTo(tool, 20)),
+		detail,
+	}
+}
+
+// tailDetail summarises what happened in an event.
+func tailDetail(e events.Event) string {
+	switch {
+	case e.Desire != nil:
+		return truncateTo(e.Desire.Error, 60)
+	case e.Invocation != nil:
+		if e.Invocation.IsError {
+			return truncateTo("error: "+e.Invocation.Error, 60)
+		}
+		return "ok"
+	case e.Recovery != nil:
+		return "recovered"
+	}
+	return ""
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/events"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// syncBuffer is a bytes.Buffer safe for a writer and a reader goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func resetTailFlags(t *testing.T) {
+	t.Helper()
+	tailFollow = false
+	tailLines = 10
+	tailTool, tailSource, tailSession = "", "", ""
+	tailTypes = nil
+	tailInterval = time.Second
+	jsonOutput = false
+}
+
+func TestTailRecent(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour)
+	for i, tool := range []string{"read_file", "grep_search", "read_file"} {
+		d := model.Desire{ID: "d" + string(rune('1'+i)), ToolName: tool, Error: "unknown tool", Source: "claude-code", Timestamp: base.Add(time.Duration(i) * time.Minute)}
+		if err := s.RecordDesire(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	dbPath = db
+	resetTailFlags(t)
+	t.Cleanup(func() { resetTailFlags(t) })
+
+	var buf bytes.Buffer
+	tailTool = "read_file"
+	if err := runTail(ctx, &buf); err != nil {
+		t.Fatalf("runTail: %v", err)
+	}
+	out := buf.String()
+	if strings.Count(out, "read_file") != 2 || strings.Contains(out, "grep_search") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	buf.Reset()
+	tailTool = ""
+	tailLines = 1
+	jsonOutput = true
+	if err := runTail(ctx, &buf); err != nil {
+		t.Fatalf("runTail: %v", err)
+	}
+	var e events.Event
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if e.Type != events.TypeDesire || e.Desire == nil || e.Desire.ID != "d3" {
+		t.Errorf("last event = %+v", e)
+	}
+}
+
+func TestTailFollowLocal(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "test.db")
+	dbPath = db
+	resetTailFlags(t)
+	t.Cleanup(func() { resetTailFlags(t) })
+	tailFollow = true
+	tailInterval = 20 * time.Millisecond
+	tailTypes = []string{events.TypeDesire}
+
+	// Create the database first, so the follower and this writer don't
+	// both run the migrations.
+	s, err := store.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() { done <- runTail(ctx, &out) }()
+
+	time.Sleep(50 * time.Millisecond)
+	if err := s.RecordDesire(context.Background(), model.Desire{ID: "live", ToolName: "web_fetch", Error: "no such tool", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(out.String(), "web_fetch") && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("runTail: %v", err)
+	}
+	if got := out.String(); strings.Count(got, "web_fetch") != 1 {
+		t.Errorf("expected the new desire exactly once, got:\n%s", got)
+	}
+}
+
+func TestTailFollowRemoteReconnects(t *testing.T) {
+	resetTailFlags(t)
+	t.Cleanup(func() { resetTailFlags(t) })
+	tailTypes = []string{events.TypeDesire}
+	oldMin := tailReconnectMin
+	tailReconnectMin = 10 * time.Millisecond
+	t.Cleanup(func() { tailReconnectMin = oldMin })
+
+	desire := func(id string, ts time.Time) model.Desire {
+		return model.Desire{ID: id, ToolName: "tool_" + id, Error: "boom", Timestamp: ts}
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	d1, d2, d3 := desire("d1", now), desire("d2", now.Add(time.Second)), desire("d3", now.Add(2*time.Second))
+
+	var mu sync.Mutex
+	conns := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/events", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conns++
+		n := conns
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		send := func(d model.Desire) {
+			data, _ := json.Marshal(events.FromDesire(d))
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+		if n == 1 {
+			send(d1) // then drop the connection
+			return
+		}
+		send(d3)
+		<-r.Context().Done()
+	})
+	// Catch-up after the drop returns d1 again (already shown) and d2,
+	// which was recorded while disconnected.
+	mux.HandleFunc("GET /api/v1/desires", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]model.Desire{d1, d2})
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- followRemote(ctx, store.NewRemote(ts.URL), events.Filter{Types: tailTypes}, now.Add(-time.Minute), nil, &tailPrinter{w: &out})
+	}()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(out.String(), "tool_d3") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("followRemote: %v", err)
+	}
+
+	got := out.String()
+	for _, tool := range []string{"tool_d1", "tool_d2", "tool_d3"} {
+		if strings.Count(got, tool) != 1 {
+			t.Errorf("expected %s exactly once, got:\n%s", tool, got)
+		}
+	}
+	if i1, i2, i3 := strings.Index(got, "tool_d1"), strings.Index(got, "tool_d2"), strings.Index(got, "tool_d3"); !(i1 < i2 && i2 < i3) {
+		t.Errorf("events out of order:\n%s", got)
+	}
+}
+
+func TestTailFollowRemoteStopsOnAuthError(t *testing.T) {
+	resetTailFlags(t)
+	t.Cleanup(func() { resetTailFlags(t) })
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid token"})
+	}))
+	t.Cleanup(ts.Close)
+
+	err := followRemote(context.Background(), store.NewRemote(ts.URL), events.Filter{}, time.Now(), nil, &tailPrinter{w: &bytes.Buffer{}})
+	if err == nil || !strings.Contains(err.Error(), "invalid token") {
+		t.Fatalf("expected the auth error, got %v", err)
+	}
+}
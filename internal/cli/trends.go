@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trendsBucket  string
+	trendsBuckets int
+	trendsTop     int
+	trendsUntil   string
+)
+
+// trendsCmd compares each path's desires with the previous window.
+var trendsCmd = &cobra.Command{
+	Use:   "trends",
+	Short: "Show which paths are growing, shrinking, new, or gone",
+	Long: `Trends compares each desire path's count in the current window with the
+window of the same length before it. The window is --buckets buckets of size
+--bucket (hour, day, or week), ending now.
+
+Paths with no desires in the previous window are marked new; paths with none
+in the current window are marked gone. The TREND column is a sparkline of the
+current window's buckets, oldest first. Rows are ordered by how much the
+path grew, so the ones getting worse come first, gone ones last, and --top
+keeps the biggest growth.`,
+	Example: `  dp trends
+  dp trends --bucket hour --buckets 24
+  dp trends --bucket week --buckets 4 --top 10
+  dp trends --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		opts := store.TrendOpts{Bucket: trendsBucket, Buckets: trendsBuckets, Top: trendsTop}
+		if _, err := store.BucketSize(opts.Bucket); err != nil {
+			return err
+		}
+		if trendsUntil != "" {
+			t, err := time.Parse(time.RFC3339, trendsUntil)
+			if err != nil {
+				return fmt.Errorf("parse --until: %w", err)
+			}
+			opts.Until = t
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return fmt.Errorf("open store: %w", err)
+		}
+		defer s.Close()
+
+		res, err := s.PathTrends(context.Background(), opts)
+		if err != nil {
+			return fmt.Errorf("path trends: %w", err)
+		}
+
+		if jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(res)
+		}
+		writeTrendsTable(os.Stdout, res)
+		return nil
+	},
+}
+
+func init() {
+	trendsCmd.Flags().StringVar(&trendsBucket, "bucket", store.BucketDay, "bucket size: hour, day, or week")
+	trendsCmd.Flags().IntVar(&trendsBuckets, "buckets", 7, "buckets per window")
+	trendsCmd.Flags().IntVar(&trendsTop, "top", 20, "maximum number of paths to display")
+	trendsCmd.Flags().StringVar(&trendsUntil, "until", "", "end of the current window (RFC3339, default now)")
+	rootCmd.AddCommand(trendsCmd)
+}
+
+// writeTrendsTable writes a window summary line and the path trends as an
+// aligned text table to w.
+func writeTrendsTable(w io.Writer, res *store.TrendResult) {
+	unit := res.Bucket
+	if res.Buckets != 1 {
+		unit += "s"
+	}
+	fmt.Fprintf(w, "Last %d %s vs the %d before (%s to %s)\n\n", res.Buckets, unit, res.Buckets,
+		res.Start.UTC().Format(time.RFC3339), res.Until.UTC().Format(time.RFC3339))
+
+	tbl := NewTable(w, "PATTERN", "NOW", "BEFORE", "DELTA", "TREND", "STATUS")
+	for _, p := range res.Paths {
+		tbl.Row(
+			p.Pattern,
+			fmt.Sprintf("%d", p.Current),
+			fmt.Sprintf("%d", p.Previous),
+			fmt.Sprintf("%+d", p.Delta),
+			sparkline(p.Counts),
+			p.Status,
+		)
+	}
+	tbl.Flush()
+}
+
+// sparkLevels are the block characters sparkline draws, lowest first.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws counts as a row of block characters scaled to the largest
+// count. Zero counts draw as the lowest block.
+func sparkline(counts []int) string {
+	max := 0
+	for _, n := range counts {
+		if n > max {
+			max = n
+		}
+	}
+	var b strings.Builder
+	for _, n := range counts {
+		i := 0
+		if max > 0 {
+			i = n * (len(sparkLevels) - 1) / max
+		}
+		b.WriteRune(sparkLevels[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func TestWriteTrendsTable(t *testing.T) {
+	until := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	res := &store.TrendResult{
+		Bucket:  store.BucketDay,
+		Buckets: 3,
+		Start:   until.Add(-72 * time.Hour),
+		Until:   until,
+		Paths: []store.PathTrend{
+			{Pattern: "read_file", Counts: []int{0, 2, 4}, Current: 6, Previous: 1, Delta: 5, Status: store.TrendUp},
+			{Pattern: "old_tool", Counts: []int{0, 0, 0}, Current: 0, Previous: 3, Delta: -3, Status: store.TrendGone},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeTrendsTable(&buf, res)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Last 3 days vs the 3 before",
+		"PATTERN", "DELTA", "TREND",
+		"read_file", "+5", "▁▄█", "up",
+		"old_tool", "-3", "▁▁▁", "gone",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		counts []int
+		want   string
+	}{
+		{nil, ""},
+		{[]int{0, 0}, "▁▁"},
+		{[]int{1, 1}, "██"},
+		{[]int{0, 7, 14}, "▁▄█"},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.counts); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.counts, got, tt.want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/analyze"
 	"github.com/scbrown/desire-path/internal/config"
 	"github.com/scbrown/desire-path/internal/store"
 	"github.com/spf13/cobra"
@@ -19,6 +20,13 @@ var (
 	turnsSession   string
 	turnsPatterns  bool
 	turnsPattern   string
+
+	turnsMine          bool
+	turnsMinSupport    int
+	turnsMinConfidence float64
+	turnsMinSteps      int
+	turnsMaxSteps      int
+	turnsSurface       bool
 )
 
 var turnsCmd = &cobra.Command{
@@ -29,11 +37,24 @@ human→model→human cycle in an AI session. Long turns (many tool calls) signa
 that the agent's intent didn't map cleanly to available tools.
 
 By default, shows turns exceeding the configured threshold (default 5).
-Use --patterns to see clustered abstract patterns instead.`,
+Use --patterns to see clustered abstract patterns instead.
+
+Use --mine to find recurring sub-workflows: tool steps that occur in the same
+order (not necessarily adjacent) in turns across several sessions. Repeated
+calls to one tool count as a single step. A sub-workflow is listed when it
+appears in at least --min-support sessions and its last step follows the
+others in at least --min-confidence of the sessions that have them. Only the
+longest sub-workflow with a given support is shown. These are candidates for
+new tools or slash commands. Add --surface to record the ones no exact
+pattern already covers as turn-pattern desires, so they show up in dp paths
+(at most 10 per run; ingest does not mine, as mining is too slow for a hook).`,
 	Example: `  dp turns
   dp turns --min-length 3
   dp turns --patterns
   dp turns --pattern "Grep → Read{2+} → Edit"
+  dp turns --mine
+  dp turns --mine --min-support 5 --min-confidence 0.8
+  dp turns --mine --surface
   dp turns --since 2026-02-01T00:00:00Z
   dp turns --session abc123
   dp turns --json`,
@@ -67,6 +88,20 @@ Use --patterns to see clustered abstract patterns instead.`,
 			opts.Since = t
 		}
 
+		if turnsSurface && !turnsMine {
+			return fmt.Errorf("--surface requires --mine")
+		}
+		if turnsMine {
+			if turnsMinConfidence < 0 || turnsMinConfidence > 1 {
+				return fmt.Errorf("invalid --min-confidence value %v: must be between 0 and 1", turnsMinConfidence)
+			}
+			return runTurnsMine(s, opts, analyze.MineOpts{
+				MinSupport:    turnsMinSupport,
+				MinConfidence: turnsMinConfidence,
+				MinSteps:      turnsMinSteps,
+				MaxSteps:      turnsMaxSteps,
+			})
+		}
 		if turnsPatterns {
 			return runTurnsPatterns(s, opts)
 		}
@@ -80,6 +115,12 @@ func init() {
 	turnsCmd.Flags().StringVar(&turnsSession, "session", "", "filter by session ID")
 	turnsCmd.Flags().BoolVar(&turnsPatterns, "patterns", false, "show clustered abstract patterns instead of individual turns")
 	turnsCmd.Flags().StringVar(&turnsPattern, "pattern", "", "drill down to turns matching this abstract pattern")
+	turnsCmd.Flags().BoolVar(&turnsMine, "mine", false, "mine recurring sub-workflows across sessions")
+	turnsCmd.Flags().IntVar(&turnsMinSupport, "min-support", analyze.MinPatternSessions, "with --mine, minimum number of sessions")
+	turnsCmd.Flags().Float64Var(&turnsMinConfidence, "min-confidence", analyze.DefaultMineConfidence, "with --mine, minimum confidence of the last step (0-1)")
+	turnsCmd.Flags().IntVar(&turnsMinSteps, "min-steps", analyze.DefaultMineMinSteps, "with --mine, fewest steps in a sub-workflow")
+	turnsCmd.Flags().IntVar(&turnsMaxSteps, "max-steps", analyze.DefaultMineMaxSteps, "with --mine, most steps in a sub-workflow")
+	turnsCmd.Flags().BoolVar(&turnsSurface, "surface", false, "with --mine, record new sub-workflows as turn-pattern desires")
 	rootCmd.AddCommand(turnsCmd)
 }
 
@@ -109,6 +150,27 @@ func runTurnsPatterns(s store.Store, opts store.TurnOpts) error {
 	return nil
 }
 
+func runTurnsMine(s store.Store, opts store.TurnOpts, mineOpts analyze.MineOpts) error {
+	turns, err := s.ListTurns(context.Background(), opts)
+	if err != nil {
+		return fmt.Errorf("list turns: %w", err)
+	}
+	mined := analyze.MineTurnPatterns(turns, mineOpts)
+	if turnsSurface {
+		created, err := analyze.SurfaceMinedPatternDesires(context.Background(), s, mined, opts.MinLength)
+		if err != nil {
+			return fmt.Errorf("surface sub-workflows: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Recorded %d new sub-workflow(s) as turn-pattern desires.\n", len(created))
+	}
+
+	if jsonOutput {
+		return writeMinedPatternsJSON(os.Stdout, mined)
+	}
+	writeMinedPatternsTable(os.Stdout, mined)
+	return nil
+}
+
 func writeTurnsJSON(w io.Writer, turns []store.TurnRow) error {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
@@ -158,6 +220,33 @@ func writeTurnPatternsTable(w io.Writer, patterns []store.TurnPattern) {
 	tbl.Flush()
 }
 
+func writeMinedPatternsJSON(w io.Writer, mined []analyze.MinedPattern) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if mined == nil {
+		mined = []analyze.MinedPattern{}
+	}
+	return enc.Encode(mined)
+}
+
+func writeMinedPatternsTable(w io.Writer, mined []analyze.MinedPattern) {
+	if len(mined) == 0 {
+		fmt.Fprintln(w, "No recurring sub-workflows found.")
+		return
+	}
+	tbl := NewTable(w, "PATTERN", "STEPS", "SESSIONS", "TURNS", "CONFIDENCE")
+	for _, m := range mined {
+		tbl.Row(
+			m.Pattern,
+			fmt.Sprintf("%d", len(m.Steps)),
+			fmt.Sprintf("%d", m.Sessions),
+			fmt.Sprintf("%d", m.Turns),
+			fmt.Sprintf("%.0f%%", m.Confidence*100),
+		)
+	}
+	tbl.Flush()
+}
+
 // lastColon returns the index of the last ':' in s, or -1.
 func lastColon(s string) int {
 	for i := len(s) - 1; i >= 0; i-- {
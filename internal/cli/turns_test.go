@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/scbrown/desire-path/internal/analyze"
+)
+
+func TestWriteMinedPatternsTable(t *testing.T) {
+	var buf bytes.Buffer
+	writeMinedPatternsTable(&buf, []analyze.MinedPattern{{
+		Steps:      []string{"Grep", "Read", "Edit"},
+		Pattern:    "Grep → Read → Edit",
+		Sessions:   4,
+		Turns:      9,
+		Confidence: 0.8,
+	}})
+	out := buf.String()
+	for _, want := range []string{"PATTERN", "CONFIDENCE", "Grep → Read → Edit", "80%"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	writeMinedPatternsTable(&buf, nil)
+	if !strings.Contains(buf.String(), "No recurring sub-workflows found.") {
+		t.Errorf("unexpected empty output: %q", buf.String())
+	}
+}
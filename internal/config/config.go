@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	toml "github.com/pelletier/go-toml/v2"
+	"github.com/scbrown/desire-path/internal/model"
 )
 
 // DefaultTurnLengthThreshold is the default minimum tool call count for a turn
@@ -19,14 +21,60 @@ const DefaultTurnLengthThreshold = 5
 
 // Config holds dp configuration settings.
 type Config struct {
-	DBPath               string   `toml:"db_path,omitempty" json:"db_path,omitempty"`
-	DefaultSource        string   `toml:"default_source,omitempty" json:"default_source,omitempty"`
-	KnownTools           []string `toml:"known_tools,omitempty" json:"known_tools,omitempty"`
-	TrackTools           []string `toml:"track_tools,omitempty" json:"track_tools,omitempty"`
-	DefaultFormat        string   `toml:"default_format,omitempty" json:"default_format,omitempty"`
-	StoreMode            string   `toml:"store_mode,omitempty" json:"store_mode,omitempty"`
-	RemoteURL            string   `toml:"remote_url,omitempty" json:"remote_url,omitempty"`
-	TurnLengthThreshold  int      `toml:"turn_length_threshold,omitempty" json:"turn_length_threshold,omitempty"`
+	DBPath              string                `toml:"db_path,omitempty" json:"db_path,omitempty"`
+	DefaultSource       string                `toml:"default_source,omitempty" json:"default_source,omitempty"`
+	KnownTools          []string              `toml:"known_tools,omitempty" json:"known_tools,omitempty"`
+	TrackTools          []string              `toml:"track_tools,omitempty" json:"track_tools,omitempty"`
+	DefaultFormat       string                `toml:"default_format,omitempty" json:"default_format,omitempty"`
+	StoreMode           string                `toml:"store_mode,omitempty" json:"store_mode,omitempty"`
+	RemoteURL           string                `toml:"remote_url,omitempty" json:"remote_url,omitempty"`
+	RemoteToken         string                `toml:"remote_token,omitempty" json:"remote_token,omitempty"`
+	TurnLengthThreshold int                   `toml:"turn_length_threshold,omitempty" json:"turn_length_threshold,omitempty"`
+	Workspace           string                `toml:"workspace,omitempty" json:"workspace,omitempty"`
+	AlertThreshold      float64               `toml:"alert_threshold,omitempty" json:"alert_threshold,omitempty"`
+	AlertMinCount       int                   `toml:"alert_min_count,omitempty" json:"alert_min_count,omitempty"`
+	AlertCommand        string                `toml:"alert_command,omitempty" json:"alert_command,omitempty"`
+	AlertFile           string                `toml:"alert_file,omitempty" json:"alert_file,omitempty"`
+	AlertWebhook        string                `toml:"alert_webhook,omitempty" json:"alert_webhook,omitempty"`
+	ImpactSessionWeight *float64              `toml:"impact_session_weight,omitempty" json:"impact_session_weight,omitempty"`
+	ImpactRetryWeight   *float64              `toml:"impact_retry_weight,omitempty" json:"impact_retry_weight,omitempty"`
+	ImpactHalfLifeDays  float64               `toml:"impact_half_life_days,omitempty" json:"impact_half_life_days,omitempty"`
+	TokenPrices         map[string]TokenPrice `toml:"token_prices,omitempty" json:"token_prices,omitempty"`
+}
+
+// TokenPrice is what a model charges per million tokens, in US dollars.
+type TokenPrice struct {
+	Input      float64 `toml:"input" json:"input"`
+	Output     float64 `toml:"output" json:"output"`
+	CacheRead  float64 `toml:"cache_read" json:"cache_read"`
+	CacheWrite float64 `toml:"cache_write" json:"cache_write"`
+}
+
+// DefaultTokenPrice is used when token_prices has no "default" entry.
+var DefaultTokenPrice = TokenPrice{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+
+// Cost returns the price of the given token counts.
+func (p TokenPrice) Cost(input, output, cacheRead, cacheWrite int) float64 {
+	return (float64(input)*p.Input + float64(output)*p.Output +
+		float64(cacheRead)*p.CacheRead + float64(cacheWrite)*p.CacheWrite) / 1e6
+}
+
+// EffectiveTokenPrice returns the "default" entry of token_prices, or
+// DefaultTokenPrice.
+func (c *Config) EffectiveTokenPrice() TokenPrice {
+	if p, ok := c.TokenPrices["default"]; ok {
+		return p
+	}
+	return DefaultTokenPrice
+}
+
+// TokenPriceFor returns the token_prices entry named after model, falling
+// back to EffectiveTokenPrice.
+func (c *Config) TokenPriceFor(model string) TokenPrice {
+	if p, ok := c.TokenPrices[model]; ok && model != "" {
+		return p
+	}
+	return c.EffectiveTokenPrice()
 }
 
 // EffectiveTurnLengthThreshold returns the configured threshold, or the default.
@@ -39,19 +87,30 @@ func (c *Config) EffectiveTurnLengthThreshold() int {
 
 // validKeys lists the allowed configuration keys.
 var validKeys = map[string]bool{
-	"db_path":                true,
-	"default_source":         true,
-	"known_tools":            true,
-	"track_tools":            true,
-	"default_format":         true,
-	"store_mode":             true,
-	"remote_url":             true,
-	"turn_length_threshold":  true,
+	"db_path":               true,
+	"default_source":        true,
+	"known_tools":           true,
+	"track_tools":           true,
+	"default_format":        true,
+	"store_mode":            true,
+	"remote_url":            true,
+	"remote_token":          true,
+	"turn_length_threshold": true,
+	"workspace":             true,
+	"alert_threshold":       true,
+	"alert_min_count":       true,
+	"alert_command":         true,
+	"alert_file":            true,
+	"alert_webhook":         true,
+	"impact_session_weight": true,
+	"impact_retry_weight":   true,
+	"impact_half_life_days": true,
+	"token_prices":          true,
 }
 
 // ValidKeys returns the sorted list of valid configuration keys.
 func ValidKeys() []string {
-	return []string{"db_path", "default_format", "default_source", "known_tools", "remote_url", "store_mode", "track_tools", "turn_length_threshold"}
+	return []string{"alert_command", "alert_file", "alert_min_count", "alert_threshold", "alert_webhook", "db_path", "default_format", "default_source", "impact_half_life_days", "impact_retry_weight", "impact_session_weight", "known_tools", "remote_token", "remote_url", "store_mode", "token_prices", "track_tools", "turn_length_threshold", "workspace"}
 }
 
 // Path returns the default config file path (~/.dp/config.toml).
@@ -190,11 +249,49 @@ func (c *Config) Get(key string) (string, error) {
 		return c.StoreMode, nil
 	case "remote_url":
 		return c.RemoteURL, nil
+	case "remote_token":
+		return c.RemoteToken, nil
 	case "turn_length_threshold":
 		if c.TurnLengthThreshold == 0 {
 			return "", nil
 		}
 		return fmt.Sprintf("%d", c.TurnLengthThreshold), nil
+	case "workspace":
+		return c.Workspace, nil
+	case "alert_threshold":
+		if c.AlertThreshold == 0 {
+			return "", nil
+		}
+		return strconv.FormatFloat(c.AlertThreshold, 'g', -1, 64), nil
+	case "alert_min_count":
+		if c.AlertMinCount == 0 {
+			return "", nil
+		}
+		return fmt.Sprintf("%d", c.AlertMinCount), nil
+	case "alert_command":
+		return c.AlertCommand, nil
+	case "alert_file":
+		return c.AlertFile, nil
+	case "alert_webhook":
+		return c.AlertWebhook, nil
+	case "impact_session_weight":
+		return formatOptionalFloat(c.ImpactSessionWeight), nil
+	case "impact_retry_weight":
+		return formatOptionalFloat(c.ImpactRetryWeight), nil
+	case "impact_half_life_days":
+		if c.ImpactHalfLifeDays == 0 {
+			return "", nil
+		}
+		return strconv.FormatFloat(c.ImpactHalfLifeDays, 'g', -1, 64), nil
+	case "token_prices":
+		if len(c.TokenPrices) == 0 {
+			return "", nil
+		}
+		b, err := json.Marshal(c.TokenPrices)
+		if err != nil {
+			return "", fmt.Errorf("marshaling token_prices: %w", err)
+		}
+		return string(b), nil
 	default:
 		return "", fmt.Errorf("unknown config key %q", key)
 	}
@@ -237,12 +334,14 @@ func (c *Config) Set(key, value string) error {
 		}
 		c.DefaultFormat = value
 	case "store_mode":
-		if value != "" && value != "local" && value != "remote" {
-			return fmt.Errorf("store_mode must be \"local\" or \"remote\", got %q", value)
+		if value != "" && value != "local" && value != "remote" && value != "hybrid" {
+			return fmt.Errorf("store_mode must be \"local\", \"remote\", or \"hybrid\", got %q", value)
 		}
 		c.StoreMode = value
 	case "remote_url":
 		c.RemoteURL = value
+	case "remote_token":
+		c.RemoteToken = value
 	case "turn_length_threshold":
 		if value == "" {
 			c.TurnLengthThreshold = 0
@@ -253,6 +352,89 @@ func (c *Config) Set(key, value string) error {
 			}
 			c.TurnLengthThreshold = n
 		}
+	case "workspace":
+		if value != "" && !model.ValidWorkspace(value) {
+			return fmt.Errorf("workspace must be lowercase letters, digits, '-' and '_', got %q", value)
+		}
+		c.Workspace = value
+	case "alert_threshold":
+		if value == "" {
+			c.AlertThreshold = 0
+		} else {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || f <= 0 {
+				return fmt.Errorf("alert_threshold must be a positive number, got %q", value)
+			}
+			c.AlertThreshold = f
+		}
+	case "alert_min_count":
+		if value == "" {
+			c.AlertMinCount = 0
+		} else {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("alert_min_count must be a non-negative integer, got %q", value)
+			}
+			c.AlertMinCount = n
+		}
+	case "alert_command":
+		c.AlertCommand = value
+	case "alert_file":
+		c.AlertFile = value
+	case "alert_webhook":
+		if value != "" && !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+			return fmt.Errorf("alert_webhook must be an http:// or https:// URL, got %q", value)
+		}
+		c.AlertWebhook = value
+	case "impact_session_weight", "impact_retry_weight":
+		var w *float64
+		if value != "" {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+				return fmt.Errorf("%s must be a non-negative number, got %q", key, value)
+			}
+			w = &f
+		}
+		if key == "impact_session_weight" {
+			c.ImpactSessionWeight = w
+		} else {
+			c.ImpactRetryWeight = w
+		}
+	case "impact_half_life_days":
+		if value == "" {
+			c.ImpactHalfLifeDays = 0
+		} else {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || f <= 0 || math.IsInf(f, 0) {
+				return fmt.Errorf("impact_half_life_days must be a positive number, got %q", value)
+			}
+			c.ImpactHalfLifeDays = f
+		}
+	case "token_prices":
+		if value == "" {
+			c.TokenPrices = nil
+		} else {
+			var prices map[string]TokenPrice
+			if err := json.Unmarshal([]byte(value), &prices); err != nil {
+				return fmt.Errorf(`token_prices must be a JSON object of prices per million tokens, e.g. '{"default":{"input":3,"output":15,"cache_read":0.3,"cache_write":3.75}}': %w`, err)
+			}
+			for name, p := range prices {
+				for _, f := range []float64{p.Input, p.Output, p.CacheRead, p.CacheWrite} {
+					if f < 0 {
+						return fmt.Errorf("token_prices[%q]: prices must be non-negative", name)
+					}
+				}
+			}
+			c.TokenPrices = prices
+		}
 	}
 	return nil
 }
+
+// formatOptionalFloat formats f, or returns "" if it is unset.
+func formatOptionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'g', -1, 64)
+}
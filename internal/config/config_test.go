@@ -116,9 +116,25 @@ func TestGetSet(t *testing.T) {
 		{"track_tools empty", "track_tools", "", ""},
 		{"store_mode local", "store_mode", "local", "local"},
 		{"store_mode remote", "store_mode", "remote", "remote"},
+		{"store_mode hybrid", "store_mode", "hybrid", "hybrid"},
 		{"store_mode empty", "store_mode", "", ""},
 		{"remote_url", "remote_url", "http://localhost:7273", "http://localhost:7273"},
 		{"remote_url empty", "remote_url", "", ""},
+		{"remote_token", "remote_token", "dp_abc123", "dp_abc123"},
+		{"workspace", "workspace", "team-a", "team-a"},
+		{"workspace empty", "workspace", "", ""},
+		{"alert_threshold", "alert_threshold", "2.5", "2.5"},
+		{"alert_threshold empty", "alert_threshold", "", ""},
+		{"alert_min_count", "alert_min_count", "10", "10"},
+		{"alert_command", "alert_command", "notify-send dp \"$DP_ALERT_KEY\"", "notify-send dp \"$DP_ALERT_KEY\""},
+		{"alert_file", "alert_file", "/tmp/alerts.ndjson", "/tmp/alerts.ndjson"},
+		{"alert_webhook", "alert_webhook", "https://hooks.example.com/dp", "https://hooks.example.com/dp"},
+		{"impact_session_weight", "impact_session_weight", "2", "2"},
+		{"impact_retry_weight zero", "impact_retry_weight", "0", "0"},
+		{"impact_retry_weight empty", "impact_retry_weight", "", ""},
+		{"impact_half_life_days", "impact_half_life_days", "3.5", "3.5"},
+		{"token_prices", "token_prices", `{"default":{"input":1,"output":5,"cache_read":0.1,"cache_write":1.25}}`, `{"default":{"input":1,"output":5,"cache_read":0.1,"cache_write":1.25}}`},
+		{"token_prices empty", "token_prices", "", ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -169,10 +185,29 @@ func TestSetInvalidStoreMode(t *testing.T) {
 	}
 }
 
+func TestSetInvalidValues(t *testing.T) {
+	for _, kv := range [][2]string{
+		{"alert_threshold", "0"},
+		{"alert_threshold", "high"},
+		{"alert_min_count", "-1"},
+		{"alert_webhook", "hooks.example.com"},
+		{"impact_session_weight", "-1"},
+		{"impact_retry_weight", "NaN"},
+		{"impact_half_life_days", "0"},
+		{"token_prices", "3,15"},
+		{"token_prices", `{"default":{"input":-1}}`},
+	} {
+		cfg := &Config{}
+		if err := cfg.Set(kv[0], kv[1]); err == nil {
+			t.Errorf("Set(%q, %q) should fail", kv[0], kv[1])
+		}
+	}
+}
+
 func TestValidKeys(t *testing.T) {
 	keys := ValidKeys()
-	if len(keys) != 8 {
-		t.Fatalf("expected 8 keys, got %d", len(keys))
+	if len(keys) != 19 {
+		t.Fatalf("expected 19 keys, got %d", len(keys))
 	}
 	// Verify sorted order.
 	for i := 1; i < len(keys); i++ {
@@ -182,6 +217,39 @@ func TestValidKeys(t *testing.T) {
 	}
 }
 
+func TestEffectiveTokenPrice(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.EffectiveTokenPrice(); got != DefaultTokenPrice {
+		t.Errorf("unset: got %+v, want %+v", got, DefaultTokenPrice)
+	}
+	if err := cfg.Set("token_prices", `{"default":{"input":1,"output":5}}`); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	p := cfg.EffectiveTokenPrice()
+	if p != (TokenPrice{Input: 1, Output: 5}) {
+		t.Errorf("set: got %+v", p)
+	}
+	if got := p.Cost(2_000_000, 1_000_000, 5_000_000, 0); got != 7 {
+		t.Errorf("Cost = %v, want 7", got)
+	}
+}
+
+func TestTokenPriceFor(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.TokenPriceFor("claude-haiku-4-5"); got != DefaultTokenPrice {
+		t.Errorf("unset: got %+v, want %+v", got, DefaultTokenPrice)
+	}
+	if err := cfg.Set("token_prices", `{"default":{"input":1,"output":5},"claude-haiku-4-5":{"input":0.5,"output":2}}`); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if got := cfg.TokenPriceFor("claude-haiku-4-5"); got != (TokenPrice{Input: 0.5, Output: 2}) {
+		t.Errorf("by model: got %+v", got)
+	}
+	if got := cfg.TokenPriceFor("gpt-5"); got != (TokenPrice{Input: 1, Output: 5}) {
+		t.Errorf("unpriced model: got %+v, want the default entry", got)
+	}
+}
+
 func TestTurnLengthThresholdGetSet(t *testing.T) {
 	cfg := &Config{}
 
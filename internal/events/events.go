@@ -0,0 +1,181 @@
+// Package events provides an in-process publish/subscribe bus for live
+// desire-path activity: recorded desires, invocations, and recoveries.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// Event types.
+const (
+	TypeDesire     = "desire"
+	TypeInvocation = "invocation"
+	TypeRecovery   = "recovery"
+)
+
+// Event is a single piece of live activity. ToolName, Source, and SessionID
+// are copied from the payload so subscribers can filter without unpacking it.
+// Exactly one of Desire, Invocation, or Recovery is set, matching Type.
+type Event struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	ToolName  string    `json:"tool_name"`
+	Source    string    `json:"source,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+
+	Desire     *model.Desire     `json:"desire,omitempty"`
+	Invocation *model.Invocation `json:"invocation,omitempty"`
+	Recovery   *model.Recovery   `json:"recovery,omitempty"`
+}
+
+// ID returns the ID of the event's payload.
+func (e Event) ID() string {
+	switch {
+	case e.Desire != nil:
+		return e.Desire.ID
+	case e.Invocation != nil:
+		return e.Invocation.ID
+	case e.Recovery != nil:
+		return e.Recovery.ID
+	}
+	return ""
+}
+
+// FromDesire builds a desire event.
+func FromDesire(d model.Desire) Event {
+	return Event{
+		Type:      TypeDesire,
+		Timestamp: d.Timestamp,
+		ToolName:  d.ToolName,
+		Source:    d.Source,
+		SessionID: d.SessionID,
+		Desire:    &d,
+	}
+}
+
+// FromInvocation builds an invocation event.
+func FromInvocation(inv model.Invocation) Event {
+	return Event{
+		Type:       TypeInvocation,
+		Timestamp:  inv.Timestamp,
+		ToolName:   inv.ToolName,
+		Source:     inv.Source,
+		SessionID:  inv.InstanceID,
+		Invocation: &inv,
+	}
+}
+
+// FromRecovery builds a recovery event. The invocation that recovered, if
+// known, supplies the source and session.
+func FromRecovery(r model.Recovery, inv *model.Invocation) Event {
+	e := Event{
+		Type:      TypeRecovery,
+		Timestamp: r.Timestamp,
+		ToolName:  r.ToolName,
+		Recovery:  &r,
+	}
+	if inv != nil {
+		e.Source = inv.Source
+		e.SessionID = inv.InstanceID
+	}
+	return e
+}
+
+// Filter selects events. Empty fields match everything.
+type Filter struct {
+	Types     []string // event types; empty means all
+	ToolName  string
+	Source    string
+	SessionID string
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e Event) bool {
+	if len(f.Types) > 0 {
+		ok := false
+		for _, t := range f.Types {
+			if t == e.Type {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if f.ToolName != "" && f.ToolName != e.ToolName {
+		return false
+	}
+	if f.Source != "" && f.Source != e.Source {
+		return false
+	}
+	if f.SessionID != "" && f.SessionID != e.SessionID {
+		return false
+	}
+	return true
+}
+
+// subscriberBuffer is how many events a slow subscriber may fall behind by
+// before further events to it are dropped.
+const subscriberBuffer = 64
+
+type subscriber struct {
+	ch     chan Event
+	filter Filter
+}
+
+// Bus fans published events out to subscribers. Publishing never blocks: a
+// subscriber whose buffer is full misses events rather than stalling the
+// writer. The zero value is not usable; create one with NewBus.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+// NewBus creates an empty Bus.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*subscriber]struct{})}
+}
+
+// Publish delivers e to every subscriber whose filter matches. A nil Bus
+// discards the event.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default: // subscriber is behind; drop
+		}
+	}
+}
+
+// Subscribe registers a subscriber for events matching f. The returned
+// cancel function unsubscribes and closes the channel; it is safe to call
+// more than once.
+func (b *Bus) Subscribe(f Filter) (<-chan Event, func()) {
+	s := &subscriber{ch: make(chan Event, subscriberBuffer), filter: f}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, s)
+			b.mu.Unlock()
+			close(s.ch)
+		})
+	}
+	return s.ch, cancel
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func TestFilterMatch(t *testing.T) {
+	e := FromInvocation(model.Invocation{ID: "i1", Source: "claude-code", InstanceID: "s1", ToolName: "Bash"})
+	tests := []struct {
+		name string
+		f    Filter
+		want bool
+	}{
+		{"empty", Filter{}, true},
+		{"tool", Filter{ToolName: "Bash"}, true},
+		{"other tool", Filter{ToolName: "Read"}, false},
+		{"source and session", Filter{Source: "claude-code", SessionID: "s1"}, true},
+		{"other session", Filter{SessionID: "s2"}, false},
+		{"type", Filter{Types: []string{TypeDesire, TypeInvocation}}, true},
+		{"other type", Filter{Types: []string{TypeRecovery}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.Match(e); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBusPublishSubscribe(t *testing.T) {
+	b := NewBus()
+	all, cancelAll := b.Subscribe(Filter{})
+	defer cancelAll()
+	reads, cancelReads := b.Subscribe(Filter{ToolName: "Read"})
+
+	b.Publish(FromDesire(model.Desire{ID: "d1", ToolName: "read_file", Timestamp: time.Now()}))
+	b.Publish(FromRecovery(model.Recovery{ID: "r1", ToolName: "Read"}, nil))
+
+	if e := <-all; e.ID() != "d1" || e.Type != TypeDesire {
+		t.Errorf("first event = %+v", e)
+	}
+	if e := <-all; e.ID() != "r1" || e.Type != TypeRecovery {
+		t.Errorf("second event = %+v", e)
+	}
+	if e := <-reads; e.ID() != "r1" {
+		t.Errorf("filtered event = %+v", e)
+	}
+
+	cancelReads()
+	cancelReads() // idempotent
+	if _, ok := <-reads; ok {
+		t.Error("channel should be closed after cancel")
+	}
+	b.Publish(FromRecovery(model.Recovery{ID: "r2", ToolName: "Read"}, nil)) // must not panic
+}
+
+func TestBusDropsForSlowSubscriber(t *testing.T) {
+	b := NewBus()
+	ch, cancel := b.Subscribe(Filter{})
+	defer cancel()
+	for i := 0; i < subscriberBuffer+10; i++ {
+		b.Publish(FromDesire(model.Desire{ID: "d", ToolName: "x"}))
+	}
+	if len(ch) != subscriberBuffer {
+		t.Errorf("buffered %d events, want %d", len(ch), subscriberBuffer)
+	}
+}
+
+func TestNilBusPublish(t *testing.T) {
+	var b *Bus
+	b.Publish(FromDesire(model.Desire{ID: "d1"})) // must not panic
+}
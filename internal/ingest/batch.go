@@ -0,0 +1,119 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/scbrown/desire-path/internal/analyze"
+	"github.com/scbrown/desire-path/internal/config"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/source"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// maxBatchLine is the longest NDJSON line IngestBatch accepts.
+const maxBatchLine = 10 << 20
+
+// LineResult is the outcome of one line of a batch: the ID of the stored
+// invocation, or why the line was rejected. Line numbers start at 1.
+type LineResult struct {
+	Line  int    `json:"line"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// BatchResult summarises a batch ingest. Lines holds one result per
+// non-blank input line, in input order.
+type BatchResult struct {
+	Accepted int          `json:"accepted"`
+	Rejected int          `json:"rejected"`
+	Lines    []LineResult `json:"lines"`
+}
+
+// IngestBatch reads newline-delimited source payloads from r and ingests
+// each as Ingest would. A line that cannot be parsed is rejected in its
+// LineResult without affecting the others. All accepted lines are then
+// stored with one RecordInvocations call, in one transaction, and their
+// desires with one RecordDesires call, in another. If storing the desires
+// fails, the invocations stay stored. Recoveries and retry chains are then
+// detected line by line; a recovery only counts failures from before the
+// successful call, so later lines' desires do not affect earlier ones.
+//
+// Blank lines are skipped. An unknown source, an unreadable body, or a
+// storage failure is returned as an error; a storage failure is a
+// *StoreError.
+func IngestBatch(ctx context.Context, s store.Store, r io.Reader, sourceName string) (BatchResult, error) {
+	src := source.Get(sourceName)
+	if src == nil {
+		return BatchResult{}, fmt.Errorf("unknown source: %q", sourceName)
+	}
+
+	var (
+		res      BatchResult
+		invs     []model.Invocation
+		inputs   []json.RawMessage // tool input of each invocation
+		desires  []model.Desire
+		longTurn bool
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxBatchLine)
+	for n := 1; sc.Scan(); n++ {
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		fields, err := src.Extract(raw)
+		if err != nil {
+			res.Lines = append(res.Lines, LineResult{Line: n, Error: fmt.Sprintf("extracting fields: %v", err)})
+			continue
+		}
+		inv, err := toInvocation(fields, sourceName)
+		if err != nil {
+			res.Lines = append(res.Lines, LineResult{Line: n, Error: err.Error()})
+			continue
+		}
+		enrichTurnContext(&inv, fields)
+
+		invs = append(invs, inv)
+		inputs = append(inputs, fields.ToolInput)
+		if inv.IsError {
+			desires = append(desires, toDesire(fields, inv))
+		}
+		if inv.TurnLength >= config.DefaultTurnLengthThreshold {
+			longTurn = true
+		}
+		res.Lines = append(res.Lines, LineResult{Line: n, ID: inv.ID})
+	}
+	if err := sc.Err(); err != nil {
+		return BatchResult{}, fmt.Errorf("reading batch: %w", err)
+	}
+
+	if err := s.RecordInvocations(ctx, invs); err != nil {
+		return BatchResult{}, &StoreError{fmt.Errorf("storing invocations: %w", err)}
+	}
+	if err := s.RecordDesires(ctx, desires); err != nil {
+		return BatchResult{}, &StoreError{fmt.Errorf("storing desires: %w", err)}
+	}
+
+	for i, inv := range invs {
+		if !inv.IsError {
+			_ = s.DetectAndRecordRecovery(ctx, inv) // best-effort
+		}
+		_ = s.TrackRetryChain(ctx, inv, inputs[i]) // best-effort
+	}
+	if longTurn {
+		// Best-effort, and only once per batch rather than once per line.
+		analyze.SurfaceTurnPatternDesires(ctx, s, config.DefaultTurnLengthThreshold)
+	}
+
+	res.Accepted = len(invs)
+	res.Rejected = len(res.Lines) - len(invs)
+	if res.Lines == nil {
+		res.Lines = []LineResult{}
+	}
+	return res, nil
+}
@@ -0,0 +1,126 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scbrown/desire-path/internal/source"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// jsonSource decodes each payload straight into source.Fields.
+type jsonSource struct{ name string }
+
+func (j jsonSource) Name() string        { return j.name }
+func (j jsonSource) Description() string { return "decodes Fields as JSON" }
+func (j jsonSource) Extract(raw []byte) (*source.Fields, error) {
+	var f source.Fields
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func TestIngestBatch(t *testing.T) {
+	source.Register(jsonSource{name: "test-batch-json"})
+
+	input := strings.Join([]string{
+		`{"tool_name":"Read"}`,
+		``,
+		`{"tool_name":"Bash","error":"command not found"}`,
+		`not json`,
+		`{"tool_name":""}`,
+		`{"tool_name":"Edit"}`,
+	}, "\n")
+
+	fs := &fakeStore{}
+	res, err := IngestBatch(context.Background(), fs, strings.NewReader(input), "test-batch-json")
+	if err != nil {
+		t.Fatalf("IngestBatch: %v", err)
+	}
+	if res.Accepted != 3 || res.Rejected != 2 || len(res.Lines) != 5 {
+		t.Fatalf("result = %+v, want 3 accepted, 2 rejected, 5 lines", res)
+	}
+
+	wantLines := []int{1, 3, 4, 5, 6}
+	for i, lr := range res.Lines {
+		if lr.Line != wantLines[i] {
+			t.Errorf("result %d: line %d, want %d", i, lr.Line, wantLines[i])
+		}
+	}
+	if res.Lines[2].Error == "" || res.Lines[3].Error == "" {
+		t.Errorf("lines 4 and 5 should be rejected: %+v", res.Lines)
+	}
+	if res.Lines[3].Error != "missing required field: tool_name" {
+		t.Errorf("line 5 error = %q", res.Lines[3].Error)
+	}
+
+	if len(fs.recorded) != 3 || len(fs.desires) != 1 {
+		t.Fatalf("stored %d invocations and %d desires, want 3 and 1", len(fs.recorded), len(fs.desires))
+	}
+	if fs.recorded[1].ID != res.Lines[1].ID || fs.recorded[1].Source != "test-batch-json" {
+		t.Errorf("stored invocation = %+v, want ID %s", fs.recorded[1], res.Lines[1].ID)
+	}
+	if fs.desires[0].ToolName != "Bash" || fs.desires[0].Error != "command not found" {
+		t.Errorf("desire = %+v", fs.desires[0])
+	}
+}
+
+func TestIngestBatchRecoveryOrder(t *testing.T) {
+	source.Register(jsonSource{name: "test-batch-order"})
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name  string
+		lines []string
+		want  int
+	}{
+		{"success before failure", []string{`{"tool_name":"Bash"}`, `{"tool_name":"Bash","error":"exit 1"}`}, 0},
+		{"failure before success", []string{`{"tool_name":"Bash","error":"exit 1"}`, `{"tool_name":"Bash"}`}, 1},
+		{"one recovery per failure", []string{`{"tool_name":"Bash","error":"exit 1"}`, `{"tool_name":"Bash"}`, `{"tool_name":"Bash"}`, `{"tool_name":"Bash","error":"exit 1"}`}, 1},
+	} {
+		s, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := IngestBatch(ctx, s, strings.NewReader(strings.Join(tt.lines, "\n")), "test-batch-order"); err != nil {
+			t.Fatalf("%s: IngestBatch: %v", tt.name, err)
+		}
+		recs, err := s.ListRecoveries(ctx, store.RecoveryOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recs) != tt.want {
+			t.Errorf("%s: %d recoveries, want %d", tt.name, len(recs), tt.want)
+		}
+		s.Close()
+	}
+}
+
+func TestIngestBatchEmpty(t *testing.T) {
+	source.Register(jsonSource{name: "test-batch-empty"})
+	res, err := IngestBatch(context.Background(), &fakeStore{}, strings.NewReader("\n\n"), "test-batch-empty")
+	if err != nil {
+		t.Fatalf("IngestBatch: %v", err)
+	}
+	if res.Accepted != 0 || res.Rejected != 0 || res.Lines == nil {
+		t.Errorf("result = %+v, want empty with non-nil Lines", res)
+	}
+}
+
+func TestIngestBatchErrors(t *testing.T) {
+	if _, err := IngestBatch(context.Background(), &fakeStore{}, strings.NewReader("{}"), "no-such-source"); err == nil {
+		t.Error("expected error for unknown source")
+	}
+
+	source.Register(jsonSource{name: "test-batch-store-err"})
+	fs := &fakeStore{err: errors.New("disk full")}
+	_, err := IngestBatch(context.Background(), fs, strings.NewReader(`{"tool_name":"Read"}`), "test-batch-store-err")
+	if err == nil || !strings.Contains(err.Error(), "storing invocations") {
+		t.Errorf("err = %v, want storing invocations error", err)
+	}
+}
@@ -17,6 +17,16 @@ import (
 	"github.com/scbrown/desire-path/internal/transcript"
 )
 
+// StoreError reports that a payload was understood but could not be
+// stored, as opposed to a payload that could not be used.
+type StoreError struct {
+	Err error
+}
+
+func (e *StoreError) Error() string { return e.Err.Error() }
+
+func (e *StoreError) Unwrap() error { return e.Err }
+
 // Ingest parses raw bytes using the named source plugin, converts the
 // extracted fields into an Invocation, and persists it via the store.
 //
@@ -57,18 +67,19 @@ func IngestFields(ctx context.Context, s store.Store, fields *source.Fields, sou
 	enrichTurnContext(&inv, fields)
 
 	if err := s.RecordInvocation(ctx, inv); err != nil {
-		return model.Invocation{}, fmt.Errorf("storing invocation: %w", err)
+		return model.Invocation{}, &StoreError{fmt.Errorf("storing invocation: %w", err)}
 	}
 
 	// Detect recovery: successful invocation for a tool that previously failed
 	if !inv.IsError {
 		_ = s.DetectAndRecordRecovery(ctx, inv) // best-effort
 	}
+	_ = s.TrackRetryChain(ctx, inv, fields.ToolInput) // best-effort
 
 	if inv.IsError {
-		d := toDesire(fields, sourceName, inv.Timestamp, inv.Metadata)
+		d := toDesire(fields, inv)
 		if err := s.RecordDesire(ctx, d); err != nil {
-			return model.Invocation{}, fmt.Errorf("storing desire: %w", err)
+			return model.Invocation{}, &StoreError{fmt.Errorf("storing desire: %w", err)}
 		}
 	}
 
@@ -83,21 +94,23 @@ func IngestFields(ctx context.Context, s store.Store, fields *source.Fields, sou
 	return inv, nil
 }
 
-// toDesire converts source.Fields into a model.Desire, reusing the timestamp
-// and pre-marshaled metadata from the companion invocation for consistency.
-// It also auto-categorizes the desire based on error patterns.
-func toDesire(f *source.Fields, sourceName string, ts time.Time, metadata json.RawMessage) model.Desire {
+// toDesire converts source.Fields into a model.Desire, reusing the source,
+// timestamp, model and pre-marshaled metadata from the companion invocation
+// for consistency. It also auto-categorizes the desire based on error
+// patterns.
+func toDesire(f *source.Fields, inv model.Invocation) model.Desire {
 	return model.Desire{
 		ID:        uuid.New().String(),
 		ToolName:  f.ToolName,
 		ToolInput: f.ToolInput,
 		Error:     f.Error,
 		Category:  analyze.CategorizeDesire(f.ToolName, f.Error, f.ToolInput),
-		Source:    sourceName,
+		Source:    inv.Source,
 		SessionID: f.InstanceID,
 		CWD:       f.CWD,
-		Timestamp: ts,
-		Metadata:  metadata,
+		Timestamp: inv.Timestamp,
+		Metadata:  inv.Metadata,
+		Model:     inv.Model,
 	}
 }
 
@@ -117,6 +130,8 @@ func toInvocation(f *source.Fields, sourceName string) (model.Invocation, error)
 		Error:      f.Error,
 		CWD:        f.CWD,
 		Timestamp:  time.Now(),
+		Model:      f.Model,
+		DurationMs: f.DurationMs,
 	}
 
 	if len(f.Extra) > 0 {
@@ -130,9 +145,10 @@ func toInvocation(f *source.Fields, sourceName string) (model.Invocation, error)
 	return inv, nil
 }
 
-// enrichTurnContext parses the transcript (if available) to populate turn
-// fields on the invocation. It matches the current tool_use_id within the
-// transcript to determine which turn this invocation belongs to.
+// enrichTurnContext parses the transcript (if available) to populate turn,
+// token, model and duration fields on the invocation. It matches the
+// current tool_use_id within the transcript to determine which turn this
+// invocation belongs to.
 //
 // If transcript_path or tool_use_id are missing from Fields.Extra, or if
 // parsing fails, the invocation is left with zero-value turn fields (which
@@ -161,6 +177,16 @@ func enrichTurnContext(inv *model.Invocation, fields *source.Fields) {
 				inv.TurnID = fmt.Sprintf("%s:%d", turn.SessionID, turn.Index)
 				inv.TurnSequence = step.Sequence
 				inv.TurnLength = len(turn.Steps)
+				inv.InputTokens = step.Usage.InputTokens
+				inv.OutputTokens = step.Usage.OutputTokens
+				inv.CacheReadTokens = step.Usage.CacheReadTokens
+				inv.CacheWriteTokens = step.Usage.CacheWriteTokens
+				if inv.Model == "" {
+					inv.Model = step.Model
+				}
+				if inv.DurationMs == 0 {
+					inv.DurationMs = step.DurationMs
+				}
 				return
 			}
 		}
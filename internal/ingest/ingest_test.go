@@ -37,6 +37,7 @@ type fakeStore struct {
 	desires       []model.Desire
 	err           error
 	desireErr     error
+	chainInputs   []json.RawMessage // tool inputs passed to TrackRetryChain
 }
 
 func (f *fakeStore) RecordInvocation(_ context.Context, inv model.Invocation) error {
@@ -60,14 +61,14 @@ func (f *fakeStore) ListDesires(context.Context, store.ListOpts) ([]model.Desire
 func (f *fakeStore) GetPaths(context.Context, store.PathOpts) ([]model.Path, error) {
 	return nil, nil
 }
-func (f *fakeStore) SetAlias(context.Context, model.Alias) error { return nil }
-func (f *fakeStore) GetAlias(context.Context, string, string, string, string, string) (*model.Alias, error) {
+func (f *fakeStore) PathTrends(context.Context, store.TrendOpts) (*store.TrendResult, error) {
 	return nil, nil
 }
+func (f *fakeStore) SetAlias(context.Context, model.Alias) error { return nil }
+func (f *fakeStore) GetAlias(context.Context, model.AliasKey) (*model.Alias, error) { return nil, nil }
+func (f *fakeStore) GetToolAliases(context.Context, string) ([]model.Alias, error) { return nil, nil }
 func (f *fakeStore) GetAliases(context.Context) ([]model.Alias, error)                       { return nil, nil }
-func (f *fakeStore) DeleteAlias(context.Context, string, string, string, string, string) (bool, error) {
-	return false, nil
-}
+func (f *fakeStore) DeleteAlias(context.Context, model.AliasKey) (bool, error) { return false, nil }
 func (f *fakeStore) GetRulesForTool(context.Context, string) ([]model.Alias, error) { return nil, nil }
 func (f *fakeStore) Stats(context.Context) (store.Stats, error)        { return store.Stats{}, nil }
 func (f *fakeStore) InspectPath(context.Context, store.InspectOpts) (*store.InspectResult, error) {
@@ -91,12 +92,19 @@ func (f *fakeStore) ToolTurnStats(context.Context, store.TurnOpts) ([]store.Tool
 func (f *fakeStore) DetectAndRecordRecovery(context.Context, model.Invocation) error {
 	return nil
 }
-func (f *fakeStore) ListRecoveries(context.Context, time.Time, int) ([]model.Recovery, error) {
+func (f *fakeStore) ListRecoveries(context.Context, store.RecoveryOpts) ([]model.Recovery, error) {
 	return nil, nil
 }
 func (f *fakeStore) RecoveryStats(context.Context) ([]model.RecoveryStat, error) {
 	return nil, nil
 }
+func (f *fakeStore) TrackRetryChain(_ context.Context, _ model.Invocation, input json.RawMessage) error {
+	f.chainInputs = append(f.chainInputs, input)
+	return nil
+}
+func (f *fakeStore) ListRetryChains(context.Context, store.ChainOpts) ([]model.RetryChain, error) {
+	return nil, nil
+}
 func (f *fakeStore) SetDocMapping(context.Context, model.DocMapping) error { return nil }
 func (f *fakeStore) GetDocMappings(context.Context) ([]model.DocMapping, error) { return nil, nil }
 func (f *fakeStore) DeleteDocMapping(context.Context, string) (bool, error) { return false, nil }
@@ -107,6 +115,37 @@ func (f *fakeStore) IncrementDocMatchCount(context.Context, string) error { retu
 func (f *fakeStore) StrugglingTools(context.Context, store.StrugglingOpts) ([]model.StrugglingTool, error) {
 	return nil, nil
 }
+func (f *fakeStore) RecordIntervention(context.Context, model.Intervention) error { return nil }
+func (f *fakeStore) InterventionStats(context.Context, time.Time) ([]model.InterventionStat, error) {
+	return nil, nil
+}
+func (f *fakeStore) CreateToken(context.Context, model.APIToken) error { return nil }
+func (f *fakeStore) ListTokens(context.Context) ([]model.APIToken, error) {
+	return nil, nil
+}
+func (f *fakeStore) RevokeToken(context.Context, string) (bool, error) { return false, nil }
+func (f *fakeStore) AuthenticateToken(context.Context, string) (*model.APIToken, error) {
+	return nil, nil
+}
+func (f *fakeStore) SourceToolCounts(context.Context) ([]store.SourceToolCount, error) {
+	return nil, nil
+}
+func (f *fakeStore) RecordDesires(ctx context.Context, ds []model.Desire) error {
+	for _, d := range ds {
+		if err := f.RecordDesire(ctx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (f *fakeStore) RecordInvocations(ctx context.Context, invs []model.Invocation) error {
+	for _, inv := range invs {
+		if err := f.RecordInvocation(ctx, inv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 func (f *fakeStore) Close() error { return nil }
 
 // registerTestSource registers a fake source and returns a cleanup function
@@ -179,6 +218,23 @@ func TestIngestFullClaudeCodePayload(t *testing.T) {
 	}
 }
 
+func TestIngestTracksRetryChain(t *testing.T) {
+	srcName := "test-retry-chain"
+	registerTestSource(t, srcName, &source.Fields{
+		ToolName:   "Bash",
+		InstanceID: "session-abc123",
+		ToolInput:  json.RawMessage(`{"command":"scp -r a host:"}`),
+	}, nil)
+
+	fs := &fakeStore{}
+	if _, err := Ingest(context.Background(), fs, []byte(`{}`), srcName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fs.chainInputs) != 1 || string(fs.chainInputs[0]) != `{"command":"scp -r a host:"}` {
+		t.Errorf("TrackRetryChain inputs = %s", fs.chainInputs)
+	}
+}
+
 func TestIngestExtraFieldsInMetadata(t *testing.T) {
 	srcName := "test-extra-metadata"
 	registerTestSource(t, srcName, &source.Fields{
@@ -457,6 +513,7 @@ func TestIngestDualWriteOnError(t *testing.T) {
 		ToolInput:  json.RawMessage(`{"command":"rm -rf /"}`),
 		CWD:        "/home/user",
 		Error:      "permission denied",
+		Model:      "cursor-fast",
 		Extra: map[string]json.RawMessage{
 			"hook_event_name": json.RawMessage(`"PostToolUseFailure"`),
 		},
@@ -504,6 +561,9 @@ func TestIngestDualWriteOnError(t *testing.T) {
 	if d.CWD != "/home/user" {
 		t.Errorf("desire CWD = %q, want %q", d.CWD, "/home/user")
 	}
+	if d.Model != "cursor-fast" || inv.Model != "cursor-fast" {
+		t.Errorf("Model = %q (desire), %q (invocation), want cursor-fast", d.Model, inv.Model)
+	}
 	if string(d.ToolInput) != `{"command":"rm -rf /"}` {
 		t.Errorf("desire ToolInput = %s, want %s", d.ToolInput, `{"command":"rm -rf /"}`)
 	}
@@ -638,6 +698,40 @@ func TestEnrichTurnContextFromTranscript(t *testing.T) {
 	}
 }
 
+func TestEnrichTurnContextTokens(t *testing.T) {
+	dir := t.TempDir()
+	transcriptPath := dir + "/session.jsonl"
+	transcript := `{"type":"user","uuid":"u1","parentUuid":null,"sessionId":"sess-tok","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"Hi"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","sessionId":"sess-tok","timestamp":"2026-01-15T10:00:01Z","message":{"id":"msg_1","model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"tool_use","id":"toolu_001","name":"Read","input":{}}],"usage":{"input_tokens":12,"output_tokens":40,"cache_read_input_tokens":900,"cache_creation_input_tokens":300}}}
+{"type":"user","uuid":"u2","parentUuid":"a1","sessionId":"sess-tok","timestamp":"2026-01-15T10:00:03.5Z","sourceToolAssistantUUID":"a1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_001","content":"ok"}]}}
+`
+	if err := os.WriteFile(transcriptPath, []byte(transcript), 0644); err != nil {
+		t.Fatalf("write transcript: %v", err)
+	}
+
+	fields := &source.Fields{
+		ToolName: "Read",
+		Extra: map[string]json.RawMessage{
+			"transcript_path": json.RawMessage(fmt.Sprintf("%q", transcriptPath)),
+			"tool_use_id":     json.RawMessage(`"toolu_001"`),
+		},
+	}
+	inv := model.Invocation{ID: "tokens", ToolName: "Read"}
+
+	enrichTurnContext(&inv, fields)
+
+	if inv.InputTokens != 12 || inv.OutputTokens != 40 || inv.CacheReadTokens != 900 || inv.CacheWriteTokens != 300 {
+		t.Errorf("tokens = %d/%d/%d/%d, want 12/40/900/300",
+			inv.InputTokens, inv.OutputTokens, inv.CacheReadTokens, inv.CacheWriteTokens)
+	}
+	if inv.Model != "claude-sonnet-4-5" {
+		t.Errorf("Model = %q, want claude-sonnet-4-5", inv.Model)
+	}
+	if inv.DurationMs != 2500 {
+		t.Errorf("DurationMs = %d, want 2500", inv.DurationMs)
+	}
+}
+
 func TestEnrichTurnContextNoTranscript(t *testing.T) {
 	fields := &source.Fields{
 		ToolName: "Read",
@@ -231,37 +231,47 @@ func TestConfigStoreModeRemoteConstructsRemoteStore(t *testing.T) {
 }
 
 // TestConfigStoreModeRemoteUnreachable verifies that when store_mode=remote
-// points at an unreachable server, CLI commands fail with a meaningful error.
+// points at an unreachable server, hook writes are spooled with a notice
+// instead of failing, and dp sync delivers them once the server is up.
 func TestConfigStoreModeRemoteUnreachable(t *testing.T) {
 	t.Parallel()
 	e := newEnv(t)
 
-	// Listen on a port, then close it immediately to guarantee fast ECONNREFUSED.
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("listen: %v", err)
+	// Reserve a port, then close it so that connections are refused until
+	// the server below starts on it.
+	addr := "127.0.0.1:" + freePort(t)
+	e.writeConfig(fmt.Sprintf("store_mode = \"remote\"\nremote_url = \"http://%s\"\n", addr))
+
+	// Ingest succeeds: the write goes to the spool.
+	_, stderr := e.mustRun(e.fixture("tool_x", "s1", "err"), "ingest", "--source", "claude-code")
+	if !strings.Contains(stderr, "spooled") || !strings.Contains(stderr, "dp sync") {
+		t.Errorf("ingest stderr should say the write was spooled, got:\n%s", stderr)
 	}
-	addr := ln.Addr().String()
-	ln.Close() // Close immediately — connections will be refused.
 
-	e.writeConfig(fmt.Sprintf("store_mode = \"remote\"\nremote_url = \"http://%s\"\n", addr))
+	stdout, _ := e.mustRun(nil, "sync", "--status", "--json")
+	var st struct {
+		Entries int `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &st); err != nil {
+		t.Fatalf("parse sync status: %v\noutput: %s", err, stdout)
+	}
+	if st.Entries == 0 {
+		t.Fatalf("expected spooled writes, got:\n%s", stdout)
+	}
 
-	// Ingest should fail because the remote server is unreachable.
-	_, stderr, runErr := e.run(e.fixture("tool_x", "s1", "err"), "ingest", "--source", "claude-code")
-	if runErr == nil {
-		t.Fatal("expected error when remote server is unreachable, got success")
-	}
-	// The error message should indicate a connection problem, not a panic.
-	combined := stderr
-	if combined == "" {
-		combined = "exit status 1"
-	}
-	if !strings.Contains(strings.ToLower(combined), "connect") &&
-		!strings.Contains(strings.ToLower(combined), "remote") &&
-		!strings.Contains(strings.ToLower(combined), "refused") &&
-		!strings.Contains(strings.ToLower(combined), "dial") {
-		t.Logf("stderr: %s", stderr)
-		// Still acceptable — the important thing is it failed, not panicked.
+	// Bring the server up and replay the spool.
+	startServe(t, newEnv(t), addr)
+	stdout, _ = e.mustRun(nil, "sync")
+	if !strings.Contains(stdout, fmt.Sprintf("Sent %d spooled writes.", st.Entries)) {
+		t.Errorf("sync output:\n%s", stdout)
+	}
+	stdout, _ = e.mustRun(nil, "list", "--json")
+	if !strings.Contains(stdout, "tool_x") {
+		t.Errorf("list after sync missing tool_x:\n%s", stdout)
+	}
+	stdout, _ = e.mustRun(nil, "sync", "--status")
+	if !strings.Contains(stdout, "Spool is empty") {
+		t.Errorf("spool should be empty after sync, got:\n%s", stdout)
 	}
 }
 
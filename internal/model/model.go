@@ -15,8 +15,58 @@ const (
 	// CategoryTurnPattern indicates a recurring multi-tool turn pattern that
 	// signals the agent's intent didn't map cleanly to available tools.
 	CategoryTurnPattern = "turn-pattern"
+
+	// CategoryTimeout indicates a call that ran out of time: the tool may
+	// well exist and be used correctly, it was just slow.
+	CategoryTimeout = "timeout"
+)
+
+// Intervention kinds describe how pave-check acted on a tool call.
+const (
+	// InterventionBlock indicates a tool-name alias blocked the call (exit 2).
+	InterventionBlock = "block"
+
+	// InterventionCorrect indicates a parameter correction rewrote the input.
+	InterventionCorrect = "correct"
+
+	// InterventionDeny indicates a policy deny rule refused the call.
+	InterventionDeny = "deny"
 )
 
+// Token scopes grant access to dp serve, from least to most privileged. Each
+// scope includes the ones before it.
+const (
+	// ScopeRead allows GET requests.
+	ScopeRead = "read"
+
+	// ScopeWrite allows GET and POST requests.
+	ScopeWrite = "write"
+
+	// ScopeAdmin allows every request, including DELETE.
+	ScopeAdmin = "admin"
+)
+
+// DefaultWorkspace names the workspace backed by dp serve's own database.
+// Requests without a workspace use it.
+const DefaultWorkspace = "default"
+
+// ValidWorkspace reports whether name can name a workspace: 1 to 63
+// lowercase letters, digits, '-', or '_', starting with a letter or digit.
+func ValidWorkspace(name string) bool {
+	if len(name) == 0 || len(name) > 63 {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case (c == '-' || c == '_') && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Desire represents a single failed tool call from an AI coding assistant.
 type Desire struct {
 	ID        string          `json:"id"`
@@ -29,6 +79,8 @@ type Desire struct {
 	CWD       string          `json:"cwd,omitempty"`
 	Timestamp time.Time       `json:"timestamp"`
 	Metadata  json.RawMessage `json:"metadata,omitempty"`
+	ClientID  string          `json:"client_id,omitempty"` // API token name that wrote it (dp serve)
+	Model     string          `json:"model,omitempty"`     // LLM that made the call, when the source reports it
 }
 
 // Path represents an aggregated pattern of repeated desires.
@@ -39,6 +91,8 @@ type Path struct {
 	FirstSeen time.Time `json:"first_seen"`
 	LastSeen  time.Time `json:"last_seen"`
 	AliasTo   string    `json:"alias_to,omitempty"`
+	Sessions  int       `json:"sessions"` // distinct sessions with a desire
+	Impact    float64   `json:"impact"`   // waste-weighted score (see store.ImpactWeights)
 }
 
 // Alias maps a hallucinated tool name to a real tool, or defines a parameter
@@ -55,9 +109,62 @@ type Alias struct {
 	Tool      string    `json:"tool,omitempty"`       // target tool ("" = tool-name alias)
 	Param     string    `json:"param,omitempty"`      // target parameter
 	Command   string    `json:"command,omitempty"`    // target CLI command (e.g., "scp")
-	MatchKind string    `json:"match_kind,omitempty"` // "flag", "literal", "command", "regex"
+	MatchKind string    `json:"match_kind,omitempty"` // "flag", "literal", "command", "regex", "recipe", "deny"
 	Message   string    `json:"message,omitempty"`    // custom explanation
 	CreatedAt time.Time `json:"created_at"`
+
+	// Optional scope. An unscoped alias applies everywhere.
+	ScopeCWD    string     `json:"scope_cwd,omitempty"`    // glob matched against the working directory and its parents
+	ScopeRemote string     `json:"scope_remote,omitempty"` // substring of the git remote URL (origin)
+	ScopeSource string     `json:"scope_source,omitempty"` // source plugin name (e.g., "claude-code")
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`   // rule stops applying after this time
+}
+
+// AliasKey identifies an alias. Variants of the same rule with different
+// scopes are different aliases, so the scope is part of the key.
+type AliasKey struct {
+	From        string
+	Tool        string
+	Param       string
+	Command     string
+	MatchKind   string
+	ScopeCWD    string
+	ScopeRemote string
+	ScopeSource string
+}
+
+// Key returns the key that identifies a.
+func (a Alias) Key() AliasKey {
+	return AliasKey{
+		From:        a.From,
+		Tool:        a.Tool,
+		Param:       a.Param,
+		Command:     a.Command,
+		MatchKind:   a.MatchKind,
+		ScopeCWD:    a.ScopeCWD,
+		ScopeRemote: a.ScopeRemote,
+		ScopeSource: a.ScopeSource,
+	}
+}
+
+// Same reports whether a and b are equal apart from their timestamps.
+func (a Alias) Same(b Alias) bool {
+	a.CreatedAt, b.CreatedAt = time.Time{}, time.Time{}
+	if (a.ExpiresAt == nil) != (b.ExpiresAt == nil) {
+		return false
+	}
+	if a.ExpiresAt != nil && !a.ExpiresAt.Equal(*b.ExpiresAt) {
+		return false
+	}
+	a.ExpiresAt, b.ExpiresAt = nil, nil
+	return a == b
+}
+
+// Unscoped returns k without its scope, identifying the rule that all of
+// its scoped variants share.
+func (k AliasKey) Unscoped() AliasKey {
+	k.ScopeCWD, k.ScopeRemote, k.ScopeSource = "", "", ""
+	return k
 }
 
 // IsToolNameAlias returns true if this alias is a simple tool-name mapping.
@@ -65,6 +172,51 @@ func (a Alias) IsToolNameAlias() bool {
 	return a.Tool == "" && a.Param == ""
 }
 
+// IsScoped returns true if the alias carries any scope or expiry.
+func (a Alias) IsScoped() bool {
+	return a.ScopeCWD != "" || a.ScopeRemote != "" || a.ScopeSource != "" || a.ExpiresAt != nil
+}
+
+// Expired reports whether the alias has an expiry at or before now.
+func (a Alias) Expired(now time.Time) bool {
+	return a.ExpiresAt != nil && !now.Before(*a.ExpiresAt)
+}
+
+// IsDeny returns true if this alias is a policy deny rule. Deny rules never
+// rewrite input: a match refuses the call and Message explains why. When
+// Command is set, From is a command prefix matched against each segment of
+// the parameter value; otherwise From is a regex matched against the whole
+// value.
+func (a Alias) IsDeny() bool {
+	return a.MatchKind == "deny"
+}
+
+// Alias sync actions recorded in hybrid mode.
+const (
+	AliasSyncPull       = "pull"        // server version copied to the local database
+	AliasSyncPush       = "push"        // local version sent to the server
+	AliasSyncDelete     = "delete"      // deleted locally; kept as a tombstone until pushed
+	AliasSyncPushDelete = "push-delete" // local delete applied on the server
+	AliasSyncRemove     = "remove"      // deleted on the server, so removed locally
+	AliasSyncConflict   = "conflict"    // changed on both sides; the newer one won
+)
+
+// AliasSync is one entry in the audit trail of alias replication between
+// the local database and the team server in hybrid mode.
+type AliasSync struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Action    string    `json:"action"`
+	Winner    string    `json:"winner,omitempty"` // "local" or "remote", for conflicts
+	From      string    `json:"from"`
+	Tool      string    `json:"tool,omitempty"`
+	Param     string    `json:"param,omitempty"`
+	Command   string    `json:"command,omitempty"`
+	MatchKind string    `json:"match_kind,omitempty"`
+	Local     *Alias    `json:"local,omitempty"`  // local version before the action
+	Remote    *Alias    `json:"remote,omitempty"` // server version before the action
+}
+
 // Invocation represents a single tool invocation from any source plugin.
 type Invocation struct {
 	ID           string          `json:"id"`
@@ -80,6 +232,16 @@ type Invocation struct {
 	TurnID       string          `json:"turn_id,omitempty"`
 	TurnSequence int             `json:"turn_sequence"`
 	TurnLength   int             `json:"turn_length"`
+	ClientID     string          `json:"client_id,omitempty"`   // API token name that wrote it (dp serve)
+	Model        string          `json:"model,omitempty"`       // LLM that made the call, when the source reports it
+	DurationMs   int             `json:"duration_ms,omitempty"` // how long the call ran, 0 if unknown
+
+	// Tokens of the assistant message that made the call, from the
+	// transcript. A message that made several calls is split among them.
+	InputTokens      int `json:"input_tokens,omitempty"`
+	OutputTokens     int `json:"output_tokens,omitempty"`
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
 }
 
 // Recovery represents a detected recovery event — when a previously-failing
@@ -91,6 +253,38 @@ type Recovery struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// Retry chain outcomes.
+const (
+	// ChainOpen marks a chain whose last call failed and that may yet recover.
+	ChainOpen = "open"
+
+	// ChainRecovered marks a chain that ended in a successful call.
+	ChainRecovered = "recovered"
+
+	// ChainAbandoned marks a chain the session gave up on: a new turn
+	// started, or it went quiet, without a successful call.
+	ChainAbandoned = "abandoned"
+)
+
+// RetryChain is a run of consecutive failed calls with the same intent in
+// one session, ending in a success or abandonment. The intent is the tool
+// name, qualified by the command name for shell tools (e.g. "Bash:scp").
+type RetryChain struct {
+	ID          string    `json:"id"`
+	SessionID   string    `json:"session_id"`
+	TurnID      string    `json:"turn_id,omitempty"`
+	ToolName    string    `json:"tool_name"`
+	Intent      string    `json:"intent"`
+	Attempts    int       `json:"attempts"` // failed calls in the chain
+	Outcome     string    `json:"outcome"`
+	StartedAt   time.Time `json:"started_at"` // first failure
+	EndedAt     time.Time `json:"ended_at"`   // the success, or the last failure
+	ElapsedMs   int64     `json:"elapsed_ms"`
+	LastError   string    `json:"last_error,omitempty"`
+	InputDiff   string    `json:"input_diff,omitempty"`   // last failed input vs. the successful one
+	RecoveredBy string    `json:"recovered_by,omitempty"` // ID of the successful invocation
+}
+
 // RecoveryStat holds aggregated recovery counts per tool.
 type RecoveryStat struct {
 	ToolName     string    `json:"tool_name"`
@@ -98,6 +292,62 @@ type RecoveryStat struct {
 	LastRecovery time.Time `json:"last_recovery"`
 }
 
+// Intervention records a single pave-check action on a tool call.
+type Intervention struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"` // "block", "correct", "deny"
+	ToolName  string    `json:"tool_name"`
+	Rule      string    `json:"rule"` // From of the alias or rule that fired
+	MatchKind string    `json:"match_kind,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	CWD       string    `json:"cwd,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	ClientID  string    `json:"client_id,omitempty"` // API token name that wrote it (dp serve)
+}
+
+// InterventionStat holds aggregated intervention counts per kind and tool.
+type InterventionStat struct {
+	Kind     string    `json:"kind"`
+	ToolName string    `json:"tool_name"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// APIToken is a bearer token accepted by dp serve. Only a SHA-256 hash of
+// the secret is stored; the secret itself is shown once at creation.
+type APIToken struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`  // identity recorded on rows the token writes
+	Scope     string     `json:"scope"` // "read", "write", "admin"
+	Hash      string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Workspace string     `json:"workspace,omitempty"` // only workspace the token may use ("" = any)
+}
+
+// Allows reports whether the token's scope grants the required scope.
+func (t APIToken) Allows(required string) bool {
+	return scopeRank(t.Scope) >= scopeRank(required) && scopeRank(required) > 0
+}
+
+// ValidScope reports whether s is a known token scope.
+func ValidScope(s string) bool {
+	return scopeRank(s) > 0
+}
+
+func scopeRank(s string) int {
+	switch s {
+	case ScopeRead:
+		return 1
+	case ScopeWrite:
+		return 2
+	case ScopeAdmin:
+		return 3
+	}
+	return 0
+}
+
 // DocMapping links documentation to a tool failure pattern.
 // When an agent struggles with a tool (repeated failures), matching
 // doc mappings surface relevant documentation.
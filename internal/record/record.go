@@ -33,6 +33,7 @@ var knownFields = map[string]bool{
 	"cwd":        true,
 	"timestamp":  true,
 	"metadata":   true,
+	"model":      true,
 }
 
 // Record reads JSON from input, extracts fields into a Desire, and persists
@@ -96,6 +97,7 @@ func fieldsToDesire(f *source.Fields, sourceName string) (model.Desire, error) {
 		SessionID: f.InstanceID,
 		CWD:       f.CWD,
 		Timestamp: time.Now(),
+		Model:     f.Model,
 	}
 
 	if len(f.Extra) > 0 {
@@ -158,6 +160,12 @@ func extractDesire(fields map[string]json.RawMessage, source string) (model.Desi
 		}
 	}
 
+	if v, ok := fields["model"]; ok {
+		if err := json.Unmarshal(v, &d.Model); err != nil {
+			return d, fmt.Errorf("parsing model: %w", err)
+		}
+	}
+
 	if v, ok := fields["timestamp"]; ok {
 		if err := json.Unmarshal(v, &d.Timestamp); err != nil {
 			return d, fmt.Errorf("parsing timestamp: %w", err)
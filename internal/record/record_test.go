@@ -32,14 +32,14 @@ func (f *fakeStore) ListDesires(context.Context, store.ListOpts) ([]model.Desire
 func (f *fakeStore) GetPaths(context.Context, store.PathOpts) ([]model.Path, error) {
 	return nil, nil
 }
-func (f *fakeStore) SetAlias(context.Context, model.Alias) error { return nil }
-func (f *fakeStore) GetAlias(context.Context, string, string, string, string, string) (*model.Alias, error) {
+func (f *fakeStore) PathTrends(context.Context, store.TrendOpts) (*store.TrendResult, error) {
 	return nil, nil
 }
+func (f *fakeStore) SetAlias(context.Context, model.Alias) error { return nil }
+func (f *fakeStore) GetAlias(context.Context, model.AliasKey) (*model.Alias, error) { return nil, nil }
+func (f *fakeStore) GetToolAliases(context.Context, string) ([]model.Alias, error) { return nil, nil }
 func (f *fakeStore) GetAliases(context.Context) ([]model.Alias, error)                       { return nil, nil }
-func (f *fakeStore) DeleteAlias(context.Context, string, string, string, string, string) (bool, error) {
-	return false, nil
-}
+func (f *fakeStore) DeleteAlias(context.Context, model.AliasKey) (bool, error) { return false, nil }
 func (f *fakeStore) GetRulesForTool(context.Context, string) ([]model.Alias, error) { return nil, nil }
 func (f *fakeStore) Stats(context.Context) (store.Stats, error)        { return store.Stats{}, nil }
 func (f *fakeStore) InspectPath(context.Context, store.InspectOpts) (*store.InspectResult, error) {
@@ -66,12 +66,18 @@ func (f *fakeStore) ToolTurnStats(context.Context, store.TurnOpts) ([]store.Tool
 func (f *fakeStore) DetectAndRecordRecovery(context.Context, model.Invocation) error {
 	return nil
 }
-func (f *fakeStore) ListRecoveries(context.Context, time.Time, int) ([]model.Recovery, error) {
+func (f *fakeStore) ListRecoveries(context.Context, store.RecoveryOpts) ([]model.Recovery, error) {
 	return nil, nil
 }
 func (f *fakeStore) RecoveryStats(context.Context) ([]model.RecoveryStat, error) {
 	return nil, nil
 }
+func (f *fakeStore) TrackRetryChain(context.Context, model.Invocation, json.RawMessage) error {
+	return nil
+}
+func (f *fakeStore) ListRetryChains(context.Context, store.ChainOpts) ([]model.RetryChain, error) {
+	return nil, nil
+}
 func (f *fakeStore) SetDocMapping(context.Context, model.DocMapping) error { return nil }
 func (f *fakeStore) GetDocMappings(context.Context) ([]model.DocMapping, error) { return nil, nil }
 func (f *fakeStore) DeleteDocMapping(context.Context, string) (bool, error) { return false, nil }
@@ -82,6 +88,37 @@ func (f *fakeStore) IncrementDocMatchCount(context.Context, string) error { retu
 func (f *fakeStore) StrugglingTools(context.Context, store.StrugglingOpts) ([]model.StrugglingTool, error) {
 	return nil, nil
 }
+func (f *fakeStore) RecordIntervention(context.Context, model.Intervention) error { return nil }
+func (f *fakeStore) InterventionStats(context.Context, time.Time) ([]model.InterventionStat, error) {
+	return nil, nil
+}
+func (f *fakeStore) CreateToken(context.Context, model.APIToken) error { return nil }
+func (f *fakeStore) ListTokens(context.Context) ([]model.APIToken, error) {
+	return nil, nil
+}
+func (f *fakeStore) RevokeToken(context.Context, string) (bool, error) { return false, nil }
+func (f *fakeStore) AuthenticateToken(context.Context, string) (*model.APIToken, error) {
+	return nil, nil
+}
+func (f *fakeStore) SourceToolCounts(context.Context) ([]store.SourceToolCount, error) {
+	return nil, nil
+}
+func (f *fakeStore) RecordDesires(ctx context.Context, ds []model.Desire) error {
+	for _, d := range ds {
+		if err := f.RecordDesire(ctx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (f *fakeStore) RecordInvocations(ctx context.Context, invs []model.Invocation) error {
+	for _, inv := range invs {
+		if err := f.RecordInvocation(ctx, inv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 func (f *fakeStore) Close() error { return nil }
 
 func TestRecord(t *testing.T) {
@@ -124,7 +161,7 @@ func TestRecord(t *testing.T) {
 		},
 		{
 			name:  "full input with all known fields",
-			input: `{"id":"abc-123","tool_name":"Bash","tool_input":{"command":"ls"},"error":"failed","source":"cursor","session_id":"sess-1","cwd":"/tmp","timestamp":"2025-01-15T10:30:00Z"}`,
+			input: `{"id":"abc-123","tool_name":"Bash","tool_input":{"command":"ls"},"error":"failed","source":"cursor","session_id":"sess-1","cwd":"/tmp","timestamp":"2025-01-15T10:30:00Z","model":"gpt-5"}`,
 			check: func(t *testing.T, d model.Desire) {
 				if d.ID != "abc-123" {
 					t.Errorf("ID = %q, want %q", d.ID, "abc-123")
@@ -144,6 +181,12 @@ func TestRecord(t *testing.T) {
 				if d.CWD != "/tmp" {
 					t.Errorf("CWD = %q, want %q", d.CWD, "/tmp")
 				}
+				if d.Model != "gpt-5" {
+					t.Errorf("Model = %q, want %q", d.Model, "gpt-5")
+				}
+				if d.Metadata != nil {
+					t.Errorf("Metadata = %s, want nil", d.Metadata)
+				}
 				want := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
 				if !d.Timestamp.Equal(want) {
 					t.Errorf("Timestamp = %v, want %v", d.Timestamp, want)
@@ -185,8 +228,8 @@ func TestRecord(t *testing.T) {
 				if err := json.Unmarshal(d.Metadata, &meta); err != nil {
 					t.Fatalf("unmarshaling metadata: %v", err)
 				}
-				if meta["model"] != "claude-3" {
-					t.Errorf("metadata.model = %q, want %q", meta["model"], "claude-3")
+				if _, ok := meta["model"]; ok || d.Model != "claude-3" {
+					t.Errorf("model should be a field, not metadata: Model = %q, metadata = %s", d.Model, d.Metadata)
 				}
 				if meta["user"] != "alice" {
 					t.Errorf("metadata.user = %q, want %q", meta["user"], "alice")
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// tokenPrefix marks dp API tokens so they are recognisable in configs and logs.
+const tokenPrefix = "dp_"
+
+// NewToken generates a fresh API token. The returned secret is what clients
+// send as "Authorization: Bearer <secret>"; only its hash is kept in the
+// returned model.APIToken, so the secret cannot be recovered later.
+func NewToken(name, scope string) (model.APIToken, string, error) {
+	if name == "" {
+		return model.APIToken{}, "", fmt.Errorf("token name is required")
+	}
+	if !model.ValidScope(scope) {
+		return model.APIToken{}, "", fmt.Errorf("scope must be %q, %q, or %q, got %q",
+			model.ScopeRead, model.ScopeWrite, model.ScopeAdmin, scope)
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return model.APIToken{}, "", fmt.Errorf("generate token: %w", err)
+	}
+	secret := tokenPrefix + hex.EncodeToString(buf)
+	t := model.APIToken{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Scope:     scope,
+		Hash:      HashToken(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	return t, secret, nil
+}
+
+// HashToken returns the stored form of a token secret.
+func HashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// requiredScope maps a request to the token scope it needs: reads need
+// "read", writes need "write", and deletes need "admin". Deletes sent as
+// POST (of doc mappings) need "admin" too; overwriting an alias is checked
+// by its handler with tokenAllows, since only the store knows it exists.
+func requiredScope(method, path string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return model.ScopeRead
+	case http.MethodDelete:
+		return model.ScopeAdmin
+	}
+	if _, rest := splitWorkspace(path); rest == "/api/v1/doc-mappings/delete" {
+		return model.ScopeAdmin
+	}
+	return model.ScopeWrite
+}
+
+type tokenKey struct{}
+
+// clientID returns the name of the token that authenticated the request,
+// or "" for unauthenticated requests.
+func clientID(ctx context.Context) string {
+	tok, _ := ctx.Value(tokenKey{}).(*model.APIToken)
+	if tok == nil {
+		return ""
+	}
+	return tok.Name
+}
+
+// tokenAllows reports whether the request may do what needs scope. Requests
+// without a token only get this far when tokens are not enforced, so they
+// are allowed.
+func tokenAllows(ctx context.Context, scope string) bool {
+	tok, _ := ctx.Value(tokenKey{}).(*model.APIToken)
+	return tok == nil || tok.Allows(scope)
+}
+
+// auth wraps next with bearer-token authentication. Tokens are only enforced
+// once at least one active token exists, so a fresh server keeps working
+// until its first token is created. The API and /metrics are protected;
+// the health check, the OpenAPI document, and the dashboard's static files
+// are always open, and the dashboard sends a token on its own API calls.
+func (s *Server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !protected(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			enforced, err := s.authEnforced(r.Context())
+			if err != nil {
+				writeErr(w, http.StatusInternalServerError, "checking tokens: %v", err)
+				return
+			}
+			if enforced {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="dp"`)
+				writeErr(w, http.StatusUnauthorized, "missing bearer token")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		secret, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(secret) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dp"`)
+			writeErr(w, http.StatusUnauthorized, "malformed Authorization header; expected \"Bearer <token>\"")
+			return
+		}
+		tok, err := s.store.AuthenticateToken(r.Context(), HashToken(strings.TrimSpace(secret)))
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "checking token: %v", err)
+			return
+		}
+		if tok == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dp", error="invalid_token"`)
+			writeErr(w, http.StatusUnauthorized, "invalid or revoked token")
+			return
+		}
+		if need := requiredScope(r.Method, r.URL.Path); !tok.Allows(need) {
+			writeErr(w, http.StatusForbidden, "token %q has scope %q; %s %s requires %q",
+				tok.Name, tok.Scope, r.Method, r.URL.Path, need)
+			return
+		}
+		if ws, _ := splitWorkspace(r.URL.Path); tok.Workspace != "" && ws != tok.Workspace {
+			writeErr(w, http.StatusForbidden, "token %q is limited to workspace %q", tok.Name, tok.Workspace)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, tok)))
+	})
+}
+
+// protected reports whether requests for path need a token once tokens
+// are enforced.
+func protected(path string) bool {
+	if path == "/api/v1/health" || path == "/api/v1/openapi.json" {
+		return false
+	}
+	return strings.HasPrefix(path, "/api/") || path == "/metrics"
+}
+
+// authEnforced reports whether any active token exists. Stores that cache
+// the answer (as SQLiteStore does) are asked directly; otherwise the
+// tokens are listed.
+func (s *Server) authEnforced(ctx context.Context) (bool, error) {
+	if c, ok := s.store.(interface {
+		HasActiveTokens(context.Context) (bool, error)
+	}); ok {
+		return c.HasActiveTokens(ctx)
+	}
+	tokens, err := s.store.ListTokens(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range tokens {
+		if t.RevokedAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// clientStore stamps the authenticated client's identity on every row it
+// writes, for handlers (like ingest) that record through a store.Store.
+type clientStore struct {
+	store.Store
+	client string
+}
+
+func (c clientStore) RecordDesire(ctx context.Context, d model.Desire) error {
+	d.ClientID = c.client
+	return c.Store.RecordDesire(ctx, d)
+}
+
+func (c clientStore) RecordInvocation(ctx context.Context, inv model.Invocation) error {
+	inv.ClientID = c.client
+	return c.Store.RecordInvocation(ctx, inv)
+}
+
+func (c clientStore) RecordDesires(ctx context.Context, ds []model.Desire) error {
+	for i := range ds {
+		ds[i].ClientID = c.client
+	}
+	return c.Store.RecordDesires(ctx, ds)
+}
+
+func (c clientStore) RecordInvocations(ctx context.Context, invs []model.Invocation) error {
+	for i := range invs {
+		invs[i].ClientID = c.client
+	}
+	return c.Store.RecordInvocations(ctx, invs)
+}
+
+func (c clientStore) RecordIntervention(ctx context.Context, iv model.Intervention) error {
+	iv.ClientID = c.client
+	return c.Store.RecordIntervention(ctx, iv)
+}
+
+// storeFor returns the store to write through for r: the store of the
+// request's workspace, wrapped to record the client identity when the
+// request carried a token.
+func (s *Server) storeFor(r *http.Request) store.Store {
+	if id := clientID(r.Context()); id != "" {
+		return clientStore{Store: s.storeOf(r), client: id}
+	}
+	return s.storeOf(r)
+}
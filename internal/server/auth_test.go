@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// addToken creates a token in the server's store and returns its secret.
+func addToken(t *testing.T, srv *Server, name, scope string) string {
+	t.Helper()
+	tok, secret, err := NewToken(name, scope)
+	if err != nil {
+		t.Fatalf("NewToken: %v", err)
+	}
+	if err := srv.store.CreateToken(context.Background(), tok); err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	return secret
+}
+
+func doAuth(t *testing.T, method, url, token string, body any) *http.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, url, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestNewToken(t *testing.T) {
+	tok, secret, err := NewToken("ci", model.ScopeRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, "dp_") || tok.Hash != HashToken(secret) || strings.Contains(tok.Hash, secret) {
+		t.Errorf("token = %+v, secret = %q", tok, secret)
+	}
+	if _, _, err := NewToken("ci", "root"); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+	if _, _, err := NewToken("", model.ScopeRead); err == nil {
+		t.Error("expected error for empty name")
+	}
+}
+
+func TestAuthOpenUntilFirstToken(t *testing.T) {
+	_, ts := testServer(t)
+	if resp := doAuth(t, "GET", ts.URL+"/api/v1/desires", "", nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("no tokens: status %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestAuthScopes(t *testing.T) {
+	srv, ts := testServer(t)
+	read := addToken(t, srv, "dashboard", model.ScopeRead)
+	write := addToken(t, srv, "alice-laptop", model.ScopeWrite)
+	admin := addToken(t, srv, "ops", model.ScopeAdmin)
+
+	desire := model.Desire{ID: "d1", ToolName: "read_file", Timestamp: time.Now()}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   any
+		want   int
+	}{
+		{"health is open", "GET", "/api/v1/health", "", nil, http.StatusOK},
+		{"missing token", "GET", "/api/v1/desires", "", nil, http.StatusUnauthorized},
+		{"bad token", "GET", "/api/v1/desires", "dp_bogus", nil, http.StatusUnauthorized},
+		{"read can GET", "GET", "/api/v1/desires", read, nil, http.StatusOK},
+		{"read cannot POST", "POST", "/api/v1/desires", read, desire, http.StatusForbidden},
+		{"write can POST", "POST", "/api/v1/desires", write, desire, http.StatusCreated},
+		{"write cannot DELETE", "DELETE", "/api/v1/aliases/read_file", write, nil, http.StatusForbidden},
+		{"admin can DELETE", "DELETE", "/api/v1/aliases/read_file", admin, nil, http.StatusNotFound},
+		{"write cannot delete doc mappings", "POST", "/api/v1/doc-mappings/delete", write, map[string]string{"id": "dm-1"}, http.StatusForbidden},
+		{"admin can delete doc mappings", "POST", "/api/v1/doc-mappings/delete", admin, map[string]string{"id": "dm-1"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doAuth(t, tt.method, ts.URL+tt.path, tt.token, tt.body)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+
+	// The writing token's name is recorded on the row.
+	desires, err := srv.store.ListDesires(context.Background(), store.ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(desires) != 1 || desires[0].ClientID != "alice-laptop" {
+		t.Errorf("desires = %+v, want one with client_id alice-laptop", desires)
+	}
+}
+
+func TestAuthAliasOverwriteNeedsAdmin(t *testing.T) {
+	srv, ts := testServer(t)
+	write := addToken(t, srv, "alice-laptop", model.ScopeWrite)
+	admin := addToken(t, srv, "ops", model.ScopeAdmin)
+
+	alias := model.Alias{From: "read_file", To: "Read"}
+	changed := model.Alias{From: "read_file", To: "Grep"}
+	for _, tt := range []struct {
+		name  string
+		token string
+		body  model.Alias
+		want  int
+	}{
+		{"write can add", write, alias, http.StatusCreated},
+		{"write can resend unchanged", write, alias, http.StatusCreated},
+		{"write cannot change", write, changed, http.StatusForbidden},
+		{"write can add a scoped variant", write, model.Alias{From: "read_file", To: "Grep", ScopeCWD: "/src/*"}, http.StatusCreated},
+		{"admin can change", admin, changed, http.StatusCreated},
+	} {
+		if resp := doAuth(t, "POST", ts.URL+"/api/v1/aliases", tt.token, tt.body); resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+	got, err := srv.store.GetAlias(context.Background(), alias.Key())
+	if err != nil || got == nil || got.To != "Grep" {
+		t.Errorf("alias = %+v, %v; want the admin's change", got, err)
+	}
+}
+
+func TestAuthRecordsClientOnIngest(t *testing.T) {
+	srv, ts := testServer(t)
+	write := addToken(t, srv, "bob-desktop", model.ScopeWrite)
+
+	payload := map[string]any{"tool_name": "Read", "session_id": "s1", "cwd": "/tmp"}
+	resp := doAuth(t, "POST", ts.URL+"/api/v1/ingest?source=claude-code", write, payload)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("ingest status = %d", resp.StatusCode)
+	}
+	invs, err := srv.store.ListInvocations(context.Background(), store.InvocationOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invs) != 1 || invs[0].ClientID != "bob-desktop" {
+		t.Errorf("invocations = %+v, want client_id bob-desktop", invs)
+	}
+}
+
+func TestAuthRevokedToken(t *testing.T) {
+	srv, ts := testServer(t)
+	secret := addToken(t, srv, "old", model.ScopeAdmin)
+	tokens, _ := srv.store.ListTokens(context.Background())
+	if _, err := srv.store.RevokeToken(context.Background(), tokens[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if resp := doAuth(t, "GET", ts.URL+"/api/v1/stats", secret, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("revoked token: status %d, want 401", resp.StatusCode)
+	}
+	// With every token revoked, the server is open again.
+	if resp := doAuth(t, "GET", ts.URL+"/api/v1/stats", "", nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("no active tokens: status %d, want 200", resp.StatusCode)
+	}
+}
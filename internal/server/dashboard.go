@@ -0,0 +1,25 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webFiles holds the dashboard: a single page plus its script and styles.
+// It talks only to the /api/v1 endpoints and loads nothing from the network.
+//
+//go:embed web
+var webFiles embed.FS
+
+// dashboardRoutes serves the embedded dashboard at / and its assets under
+// /static/.
+func (s *Server) dashboardRoutes() {
+	sub, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err) // the embed directive guarantees "web" exists
+	}
+	files := http.FileServerFS(sub)
+	s.mux.Handle("GET /{$}", files)
+	s.mux.Handle("GET /static/", http.StripPrefix("/static/", files))
+}
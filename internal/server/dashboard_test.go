@@ -0,0 +1,89 @@
+package server
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func TestDashboardServed(t *testing.T) {
+	srv, ts := testServer(t)
+	// The dashboard stays reachable when tokens are enforced; its API calls
+	// carry the token instead.
+	addToken(t, srv, "ops", model.ScopeRead)
+
+	for path, want := range map[string]string{
+		"/":                 "<title>dp dashboard</title>",
+		"/static/app.js":    "async function api(",
+		"/static/style.css": ":root",
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("GET %s: status %d, body missing %q", path, resp.StatusCode, want)
+		}
+	}
+
+	if resp := doAuth(t, "GET", ts.URL+"/api/v1/stats", "", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("API without token: status %d, want 401", resp.StatusCode)
+	}
+	if resp := doAuth(t, "GET", ts.URL+"/nope", "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown path: status %d, want 404", resp.StatusCode)
+	}
+}
+
+// TestDashboardSelfContained guards against the dashboard pulling in
+// external scripts, styles, or fonts.
+func TestDashboardSelfContained(t *testing.T) {
+	external := regexp.MustCompile(`https?://`)
+	err := fs.WalkDir(webFiles, "web", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := webFiles.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			// The SVG namespace is an identifier, not a fetch.
+			if external.MatchString(line) && !strings.Contains(line, "http://www.w3.org/2000/svg") {
+				t.Errorf("%s references an external URL: %s", path, strings.TrimSpace(line))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStrugglingTools(t *testing.T) {
+	_, ts := testServer(t)
+	resp, err := http.Get(ts.URL + "/api/v1/struggling?since=7d&min_fails=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != "[]" {
+		t.Errorf("status %d, body %s", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/struggling?min_fails=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad min_fails: status %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/events"
+)
+
+// sseHeartbeat is how often an idle event stream sends a comment line, so
+// proxies and clients can tell a quiet stream from a dead one.
+const sseHeartbeat = 15 * time.Second
+
+// handleEvents streams live events as Server-Sent Events. Each event is
+// written as "event: <type>" with the JSON-encoded events.Event as data.
+// Query parameters tool, source, session, and type (comma-separated)
+// filter the stream.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := events.Filter{
+		ToolName:  q.Get("tool"),
+		Source:    q.Get("source"),
+		SessionID: q.Get("session"),
+	}
+	if t := q.Get("type"); t != "" {
+		f.Types = strings.Split(t, ",")
+	}
+
+	rc := http.NewResponseController(w)
+	// Streams outlive the server's write timeout.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		writeErr(w, http.StatusInternalServerError, "event stream: %v", err)
+		return
+	}
+
+	ch, cancel := s.busOf(r).Subscribe(f)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.quit:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID(), e.Type, data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
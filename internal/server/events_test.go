@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/events"
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// readSSE returns the next n events from an SSE stream.
+func readSSE(t *testing.T, sc *bufio.Scanner, n int) []events.Event {
+	t.Helper()
+	var out []events.Event
+	for len(out) < n && sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e events.Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestEventsStream(t *testing.T) {
+	srv, ts := testServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/v1/events?source=claude-code", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	sc := bufio.NewScanner(resp.Body)
+	sc.Scan() // ": connected" comment; the subscription is live once it arrives
+
+	now := time.Now().UTC()
+	st := srv.store
+	if err := st.RecordDesire(ctx, model.Desire{ID: "d-other", ToolName: "x", Source: "cursor", Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.RecordDesire(ctx, model.Desire{ID: "d1", ToolName: "Read", Source: "claude-code", Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+	// A success after a failure is a recovery; it carries the invocation's source.
+	if err := st.RecordInvocation(ctx, model.Invocation{ID: "i1", ToolName: "Read", Source: "claude-code", Timestamp: now.Add(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.DetectAndRecordRecovery(ctx, model.Invocation{ID: "i1", ToolName: "Read", Source: "claude-code", Timestamp: now.Add(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readSSE(t, sc, 3)
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 3", len(got))
+	}
+	wantTypes := []string{events.TypeDesire, events.TypeInvocation, events.TypeRecovery}
+	for i, e := range got {
+		if e.Type != wantTypes[i] || e.Source != "claude-code" {
+			t.Errorf("event %d = %s/%s, want %s from claude-code", i, e.Type, e.Source, wantTypes[i])
+		}
+	}
+	if got[0].Desire == nil || got[0].Desire.ID != "d1" {
+		t.Errorf("desire payload = %+v", got[0].Desire)
+	}
+}
+
+func TestEventsStreamEndsOnShutdown(t *testing.T) {
+	srv, ts := testServer(t)
+	resp, err := http.Get(ts.URL + "/api/v1/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	sc := bufio.NewScanner(resp.Body)
+	sc.Scan()
+
+	done := make(chan struct{})
+	go func() {
+		for sc.Scan() {
+		}
+		close(done)
+	}()
+	srv.Shutdown(context.Background())
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream did not end on shutdown")
+	}
+}
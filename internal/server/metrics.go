@@ -0,0 +1,252 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram. They match the Prometheus client libraries' defaults.
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// strugglingWindow is the period the failure-rate gauges cover.
+const strugglingWindow = 24 * time.Hour
+
+// histogram is a cumulative latency histogram with latencyBuckets bounds.
+type histogram struct {
+	buckets []uint64 // buckets[i] counts observations <= latencyBuckets[i]
+	count   uint64
+	sum     float64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, le := range latencyBuckets {
+		if v <= le {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+type requestKey struct {
+	method, route, code string
+}
+
+// requestMetrics collects HTTP request latencies per method, route, and
+// status code.
+type requestMetrics struct {
+	mu    sync.Mutex
+	hists map[requestKey]*histogram
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{hists: make(map[requestKey]*histogram)}
+}
+
+func (m *requestMetrics) observe(k requestKey, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.hists[k]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(latencyBuckets))}
+		m.hists[k] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// instrument records the latency of every request served by next, labelled
+// with the mux pattern that matched it so that path parameters do not
+// multiply series. Event streams are long-lived and are not recorded.
+func (s *Server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/events" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		// The mux sets r.Pattern, e.g. "GET /api/v1/aliases/{from}".
+		route := "unmatched"
+		if r.Pattern != "" {
+			_, path, found := strings.Cut(r.Pattern, " ")
+			if !found {
+				path = r.Pattern
+			}
+			route = path
+		}
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		s.metrics.observe(requestKey{r.Method, route, strconv.Itoa(rec.status)}, time.Since(start))
+	})
+}
+
+// handleMetrics serves metrics in the Prometheus text exposition format.
+// Store-derived values come from a handful of aggregate queries per scrape.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var buf bytes.Buffer
+	p := promWriter{&buf}
+
+	counts, err := s.store.SourceToolCounts(ctx)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "metrics: %v", err)
+		return
+	}
+	p.header("dp_desires_total", "counter", "Desires (failed tool calls) recorded, by source and tool.")
+	for _, c := range counts {
+		if c.Desires > 0 {
+			p.sample("dp_desires_total", float64(c.Desires), "source", c.Source, "tool", c.ToolName)
+		}
+	}
+	p.header("dp_invocations_total", "counter", "Tool invocations recorded, by source and tool.")
+	for _, c := range counts {
+		if c.Invocations > 0 {
+			p.sample("dp_invocations_total", float64(c.Invocations), "source", c.Source, "tool", c.ToolName)
+		}
+	}
+	p.header("dp_invocation_errors_total", "counter", "Tool invocations that returned an error, by source and tool.")
+	for _, c := range counts {
+		if c.Invocations > 0 {
+			p.sample("dp_invocation_errors_total", float64(c.InvocationErrors), "source", c.Source, "tool", c.ToolName)
+		}
+	}
+
+	struggling, err := s.store.StrugglingTools(ctx, store.StrugglingOpts{Since: time.Now().Add(-strugglingWindow)})
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "metrics: %v", err)
+		return
+	}
+	p.header("dp_tool_failure_ratio", "gauge", "Failure ratio over the last 24 hours for tools with at least 3 failures.")
+	for _, st := range struggling {
+		p.sample("dp_tool_failure_ratio", st.FailureRate, "tool", st.ToolName)
+	}
+
+	recoveries, err := s.store.RecoveryStats(ctx)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "metrics: %v", err)
+		return
+	}
+	p.header("dp_recoveries_total", "counter", "Tools succeeding after recently failing, by tool.")
+	for _, rs := range recoveries {
+		p.sample("dp_recoveries_total", float64(rs.Count), "tool", rs.ToolName)
+	}
+
+	interventions, err := s.store.InterventionStats(ctx, time.Time{})
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "metrics: %v", err)
+		return
+	}
+	p.header("dp_interventions_total", "counter", "Pave-check interventions, by kind and tool.")
+	for _, iv := range interventions {
+		p.sample("dp_interventions_total", float64(iv.Count), "kind", iv.Kind, "tool", iv.ToolName)
+	}
+
+	s.metrics.write(p)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// write emits the request latency histograms, sorted by label for stable
+// output.
+func (m *requestMetrics) write(p promWriter) {
+	const name = "dp_http_request_duration_seconds"
+	p.header(name, "histogram", "HTTP request latency, by method, route, and status code.")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]requestKey, 0, len(m.hists))
+	for k := range m.hists {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+	for _, k := range keys {
+		h := m.hists[k]
+		for i, le := range latencyBuckets {
+			p.sample(name+"_bucket", float64(h.buckets[i]), "method", k.method, "route", k.route, "code", k.code, "le", formatFloat(le))
+		}
+		p.sample(name+"_bucket", float64(h.count), "method", k.method, "route", k.route, "code", k.code, "le", "+Inf")
+		p.sample(name+"_sum", h.sum, "method", k.method, "route", k.route, "code", k.code)
+		p.sample(name+"_count", float64(h.count), "method", k.method, "route", k.route, "code", k.code)
+	}
+}
+
+// promWriter writes the Prometheus text exposition format.
+type promWriter struct {
+	buf *bytes.Buffer
+}
+
+func (p promWriter) header(name, typ, help string) {
+	fmt.Fprintf(p.buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one sample; labels are alternating names and values.
+func (p promWriter) sample(name string, v float64, labels ...string) {
+	p.buf.WriteString(name)
+	if len(labels) > 0 {
+		p.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				p.buf.WriteByte(',')
+			}
+			fmt.Fprintf(p.buf, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		p.buf.WriteByte('}')
+	}
+	p.buf.WriteByte(' ')
+	p.buf.WriteString(formatFloat(v))
+	p.buf.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func scrape(t *testing.T, url, token string) string {
+	t.Helper()
+	req, err := http.NewRequest("GET", url+"/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics: status %d: %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	srv, ts := testServer(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	if err := srv.store.RecordDesire(ctx, model.Desire{ID: "d1", ToolName: "read_file", Error: "unknown tool", Source: "claude-code", Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+	for i, isErr := range []bool{true, true, true, false} {
+		inv := model.Invocation{ID: string(rune('a' + i)), ToolName: `Bash"x`, Source: "claude-code", IsError: isErr, Timestamp: now}
+		if err := srv.store.RecordInvocation(ctx, inv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := srv.store.RecordIntervention(ctx, model.Intervention{ID: "iv1", Kind: model.InterventionDeny, ToolName: "Bash", Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/aliases/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	body := scrape(t, ts.URL, "")
+	for _, want := range []string{
+		"# TYPE dp_desires_total counter",
+		`dp_desires_total{source="claude-code",tool="read_file"} 1`,
+		`dp_invocations_total{source="claude-code",tool="Bash\"x"} 4`,
+		`dp_invocation_errors_total{source="claude-code",tool="Bash\"x"} 3`,
+		`dp_tool_failure_ratio{tool="Bash\"x"} 0.75`,
+		`dp_interventions_total{kind="deny",tool="Bash"} 1`,
+		"# TYPE dp_recoveries_total counter",
+		"# TYPE dp_http_request_duration_seconds histogram",
+		`dp_http_request_duration_seconds_bucket{method="GET",route="/api/v1/aliases/{from}",code="404",le="+Inf"} 1`,
+		`dp_http_request_duration_seconds_count{method="GET",route="/api/v1/aliases/{from}",code="404"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsRequiresToken(t *testing.T) {
+	srv, ts := testServer(t)
+	secret := addToken(t, srv, "prometheus", model.ScopeRead)
+
+	if resp := doAuth(t, "GET", ts.URL+"/metrics", "", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without token: status %d, want 401", resp.StatusCode)
+	}
+	if body := scrape(t, ts.URL, secret); !strings.Contains(body, "dp_desires_total") {
+		t.Errorf("with token: unexpected body\n%s", body)
+	}
+}
+
+func TestSourceToolCountsEndpoint(t *testing.T) {
+	_, ts := testServer(t)
+	resp, err := http.Get(ts.URL + "/api/v1/counts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != "[]" {
+		t.Errorf("status %d, body %s", resp.StatusCode, body)
+	}
+}
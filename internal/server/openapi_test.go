@@ -0,0 +1,628 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/events"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// openAPI is the part of an OpenAPI document the tests read. Schemas stay
+// generic maps so validate can walk them.
+type openAPI struct {
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas    map[string]map[string]any `json:"schemas"`
+		Parameters map[string]openAPIParam   `json:"parameters"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	Parameters  []openAPIParam `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema map[string]any `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]struct {
+			Schema map[string]any `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+type openAPIParam struct {
+	Ref  string `json:"$ref"`
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+func loadOpenAPI(t *testing.T) *openAPI {
+	t.Helper()
+	var doc openAPI
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+	return &doc
+}
+
+// operation returns the operation documented for a request, matching path
+// templates such as /api/v1/aliases/{from}. Like http.ServeMux, a literal
+// path wins over a template.
+func (doc *openAPI) operation(method, path string) (string, *openAPIOperation) {
+	if op, ok := doc.Paths[path][strings.ToLower(method)]; ok {
+		return path, &op
+	}
+	for tmpl, ops := range doc.Paths {
+		re := "^" + regexp.MustCompile(`\\\{[^}]+\\\}`).ReplaceAllString(regexp.QuoteMeta(tmpl), `[^/]+`) + "$"
+		if !regexp.MustCompile(re).MatchString(path) {
+			continue
+		}
+		if op, ok := ops[strings.ToLower(method)]; ok {
+			return tmpl, &op
+		}
+	}
+	return "", nil
+}
+
+// queryParams returns the names of the operation's query parameters.
+func (doc *openAPI) queryParams(op *openAPIOperation) map[string]bool {
+	names := make(map[string]bool)
+	for _, p := range op.Parameters {
+		if name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/"); ok {
+			p = doc.Components.Parameters[name]
+		}
+		if p.In == "query" {
+			names[p.Name] = true
+		}
+	}
+	return names
+}
+
+// validate checks a decoded JSON value against a schema and returns the
+// mismatches. Objects may only have the properties their schema lists,
+// unless it allows additionalProperties, so fields added to a Go type
+// without updating the document are caught.
+func (doc *openAPI) validate(schema map[string]any, v any, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		s, ok := doc.Components.Schemas[name]
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown schema %s", at, ref)}
+		}
+		return doc.validate(s, v, at)
+	}
+	if v == nil {
+		if schema["nullable"] == true || schema["type"] == nil {
+			return nil
+		}
+		return []string{at + ": null is not allowed"}
+	}
+	if alts, ok := schema["oneOf"].([]any); ok {
+		matched := 0
+		for _, alt := range alts {
+			if len(doc.validate(alt.(map[string]any), v, at)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return []string{fmt.Sprintf("%s: matches %d of the oneOf schemas", at, matched)}
+		}
+		return nil
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == v
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %v is not in %v", at, v, enum)}
+		}
+	}
+
+	var errs []string
+	switch schema["type"] {
+	case nil:
+		return nil
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: want object, got %T", at, v)}
+		}
+		for _, req := range anySlice(schema["required"]) {
+			if _, ok := obj[req.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %q", at, req))
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := props[k].(map[string]any); ok {
+				errs = append(errs, doc.validate(ps, obj[k], at+"."+k)...)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					errs = append(errs, fmt.Sprintf("%s: undocumented property %q", at, k))
+				}
+			case map[string]any:
+				errs = append(errs, doc.validate(extra, obj[k], at+"."+k)...)
+			default:
+				errs = append(errs, fmt.Sprintf("%s: undocumented property %q", at, k))
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: want array, got %T", at, v)}
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range arr {
+			errs = append(errs, doc.validate(items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: want string, got %T", at, v)}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %q is not a date-time", at, s))
+			}
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != float64(int64(n)) {
+			errs = append(errs, fmt.Sprintf("%s: want integer, got %v", at, v))
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			errs = append(errs, fmt.Sprintf("%s: want number, got %T", at, v))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			errs = append(errs, fmt.Sprintf("%s: want boolean, got %T", at, v))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("%s: unsupported schema type %v", at, schema["type"]))
+	}
+	return errs
+}
+
+func anySlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	srv, _ := testServer(t)
+	doc := loadOpenAPI(t)
+
+	registered := make(map[string]bool)
+	for _, pattern := range srv.patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			t.Errorf("route %q has no method", pattern)
+			continue
+		}
+		registered[strings.ToLower(method)+" "+path] = true
+		if _, ok := doc.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("route %q is missing from openapi.json", pattern)
+		}
+	}
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			if !registered[method+" "+path] {
+				t.Errorf("openapi.json documents %s %s, which is not a route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	srv, ts := testServer(t)
+	addToken(t, srv, "reader", model.ScopeRead)
+
+	// The document is served without a token, like the health check.
+	resp, err := http.Get(ts.URL + "/api/v1/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("status = %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !bytes.Equal(body, openAPISpec) {
+		t.Error("served document differs from openapi.json")
+	}
+	var v map[string]any
+	if err := json.Unmarshal(body, &v); err != nil || v["openapi"] != "3.0.3" {
+		t.Errorf("document: openapi = %v, %v", v["openapi"], err)
+	}
+}
+
+// exchange is one request RemoteStore made, with the server's response.
+type exchange struct {
+	method, path string
+	query        map[string][]string
+	reqBody      []byte
+	reqType      string
+	status       int
+	respType     string
+	respBody     bytes.Buffer
+	done         bool
+}
+
+// recorder tees every request and response through to the test.
+type recorder struct {
+	next http.Handler
+	mu   sync.Mutex
+	log  []*exchange
+}
+
+type teeWriter struct {
+	http.ResponseWriter
+	ex *exchange
+	mu *sync.Mutex
+}
+
+func (w *teeWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	w.ex.status = status
+	w.ex.respType = w.Header().Get("Content-Type")
+	w.mu.Unlock()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *teeWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if w.ex.status == 0 {
+		w.ex.status = http.StatusOK
+		w.ex.respType = w.Header().Get("Content-Type")
+	}
+	w.ex.respBody.Write(p)
+	w.mu.Unlock()
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *teeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	ex := &exchange{method: r.Method, path: r.URL.Path, query: r.URL.Query(), reqBody: body, reqType: r.Header.Get("Content-Type")}
+	rec.mu.Lock()
+	rec.log = append(rec.log, ex)
+	rec.mu.Unlock()
+	rec.next.ServeHTTP(&teeWriter{ResponseWriter: w, ex: ex, mu: &rec.mu}, r)
+	rec.mu.Lock()
+	ex.done = true
+	rec.mu.Unlock()
+}
+
+// since returns the exchanges after the first n, once their handlers finish.
+func (rec *recorder) since(t *testing.T, n int) []*exchange {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec.mu.Lock()
+		exs := rec.log[n:]
+		done := true
+		for _, ex := range exs {
+			done = done && ex.done
+		}
+		rec.mu.Unlock()
+		if done || time.Now().After(deadline) {
+			return exs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (rec *recorder) count() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return len(rec.log)
+}
+
+// TestRemoteStoreContract calls every RemoteStore method against a real
+// server and checks each request against openapi.json: the route must be
+// documented, its query parameters declared, and both bodies must match
+// their schemas.
+func TestRemoteStoreContract(t *testing.T) {
+	srv, _ := testServer(t)
+	doc := loadOpenAPI(t)
+	rec := &recorder{next: srv.Handler()}
+	ts := httptest.NewServer(rec)
+	t.Cleanup(ts.Close)
+
+	ctx := context.Background()
+	now := time.Now().UTC().Add(-time.Minute)
+	seed := srv.store
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(seed.RecordDesire(ctx, model.Desire{ID: "seed-d", ToolName: "read_file", Error: "unknown tool", Source: "claude-code",
+		ToolInput: json.RawMessage(`{"path":"a"}`), Timestamp: now}))
+	must(seed.RecordInvocation(ctx, model.Invocation{ID: "seed-i", Source: "claude-code", InstanceID: "s1", ToolName: "Read",
+		IsError: true, Error: "boom", Timestamp: now, TurnID: "t1", TurnSequence: 1, TurnLength: 2}))
+	must(seed.RecordInvocation(ctx, model.Invocation{ID: "seed-j", Source: "claude-code", InstanceID: "s1", ToolName: "Edit",
+		Timestamp: now, TurnID: "t1", TurnSequence: 2, TurnLength: 2}))
+	must(seed.SetDocMapping(ctx, model.DocMapping{ID: "seed-m", Pattern: "Read", Tool: "Read", DocPath: "docs/read.md"}))
+
+	r := store.NewRemote(ts.URL)
+	alias := model.Alias{From: "read_file", To: "Read"}
+	rule := model.Alias{From: "-r", To: "-R", Tool: "Bash", Param: "command", Command: "scp", MatchKind: "flag"}
+	calls := map[string]func() error{
+		"RecordDesire": func() error {
+			return r.RecordDesire(ctx, model.Desire{ID: "c-d1", ToolName: "grep_files", Error: "x", Timestamp: now})
+		},
+		"RecordDesires": func() error {
+			return r.RecordDesires(ctx, []model.Desire{{ID: "c-d2", ToolName: "a", Error: "x", Timestamp: now}, {ID: "c-d3", ToolName: "b", Error: "x", Timestamp: now}})
+		},
+		"ListDesires": func() error {
+			_, err := r.ListDesires(ctx, store.ListOpts{Since: now.Add(-time.Hour), Source: "claude-code", ToolName: "read_file", Limit: 5})
+			return err
+		},
+		"GetPaths": func() error {
+			_, err := r.GetPaths(ctx, store.PathOpts{Top: 5, Since: now.Add(-time.Hour)})
+			return err
+		},
+		"PathTrends": func() error {
+			_, err := r.PathTrends(ctx, store.TrendOpts{Bucket: store.BucketHour, Buckets: 3, Until: now.Add(time.Hour), Top: 5})
+			return err
+		},
+		"SetAlias":        func() error { return errors.Join(r.SetAlias(ctx, alias), r.SetAlias(ctx, rule)) },
+		"GetAlias":        func() error { _, err := r.GetAlias(ctx, rule.Key()); return err },
+		"GetToolAliases":  func() error { _, err := r.GetToolAliases(ctx, alias.From); return err },
+		"GetAliases":      func() error { _, err := r.GetAliases(ctx); return err },
+		"DeleteAlias":     func() error { _, err := r.DeleteAlias(ctx, model.AliasKey{From: alias.From}); return err },
+		"GetRulesForTool": func() error { _, err := r.GetRulesForTool(ctx, "Bash"); return err },
+		"Stats":           func() error { _, err := r.Stats(ctx); return err },
+		"InspectPath": func() error {
+			_, err := r.InspectPath(ctx, store.InspectOpts{Pattern: "read_file", Since: now.Add(-time.Hour), TopN: 3})
+			return err
+		},
+		"RecordInvocation": func() error {
+			return r.RecordInvocation(ctx, model.Invocation{ID: "c-i1", Source: "claude-code", ToolName: "Read", Timestamp: now})
+		},
+		"RecordInvocations": func() error {
+			return r.RecordInvocations(ctx, []model.Invocation{{ID: "c-i2", Source: "claude-code", ToolName: "Grep", Timestamp: now}})
+		},
+		"ListInvocations": func() error {
+			_, err := r.ListInvocations(ctx, store.InvocationOpts{Since: now.Add(-time.Hour), Source: "claude-code", InstanceID: "s1", ToolName: "Read", ErrorsOnly: true, Limit: 5})
+			return err
+		},
+		"InvocationStats": func() error { _, err := r.InvocationStats(ctx); return err },
+		"ListTurns": func() error {
+			_, err := r.ListTurns(ctx, store.TurnOpts{MinLength: 1, Since: now.Add(-time.Hour), SessionID: "s1", Pattern: "Read → Edit", Limit: 5})
+			return err
+		},
+		"TurnPatternStats": func() error {
+			_, err := r.TurnPatternStats(ctx, store.TurnOpts{MinLength: 1, Since: now.Add(-time.Hour), Limit: 5})
+			return err
+		},
+		"ToolTurnStats": func() error {
+			_, err := r.ToolTurnStats(ctx, store.TurnOpts{MinLength: 1, Since: now.Add(-time.Hour), Limit: 5})
+			return err
+		},
+		"DetectAndRecordRecovery": func() error {
+			return r.DetectAndRecordRecovery(ctx, model.Invocation{ID: "c-i3", Source: "claude-code", ToolName: "Read", Timestamp: time.Now().UTC()})
+		},
+		"ListRecoveries": func() error {
+			_, err := r.ListRecoveries(ctx, store.RecoveryOpts{Since: now.Add(-time.Hour), Limit: 5})
+			return err
+		},
+		"RecoveryStats": func() error { _, err := r.RecoveryStats(ctx); return err },
+		"TrackRetryChain": func() error {
+			inv := model.Invocation{ID: "c-i4", Source: "claude-code", InstanceID: "c-s2", ToolName: "Bash", IsError: true, Error: "exit 1", Timestamp: time.Now().UTC()}
+			return r.TrackRetryChain(ctx, inv, json.RawMessage(`{"command":"scp -r a b:"}`))
+		},
+		"ListRetryChains": func() error {
+			// Close a chain of its own so there is one to list.
+			fail := model.Invocation{ID: "c-i5", Source: "claude-code", InstanceID: "c-s3", ToolName: "Bash", IsError: true, Error: "exit 1", Timestamp: time.Now().UTC()}
+			ok := model.Invocation{ID: "c-i6", Source: "claude-code", InstanceID: "c-s3", ToolName: "Bash", Timestamp: time.Now().UTC()}
+			if err := errors.Join(
+				r.TrackRetryChain(ctx, fail, json.RawMessage(`{"command":"scp -r a b:"}`)),
+				r.TrackRetryChain(ctx, ok, json.RawMessage(`{"command":"scp -R a b:"}`)),
+			); err != nil {
+				return err
+			}
+			_, err := r.ListRetryChains(ctx, store.ChainOpts{Since: now.Add(-time.Hour), Outcome: model.ChainRecovered, Limit: 5})
+			return err
+		},
+		"SetDocMapping": func() error {
+			return r.SetDocMapping(ctx, model.DocMapping{ID: "c-m1", Pattern: "Grep", DocPath: "docs/grep.md"})
+		},
+		"GetDocMappings":         func() error { _, err := r.GetDocMappings(ctx); return err },
+		"DeleteDocMapping":       func() error { _, err := r.DeleteDocMapping(ctx, "c-m1"); return err },
+		"SuggestDocs":            func() error { _, err := r.SuggestDocs(ctx, "Read", "boom"); return err },
+		"IncrementDocMatchCount": func() error { return r.IncrementDocMatchCount(ctx, "seed-m") },
+		"StrugglingTools": func() error {
+			_, err := r.StrugglingTools(ctx, store.StrugglingOpts{Since: now.Add(-time.Hour), MinFails: 1, SessionID: "s1", Limit: 5})
+			return err
+		},
+		"RecordIntervention": func() error {
+			return r.RecordIntervention(ctx, model.Intervention{ID: "c-v1", Kind: model.InterventionBlock, ToolName: "read_file", Rule: "read_file", Timestamp: now})
+		},
+		"InterventionStats": func() error { _, err := r.InterventionStats(ctx, now.Add(-time.Hour)); return err },
+		"SourceToolCounts":  func() error { _, err := r.SourceToolCounts(ctx); return err },
+		"StreamEvents": func() error {
+			sctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+			defer cancel()
+			r.StreamEvents(sctx, events.Filter{Types: []string{"desire"}, ToolName: "Read", Source: "claude-code", SessionID: "s1"},
+				func(events.Event) error { return nil })
+			return nil
+		},
+
+		// Tokens live in the server's database and never go over the API.
+		"CreateToken":       nil,
+		"ListTokens":        nil,
+		"RevokeToken":       nil,
+		"AuthenticateToken": nil,
+		"Close":             nil,
+	}
+
+	// Every Store method must be covered, so a new one cannot skip the
+	// contract.
+	storeType := reflect.TypeOf((*store.Store)(nil)).Elem()
+	order := []string{}
+	for i := range storeType.NumMethod() {
+		name := storeType.Method(i).Name
+		if _, ok := calls[name]; !ok {
+			t.Errorf("Store method %s has no contract call", name)
+		}
+		order = append(order, name)
+	}
+	order = append(order, "StreamEvents")
+	// Writes first, so the reads return data; deletes last.
+	sort.SliceStable(order, func(i, j int) bool { return rank(order[i]) < rank(order[j]) })
+
+	for _, name := range order {
+		call := calls[name]
+		if call == nil {
+			continue
+		}
+		n := rec.count()
+		if err := call(); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		exs := rec.since(t, n)
+		if len(exs) == 0 {
+			t.Errorf("%s made no request", name)
+		}
+		for _, ex := range exs {
+			checkExchange(t, doc, name, ex)
+		}
+	}
+}
+
+func rank(method string) int {
+	switch {
+	case strings.HasPrefix(method, "Record"), strings.HasPrefix(method, "Set"), strings.HasPrefix(method, "Detect"):
+		return 0
+	case strings.HasPrefix(method, "Delete"):
+		return 2
+	}
+	return 1
+}
+
+// checkExchange checks one request and its response against the document.
+func checkExchange(t *testing.T, doc *openAPI, method string, ex *exchange) {
+	t.Helper()
+	where := fmt.Sprintf("%s: %s %s", method, ex.method, ex.path)
+	tmpl, op := doc.operation(ex.method, ex.path)
+	if op == nil {
+		t.Errorf("%s: not documented", where)
+		return
+	}
+	declared := doc.queryParams(op)
+	for name := range ex.query {
+		if !declared[name] {
+			t.Errorf("%s: query parameter %q is not documented for %s", where, name, tmpl)
+		}
+	}
+
+	if len(ex.reqBody) > 0 {
+		if op.RequestBody == nil {
+			t.Errorf("%s: sends a body, but none is documented", where)
+		} else if media, ok := op.RequestBody.Content[mediaType(ex.reqType)]; !ok {
+			t.Errorf("%s: request content type %q is not documented", where, ex.reqType)
+		} else if mediaType(ex.reqType) == "application/json" {
+			var v any
+			if err := json.Unmarshal(ex.reqBody, &v); err != nil {
+				t.Errorf("%s: request body: %v", where, err)
+			}
+			for _, e := range doc.validate(media.Schema, v, "request") {
+				t.Errorf("%s: %s", where, e)
+			}
+		}
+	}
+
+	resp, ok := op.Responses[strconv.Itoa(ex.status)]
+	if !ok {
+		t.Errorf("%s: response status %d is not documented", where, ex.status)
+		return
+	}
+	if ex.status >= 400 {
+		t.Errorf("%s: status %d: %s", where, ex.status, ex.respBody.String())
+	}
+	media, ok := resp.Content[mediaType(ex.respType)]
+	if !ok {
+		t.Errorf("%s: response content type %q is not documented", where, ex.respType)
+		return
+	}
+	if mediaType(ex.respType) != "application/json" {
+		return
+	}
+	var v any
+	if err := json.Unmarshal(ex.respBody.Bytes(), &v); err != nil {
+		t.Errorf("%s: response body: %v", where, err)
+		return
+	}
+	for _, e := range doc.validate(media.Schema, v, "response") {
+		t.Errorf("%s: %s", where, e)
+	}
+}
+
+func mediaType(contentType string) string {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return mt
+}
+
+func TestOpenAPIValidate(t *testing.T) {
+	// The validator must reject what the contract test relies on it to
+	// catch.
+	doc := loadOpenAPI(t)
+	desire := map[string]any{"$ref": "#/components/schemas/Desire"}
+	tests := []struct {
+		name string
+		v    string
+		ok   bool
+	}{
+		{"valid", `{"id":"1","tool_name":"Read","error":"x","timestamp":"2026-10-18T09:00:00Z"}`, true},
+		{"missing required", `{"id":"1","tool_name":"Read","timestamp":"2026-10-18T09:00:00Z"}`, false},
+		{"undocumented property", `{"id":"1","tool_name":"Read","error":"x","timestamp":"2026-10-18T09:00:00Z","extra":1}`, false},
+		{"wrong type", `{"id":1,"tool_name":"Read","error":"x","timestamp":"2026-10-18T09:00:00Z"}`, false},
+		{"bad date-time", `{"id":"1","tool_name":"Read","error":"x","timestamp":"yesterday"}`, false},
+	}
+	for _, tt := range tests {
+		var v any
+		if err := json.Unmarshal([]byte(tt.v), &v); err != nil {
+			t.Fatal(err)
+		}
+		errs := doc.validate(desire, v, "desire")
+		if (len(errs) == 0) != tt.ok {
+			t.Errorf("%s: errors = %v", tt.name, errs)
+		}
+	}
+}
@@ -2,10 +2,12 @@ package server
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/model"
 	"github.com/scbrown/desire-path/internal/store"
 )
 
@@ -66,7 +68,9 @@ func parseListOpts(r *http.Request) (store.ListOpts, error) {
 		Since:    since,
 		Source:   r.URL.Query().Get("source"),
 		ToolName: r.URL.Query().Get("tool"),
+		Model:    r.URL.Query().Get("model"),
 		Limit:    limit,
+		Cursor:   r.URL.Query().Get("cursor"),
 	}, nil
 }
 
@@ -79,9 +83,71 @@ func parsePathOpts(r *http.Request) (store.PathOpts, error) {
 	if err != nil {
 		return store.PathOpts{}, err
 	}
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy != "" && sortBy != store.PathSortCount && sortBy != store.PathSortImpact {
+		return store.PathOpts{}, fmt.Errorf("invalid sort value %q: expected %s or %s", sortBy, store.PathSortCount, store.PathSortImpact)
+	}
+	w := store.DefaultImpactWeights
+	for key, dst := range map[string]*float64{
+		"session_weight": &w.Sessions,
+		"retry_weight":   &w.Retries,
+		"half_life_days": &w.HalfLifeDays,
+	} {
+		f, err := parseFloat(r, key)
+		if err != nil {
+			return store.PathOpts{}, err
+		}
+		if f != nil {
+			*dst = *f
+		}
+	}
 	return store.PathOpts{
-		Top:   top,
-		Since: since,
+		Top:     top,
+		Since:   since,
+		Sort:    sortBy,
+		Weights: w,
+		Score:   parseBool(r, "score"),
+	}, nil
+}
+
+// parseFloat returns the non-negative number in query parameter key, or nil
+// if it is absent.
+func parseFloat(r *http.Request, key string) (*float64, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("invalid %s value %q: expected a non-negative number", key, s)
+	}
+	return &f, nil
+}
+
+func parseTrendOpts(r *http.Request) (store.TrendOpts, error) {
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := store.BucketSize(bucket); err != nil {
+		return store.TrendOpts{}, err
+	}
+	buckets, err := parseInt(r, "buckets")
+	if err != nil {
+		return store.TrendOpts{}, err
+	}
+	top, err := parseInt(r, "top")
+	if err != nil {
+		return store.TrendOpts{}, err
+	}
+	var until time.Time
+	if s := r.URL.Query().Get("until"); s != "" {
+		if until, err = time.Parse(time.RFC3339, s); err != nil {
+			return store.TrendOpts{}, fmt.Errorf("invalid until value %q: expected RFC3339 timestamp", s)
+		}
+	}
+	return store.TrendOpts{
+		Bucket:  bucket,
+		Buckets: buckets,
+		Until:   until,
+		Top:     top,
 	}, nil
 }
 
@@ -124,6 +190,7 @@ func parseTurnOpts(r *http.Request) (store.TurnOpts, error) {
 		SessionID: r.URL.Query().Get("session"),
 		Pattern:   r.URL.Query().Get("pattern"),
 		Limit:     limit,
+		Cursor:    r.URL.Query().Get("cursor"),
 	}, nil
 }
 
@@ -141,7 +208,71 @@ func parseInvocationOpts(r *http.Request) (store.InvocationOpts, error) {
 		Source:     r.URL.Query().Get("source"),
 		InstanceID: r.URL.Query().Get("instance_id"),
 		ToolName:   r.URL.Query().Get("tool"),
+		Model:      r.URL.Query().Get("model"),
 		ErrorsOnly: parseBool(r, "errors_only"),
 		Limit:      limit,
+		Cursor:     r.URL.Query().Get("cursor"),
+	}, nil
+}
+
+func parseRecoveryOpts(r *http.Request) (store.RecoveryOpts, error) {
+	since, err := parseSince(r)
+	if err != nil {
+		return store.RecoveryOpts{}, err
+	}
+	limit, err := parseInt(r, "limit")
+	if err != nil {
+		return store.RecoveryOpts{}, err
+	}
+	return store.RecoveryOpts{
+		Since:  since,
+		Limit:  limit,
+		Cursor: r.URL.Query().Get("cursor"),
+	}, nil
+}
+
+func parseChainOpts(r *http.Request) (store.ChainOpts, error) {
+	since, err := parseSince(r)
+	if err != nil {
+		return store.ChainOpts{}, err
+	}
+	limit, err := parseInt(r, "limit")
+	if err != nil {
+		return store.ChainOpts{}, err
+	}
+	outcome := r.URL.Query().Get("outcome")
+	switch outcome {
+	case "", model.ChainOpen, model.ChainRecovered, model.ChainAbandoned:
+	default:
+		return store.ChainOpts{}, fmt.Errorf("invalid outcome value %q: expected open, recovered, or abandoned", outcome)
+	}
+	return store.ChainOpts{
+		Since:     since,
+		ToolName:  r.URL.Query().Get("tool"),
+		SessionID: r.URL.Query().Get("session"),
+		Outcome:   outcome,
+		Limit:     limit,
+		Cursor:    r.URL.Query().Get("cursor"),
+	}, nil
+}
+
+func parseStrugglingOpts(r *http.Request) (store.StrugglingOpts, error) {
+	since, err := parseSince(r)
+	if err != nil {
+		return store.StrugglingOpts{}, err
+	}
+	minFails, err := parseInt(r, "min_fails")
+	if err != nil {
+		return store.StrugglingOpts{}, err
+	}
+	limit, err := parseInt(r, "limit")
+	if err != nil {
+		return store.StrugglingOpts{}, err
+	}
+	return store.StrugglingOpts{
+		Since:     since,
+		MinFails:  minFails,
+		SessionID: r.URL.Query().Get("session"),
+		Limit:     limit,
 	}, nil
 }
@@ -5,12 +5,15 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/events"
 	"github.com/scbrown/desire-path/internal/ingest"
 	"github.com/scbrown/desire-path/internal/model"
 	"github.com/scbrown/desire-path/internal/store"
@@ -18,47 +21,100 @@ import (
 
 // Server wraps a store.Store and exposes it over HTTP.
 type Server struct {
-	store store.Store
-	mux   *http.ServeMux
-	srv   *http.Server
+	store   store.Store
+	mux     *http.ServeMux
+	handler http.Handler
+	srv     *http.Server
+	bus     *events.Bus
+	metrics *requestMetrics
+
+	patterns []string // API routes, as registered by routes()
+
+	workspaces *store.Workspaces     // nil: only the default workspace
+	wsMu       sync.Mutex            // guards wsOpen
+	wsOpen     map[string]*workspace // workspaces opened so far, by name
+
+	quit     chan struct{} // closed on Shutdown to end event streams
+	quitOnce sync.Once
 }
 
-// New creates a Server that delegates to the given store.
+// New creates a Server that delegates to the given store. Requests are
+// authenticated with bearer tokens from the store once any exist. If the
+// store can publish events (as SQLiteStore can), they are streamed at
+// /api/v1/events. Prometheus metrics are served at /metrics. SetWorkspaces
+// adds further workspaces, each with its own store.
 func New(s store.Store) *Server {
-	srv := &Server{store: s, mux: http.NewServeMux()}
+	srv := &Server{
+		store:   s,
+		mux:     http.NewServeMux(),
+		bus:     events.NewBus(),
+		metrics: newRequestMetrics(),
+		quit:    make(chan struct{}),
+	}
+	if p, ok := s.(interface{ SetEventBus(*events.Bus) }); ok {
+		p.SetEventBus(srv.bus)
+	}
 	srv.routes()
+	srv.handler = srv.auth(srv.routeWorkspace(srv.instrument(srv.mux)))
 	return srv
 }
 
+// routes registers the API. Every route must be described in openapi.json;
+// TestOpenAPIRoutes checks this against s.patterns.
 func (s *Server) routes() {
-	s.mux.HandleFunc("POST /api/v1/ingest", s.handleIngest)
-	s.mux.HandleFunc("POST /api/v1/desires", s.handleRecordDesire)
-	s.mux.HandleFunc("GET /api/v1/desires", s.handleListDesires)
-	s.mux.HandleFunc("GET /api/v1/paths", s.handleGetPaths)
-	s.mux.HandleFunc("POST /api/v1/aliases", s.handleSetAlias)
-	s.mux.HandleFunc("GET /api/v1/aliases", s.handleGetAliases)
-	s.mux.HandleFunc("GET /api/v1/aliases/rules", s.handleGetRulesForTool)
-	s.mux.HandleFunc("GET /api/v1/aliases/{from}", s.handleGetAlias)
-	s.mux.HandleFunc("DELETE /api/v1/aliases/{from}", s.handleDeleteAlias)
-	s.mux.HandleFunc("GET /api/v1/stats", s.handleStats)
-	s.mux.HandleFunc("GET /api/v1/inspect", s.handleInspectPath)
-	s.mux.HandleFunc("POST /api/v1/invocations", s.handleRecordInvocation)
-	s.mux.HandleFunc("GET /api/v1/invocations", s.handleListInvocations)
-	s.mux.HandleFunc("GET /api/v1/invocations/stats", s.handleInvocationStats)
-	s.mux.HandleFunc("GET /api/v1/turns", s.handleListTurns)
-	s.mux.HandleFunc("GET /api/v1/turns/patterns", s.handleTurnPatterns)
-	s.mux.HandleFunc("GET /api/v1/turns/tool-stats", s.handleToolTurnStats)
-	s.mux.HandleFunc("POST /api/v1/recoveries/detect", s.handleDetectRecovery)
-	s.mux.HandleFunc("GET /api/v1/recoveries", s.handleListRecoveries)
-	s.mux.HandleFunc("GET /api/v1/recoveries/stats", s.handleRecoveryStats)
-	s.mux.HandleFunc("GET /api/v1/health", s.handleHealth)
+	s.handle("POST /api/v1/ingest", s.handleIngest)
+	s.handle("POST /api/v1/ingest/batch", s.handleIngestBatch)
+	s.handle("POST /api/v1/desires", s.handleRecordDesire)
+	s.handle("POST /api/v1/desires/batch", s.handleRecordDesires)
+	s.handle("GET /api/v1/desires", s.handleListDesires)
+	s.handle("GET /api/v1/paths", s.handleGetPaths)
+	s.handle("GET /api/v1/paths/trends", s.handlePathTrends)
+	s.handle("POST /api/v1/aliases", s.handleSetAlias)
+	s.handle("GET /api/v1/aliases", s.handleGetAliases)
+	s.handle("GET /api/v1/aliases/rules", s.handleGetRulesForTool)
+	s.handle("GET /api/v1/aliases/{from}", s.handleGetAlias)
+	s.handle("DELETE /api/v1/aliases/{from}", s.handleDeleteAlias)
+	s.handle("GET /api/v1/stats", s.handleStats)
+	s.handle("GET /api/v1/inspect", s.handleInspectPath)
+	s.handle("POST /api/v1/invocations", s.handleRecordInvocation)
+	s.handle("POST /api/v1/invocations/batch", s.handleRecordInvocations)
+	s.handle("GET /api/v1/invocations", s.handleListInvocations)
+	s.handle("GET /api/v1/invocations/stats", s.handleInvocationStats)
+	s.handle("GET /api/v1/turns", s.handleListTurns)
+	s.handle("GET /api/v1/turns/patterns", s.handleTurnPatterns)
+	s.handle("GET /api/v1/turns/tool-stats", s.handleToolTurnStats)
+	s.handle("POST /api/v1/recoveries/detect", s.handleDetectRecovery)
+	s.handle("GET /api/v1/recoveries", s.handleListRecoveries)
+	s.handle("GET /api/v1/recoveries/stats", s.handleRecoveryStats)
+	s.handle("POST /api/v1/chains/track", s.handleTrackRetryChain)
+	s.handle("GET /api/v1/chains", s.handleListRetryChains)
+	s.handle("GET /api/v1/struggling", s.handleStrugglingTools)
+	s.handle("GET /api/v1/counts", s.handleSourceToolCounts)
+	s.handle("POST /api/v1/interventions", s.handleRecordIntervention)
+	s.handle("GET /api/v1/interventions/stats", s.handleInterventionStats)
+	s.handle("POST /api/v1/doc-mappings", s.handleSetDocMapping)
+	s.handle("GET /api/v1/doc-mappings", s.handleGetDocMappings)
+	s.handle("GET /api/v1/doc-mappings/suggest", s.handleSuggestDocs)
+	s.handle("POST /api/v1/doc-mappings/delete", s.handleDeleteDocMapping)
+	s.handle("POST /api/v1/doc-mappings/match", s.handleDocMatch)
+	s.handle("GET /api/v1/events", s.handleEvents)
+	s.handle("GET /api/v1/health", s.handleHealth)
+	s.handle("GET /api/v1/openapi.json", s.handleOpenAPI)
+	s.handle("GET /metrics", s.handleMetrics)
+	s.dashboardRoutes()
+}
+
+// handle registers an API route and remembers its pattern.
+func (s *Server) handle(pattern string, h http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, h)
+	s.patterns = append(s.patterns, pattern)
 }
 
 // ListenAndServe starts the HTTP server on the given address.
 func (s *Server) ListenAndServe(addr string) error {
 	s.srv = &http.Server{
 		Addr:         addr,
-		Handler:      s.mux,
+		Handler:      s.handler,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
@@ -68,7 +124,7 @@ func (s *Server) ListenAndServe(addr string) error {
 // Serve accepts connections on the given listener.
 func (s *Server) Serve(ln net.Listener) error {
 	s.srv = &http.Server{
-		Handler:      s.mux,
+		Handler:      s.handler,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
@@ -77,11 +133,13 @@ func (s *Server) Serve(ln net.Listener) error {
 
 // Handler returns the HTTP handler for use with httptest.Server or custom listeners.
 func (s *Server) Handler() http.Handler {
-	return s.mux
+	return s.handler
 }
 
 // Shutdown gracefully shuts down the server.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.quitOnce.Do(func() { close(s.quit) })
+	defer s.closeWorkspaces()
 	if s.srv == nil {
 		return nil
 	}
@@ -103,42 +161,73 @@ func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "reading request body: %v", err)
 		return
 	}
-	inv, err := ingest.Ingest(r.Context(), s.store, raw, sourceName)
+	inv, err := ingest.Ingest(r.Context(), s.storeFor(r), raw, sourceName)
 	if err != nil {
-		writeErr(w, http.StatusUnprocessableEntity, "ingest: %v", err)
+		writeErr(w, ingestStatus(err), "ingest: %v", err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, inv)
 }
 
+// handleIngestBatch ingests an NDJSON body of source payloads, one per
+// line, and reports the outcome of each line.
+func (s *Server) handleIngestBatch(w http.ResponseWriter, r *http.Request) {
+	sourceName := r.URL.Query().Get("source")
+	if sourceName == "" {
+		writeErr(w, http.StatusBadRequest, "source query parameter is required")
+		return
+	}
+	res, err := ingest.IngestBatch(r.Context(), s.storeFor(r), r.Body, sourceName)
+	if err != nil {
+		writeErr(w, ingestStatus(err), "ingest batch: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
 func (s *Server) handleRecordDesire(w http.ResponseWriter, r *http.Request) {
 	var d model.Desire
 	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
 		writeErr(w, http.StatusBadRequest, "invalid request body: %v", err)
 		return
 	}
-	if err := s.store.RecordDesire(r.Context(), d); err != nil {
-		writeErr(w, http.StatusInternalServerError, "recording desire: %v", err)
+	d.ClientID = clientID(r.Context())
+	if err := s.storeOf(r).RecordDesire(r.Context(), d); err != nil {
+		writeErr(w, recordStatus(err), "recording desire: %v", err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, d)
 }
 
+func (s *Server) handleRecordDesires(w http.ResponseWriter, r *http.Request) {
+	var ds []model.Desire
+	if err := json.NewDecoder(r.Body).Decode(&ds); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+	client := clientID(r.Context())
+	for i := range ds {
+		ds[i].ClientID = client
+	}
+	if err := s.storeOf(r).RecordDesires(r.Context(), ds); err != nil {
+		writeErr(w, http.StatusInternalServerError, "recording desires: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]int{"recorded": len(ds)})
+}
+
 func (s *Server) handleListDesires(w http.ResponseWriter, r *http.Request) {
 	opts, err := parseListOpts(r)
 	if err != nil {
 		writeErr(w, http.StatusBadRequest, "%v", err)
 		return
 	}
-	desires, err := s.store.ListDesires(r.Context(), opts)
+	desires, err := s.storeOf(r).ListDesires(r.Context(), opts)
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, "listing desires: %v", err)
+		writeErr(w, listStatus(err), "listing desires: %v", err)
 		return
 	}
-	if desires == nil {
-		desires = []model.Desire{}
-	}
-	writeJSON(w, http.StatusOK, desires)
+	writeList(w, r, desires, opts.Limit, store.DesireCursor)
 }
 
 func (s *Server) handleGetPaths(w http.ResponseWriter, r *http.Request) {
@@ -147,7 +236,7 @@ func (s *Server) handleGetPaths(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "%v", err)
 		return
 	}
-	paths, err := s.store.GetPaths(r.Context(), opts)
+	paths, err := s.storeOf(r).GetPaths(r.Context(), opts)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "getting paths: %v", err)
 		return
@@ -158,17 +247,45 @@ func (s *Server) handleGetPaths(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, paths)
 }
 
+func (s *Server) handlePathTrends(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseTrendOpts(r)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	res, err := s.storeOf(r).PathTrends(r.Context(), opts)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "getting path trends: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
 func (s *Server) handleSetAlias(w http.ResponseWriter, r *http.Request) {
 	var alias model.Alias
 	if err := json.NewDecoder(r.Body).Decode(&alias); err != nil {
 		writeErr(w, http.StatusBadRequest, "invalid request body: %v", err)
 		return
 	}
-	if alias.From == "" || alias.To == "" {
+	if alias.From == "" || (alias.To == "" && !alias.IsDeny()) {
 		writeErr(w, http.StatusBadRequest, "both 'from' and 'to' fields are required")
 		return
 	}
-	if err := s.store.SetAlias(r.Context(), alias); err != nil {
+	st := s.storeOf(r)
+	if !tokenAllows(r.Context(), model.ScopeAdmin) {
+		// Changing an existing alias is as destructive as deleting it.
+		old, err := st.GetAlias(r.Context(), alias.Key())
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "getting alias: %v", err)
+			return
+		}
+		if old != nil && !old.Same(alias) {
+			writeErr(w, http.StatusForbidden, "token %q cannot change existing alias %q; that requires %q",
+				clientID(r.Context()), alias.From, model.ScopeAdmin)
+			return
+		}
+	}
+	if err := st.SetAlias(r.Context(), alias); err != nil {
 		writeErr(w, http.StatusInternalServerError, "setting alias: %v", err)
 		return
 	}
@@ -176,7 +293,13 @@ func (s *Server) handleSetAlias(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleGetAliases(w http.ResponseWriter, r *http.Request) {
-	aliases, err := s.store.GetAliases(r.Context())
+	var aliases []model.Alias
+	var err error
+	if from := r.URL.Query().Get("from"); from != "" {
+		aliases, err = s.storeOf(r).GetToolAliases(r.Context(), from)
+	} else {
+		aliases, err = s.storeOf(r).GetAliases(r.Context())
+	}
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "getting aliases: %v", err)
 		return
@@ -193,11 +316,7 @@ func (s *Server) handleGetAlias(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "alias name is required")
 		return
 	}
-	tool := r.URL.Query().Get("tool")
-	param := r.URL.Query().Get("param")
-	command := r.URL.Query().Get("command")
-	matchKind := r.URL.Query().Get("match_kind")
-	alias, err := s.store.GetAlias(r.Context(), from, tool, param, command, matchKind)
+	alias, err := s.storeOf(r).GetAlias(r.Context(), aliasKeyOf(r, from))
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "getting alias: %v", err)
 		return
@@ -215,11 +334,7 @@ func (s *Server) handleDeleteAlias(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "alias name is required")
 		return
 	}
-	tool := r.URL.Query().Get("tool")
-	param := r.URL.Query().Get("param")
-	command := r.URL.Query().Get("command")
-	matchKind := r.URL.Query().Get("match_kind")
-	deleted, err := s.store.DeleteAlias(r.Context(), from, tool, param, command, matchKind)
+	deleted, err := s.storeOf(r).DeleteAlias(r.Context(), aliasKeyOf(r, from))
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "deleting alias: %v", err)
 		return
@@ -231,13 +346,28 @@ func (s *Server) handleDeleteAlias(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]bool{"deleted": true})
 }
 
+// aliasKeyOf reads an alias key from the request's query parameters.
+func aliasKeyOf(r *http.Request, from string) model.AliasKey {
+	q := r.URL.Query()
+	return model.AliasKey{
+		From:        from,
+		Tool:        q.Get("tool"),
+		Param:       q.Get("param"),
+		Command:     q.Get("command"),
+		MatchKind:   q.Get("match_kind"),
+		ScopeCWD:    q.Get("scope_cwd"),
+		ScopeRemote: q.Get("scope_remote"),
+		ScopeSource: q.Get("scope_source"),
+	}
+}
+
 func (s *Server) handleGetRulesForTool(w http.ResponseWriter, r *http.Request) {
 	tool := r.URL.Query().Get("tool")
 	if tool == "" {
 		writeErr(w, http.StatusBadRequest, "tool query parameter is required")
 		return
 	}
-	rules, err := s.store.GetRulesForTool(r.Context(), tool)
+	rules, err := s.storeOf(r).GetRulesForTool(r.Context(), tool)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "getting rules: %v", err)
 		return
@@ -249,7 +379,7 @@ func (s *Server) handleGetRulesForTool(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := s.store.Stats(r.Context())
+	stats, err := s.storeOf(r).Stats(r.Context())
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "getting stats: %v", err)
 		return
@@ -263,7 +393,7 @@ func (s *Server) handleInspectPath(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "%v", err)
 		return
 	}
-	result, err := s.store.InspectPath(r.Context(), opts)
+	result, err := s.storeOf(r).InspectPath(r.Context(), opts)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "inspecting path: %v", err)
 		return
@@ -277,32 +407,58 @@ func (s *Server) handleRecordInvocation(w http.ResponseWriter, r *http.Request)
 		writeErr(w, http.StatusBadRequest, "invalid request body: %v", err)
 		return
 	}
-	if err := s.store.RecordInvocation(r.Context(), inv); err != nil {
-		writeErr(w, http.StatusInternalServerError, "recording invocation: %v", err)
+	inv.ClientID = clientID(r.Context())
+	if err := s.storeOf(r).RecordInvocation(r.Context(), inv); err != nil {
+		writeErr(w, recordStatus(err), "recording invocation: %v", err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, inv)
 }
 
+func (s *Server) handleRecordInvocations(w http.ResponseWriter, r *http.Request) {
+	var invs []model.Invocation
+	if err := json.NewDecoder(r.Body).Decode(&invs); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+	client := clientID(r.Context())
+	for i := range invs {
+		invs[i].ClientID = client
+	}
+	st := s.storeOf(r)
+	if err := st.RecordInvocations(r.Context(), invs); err != nil {
+		writeErr(w, http.StatusInternalServerError, "recording invocations: %v", err)
+		return
+	}
+	// Hybrid clients sync their invocations only through this endpoint, so
+	// their recoveries and retry chains are derived here.
+	if d, ok := st.(interface {
+		DetectInvocations(context.Context, []model.Invocation) error
+	}); ok {
+		if err := d.DetectInvocations(r.Context(), invs); err != nil {
+			writeErr(w, http.StatusInternalServerError, "detecting recoveries: %v", err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusCreated, map[string]int{"recorded": len(invs)})
+}
+
 func (s *Server) handleListInvocations(w http.ResponseWriter, r *http.Request) {
 	opts, err := parseInvocationOpts(r)
 	if err != nil {
 		writeErr(w, http.StatusBadRequest, "%v", err)
 		return
 	}
-	invocations, err := s.store.ListInvocations(r.Context(), opts)
+	invocations, err := s.storeOf(r).ListInvocations(r.Context(), opts)
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, "listing invocations: %v", err)
+		writeErr(w, listStatus(err), "listing invocations: %v", err)
 		return
 	}
-	if invocations == nil {
-		invocations = []model.Invocation{}
-	}
-	writeJSON(w, http.StatusOK, invocations)
+	writeList(w, r, invocations, opts.Limit, store.InvocationCursor)
 }
 
 func (s *Server) handleInvocationStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := s.store.InvocationStats(r.Context())
+	stats, err := s.storeOf(r).InvocationStats(r.Context())
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "getting invocation stats: %v", err)
 		return
@@ -316,15 +472,12 @@ func (s *Server) handleListTurns(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "%v", err)
 		return
 	}
-	turns, err := s.store.ListTurns(r.Context(), opts)
+	turns, err := s.storeOf(r).ListTurns(r.Context(), opts)
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, "listing turns: %v", err)
+		writeErr(w, listStatus(err), "listing turns: %v", err)
 		return
 	}
-	if turns == nil {
-		turns = []store.TurnRow{}
-	}
-	writeJSON(w, http.StatusOK, turns)
+	writeList(w, r, turns, opts.Limit, store.TurnCursor)
 }
 
 func (s *Server) handleTurnPatterns(w http.ResponseWriter, r *http.Request) {
@@ -333,7 +486,7 @@ func (s *Server) handleTurnPatterns(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "%v", err)
 		return
 	}
-	patterns, err := s.store.TurnPatternStats(r.Context(), opts)
+	patterns, err := s.storeOf(r).TurnPatternStats(r.Context(), opts)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "turn patterns: %v", err)
 		return
@@ -350,7 +503,7 @@ func (s *Server) handleToolTurnStats(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "%v", err)
 		return
 	}
-	stats, err := s.store.ToolTurnStats(r.Context(), opts)
+	stats, err := s.storeOf(r).ToolTurnStats(r.Context(), opts)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "tool turn stats: %v", err)
 		return
@@ -367,7 +520,7 @@ func (s *Server) handleDetectRecovery(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "decoding invocation: %v", err)
 		return
 	}
-	if err := s.store.DetectAndRecordRecovery(r.Context(), inv); err != nil {
+	if err := s.storeOf(r).DetectAndRecordRecovery(r.Context(), inv); err != nil {
 		writeErr(w, http.StatusInternalServerError, "detect recovery: %v", err)
 		return
 	}
@@ -375,39 +528,192 @@ func (s *Server) handleDetectRecovery(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleListRecoveries(w http.ResponseWriter, r *http.Request) {
-	since, err := parseSince(r)
+	opts, err := parseRecoveryOpts(r)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	recoveries, err := s.storeOf(r).ListRecoveries(r.Context(), opts)
+	if err != nil {
+		writeErr(w, listStatus(err), "list recoveries: %v", err)
+		return
+	}
+	writeList(w, r, recoveries, opts.Limit, store.RecoveryCursor)
+}
+
+func (s *Server) handleRecoveryStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.storeOf(r).RecoveryStats(r.Context())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "recovery stats: %v", err)
+		return
+	}
+	if stats == nil {
+		stats = []model.RecoveryStat{}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) handleTrackRetryChain(w http.ResponseWriter, r *http.Request) {
+	var req store.ChainTrackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "decoding chain request: %v", err)
+		return
+	}
+	if err := s.storeOf(r).TrackRetryChain(r.Context(), req.Invocation, req.ToolInput); err != nil {
+		writeErr(w, http.StatusInternalServerError, "track retry chain: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleListRetryChains(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseChainOpts(r)
 	if err != nil {
 		writeErr(w, http.StatusBadRequest, "%v", err)
 		return
 	}
-	limit, err := parseInt(r, "limit")
+	chains, err := s.storeOf(r).ListRetryChains(r.Context(), opts)
+	if err != nil {
+		writeErr(w, listStatus(err), "list retry chains: %v", err)
+		return
+	}
+	writeList(w, r, chains, opts.Limit, store.ChainCursor)
+}
+
+func (s *Server) handleSourceToolCounts(w http.ResponseWriter, r *http.Request) {
+	counts, err := s.storeOf(r).SourceToolCounts(r.Context())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "source tool counts: %v", err)
+		return
+	}
+	if counts == nil {
+		counts = []store.SourceToolCount{}
+	}
+	writeJSON(w, http.StatusOK, counts)
+}
+
+func (s *Server) handleStrugglingTools(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseStrugglingOpts(r)
 	if err != nil {
 		writeErr(w, http.StatusBadRequest, "%v", err)
 		return
 	}
-	recoveries, err := s.store.ListRecoveries(r.Context(), since, limit)
+	tools, err := s.storeOf(r).StrugglingTools(r.Context(), opts)
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, "list recoveries: %v", err)
+		writeErr(w, http.StatusInternalServerError, "struggling tools: %v", err)
 		return
 	}
-	if recoveries == nil {
-		recoveries = []model.Recovery{}
+	if tools == nil {
+		tools = []model.StrugglingTool{}
 	}
-	writeJSON(w, http.StatusOK, recoveries)
+	writeJSON(w, http.StatusOK, tools)
 }
 
-func (s *Server) handleRecoveryStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := s.store.RecoveryStats(r.Context())
+func (s *Server) handleRecordIntervention(w http.ResponseWriter, r *http.Request) {
+	var iv model.Intervention
+	if err := json.NewDecoder(r.Body).Decode(&iv); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+	iv.ClientID = clientID(r.Context())
+	if err := s.storeOf(r).RecordIntervention(r.Context(), iv); err != nil {
+		writeErr(w, recordStatus(err), "recording intervention: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, iv)
+}
+
+func (s *Server) handleInterventionStats(w http.ResponseWriter, r *http.Request) {
+	since, err := parseSince(r)
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, "recovery stats: %v", err)
+		writeErr(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	stats, err := s.storeOf(r).InterventionStats(r.Context(), since)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "intervention stats: %v", err)
 		return
 	}
 	if stats == nil {
-		stats = []model.RecoveryStat{}
+		stats = []model.InterventionStat{}
 	}
 	writeJSON(w, http.StatusOK, stats)
 }
 
+func (s *Server) handleSetDocMapping(w http.ResponseWriter, r *http.Request) {
+	var dm model.DocMapping
+	if err := json.NewDecoder(r.Body).Decode(&dm); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+	if dm.ID == "" || dm.Pattern == "" || dm.DocPath == "" {
+		writeErr(w, http.StatusBadRequest, "'id', 'pattern', and 'doc_path' fields are required")
+		return
+	}
+	if err := s.storeOf(r).SetDocMapping(r.Context(), dm); err != nil {
+		writeErr(w, http.StatusInternalServerError, "setting doc mapping: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dm)
+}
+
+func (s *Server) handleGetDocMappings(w http.ResponseWriter, r *http.Request) {
+	mappings, err := s.storeOf(r).GetDocMappings(r.Context())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "getting doc mappings: %v", err)
+		return
+	}
+	if mappings == nil {
+		mappings = []model.DocMapping{}
+	}
+	writeJSON(w, http.StatusOK, mappings)
+}
+
+func (s *Server) handleSuggestDocs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	mappings, err := s.storeOf(r).SuggestDocs(r.Context(), q.Get("tool"), q.Get("error"))
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "suggesting docs: %v", err)
+		return
+	}
+	if mappings == nil {
+		mappings = []model.DocMapping{}
+	}
+	writeJSON(w, http.StatusOK, mappings)
+}
+
+// docMappingID is the body of the doc-mapping delete and match endpoints.
+type docMappingID struct {
+	ID string `json:"id"`
+}
+
+func (s *Server) handleDeleteDocMapping(w http.ResponseWriter, r *http.Request) {
+	var req docMappingID
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		writeErr(w, http.StatusBadRequest, "'id' field is required")
+		return
+	}
+	deleted, err := s.storeOf(r).DeleteDocMapping(r.Context(), req.ID)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "deleting doc mapping: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"deleted": deleted})
+}
+
+func (s *Server) handleDocMatch(w http.ResponseWriter, r *http.Request) {
+	var req docMappingID
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		writeErr(w, http.StatusBadRequest, "'id' field is required")
+		return
+	}
+	if err := s.storeOf(r).IncrementDocMatchCount(r.Context(), req.ID); err != nil {
+		writeErr(w, http.StatusInternalServerError, "counting doc match: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
 // writeJSON encodes v as JSON and writes it to w with the given status code.
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -417,8 +723,49 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	enc.Encode(v)
 }
 
+// ingestStatus returns the HTTP status for an ingest error: 500 when the
+// store failed, and 422 when the payload could not be used.
+func ingestStatus(err error) int {
+	var se *ingest.StoreError
+	if errors.As(err, &se) {
+		return http.StatusInternalServerError
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// recordStatus maps a store write error to a response status: 409 when the
+// ID is already stored, so clients replaying writes can tell it was
+// delivered, and 500 otherwise.
+func recordStatus(err error) int {
+	if errors.Is(err, store.ErrDuplicateID) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
 // writeErr writes a JSON error response.
 func writeErr(w http.ResponseWriter, status int, format string, args ...any) {
 	msg := fmt.Sprintf(format, args...)
 	writeJSON(w, status, map[string]string{"error": msg})
 }
+
+// writeList writes the result of a list endpoint. Clients that page pass a
+// cursor parameter, empty for the first page, and get a store.Page whose
+// next_cursor continues the list; others get a plain array.
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T, limit int, cursor func(T) string) {
+	page := store.NewPage(items, limit, cursor)
+	if !r.URL.Query().Has("cursor") {
+		writeJSON(w, http.StatusOK, page.Items)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// listStatus maps a store list error to a response status: 400 for a
+// cursor the store cannot decode, and 500 otherwise.
+func listStatus(err error) int {
+	if errors.Is(err, store.ErrBadCursor) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/ingest"
 	"github.com/scbrown/desire-path/internal/model"
 	_ "github.com/scbrown/desire-path/internal/source" // register source plugins
 	"github.com/scbrown/desire-path/internal/store"
@@ -24,7 +26,7 @@ func testServer(t *testing.T) (*Server, *httptest.Server) {
 	}
 	t.Cleanup(func() { s.Close() })
 	srv := New(s)
-	ts := httptest.NewServer(srv.mux)
+	ts := httptest.NewServer(srv.Handler())
 	t.Cleanup(ts.Close)
 	return srv, ts
 }
@@ -257,6 +259,41 @@ func TestInvocationStats(t *testing.T) {
 	}
 }
 
+func TestInterventions(t *testing.T) {
+	_, ts := testServer(t)
+
+	iv := model.Intervention{
+		ID:        "iv-1",
+		Kind:      model.InterventionDeny,
+		ToolName:  "Bash",
+		Rule:      "rm -rf /",
+		MatchKind: "deny",
+		Timestamp: time.Now().UTC().Truncate(time.Second),
+	}
+	body, _ := json.Marshal(iv)
+	resp, err := http.Post(ts.URL+"/api/v1/interventions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST intervention: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", resp.StatusCode)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/interventions/stats")
+	if err != nil {
+		t.Fatalf("GET intervention stats: %v", err)
+	}
+	defer resp.Body.Close()
+	var stats []model.InterventionStat
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Kind != model.InterventionDeny || stats[0].Count != 1 {
+		t.Errorf("stats = %+v, want one deny", stats)
+	}
+}
+
 func TestPaths(t *testing.T) {
 	_, ts := testServer(t)
 
@@ -292,6 +329,90 @@ func TestPaths(t *testing.T) {
 	}
 }
 
+func TestPathsSortImpact(t *testing.T) {
+	_, ts := testServer(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, d := range []model.Desire{
+		{ID: "a1", ToolName: "loop", SessionID: "s1", Timestamp: now},
+		{ID: "a2", ToolName: "loop", SessionID: "s1", Timestamp: now},
+		{ID: "a3", ToolName: "loop", SessionID: "s1", Timestamp: now},
+		{ID: "b1", ToolName: "spread", SessionID: "s1", Timestamp: now},
+		{ID: "b2", ToolName: "spread", SessionID: "s2", Timestamp: now},
+	} {
+		d.Error = "unknown tool"
+		body, _ := json.Marshal(d)
+		resp, err := http.Post(ts.URL+"/api/v1/desires", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST desire %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/paths?sort=impact&retry_weight=0&session_weight=2")
+	if err != nil {
+		t.Fatalf("GET paths: %v", err)
+	}
+	defer resp.Body.Close()
+	var paths []model.Path
+	if err := json.NewDecoder(resp.Body).Decode(&paths); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(paths) != 2 || paths[0].Pattern != "spread" || paths[0].Sessions != 2 {
+		t.Fatalf("paths = %+v, want spread first with 2 sessions", paths)
+	}
+	// Two fresh sessions at weight 2, no retry bonus.
+	if paths[0].Impact < 3.99 || paths[0].Impact > 4 || paths[1].Impact < 1.99 || paths[1].Impact > 2 {
+		t.Errorf("impact = %v, %v; want about 4 and 2", paths[0].Impact, paths[1].Impact)
+	}
+
+	for _, q := range []string{"sort=bogus", "retry_weight=-1", "half_life_days=x"} {
+		resp, err := http.Get(ts.URL + "/api/v1/paths?" + q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", q, resp.StatusCode)
+		}
+	}
+}
+
+func TestPathTrends(t *testing.T) {
+	srv, ts := testServer(t)
+	until := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	for i, at := range []time.Time{until.Add(-time.Hour), until.Add(-2 * time.Hour), until.Add(-30 * time.Hour)} {
+		d := model.Desire{ID: fmt.Sprintf("t-%d", i), ToolName: "read_file", Error: "x", Timestamp: at}
+		if err := srv.store.RecordDesire(context.Background(), d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/paths/trends?bucket=day&buckets=1&until=" + until.Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("GET trends: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var res store.TrendResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(res.Paths) != 1 || res.Paths[0].Current != 2 || res.Paths[0].Previous != 1 || res.Paths[0].Status != store.TrendUp {
+		t.Errorf("paths = %+v, want read_file up 2 from 1", res.Paths)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/paths/trends?bucket=month")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad bucket status = %d, want 400", resp.StatusCode)
+	}
+}
+
 func TestInspect(t *testing.T) {
 	_, ts := testServer(t)
 
@@ -332,7 +453,7 @@ func TestListDesiresWithFilters(t *testing.T) {
 	// Record two desires.
 	for i, d := range []model.Desire{
 		{ID: "f-1", ToolName: "Read", Error: "err1", Source: "s1", Timestamp: time.Now().UTC()},
-		{ID: "f-2", ToolName: "Write", Error: "err2", Source: "s2", Timestamp: time.Now().UTC()},
+		{ID: "f-2", ToolName: "Write", Error: "err2", Source: "s2", Timestamp: time.Now().UTC(), Model: "cursor-fast"},
 	} {
 		body, _ := json.Marshal(d)
 		resp, _ := http.Post(ts.URL+"/api/v1/desires", "application/json", bytes.NewReader(body))
@@ -358,6 +479,80 @@ func TestListDesiresWithFilters(t *testing.T) {
 	if desires[0].Source != "s1" {
 		t.Errorf("source = %q, want s1", desires[0].Source)
 	}
+
+	// Filter by model.
+	resp, err = http.Get(ts.URL + "/api/v1/desires?model=cursor-fast")
+	if err != nil {
+		t.Fatalf("GET desires: %v", err)
+	}
+	defer resp.Body.Close()
+	desires = nil
+	if err := json.NewDecoder(resp.Body).Decode(&desires); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(desires) != 1 || desires[0].ID != "f-2" {
+		t.Errorf("desires = %+v, want f-2", desires)
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	srv, ts := testServer(t)
+	ctx := context.Background()
+	base := time.Now().UTC().Add(-time.Hour)
+	for i := range 3 {
+		d := model.Desire{ID: fmt.Sprintf("p-%d", i), ToolName: "Read", Error: "x", Timestamp: base.Add(time.Duration(i) * time.Minute)}
+		if err := srv.store.RecordDesire(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	getPage := func(query string) store.Page[model.Desire] {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/api/v1/desires?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET desires?%s: status = %d", query, resp.StatusCode)
+		}
+		var page store.Page[model.Desire]
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("decode page: %v", err)
+		}
+		return page
+	}
+
+	first := getPage("limit=2&cursor=")
+	if len(first.Items) != 2 || first.Items[0].ID != "p-2" || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	last := getPage("limit=2&cursor=" + first.NextCursor)
+	if len(last.Items) != 1 || last.Items[0].ID != "p-0" || last.NextCursor != "" {
+		t.Fatalf("last page = %+v", last)
+	}
+
+	// Without a cursor parameter the response stays a plain array.
+	resp, err := http.Get(ts.URL + "/api/v1/desires?limit=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var desires []model.Desire
+	if err := json.NewDecoder(resp.Body).Decode(&desires); err != nil || len(desires) != 2 {
+		t.Errorf("array response: %d desires, %v", len(desires), err)
+	}
+	resp.Body.Close()
+
+	for _, path := range []string{"desires", "invocations", "turns", "recoveries"} {
+		resp, err := http.Get(ts.URL + "/api/v1/" + path + "?cursor=bogus")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("GET %s with bad cursor: status = %d, want 400", path, resp.StatusCode)
+		}
+	}
 }
 
 func TestIngest(t *testing.T) {
@@ -405,6 +600,194 @@ func TestIngest(t *testing.T) {
 	}
 }
 
+func TestIngestBatch(t *testing.T) {
+	srv, ts := testServer(t)
+
+	body := `{"tool_name":"Read","session_id":"s-1","cwd":"/tmp","error":"unknown tool"}
+{"tool_name":"Bash","session_id":"s-1","cwd":"/tmp"}
+{"session_id":"s-1"}
+`
+	resp, err := http.Post(ts.URL+"/api/v1/ingest/batch?source=claude-code", "application/x-ndjson", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("POST ingest/batch: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var res ingest.BatchResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if res.Accepted != 2 || res.Rejected != 1 || len(res.Lines) != 3 {
+		t.Fatalf("result = %+v, want 2 accepted, 1 rejected", res)
+	}
+	if res.Lines[2].Line != 3 || res.Lines[2].Error == "" {
+		t.Errorf("line 3 = %+v, want an error", res.Lines[2])
+	}
+
+	ctx := context.Background()
+	invs, err := srv.store.ListInvocations(ctx, store.InvocationOpts{})
+	if err != nil || len(invs) != 2 {
+		t.Fatalf("invocations = %d (%v), want 2", len(invs), err)
+	}
+	desires, err := srv.store.ListDesires(ctx, store.ListOpts{})
+	if err != nil || len(desires) != 1 || desires[0].ToolName != "Read" {
+		t.Fatalf("desires = %+v (%v), want one for Read", desires, err)
+	}
+
+	resp, err = http.Post(ts.URL+"/api/v1/ingest/batch?source=nope", "application/x-ndjson", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("unknown source: status = %d, want 422", resp.StatusCode)
+	}
+
+	// A store failure is the server's fault, not the payload's.
+	srv.store.Close()
+	resp, err = http.Post(ts.URL+"/api/v1/ingest/batch?source=claude-code", "application/x-ndjson", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("store failure: status = %d, want 500", resp.StatusCode)
+	}
+}
+
+func TestRecordBatches(t *testing.T) {
+	srv, ts := testServer(t)
+	now := time.Now().UTC()
+
+	post := func(path string, v any) int {
+		t.Helper()
+		body, _ := json.Marshal(v)
+		resp, err := http.Post(ts.URL+path, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	ds := []model.Desire{
+		{ID: "d1", ToolName: "read_file", Error: "unknown tool", Timestamp: now},
+		{ID: "d2", ToolName: "grep", Error: "unknown tool", Timestamp: now},
+	}
+	if code := post("/api/v1/desires/batch", ds); code != http.StatusCreated {
+		t.Fatalf("desires/batch: status = %d, want 201", code)
+	}
+	invs := []model.Invocation{
+		{ID: "i1", Source: "test", ToolName: "Read", Timestamp: now},
+		{ID: "i2", Source: "test", ToolName: "Bash", Timestamp: now},
+	}
+	if code := post("/api/v1/invocations/batch", invs); code != http.StatusCreated {
+		t.Fatalf("invocations/batch: status = %d, want 201", code)
+	}
+
+	// A single write of a stored ID is a conflict, so replaying clients know
+	// it was already delivered.
+	if code := post("/api/v1/desires", ds[0]); code != http.StatusConflict {
+		t.Errorf("duplicate desire: status = %d, want 409", code)
+	}
+
+	// Replaying a batch skips IDs that are already stored.
+	if code := post("/api/v1/invocations/batch", invs); code != http.StatusCreated {
+		t.Errorf("replayed batch: status = %d, want 201", code)
+	}
+
+	ctx := context.Background()
+	gotD, _ := srv.store.ListDesires(ctx, store.ListOpts{})
+	gotI, _ := srv.store.ListInvocations(ctx, store.InvocationOpts{})
+	if len(gotD) != 2 || len(gotI) != 2 {
+		t.Errorf("stored %d desires and %d invocations, want 2 and 2", len(gotD), len(gotI))
+	}
+}
+
+func TestRecordInvocationsBatchDetects(t *testing.T) {
+	srv, ts := testServer(t)
+	rs := store.NewRemote(ts.URL)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	// A hybrid client pushes the desire first, then its invocations, which
+	// need not arrive in order.
+	d := model.Desire{ID: "d1", ToolName: "Bash", SessionID: "s1", Error: "exit 1",
+		ToolInput: json.RawMessage(`{"command":"scp -r a b"}`), Timestamp: now.Add(-2 * time.Minute)}
+	if err := rs.RecordDesires(ctx, []model.Desire{d}); err != nil {
+		t.Fatal(err)
+	}
+	invs := []model.Invocation{
+		{ID: "ok", Source: "test", InstanceID: "s1", ToolName: "Bash", Timestamp: now},
+		{ID: "fail", Source: "test", InstanceID: "s1", ToolName: "Bash", IsError: true, Error: "exit 1", Timestamp: d.Timestamp},
+	}
+	for i := 0; i < 2; i++ { // a resent batch changes nothing
+		if err := rs.RecordInvocations(ctx, invs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recs, err := srv.store.ListRecoveries(ctx, store.RecoveryOpts{})
+	if err != nil || len(recs) != 1 || recs[0].DesireID != "d1" {
+		t.Errorf("recoveries = %+v, %v; want one from d1", recs, err)
+	}
+	chains, err := srv.store.ListRetryChains(ctx, store.ChainOpts{})
+	if err != nil || len(chains) != 1 {
+		t.Fatalf("chains = %+v, %v; want one", chains, err)
+	}
+	if c := chains[0]; c.Outcome != model.ChainRecovered || c.Attempts != 1 || c.Intent != "Bash:scp" {
+		t.Errorf("chain = %+v, want a recovered scp chain of 1 attempt", c)
+	}
+}
+
+func TestDocMappings(t *testing.T) {
+	srv, ts := testServer(t)
+	rs := store.NewRemote(ts.URL)
+	ctx := context.Background()
+
+	dm := model.DocMapping{ID: "dm-1", Pattern: "Bash", Tool: "Bash", DocPath: "docs/bash.md"}
+	if err := rs.SetDocMapping(ctx, dm); err != nil {
+		t.Fatalf("SetDocMapping: %v", err)
+	}
+	got, err := rs.GetDocMappings(ctx)
+	if err != nil {
+		t.Fatalf("GetDocMappings: %v", err)
+	}
+	if len(got) != 1 || got[0].DocPath != "docs/bash.md" {
+		t.Fatalf("GetDocMappings = %+v", got)
+	}
+	suggested, err := rs.SuggestDocs(ctx, "Bash", "")
+	if err != nil {
+		t.Fatalf("SuggestDocs: %v", err)
+	}
+	if len(suggested) != 1 {
+		t.Errorf("SuggestDocs returned %d mappings, want 1", len(suggested))
+	}
+	if err := rs.IncrementDocMatchCount(ctx, "dm-1"); err != nil {
+		t.Fatalf("IncrementDocMatchCount: %v", err)
+	}
+	stored, _ := srv.store.GetDocMappings(ctx)
+	if len(stored) != 1 || stored[0].MatchCount != 1 {
+		t.Errorf("stored mappings = %+v, want match_count 1", stored)
+	}
+
+	for _, want := range []bool{true, false} {
+		deleted, err := rs.DeleteDocMapping(ctx, "dm-1")
+		if err != nil {
+			t.Fatalf("DeleteDocMapping: %v", err)
+		}
+		if deleted != want {
+			t.Errorf("DeleteDocMapping = %v, want %v", deleted, want)
+		}
+	}
+
+	if err := rs.SetDocMapping(ctx, model.DocMapping{ID: "dm-2"}); err == nil {
+		t.Error("SetDocMapping without pattern or doc_path succeeded")
+	}
+}
+
 func TestIngestMissingSource(t *testing.T) {
 	_, ts := testServer(t)
 
@@ -0,0 +1,294 @@
+// dp dashboard: a dependency-free single-page view over the /api/v1 endpoints.
+"use strict";
+
+const TOKEN_KEY = "dp_token";
+const SPARK_DAYS = 14;
+const SPARK_PATHS = 25;
+
+// --- API -----------------------------------------------------------------
+
+class AuthError extends Error {}
+
+async function api(path, opts = {}) {
+  const headers = Object.assign({ Accept: "application/json" }, opts.headers || {});
+  const token = localStorage.getItem(TOKEN_KEY);
+  if (token) headers.Authorization = "Bearer " + token;
+  if (opts.body !== undefined) headers["Content-Type"] = "application/json";
+  const resp = await fetch("/api/v1" + path, {
+    method: opts.method || "GET",
+    headers,
+    body: opts.body === undefined ? undefined : JSON.stringify(opts.body),
+  });
+  if (resp.status === 401) throw new AuthError("unauthorized");
+  const data = await resp.json().catch(() => ({}));
+  if (!resp.ok) throw new Error(data.error || resp.statusText);
+  return data;
+}
+
+// --- DOM helpers ---------------------------------------------------------
+
+function el(tag, attrs = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs)) {
+    if (k === "class") node.className = v;
+    else if (k.startsWith("on")) node.addEventListener(k.slice(2), v);
+    else node.setAttribute(k, v);
+  }
+  for (const c of children.flat()) {
+    if (c === null || c === undefined) continue;
+    node.append(c instanceof Node ? c : String(c));
+  }
+  return node;
+}
+
+const SVG_NS = "http://www.w3.org/2000/svg";
+
+function svg(tag, attrs = {}, ...children) {
+  const node = document.createElementNS(SVG_NS, tag);
+  for (const [k, v] of Object.entries(attrs)) node.setAttribute(k, v);
+  for (const c of children) node.append(c);
+  return node;
+}
+
+function table(headers, rows, numeric = []) {
+  return el("table", {},
+    el("thead", {}, el("tr", {}, headers.map((h, i) => el("th", numeric.includes(i) ? { class: "num" } : {}, h)))),
+    el("tbody", {}, rows));
+}
+
+function td(value, cls) {
+  return el("td", cls ? { class: cls } : {}, value);
+}
+
+function fmtTime(s) {
+  if (!s || s.startsWith("0001-")) return "—";
+  return new Date(s).toLocaleString();
+}
+
+function fmtPct(x) {
+  return (x * 100).toFixed(0) + "%";
+}
+
+// --- Charts --------------------------------------------------------------
+
+// lastDays returns the last n dates as YYYY-MM-DD strings (UTC), oldest first.
+function lastDays(n) {
+  const out = [];
+  const now = Date.now();
+  for (let i = n - 1; i >= 0; i--) {
+    out.push(new Date(now - i * 86400000).toISOString().slice(0, 10));
+  }
+  return out;
+}
+
+// fillHistogram maps InspectResult.Histogram onto a dense series of days.
+function fillHistogram(hist, days) {
+  const byDay = new Map((hist || []).map((d) => [d.date, d.count]));
+  return days.map((d) => byDay.get(d) || 0);
+}
+
+function sparkline(values, width = 120, height = 24) {
+  const max = Math.max(1, ...values);
+  const step = values.length > 1 ? width / (values.length - 1) : width;
+  const points = values.map((v, i) => `${(i * step).toFixed(1)},${(height - 2 - (v / max) * (height - 4)).toFixed(1)}`);
+  return svg("svg", { class: "spark", width, height, viewBox: `0 0 ${width} ${height}` },
+    svg("polyline", { points: points.join(" ") }));
+}
+
+function barChart(days, values, width = 720, height = 140) {
+  const max = Math.max(1, ...values);
+  const bw = width / values.length;
+  const chart = svg("svg", { class: "bars", width: "100%", viewBox: `0 0 ${width} ${height + 16}` });
+  values.forEach((v, i) => {
+    const h = (v / max) * height;
+    const rect = svg("rect", { x: (i * bw + 1).toFixed(1), y: (height - h).toFixed(1), width: Math.max(1, bw - 2).toFixed(1), height: h.toFixed(1) });
+    rect.append(svg("title", {}, `${days[i]}: ${v}`));
+    chart.append(rect);
+  });
+  const label = (i, anchor) => svg("text", { x: anchor === "end" ? width : 0, y: height + 12, "text-anchor": anchor }, days[i]);
+  chart.append(label(0, "start"), label(days.length - 1, "end"));
+  return chart;
+}
+
+// --- Views ---------------------------------------------------------------
+
+async function viewPaths(main) {
+  const paths = await api("/paths?top=100");
+  main.append(el("h2", {}, "Paths"));
+  if (paths.length === 0) {
+    main.append(el("p", { class: "muted" }, "No desires recorded yet."));
+    return;
+  }
+  const days = lastDays(SPARK_DAYS);
+  const since = days[0] + "T00:00:00Z";
+  const rows = paths.map((p, i) => {
+    const spark = td("");
+    if (i < SPARK_PATHS) {
+      api(`/inspect?pattern=${encodeURIComponent(p.pattern)}&since=${since}`)
+        .then((r) => spark.append(sparkline(fillHistogram(r.histogram, days))))
+        .catch(() => {});
+    }
+    return el("tr", { class: "link", onclick: () => { location.hash = "inspect/" + encodeURIComponent(p.pattern); } },
+      td(i + 1, "num"), td(el("code", {}, p.pattern)), td(p.count, "num"), spark,
+      td(p.alias_to || ""), td(fmtTime(p.last_seen)));
+  });
+  main.append(table(["#", "Pattern", "Count", `Last ${SPARK_DAYS} days`, "Alias", "Last seen"], rows, [0, 2]));
+}
+
+async function viewInspect(main, pattern) {
+  const r = await api(`/inspect?pattern=${encodeURIComponent(pattern)}&top=10`);
+  main.append(el("h2", {}, "Inspect ", el("code", {}, pattern)));
+  if (r.total === 0) {
+    main.append(el("p", { class: "muted" }, "No desires match this pattern."));
+    return;
+  }
+  main.append(el("dl", { class: "kv" },
+    el("dt", {}, "Total"), el("dd", {}, r.total),
+    el("dt", {}, "First seen"), el("dd", {}, fmtTime(r.first_seen)),
+    el("dt", {}, "Last seen"), el("dd", {}, fmtTime(r.last_seen)),
+    el("dt", {}, "Alias"), el("dd", {}, r.alias_to || el("span", { class: "muted" }, "none — add one under Aliases"))));
+
+  // Cover back to the first sighting, showing between 30 and 90 days.
+  const span = Math.min(90, Math.max(30, Math.ceil((Date.now() - new Date(r.first_seen)) / 86400000) + 1));
+  const days = lastDays(span);
+  main.append(el("h3", {}, "Per day"), barChart(days, fillHistogram(r.histogram, days)));
+
+  const counts = (items) => table(["Value", "Count"],
+    (items || []).map((nc) => el("tr", {}, td(el("code", {}, nc.name)), td(nc.count, "num"))), [1]);
+  main.append(el("h3", {}, "Top inputs"), counts(r.top_inputs));
+  main.append(el("h3", {}, "Top errors"), counts(r.top_errors));
+}
+
+async function viewTurns(main) {
+  const patterns = await api("/turns/patterns?limit=50");
+  main.append(el("h2", {}, "Turn patterns"));
+  if (patterns.length === 0) {
+    main.append(el("p", { class: "muted" }, "No long turns recorded."));
+    return;
+  }
+  main.append(table(["Pattern", "Count", "Avg length", "Sessions"],
+    patterns.map((p) => el("tr", {}, td(el("code", {}, p.pattern)), td(p.count, "num"), td(p.avg_length.toFixed(1), "num"), td(p.sessions, "num"))),
+    [1, 2, 3]));
+}
+
+async function viewRecoveries(main) {
+  const [stats, recent] = await Promise.all([api("/recoveries/stats"), api("/recoveries?limit=50")]);
+  main.append(el("h2", {}, "Recoveries"));
+  main.append(el("p", { class: "muted" }, "A recovery is a tool succeeding after it had recently failed."));
+  main.append(el("h3", {}, "By tool"), table(["Tool", "Recoveries", "Last recovery"],
+    stats.map((s) => el("tr", {}, td(el("code", {}, s.tool_name)), td(s.count, "num"), td(fmtTime(s.last_recovery)))), [1]));
+  main.append(el("h3", {}, "Recent"), table(["Time", "Tool", "Desire"],
+    recent.map((r) => el("tr", {}, td(fmtTime(r.timestamp)), td(el("code", {}, r.tool_name)), td(el("code", { class: "muted" }, r.desire_id))))));
+}
+
+async function viewStruggling(main) {
+  const tools = await api("/struggling?since=7d");
+  main.append(el("h2", {}, "Struggling tools"));
+  main.append(el("p", { class: "muted" }, "Tools with repeated failures in the last 7 days."));
+  if (tools.length === 0) {
+    main.append(el("p", {}, "Nothing is struggling."));
+    return;
+  }
+  main.append(table(["Tool", "Failures", "Total", "Failure rate", "Sessions", "Docs"],
+    tools.map((t) => el("tr", {},
+      td(el("code", {}, t.tool_name)), td(t.failures, "num"), td(t.total, "num"),
+      td(fmtPct(t.failure_rate), t.failure_rate >= 0.5 ? "num bad" : "num"), td(t.sessions, "num"),
+      td(t.has_doc ? "yes" : el("span", { class: "muted" }, "no")))),
+    [1, 2, 3, 4]));
+}
+
+const MATCH_KINDS = ["", "flag", "literal", "command", "regex", "recipe", "deny"];
+
+async function viewAliases(main) {
+  const aliases = await api("/aliases");
+  main.append(el("h2", {}, "Aliases"));
+
+  const field = (name, placeholder) => el("input", { name, placeholder });
+  const form = el("form", { class: "alias" },
+    field("from", "from (required)"), field("to", "to"),
+    field("tool", "tool"), field("param", "param"), field("command", "command"),
+    el("select", { name: "match_kind" }, MATCH_KINDS.map((k) => el("option", { value: k }, k || "tool name"))),
+    field("message", "message"),
+    el("button", { type: "submit" }, "Add / update"));
+  const status = el("div", { class: "error" });
+  form.addEventListener("submit", async (ev) => {
+    ev.preventDefault();
+    const a = Object.fromEntries(new FormData(form).entries());
+    for (const k of Object.keys(a)) if (a[k] === "") delete a[k];
+    try {
+      await api("/aliases", { method: "POST", body: a });
+      render();
+    } catch (e) {
+      status.textContent = e.message;
+    }
+  });
+  main.append(form, status);
+
+  if (aliases.length === 0) {
+    main.append(el("p", { class: "muted" }, "No aliases yet."));
+    return;
+  }
+  const rows = aliases.map((a) => {
+    const del = el("button", { class: "danger", onclick: async () => {
+      if (!confirm(`Delete rule ${a.from}?`)) return;
+      const q = new URLSearchParams({ tool: a.tool || "", param: a.param || "", command: a.command || "", match_kind: a.match_kind || "" });
+      try {
+        await api(`/aliases/${encodeURIComponent(a.from)}?${q}`, { method: "DELETE" });
+        render();
+      } catch (e) {
+        status.textContent = e.message;
+      }
+    } }, "Delete");
+    return el("tr", {},
+      td(el("code", {}, a.from)), td(el("code", {}, a.to || "")), td(a.match_kind || "tool name"),
+      td([a.tool, a.param].filter(Boolean).join(".")), td(a.command || ""), td(a.message || ""), td(del));
+  });
+  main.append(table(["From", "To", "Kind", "Tool.param", "Command", "Message", ""], rows));
+}
+
+// --- Router --------------------------------------------------------------
+
+const routes = {
+  paths: viewPaths,
+  inspect: viewInspect,
+  turns: viewTurns,
+  recoveries: viewRecoveries,
+  struggling: viewStruggling,
+  aliases: viewAliases,
+};
+
+async function renderSummary() {
+  const s = await api("/stats");
+  document.getElementById("summary").textContent =
+    `${s.total_desires} desires · ${s.unique_paths} paths · ${s.last_24h} in the last 24h`;
+}
+
+async function render() {
+  const [name, ...rest] = (location.hash.slice(1) || "paths").split("/");
+  const view = routes[name] || viewPaths;
+  for (const a of document.querySelectorAll("nav a")) {
+    a.classList.toggle("active", a.getAttribute("href") === "#" + (name === "inspect" ? "paths" : name));
+  }
+  const main = document.getElementById("view");
+  main.replaceChildren();
+  try {
+    await Promise.all([view(main, decodeURIComponent(rest.join("/"))), renderSummary()]);
+    document.getElementById("token-form").hidden = true;
+  } catch (e) {
+    if (e instanceof AuthError) {
+      document.getElementById("token-form").hidden = false;
+      main.replaceChildren();
+      return;
+    }
+    main.append(el("p", { class: "error" }, e.message));
+  }
+}
+
+document.getElementById("token-form").addEventListener("submit", (ev) => {
+  ev.preventDefault();
+  localStorage.setItem(TOKEN_KEY, document.getElementById("token-input").value.trim());
+  render();
+});
+
+window.addEventListener("hashchange", render);
+render();
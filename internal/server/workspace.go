@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/scbrown/desire-path/internal/events"
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+// workspacePrefix starts the API routes of a named workspace:
+// /api/v1/w/{workspace}/desires is /api/v1/desires in that workspace.
+const workspacePrefix = "/api/v1/w/"
+
+// workspace is a workspace database opened by the server, with the bus its
+// writes are published on.
+type workspace struct {
+	store store.Store
+	bus   *events.Bus
+}
+
+type workspaceKey struct{}
+
+// SetWorkspaces serves the workspaces in ws under /api/v1/w/{workspace}/,
+// each from its own database. Requests without a workspace, and those for
+// the "default" workspace, use the server's own store. Workspaces are opened
+// on first use, so ones created while the server runs are picked up.
+func (s *Server) SetWorkspaces(ws *store.Workspaces) {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+	s.workspaces = ws
+}
+
+// splitWorkspace splits a request path into its workspace and the path
+// within it: "/api/v1/w/team-a/desires" becomes "team-a" and
+// "/api/v1/desires". Other paths belong to the default workspace.
+func splitWorkspace(path string) (name, rest string) {
+	after, ok := strings.CutPrefix(path, workspacePrefix)
+	if !ok {
+		return model.DefaultWorkspace, path
+	}
+	name, sub, _ := strings.Cut(after, "/")
+	return name, "/api/v1/" + sub
+}
+
+// routeWorkspace serves requests under /api/v1/w/{workspace}/ from that
+// workspace: the request continues with the workspace-free path, and
+// handlers find the workspace's store with storeOf.
+func (s *Server) routeWorkspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, workspacePrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		name, rest := splitWorkspace(r.URL.Path)
+		ws, err := s.openWorkspace(name)
+		if errors.Is(err, store.ErrNoWorkspace) {
+			writeErr(w, http.StatusNotFound, "workspace %q not found", name)
+			return
+		}
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "opening workspace: %v", err)
+			return
+		}
+
+		r2 := r.WithContext(context.WithValue(r.Context(), workspaceKey{}, ws))
+		u := *r.URL
+		u.Path = rest
+		if u.RawPath != "" {
+			_, u.RawPath = splitWorkspace(u.RawPath)
+		}
+		r2.URL = &u
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// openWorkspace returns the named workspace, opening its database on first
+// use.
+func (s *Server) openWorkspace(name string) (*workspace, error) {
+	if name == model.DefaultWorkspace {
+		return &workspace{store: s.store, bus: s.bus}, nil
+	}
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+	if ws, ok := s.wsOpen[name]; ok {
+		return ws, nil
+	}
+	if s.workspaces == nil {
+		return nil, store.ErrNoWorkspace
+	}
+	st, err := s.workspaces.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	ws := &workspace{store: st, bus: events.NewBus()}
+	st.SetEventBus(ws.bus)
+	if s.wsOpen == nil {
+		s.wsOpen = make(map[string]*workspace)
+	}
+	s.wsOpen[name] = ws
+	return ws, nil
+}
+
+// closeWorkspaces closes the workspace databases the server opened.
+func (s *Server) closeWorkspaces() {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+	for name, ws := range s.wsOpen {
+		ws.store.Close()
+		delete(s.wsOpen, name)
+	}
+}
+
+// storeOf returns the store of the request's workspace.
+func (s *Server) storeOf(r *http.Request) store.Store {
+	if ws, ok := r.Context().Value(workspaceKey{}).(*workspace); ok {
+		return ws.store
+	}
+	return s.store
+}
+
+// busOf returns the event bus of the request's workspace.
+func (s *Server) busOf(r *http.Request) *events.Bus {
+	if ws, ok := r.Context().Value(workspaceKey{}).(*workspace); ok {
+		return ws.bus
+	}
+	return s.bus
+}
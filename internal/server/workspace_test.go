@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+	"github.com/scbrown/desire-path/internal/store"
+)
+
+func TestWorkspaceIsolation(t *testing.T) {
+	srv, ts := testServer(t)
+	ws := store.NewWorkspaces(filepath.Join(t.TempDir(), "workspaces"))
+	srv.SetWorkspaces(ws)
+	t.Cleanup(srv.closeWorkspaces)
+	if err := ws.Create("team-a"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	teamA := store.NewRemote(ts.URL)
+	teamA.SetWorkspace("team-a")
+	if err := teamA.RecordDesire(ctx, model.Desire{ID: "d1", ToolName: "read_file", Error: "unknown tool", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("RecordDesire in team-a: %v", err)
+	}
+	if err := teamA.SetAlias(ctx, model.Alias{From: "read_file", To: "Read"}); err != nil {
+		t.Fatalf("SetAlias in team-a: %v", err)
+	}
+
+	def := store.NewRemote(ts.URL)
+	if desires, err := def.ListDesires(ctx, store.ListOpts{}); err != nil || len(desires) != 0 {
+		t.Errorf("default desires = %v, %v; want none", desires, err)
+	}
+	if aliases, err := def.GetAliases(ctx); err != nil || len(aliases) != 0 {
+		t.Errorf("default aliases = %v, %v; want none", aliases, err)
+	}
+	desires, err := teamA.ListDesires(ctx, store.ListOpts{})
+	if err != nil || len(desires) != 1 || desires[0].ID != "d1" {
+		t.Errorf("team-a desires = %v, %v", desires, err)
+	}
+	a, err := teamA.GetAlias(ctx, model.AliasKey{From: "read_file"})
+	if err != nil || a == nil || a.To != "Read" {
+		t.Errorf("team-a alias = %+v, %v", a, err)
+	}
+
+	// The default workspace is also reachable by name.
+	explicit := store.NewRemote(ts.URL)
+	explicit.SetWorkspace("default")
+	if err := explicit.RecordDesire(ctx, model.Desire{ID: "d2", ToolName: "grep", Error: "x", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if desires, err := srv.store.ListDesires(ctx, store.ListOpts{}); err != nil || len(desires) != 1 || desires[0].ID != "d2" {
+		t.Errorf("server store desires = %v, %v", desires, err)
+	}
+}
+
+func TestWorkspaceNotFound(t *testing.T) {
+	srv, ts := testServer(t)
+	srv.SetWorkspaces(store.NewWorkspaces(t.TempDir()))
+
+	for _, path := range []string{"/api/v1/w/nope/desires", "/api/v1/w/Bad%20Name/desires"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s status = %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestWorkspaceToken(t *testing.T) {
+	srv, ts := testServer(t)
+	ws := store.NewWorkspaces(t.TempDir())
+	srv.SetWorkspaces(ws)
+	t.Cleanup(srv.closeWorkspaces)
+	for _, name := range []string{"team-a", "team-b"} {
+		if err := ws.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tok, secret, err := NewToken("team-a-ci", model.ScopeWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok.Workspace = "team-a"
+	if err := srv.store.CreateToken(context.Background(), tok); err != nil {
+		t.Fatal(err)
+	}
+	admin := addToken(t, srv, "admin", model.ScopeAdmin)
+
+	tests := []struct {
+		token, path string
+		want        int
+	}{
+		{secret, "/api/v1/w/team-a/desires", http.StatusOK},
+		{secret, "/api/v1/w/team-b/desires", http.StatusForbidden},
+		{secret, "/api/v1/desires", http.StatusForbidden},
+		{admin, "/api/v1/w/team-b/desires", http.StatusOK},
+		{admin, "/api/v1/desires", http.StatusOK},
+	}
+	for _, tt := range tests {
+		resp := doAuth(t, http.MethodGet, ts.URL+tt.path, tt.token, nil)
+		if resp.StatusCode != tt.want {
+			t.Errorf("GET %s status = %d, want %d", tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ var knownClaudeFields = map[string]bool{
 	"tool_input": true,
 	"cwd":        true,
 	"error":      true,
+	"model":      true,
 }
 
 // claudeCode implements Source for Claude Code's PostToolUseFailure hook.
@@ -82,6 +83,14 @@ func (c *claudeCode) Extract(raw []byte) (*Fields, error) {
 		}
 	}
 
+	// model → Model. Tool hooks don't send it today; ingest reads it from
+	// the transcript instead.
+	if v, ok := m["model"]; ok {
+		if err := json.Unmarshal(v, &f.Model); err != nil {
+			return nil, fmt.Errorf("claude-code: parsing model: %w", err)
+		}
+	}
+
 	// Collect everything not in knownClaudeFields into Extra.
 	// This includes tool_use_id, transcript_path, hook_event_name,
 	// permission_mode, and any future Claude Code fields.
@@ -13,7 +13,8 @@ import (
 // knownCodexNotifyFields lists JSON keys from the Codex notify hook payload
 // that map to universal Fields. Everything else goes into Extra.
 var knownCodexNotifyFields = map[string]bool{
-	"cwd": true,
+	"cwd":   true,
+	"model": true,
 }
 
 // codexCLI implements Source for OpenAI Codex CLI.
@@ -87,6 +88,13 @@ func (c *codexCLI) extractNotify(m map[string]json.RawMessage, eventType string)
 		}
 	}
 
+	// model → Model
+	if v, ok := m["model"]; ok {
+		if err := json.Unmarshal(v, &f.Model); err != nil {
+			return nil, fmt.Errorf("codex: parsing model: %w", err)
+		}
+	}
+
 	// Collect non-universal fields into Extra.
 	extra := make(map[string]json.RawMessage)
 	for k, v := range m {
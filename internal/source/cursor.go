@@ -15,6 +15,8 @@ var knownCursorFields = map[string]bool{
 	"cwd":             true,
 	"conversation_id": true,
 	"error_message":   true,
+	"model":           true,
+	"duration":        true,
 }
 
 // cursor implements Source for Cursor IDE's postToolUse/postToolUseFailure hooks.
@@ -74,6 +76,23 @@ func (c *cursor) Extract(raw []byte) (*Fields, error) {
 		}
 	}
 
+	// model → Model
+	if v, ok := m["model"]; ok {
+		if err := json.Unmarshal(v, &f.Model); err != nil {
+			return nil, fmt.Errorf("cursor: parsing model: %w", err)
+		}
+	}
+
+	// duration → DurationMs. Cursor reports milliseconds, possibly
+	// fractional.
+	if v, ok := m["duration"]; ok {
+		var ms float64
+		if err := json.Unmarshal(v, &ms); err != nil {
+			return nil, fmt.Errorf("cursor: parsing duration: %w", err)
+		}
+		f.DurationMs = int(ms)
+	}
+
 	// error_message → Error (from postToolUseFailure events)
 	if v, ok := m["error_message"]; ok {
 		if err := json.Unmarshal(v, &f.Error); err != nil {
@@ -49,6 +49,12 @@ func TestCursorExtract(t *testing.T) {
 				if f.Error != "" {
 					t.Errorf("Error = %q, want empty for successful call", f.Error)
 				}
+				if f.Model != "cursor-fast" {
+					t.Errorf("Model = %q, want %q", f.Model, "cursor-fast")
+				}
+				if f.DurationMs != 150 {
+					t.Errorf("DurationMs = %d, want 150", f.DurationMs)
+				}
 
 				// tool_input should be preserved as raw JSON.
 				var ti map[string]string
@@ -63,14 +69,14 @@ func TestCursorExtract(t *testing.T) {
 				if f.Extra == nil {
 					t.Fatal("Extra should not be nil")
 				}
-				for _, key := range []string{"hook_event_name", "tool_output", "tool_use_id", "generation_id", "model", "cursor_version", "duration", "transcript_path"} {
+				for _, key := range []string{"hook_event_name", "tool_output", "tool_use_id", "generation_id", "cursor_version", "transcript_path"} {
 					if _, ok := f.Extra[key]; !ok {
 						t.Errorf("Extra should contain %q", key)
 					}
 				}
 
 				// Universal fields should NOT be in Extra.
-				for _, key := range []string{"tool_name", "conversation_id", "cwd", "error_message", "tool_input"} {
+				for _, key := range []string{"tool_name", "conversation_id", "cwd", "error_message", "tool_input", "model", "duration"} {
 					if _, ok := f.Extra[key]; ok {
 						t.Errorf("Extra should not contain universal field %q", key)
 					}
@@ -14,6 +14,7 @@ var knownKiroFields = map[string]bool{
 	"tool_name":  true,
 	"tool_input": true,
 	"cwd":        true,
+	"model":      true,
 }
 
 // kiro implements Source for Kiro CLI's postToolUse hook.
@@ -65,6 +66,13 @@ func (k *kiro) Extract(raw []byte) (*Fields, error) {
 		}
 	}
 
+	// model → Model
+	if v, ok := m["model"]; ok {
+		if err := json.Unmarshal(v, &f.Model); err != nil {
+			return nil, fmt.Errorf("kiro: parsing model: %w", err)
+		}
+	}
+
 	// Kiro signals errors via tool_response.success=false rather than a
 	// dedicated error field. Extract the error state if present.
 	if v, ok := m["tool_response"]; ok {
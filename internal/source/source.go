@@ -39,6 +39,10 @@ type Fields struct {
 	CWD string `json:"cwd,omitempty"`
 	// Error is the error message if the tool call failed (optional).
 	Error string `json:"error,omitempty"`
+	// Model is the LLM that made the tool call, if the payload says (optional).
+	Model string `json:"model,omitempty"`
+	// DurationMs is how long the tool call ran, in milliseconds (optional).
+	DurationMs int `json:"duration_ms,omitempty"`
 	// Extra holds source-specific fields not mapped to universal fields.
 	Extra map[string]json.RawMessage `json:"extra,omitempty"`
 }
@@ -0,0 +1,499 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/cmdparse"
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// chainIdle is how long an open retry chain may go without another attempt
+// before it counts as abandoned.
+const chainIdle = 30 * time.Minute
+
+// dbtx is the part of *sql.DB and *sql.Tx that chain tracking uses.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (s *SQLiteStore) migrateV13() error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS retry_chains (
+			id              TEXT PRIMARY KEY,
+			session_id      TEXT NOT NULL,
+			turn_id         TEXT NOT NULL DEFAULT '',
+			tool_name       TEXT NOT NULL,
+			intent          TEXT NOT NULL,
+			attempts        INTEGER NOT NULL,
+			outcome         TEXT NOT NULL,
+			timestamp       TEXT NOT NULL,
+			last_failure_at TEXT NOT NULL,
+			ended_at        TEXT NOT NULL,
+			last_error      TEXT NOT NULL DEFAULT '',
+			last_input      TEXT,
+			input_diff      TEXT NOT NULL DEFAULT '',
+			recovered_by    TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_retry_chains_session ON retry_chains(session_id, outcome)`,
+		`CREATE INDEX IF NOT EXISTS idx_retry_chains_tool_name ON retry_chains(tool_name)`,
+		`CREATE INDEX IF NOT EXISTS idx_retry_chains_timestamp ON retry_chains(timestamp)`,
+		createChainAttempts,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v13: %w", err)
+		}
+	}
+	if err := s.backfillRetryChains(); err != nil {
+		return fmt.Errorf("migrate v13: %w", err)
+	}
+	if _, err := s.db.Exec(`UPDATE schema_version SET version = 13`); err != nil {
+		return fmt.Errorf("migrate v13: %w", err)
+	}
+	return nil
+}
+
+// createChainAttempts creates the table of invocations already folded into
+// a chain, which makes TrackRetryChain safe to repeat for an invocation.
+const createChainAttempts = `CREATE TABLE IF NOT EXISTS retry_chain_attempts (
+	invocation_id TEXT PRIMARY KEY,
+	chain_id      TEXT NOT NULL
+)`
+
+// migrateV17 adds retry_chain_attempts to databases whose chains were
+// tracked without it. Earlier attempts can only be recovered for the call
+// that started each chain and the one that recovered it.
+func (s *SQLiteStore) migrateV17() error {
+	stmts := []string{
+		createChainAttempts,
+		`INSERT OR IGNORE INTO retry_chain_attempts (invocation_id, chain_id)
+			SELECT substr(id, 1, length(id) - 6), id FROM retry_chains WHERE id LIKE '%-chain'`,
+		`INSERT OR IGNORE INTO retry_chain_attempts (invocation_id, chain_id)
+			SELECT recovered_by, id FROM retry_chains WHERE recovered_by != ''`,
+		`UPDATE schema_version SET version = 17`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v17: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillRetryChains replays the stored invocations through chain
+// tracking. Failed calls take their input from the matching desire;
+// successful calls have no stored input, so backfilled chains carry no
+// input diff.
+func (s *SQLiteStore) backfillRetryChains() error {
+	ctx := context.Background()
+	type key struct{ session, tool, ts string }
+	inputs := make(map[key]json.RawMessage)
+	rows, err := s.db.QueryContext(ctx, `SELECT session_id, tool_name, timestamp, tool_input FROM desires
+		WHERE session_id IS NOT NULL AND session_id != '' AND tool_input IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("backfill chains: %w", err)
+	}
+	for rows.Next() {
+		var k key
+		var input string
+		if err := rows.Scan(&k.session, &k.tool, &k.ts, &input); err != nil {
+			rows.Close()
+			return fmt.Errorf("backfill chains: %w", err)
+		}
+		inputs[k] = json.RawMessage(input)
+	}
+	rows.Close()
+
+	rows, err = s.db.QueryContext(ctx, `SELECT id, instance_id, tool_name, is_error, COALESCE(error, ''), timestamp, turn_id
+		FROM invocations WHERE instance_id IS NOT NULL AND instance_id != '' ORDER BY timestamp, id`)
+	if err != nil {
+		return fmt.Errorf("backfill chains: %w", err)
+	}
+	var invs []model.Invocation
+	var stamps []string
+	for rows.Next() {
+		var inv model.Invocation
+		var isErr int
+		var ts string
+		if err := rows.Scan(&inv.ID, &inv.InstanceID, &inv.ToolName, &isErr, &inv.Error, &ts, &inv.TurnID); err != nil {
+			rows.Close()
+			return fmt.Errorf("backfill chains: %w", err)
+		}
+		inv.IsError = isErr != 0
+		inv.Timestamp, _ = time.Parse(time.RFC3339Nano, ts)
+		invs = append(invs, inv)
+		stamps = append(stamps, ts)
+	}
+	rows.Close()
+	if len(invs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for i, inv := range invs {
+		var input json.RawMessage
+		if inv.IsError {
+			input = inputs[key{inv.InstanceID, inv.ToolName, stamps[i]}]
+		}
+		if err := trackRetryChain(ctx, tx, inv, input); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// TrackRetryChain folds inv into its session's retry chains. Invocations
+// without a session ID, and invocations already folded into a chain, are
+// ignored, so resending a batch does not count its attempts twice.
+//
+// Open chains in the session are first closed as abandoned if a new turn
+// has started or they have been idle for half an hour. A failure then
+// extends the open chain with the same intent, or starts one; a success
+// closes it as recovered, recording the elapsed time and how input differs
+// from the last failed attempt's.
+func (s *SQLiteStore) TrackRetryChain(ctx context.Context, inv model.Invocation, input json.RawMessage) error {
+	return trackRetryChain(ctx, s.db, inv, input)
+}
+
+// DetectInvocations runs recovery detection and retry-chain tracking for
+// invocations recorded in bulk, such as the rows a hybrid client syncs, in
+// timestamp order. A failed call takes its tool input from the desire
+// recorded for it, as the chain backfill does. Both steps are safe to
+// repeat, so a resent batch changes nothing.
+func (s *SQLiteStore) DetectInvocations(ctx context.Context, invs []model.Invocation) error {
+	sorted := append([]model.Invocation(nil), invs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+	for _, inv := range sorted {
+		if err := s.DetectAndRecordRecovery(ctx, inv); err != nil {
+			return err
+		}
+		var input json.RawMessage
+		if inv.IsError && inv.InstanceID != "" {
+			var in string
+			err := s.db.QueryRowContext(ctx,
+				`SELECT tool_input FROM desires WHERE session_id = ? AND tool_name = ? AND timestamp = ? AND tool_input IS NOT NULL LIMIT 1`,
+				inv.InstanceID, inv.ToolName, inv.Timestamp.UTC().Format(time.RFC3339Nano),
+			).Scan(&in)
+			if err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("find tool input: %w", err)
+			}
+			if err == nil {
+				input = json.RawMessage(in)
+			}
+		}
+		if err := trackRetryChain(ctx, s.db, inv, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func trackRetryChain(ctx context.Context, db dbtx, inv model.Invocation, input json.RawMessage) error {
+	if inv.InstanceID == "" {
+		return nil
+	}
+	var seen int
+	if err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM retry_chain_attempts WHERE invocation_id = ?`, inv.ID,
+	).Scan(&seen); err != nil {
+		return fmt.Errorf("find retry chain attempt: %w", err)
+	}
+	if seen > 0 {
+		return nil
+	}
+
+	// Only chains that failed before inv are affected by it, so that a
+	// late replay of an old call can't close a newer chain.
+	ts := inv.Timestamp.UTC().Format(time.RFC3339Nano)
+	idle := inv.Timestamp.Add(-chainIdle).UTC().Format(time.RFC3339Nano)
+	if _, err := db.ExecContext(ctx,
+		`UPDATE retry_chains SET outcome = ?, ended_at = last_failure_at
+		WHERE session_id = ? AND outcome = ? AND last_failure_at < ?
+		AND ((turn_id != '' AND ? != '' AND turn_id != ?) OR last_failure_at < ?)`,
+		model.ChainAbandoned, inv.InstanceID, model.ChainOpen, ts, inv.TurnID, inv.TurnID, idle,
+	); err != nil {
+		return fmt.Errorf("abandon retry chains: %w", err)
+	}
+
+	// A success without its input can't name a shell command, so it
+	// matches the latest open chain for the tool.
+	intent := chainIntent(inv.ToolName, input)
+	match, arg := "intent = ?", intent
+	if len(input) == 0 && !inv.IsError {
+		match, arg = "tool_name = ?", inv.ToolName
+	}
+	var id string
+	var lastInput sql.NullString
+	err := db.QueryRowContext(ctx,
+		`SELECT id, last_input FROM retry_chains
+		WHERE session_id = ? AND outcome = ? AND last_failure_at <= ? AND `+match+` ORDER BY last_failure_at DESC LIMIT 1`,
+		inv.InstanceID, model.ChainOpen, ts, arg,
+	).Scan(&id, &lastInput)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("find retry chain: %w", err)
+	}
+	open := err == nil
+	err = nil
+
+	chainID := id
+	switch {
+	case inv.IsError && open:
+		_, err = db.ExecContext(ctx,
+			`UPDATE retry_chains SET attempts = attempts + 1, last_failure_at = ?, ended_at = ?, last_error = ?, last_input = ?
+			WHERE id = ?`,
+			ts, ts, inv.Error, nullableJSON(input), id)
+	case inv.IsError:
+		chainID = inv.ID + "-chain"
+		_, err = db.ExecContext(ctx,
+			`INSERT OR IGNORE INTO retry_chains (id, session_id, turn_id, tool_name, intent, attempts, outcome, timestamp, last_failure_at, ended_at, last_error, last_input)
+			VALUES (?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?, ?)`,
+			chainID, inv.InstanceID, inv.TurnID, inv.ToolName, intent, model.ChainOpen, ts, ts, ts, inv.Error, nullableJSON(input))
+	case open:
+		var diff string
+		if lastInput.Valid {
+			diff = diffInputs(json.RawMessage(lastInput.String), input)
+		}
+		_, err = db.ExecContext(ctx,
+			`UPDATE retry_chains SET outcome = ?, ended_at = ?, input_diff = ?, recovered_by = ? WHERE id = ?`,
+			model.ChainRecovered, ts, diff, inv.ID, id)
+	default:
+		return nil // a success with nothing to recover
+	}
+	if err != nil {
+		return fmt.Errorf("record retry chain: %w", err)
+	}
+	if _, err := db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO retry_chain_attempts (invocation_id, chain_id) VALUES (?, ?)`, inv.ID, chainID,
+	); err != nil {
+		return fmt.Errorf("record retry chain attempt: %w", err)
+	}
+	return nil
+}
+
+// chainIntent names what a call was trying to do: its tool, qualified by
+// the first command name for shell calls so that a failing scp isn't
+// recovered by a later ls.
+func chainIntent(tool string, input json.RawMessage) string {
+	var in struct {
+		Command string `json:"command"`
+	}
+	if len(input) == 0 || json.Unmarshal(input, &in) != nil || in.Command == "" {
+		return tool
+	}
+	for _, seg := range cmdparse.Parse(in.Command) {
+		if seg.Command != "" {
+			return tool + ":" + seg.Command
+		}
+	}
+	return tool
+}
+
+// diffInputs describes how after differs from before. Objects are compared
+// key by key ("command: "scp -r" → "scp -R""); anything else as a whole.
+// Identical inputs give "(unchanged)", and a missing one gives "".
+func diffInputs(before, after json.RawMessage) string {
+	if len(before) == 0 || len(after) == 0 {
+		return ""
+	}
+	var b, a map[string]json.RawMessage
+	if json.Unmarshal(before, &b) != nil || json.Unmarshal(after, &a) != nil {
+		if string(before) == string(after) {
+			return "(unchanged)"
+		}
+		return diffValue(before) + " → " + diffValue(after)
+	}
+
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		bv, inB := b[k]
+		av, inA := a[k]
+		switch {
+		case !inA:
+			parts = append(parts, "-"+k)
+		case !inB:
+			parts = append(parts, "+"+k+": "+diffValue(av))
+		case compactJSON(bv) != compactJSON(av):
+			parts = append(parts, k+": "+diffValue(bv)+" → "+diffValue(av))
+		}
+	}
+	if len(parts) == 0 {
+		return "(unchanged)"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// diffValue shows a JSON value compactly, cut to 60 characters.
+func diffValue(v json.RawMessage) string {
+	s := []rune(compactJSON(v))
+	if len(s) > 60 {
+		return string(s[:57]) + "..."
+	}
+	return string(s)
+}
+
+func compactJSON(v json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil {
+		return string(v)
+	}
+	return buf.String()
+}
+
+const chainColumns = "id, session_id, turn_id, tool_name, intent, attempts, outcome, timestamp, ended_at, last_error, input_diff, recovered_by"
+
+// chainsAsOf selects retry_chains with open chains that have been idle
+// since before its two parameters (see idleCutoff) shown as abandoned at
+// their last failure. Chain tracking only abandons a chain when its session
+// makes another call, which a session that gives up and ends never does.
+var chainsAsOf = `SELECT id, session_id, turn_id, tool_name, intent, attempts,
+	CASE WHEN outcome = '` + model.ChainOpen + `' AND last_failure_at < ? THEN '` + model.ChainAbandoned + `' ELSE outcome END AS outcome,
+	timestamp, last_failure_at,
+	CASE WHEN outcome = '` + model.ChainOpen + `' AND last_failure_at < ? THEN last_failure_at ELSE ended_at END AS ended_at,
+	last_error, last_input, input_diff, recovered_by
+	FROM retry_chains`
+
+// idleCutoff returns the arguments of chainsAsOf for now.
+func idleCutoff() []any {
+	idle := time.Now().Add(-chainIdle).UTC().Format(time.RFC3339Nano)
+	return []any{idle, idle}
+}
+
+// ListRetryChains returns retry chains matching opts, newest first.
+func (s *SQLiteStore) ListRetryChains(ctx context.Context, opts ChainOpts) ([]model.RetryChain, error) {
+	query := "SELECT " + chainColumns + " FROM (" + chainsAsOf + ") WHERE 1=1"
+	args := idleCutoff()
+	if !opts.Since.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, opts.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if opts.ToolName != "" {
+		query += " AND tool_name = ?"
+		args = append(args, opts.ToolName)
+	}
+	if opts.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, opts.SessionID)
+	}
+	if opts.Outcome != "" {
+		query += " AND outcome = ?"
+		args = append(args, opts.Outcome)
+	}
+	if opts.Cursor != "" {
+		cond, cargs, err := afterTime(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query += cond
+		args = append(args, cargs...)
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query retry chains: %w", err)
+	}
+	defer rows.Close()
+
+	var chains []model.RetryChain
+	for rows.Next() {
+		var c model.RetryChain
+		var started, ended string
+		if err := rows.Scan(&c.ID, &c.SessionID, &c.TurnID, &c.ToolName, &c.Intent, &c.Attempts, &c.Outcome,
+			&started, &ended, &c.LastError, &c.InputDiff, &c.RecoveredBy); err != nil {
+			return nil, fmt.Errorf("scan retry chain: %w", err)
+		}
+		c.StartedAt, _ = time.Parse(time.RFC3339Nano, started)
+		c.EndedAt, _ = time.Parse(time.RFC3339Nano, ended)
+		c.ElapsedMs = c.EndedAt.Sub(c.StartedAt).Milliseconds()
+		chains = append(chains, c)
+	}
+	return chains, rows.Err()
+}
+
+// chainSummary aggregates the retry chains whose tool matches matchClause,
+// for InspectPath. It returns nil when there are none.
+func (s *SQLiteStore) chainSummary(ctx context.Context, matchClause string, args []any, topN int) (*ChainSummary, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT attempts, outcome, timestamp, ended_at, input_diff FROM ("+chainsAsOf+") WHERE "+matchClause,
+		append(idleCutoff(), args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("inspect retry chains: %w", err)
+	}
+	defer rows.Close()
+
+	var sum ChainSummary
+	var attempts int
+	var recoveryMs int64
+	fixes := make(map[string]int)
+	for rows.Next() {
+		var n int
+		var outcome, started, ended, diff string
+		if err := rows.Scan(&n, &outcome, &started, &ended, &diff); err != nil {
+			return nil, fmt.Errorf("scan retry chain: %w", err)
+		}
+		sum.Total++
+		attempts += n
+		switch outcome {
+		case model.ChainRecovered:
+			sum.Recovered++
+			st, _ := time.Parse(time.RFC3339Nano, started)
+			et, _ := time.Parse(time.RFC3339Nano, ended)
+			recoveryMs += et.Sub(st).Milliseconds()
+			if diff != "" {
+				fixes[diff]++
+			}
+		case model.ChainAbandoned:
+			sum.Abandoned++
+		default:
+			sum.Open++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if sum.Total == 0 {
+		return nil, nil
+	}
+	sum.AvgAttempts = float64(attempts) / float64(sum.Total)
+	if sum.Recovered > 0 {
+		sum.AvgRecoveryMs = recoveryMs / int64(sum.Recovered)
+	}
+	for fix, n := range fixes {
+		sum.TopFixes = append(sum.TopFixes, NameCount{Name: fix, Count: n})
+	}
+	sort.Slice(sum.TopFixes, func(i, j int) bool {
+		if sum.TopFixes[i].Count != sum.TopFixes[j].Count {
+			return sum.TopFixes[i].Count > sum.TopFixes[j].Count
+		}
+		return sum.TopFixes[i].Name < sum.TopFixes[j].Name
+	})
+	if len(sum.TopFixes) > topN {
+		sum.TopFixes = sum.TopFixes[:topN]
+	}
+	return &sum, nil
+}
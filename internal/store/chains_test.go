@@ -0,0 +1,298 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// chainCall is one invocation fed to TrackRetryChain in tests.
+type chainCall struct {
+	id     string
+	turn   string
+	fail   bool
+	input  string
+	offset time.Duration
+}
+
+func trackCalls(t *testing.T, s *SQLiteStore, session, tool string, base time.Time, calls []chainCall) {
+	t.Helper()
+	ctx := context.Background()
+	for _, c := range calls {
+		inv := model.Invocation{
+			ID:         c.id,
+			Source:     "claude-code",
+			InstanceID: session,
+			ToolName:   tool,
+			IsError:    c.fail,
+			TurnID:     c.turn,
+			Timestamp:  base.Add(c.offset),
+		}
+		if c.fail {
+			inv.Error = "exit status 1"
+		}
+		var input json.RawMessage
+		if c.input != "" {
+			input = json.RawMessage(c.input)
+		}
+		if err := s.TrackRetryChain(ctx, inv, input); err != nil {
+			t.Fatalf("TrackRetryChain %s: %v", c.id, err)
+		}
+	}
+}
+
+func TestTrackRetryChainRecovered(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	trackCalls(t, s, "sess-1", "Bash", base, []chainCall{
+		{id: "a", turn: "t1", fail: true, input: `{"command":"scp -r a host:"}`},
+		{id: "b", turn: "t1", fail: true, input: `{"command":"scp -r a host:/tmp"}`, offset: 5 * time.Second},
+		{id: "c", turn: "t1", input: `{"command":"scp -R a host:/tmp"}`, offset: 12 * time.Second},
+	})
+
+	chains, err := s.ListRetryChains(context.Background(), ChainOpts{})
+	if err != nil {
+		t.Fatalf("ListRetryChains: %v", err)
+	}
+	if len(chains) != 1 {
+		t.Fatalf("expected 1 chain, got %d", len(chains))
+	}
+	c := chains[0]
+	if c.ID != "a-chain" || c.Intent != "Bash:scp" || c.Attempts != 2 || c.Outcome != model.ChainRecovered {
+		t.Errorf("chain = %+v", c)
+	}
+	if c.ElapsedMs != 12000 {
+		t.Errorf("ElapsedMs = %d, want 12000", c.ElapsedMs)
+	}
+	if c.RecoveredBy != "c" || c.LastError != "exit status 1" {
+		t.Errorf("RecoveredBy = %q, LastError = %q", c.RecoveredBy, c.LastError)
+	}
+	want := `command: "scp -r a host:/tmp" → "scp -R a host:/tmp"`
+	if c.InputDiff != want {
+		t.Errorf("InputDiff = %q, want %q", c.InputDiff, want)
+	}
+}
+
+func TestTrackRetryChainSeparatesIntents(t *testing.T) {
+	s := newTestStore(t)
+	// Recent, so that open chains haven't gone idle.
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	trackCalls(t, s, "sess-1", "Bash", base, []chainCall{
+		{id: "a", fail: true, input: `{"command":"scp a host:"}`},
+		{id: "b", input: `{"command":"ls -la"}`, offset: time.Second},
+	})
+
+	chains, err := s.ListRetryChains(context.Background(), ChainOpts{})
+	if err != nil {
+		t.Fatalf("ListRetryChains: %v", err)
+	}
+	if len(chains) != 1 || chains[0].Outcome != model.ChainOpen {
+		t.Fatalf("a later ls should not recover a failing scp: %+v", chains)
+	}
+}
+
+func TestTrackRetryChainAbandoned(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	trackCalls(t, s, "sess-1", "Read", base, []chainCall{
+		// Turn changes: the first chain is abandoned.
+		{id: "a", turn: "t1", fail: true, input: `{"file_path":"/x"}`},
+		{id: "b", turn: "t2", fail: true, input: `{"file_path":"/y"}`, offset: time.Minute},
+		// Idle for over half an hour: the second one is too.
+		{id: "c", turn: "t2", input: `{"file_path":"/y"}`, offset: time.Hour},
+	})
+	// Other sessions are unaffected.
+	trackCalls(t, s, "sess-2", "Read", base, []chainCall{
+		{id: "d", turn: "t9", fail: true, input: `{"file_path":"/z"}`, offset: time.Hour},
+	})
+	// A session that ended after failing never makes another call; its
+	// chain is abandoned once it has been idle for half an hour.
+	trackCalls(t, s, "sess-3", "Read", base, []chainCall{
+		{id: "e", turn: "t5", fail: true, input: `{"file_path":"/w"}`},
+	})
+
+	chains, err := s.ListRetryChains(ctx, ChainOpts{Outcome: model.ChainAbandoned})
+	if err != nil {
+		t.Fatalf("ListRetryChains: %v", err)
+	}
+	if len(chains) != 3 {
+		t.Fatalf("expected 3 abandoned chains, got %+v", chains)
+	}
+	for _, c := range chains {
+		if c.ElapsedMs != 0 {
+			t.Errorf("abandoned chain %s should end at its last failure, elapsed %d", c.ID, c.ElapsedMs)
+		}
+	}
+	open, err := s.ListRetryChains(ctx, ChainOpts{Outcome: model.ChainOpen})
+	if err != nil {
+		t.Fatalf("ListRetryChains: %v", err)
+	}
+	if len(open) != 1 || open[0].SessionID != "sess-2" {
+		t.Errorf("open chains = %+v", open)
+	}
+}
+
+func TestTrackRetryChainResentBatch(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	// Recent, so that open chains haven't gone idle.
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	batch := []model.Invocation{
+		{ID: "a", Source: "claude-code", InstanceID: "sess-1", ToolName: "Read", IsError: true, Error: "no such file", TurnID: "t1", Timestamp: base},
+		{ID: "b", Source: "claude-code", InstanceID: "sess-1", ToolName: "Read", IsError: true, Error: "no such file", TurnID: "t1", Timestamp: base.Add(time.Second)},
+		{ID: "c", Source: "claude-code", InstanceID: "sess-1", ToolName: "Grep", IsError: true, Error: "bad regex", TurnID: "t1", Timestamp: base.Add(2 * time.Second)},
+		{ID: "d", Source: "claude-code", InstanceID: "sess-1", ToolName: "Read", TurnID: "t1", Timestamp: base.Add(3 * time.Second)},
+	}
+	// Store and track the batch the way ingest does, then resend it: the
+	// invocations are ignored as duplicates and must not be tracked again.
+	for range 2 {
+		if err := s.RecordInvocations(ctx, batch); err != nil {
+			t.Fatalf("RecordInvocations: %v", err)
+		}
+		for _, inv := range batch {
+			if err := s.TrackRetryChain(ctx, inv, nil); err != nil {
+				t.Fatalf("TrackRetryChain %s: %v", inv.ID, err)
+			}
+		}
+	}
+
+	chains, err := s.ListRetryChains(ctx, ChainOpts{})
+	if err != nil {
+		t.Fatalf("ListRetryChains: %v", err)
+	}
+	if len(chains) != 2 {
+		t.Fatalf("expected the Read and Grep chains only, got %+v", chains)
+	}
+	for _, c := range chains {
+		switch c.ToolName {
+		case "Read":
+			if c.Attempts != 2 || c.Outcome != model.ChainRecovered || c.RecoveredBy != "d" {
+				t.Errorf("Read chain = %+v", c)
+			}
+		case "Grep":
+			if c.Attempts != 1 || c.Outcome != model.ChainOpen {
+				t.Errorf("Grep chain = %+v", c)
+			}
+		}
+	}
+}
+
+func TestBackfillRetryChains(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	if err := s.RecordDesire(ctx, model.Desire{
+		ID: "d1", ToolName: "Bash", ToolInput: json.RawMessage(`{"command":"make tset"}`),
+		Error: "no rule", Source: "claude-code", SessionID: "sess-1", Timestamp: base,
+	}); err != nil {
+		t.Fatalf("RecordDesire: %v", err)
+	}
+	invs := []model.Invocation{
+		{ID: "i1", Source: "claude-code", InstanceID: "sess-1", ToolName: "Bash", IsError: true, Error: "no rule", Timestamp: base},
+		{ID: "i2", Source: "claude-code", InstanceID: "sess-1", ToolName: "Bash", Timestamp: base.Add(3 * time.Second)},
+		{ID: "i3", Source: "claude-code", ToolName: "Bash", IsError: true, Timestamp: base.Add(4 * time.Second)},
+	}
+	for _, inv := range invs {
+		if err := s.RecordInvocation(ctx, inv); err != nil {
+			t.Fatalf("RecordInvocation: %v", err)
+		}
+	}
+	if err := s.backfillRetryChains(); err != nil {
+		t.Fatalf("backfillRetryChains: %v", err)
+	}
+
+	chains, err := s.ListRetryChains(ctx, ChainOpts{})
+	if err != nil {
+		t.Fatalf("ListRetryChains: %v", err)
+	}
+	if len(chains) != 1 {
+		t.Fatalf("expected 1 chain, got %+v", chains)
+	}
+	c := chains[0]
+	if c.Intent != "Bash:make" || c.Outcome != model.ChainRecovered || c.ElapsedMs != 3000 || c.InputDiff != "" {
+		t.Errorf("chain = %+v", c)
+	}
+}
+
+func TestInspectPathChains(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	// Recent, so that open chains haven't gone idle.
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	if err := s.RecordDesire(ctx, model.Desire{ID: "d1", ToolName: "Read", Error: "not found", Source: "claude-code", Timestamp: base}); err != nil {
+		t.Fatalf("RecordDesire: %v", err)
+	}
+
+	result, err := s.InspectPath(ctx, InspectOpts{Pattern: "Read"})
+	if err != nil {
+		t.Fatalf("InspectPath: %v", err)
+	}
+	if result.Chains != nil {
+		t.Errorf("expected no chain summary, got %+v", result.Chains)
+	}
+
+	trackCalls(t, s, "sess-1", "Read", base, []chainCall{
+		{id: "a", turn: "t1", fail: true, input: `{"file_path":"a.go"}`},
+		{id: "b", turn: "t1", input: `{"file_path":"./a.go"}`, offset: 2 * time.Second},
+		{id: "c", turn: "t1", fail: true, input: `{"file_path":"b.go"}`, offset: 10 * time.Second},
+		{id: "d", turn: "t1", fail: true, input: `{"file_path":"b.go"}`, offset: 11 * time.Second},
+		{id: "e", turn: "t2", fail: true, input: `{"file_path":"c.go"}`, offset: 20 * time.Second},
+	})
+	result, err = s.InspectPath(ctx, InspectOpts{Pattern: "Read"})
+	if err != nil {
+		t.Fatalf("InspectPath: %v", err)
+	}
+	c := result.Chains
+	if c == nil {
+		t.Fatal("expected a chain summary")
+	}
+	if c.Total != 3 || c.Recovered != 1 || c.Abandoned != 1 || c.Open != 1 {
+		t.Errorf("summary = %+v", c)
+	}
+	if c.AvgAttempts < 1.33 || c.AvgAttempts > 1.34 || c.AvgRecoveryMs != 2000 {
+		t.Errorf("AvgAttempts = %v, AvgRecoveryMs = %d", c.AvgAttempts, c.AvgRecoveryMs)
+	}
+	if len(c.TopFixes) != 1 || c.TopFixes[0].Name != `file_path: "a.go" → "./a.go"` {
+		t.Errorf("TopFixes = %+v", c.TopFixes)
+	}
+}
+
+func TestDiffInputs(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{"missing", ``, `{"a":1}`, ""},
+		{"unchanged", `{"a": 1}`, `{"a":1}`, "(unchanged)"},
+		{"changed", `{"a":1,"b":"x"}`, `{"a":2,"b":"x"}`, "a: 1 → 2"},
+		{"added and removed", `{"a":1}`, `{"b":true}`, "-a; +b: true"},
+		{"not objects", `"x"`, `"y"`, `"x" → "y"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffInputs(json.RawMessage(tt.before), json.RawMessage(tt.after)); got != tt.want {
+				t.Errorf("diffInputs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainIntent(t *testing.T) {
+	tests := []struct {
+		tool, input, want string
+	}{
+		{"Bash", `{"command":"scp -r a b"}`, "Bash:scp"},
+		{"Read", `{"file_path":"/x"}`, "Read"},
+		{"Bash", ``, "Bash"},
+	}
+	for _, tt := range tests {
+		if got := chainIntent(tt.tool, json.RawMessage(tt.input)); got != tt.want {
+			t.Errorf("chainIntent(%s, %s) = %q, want %q", tt.tool, tt.input, got, tt.want)
+		}
+	}
+}
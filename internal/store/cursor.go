@@ -0,0 +1,96 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// ErrBadCursor is returned (wrapped) when a pagination cursor cannot be
+// decoded.
+var ErrBadCursor = errors.New("invalid cursor")
+
+// Page is one page of a paginated list. NextCursor is empty on the last
+// page; otherwise passing it back as the cursor returns the next page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPage returns items as a page of at most limit items. When the page is
+// full there may be more, so its NextCursor is the cursor of the last item.
+func NewPage[T any](items []T, limit int, cursor func(T) string) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	p := Page[T]{Items: items}
+	if limit > 0 && len(items) >= limit {
+		p.NextCursor = cursor(items[len(items)-1])
+	}
+	return p
+}
+
+// cursorKey is the keyset position a cursor encodes: the sort key of the
+// last row returned. Lists sorted by time use Time and ID; turns, sorted by
+// length, use Length and ID.
+type cursorKey struct {
+	Time   string `json:"t,omitempty"`
+	Length int    `json:"n,omitempty"`
+	ID     string `json:"id"`
+}
+
+func (k cursorKey) encode() string {
+	data, _ := json.Marshal(k)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursorKey, error) {
+	var k cursorKey
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return k, fmt.Errorf("%w %q", ErrBadCursor, s)
+	}
+	if err := json.Unmarshal(data, &k); err != nil || k.ID == "" {
+		return k, fmt.Errorf("%w %q", ErrBadCursor, s)
+	}
+	return k, nil
+}
+
+// timeCursor encodes a position in a list sorted newest first. The time is
+// formatted as the database stores it, so it compares like the column.
+func timeCursor(ts time.Time, id string) string {
+	return cursorKey{Time: ts.UTC().Format(time.RFC3339Nano), ID: id}.encode()
+}
+
+// DesireCursor returns the cursor that continues a desire list after d.
+func DesireCursor(d model.Desire) string { return timeCursor(d.Timestamp, d.ID) }
+
+// InvocationCursor returns the cursor that continues an invocation list
+// after inv.
+func InvocationCursor(inv model.Invocation) string { return timeCursor(inv.Timestamp, inv.ID) }
+
+// RecoveryCursor returns the cursor that continues a recovery list after r.
+func RecoveryCursor(r model.Recovery) string { return timeCursor(r.Timestamp, r.ID) }
+
+// ChainCursor returns the cursor that continues a retry chain list after c.
+func ChainCursor(c model.RetryChain) string { return timeCursor(c.StartedAt, c.ID) }
+
+// TurnCursor returns the cursor that continues a turn list after t.
+func TurnCursor(t TurnRow) string { return cursorKey{Length: t.Length, ID: t.TurnID}.encode() }
+
+// afterTime returns the SQL condition and arguments that select rows after
+// a time cursor in a list ordered by timestamp DESC, id DESC.
+func afterTime(cursor string) (string, []any, error) {
+	k, err := decodeCursor(cursor)
+	if err != nil {
+		return "", nil, err
+	}
+	if k.Time == "" {
+		return "", nil, fmt.Errorf("%w %q: not a time cursor", ErrBadCursor, cursor)
+	}
+	return " AND (timestamp < ? OR (timestamp = ? AND id < ?))", []any{k.Time, k.Time, k.ID}, nil
+}
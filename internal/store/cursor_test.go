@@ -0,0 +1,250 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func TestListDesiresCursor(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// d0 and d1 share a timestamp, so the ID breaks the tie.
+	for i, off := range []int{0, 0, 1, 2, 3} {
+		d := model.Desire{ID: fmt.Sprintf("d%d", i), ToolName: "read_file", Error: "x", Timestamp: base.Add(time.Duration(off) * time.Minute)}
+		if err := s.RecordDesire(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	opts := ListOpts{Limit: 2}
+	for range 5 {
+		page, err := s.ListDesires(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range page {
+			got = append(got, d.ID)
+		}
+		if len(page) < opts.Limit {
+			break
+		}
+		opts.Cursor = DesireCursor(page[len(page)-1])
+	}
+	want := "[d4 d3 d2 d1 d0]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("pages = %v, want %s", got, want)
+	}
+
+	if _, err := s.ListDesires(ctx, ListOpts{Cursor: "not-a-cursor"}); !errors.Is(err, ErrBadCursor) {
+		t.Errorf("bad cursor: err = %v, want ErrBadCursor", err)
+	}
+	if _, err := s.ListTurns(ctx, TurnOpts{Cursor: "e30"}); !errors.Is(err, ErrBadCursor) {
+		t.Errorf("cursor without ID: err = %v, want ErrBadCursor", err)
+	}
+}
+
+func TestListInvocationsAndRecoveriesCursor(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		inv := model.Invocation{ID: fmt.Sprintf("i%d", i), Source: "test", ToolName: "Read", Timestamp: base.Add(time.Duration(i) * time.Minute)}
+		if err := s.RecordInvocation(ctx, inv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, err := s.ListInvocations(ctx, InvocationOpts{Limit: 2})
+	if err != nil || len(first) != 2 {
+		t.Fatalf("first page = %v, %v", first, err)
+	}
+	rest, err := s.ListInvocations(ctx, InvocationOpts{Limit: 2, Cursor: InvocationCursor(first[1])})
+	if err != nil || len(rest) != 1 || rest[0].ID != "i0" {
+		t.Fatalf("second page = %v, %v", rest, err)
+	}
+
+	// A recovery cursor continues a recovery list the same way.
+	if _, err := s.db.Exec(`INSERT INTO recoveries (id, tool_name, desire_id, timestamp) VALUES
+		('r1', 'Read', 'd1', ?), ('r2', 'Read', 'd2', ?)`,
+		base.Format(time.RFC3339Nano), base.Add(time.Minute).Format(time.RFC3339Nano)); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := s.ListRecoveries(ctx, RecoveryOpts{Limit: 1})
+	if err != nil || len(recs) != 1 || recs[0].ID != "r2" {
+		t.Fatalf("first recovery page = %v, %v", recs, err)
+	}
+	recs, err = s.ListRecoveries(ctx, RecoveryOpts{Limit: 1, Cursor: RecoveryCursor(recs[0])})
+	if err != nil || len(recs) != 1 || recs[0].ID != "r1" {
+		t.Fatalf("second recovery page = %v, %v", recs, err)
+	}
+}
+
+func TestListTurnsCursor(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	// Turns t-a and t-b have 2 calls, t-c has 3.
+	for _, turn := range []struct {
+		id    string
+		tools []string
+	}{
+		{"t-b", []string{"Grep", "Read"}},
+		{"t-c", []string{"Grep", "Read", "Edit"}},
+		{"t-a", []string{"Glob", "Read"}},
+	} {
+		for i, tool := range turn.tools {
+			inv := model.Invocation{
+				ID: turn.id + strconv.Itoa(i), Source: "test", ToolName: tool, Timestamp: now,
+				TurnID: turn.id, TurnSequence: i + 1, TurnLength: len(turn.tools),
+			}
+			if err := s.RecordInvocation(ctx, inv); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var got []string
+	opts := TurnOpts{Limit: 2}
+	for range 3 {
+		page, err := s.ListTurns(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tr := range page {
+			got = append(got, tr.TurnID)
+		}
+		if len(page) < opts.Limit {
+			break
+		}
+		opts.Cursor = TurnCursor(page[len(page)-1])
+	}
+	if fmt.Sprint(got) != "[t-c t-a t-b]" {
+		t.Errorf("turn pages = %v, want [t-c t-a t-b]", got)
+	}
+
+	// A pattern filter applies before the limit.
+	turns, err := s.ListTurns(ctx, TurnOpts{Pattern: "Grep → Read", Limit: 1})
+	if err != nil || len(turns) != 1 || turns[0].TurnID != "t-b" {
+		t.Errorf("pattern turns = %v, %v", turns, err)
+	}
+}
+
+func TestListTurnsCursorGrowingLengths(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	// Each call records the turn's length so far, as ingest does.
+	for _, turn := range []struct {
+		id    string
+		tools []string
+	}{
+		{"t-a", []string{"Grep", "Read", "Edit"}},
+		{"t-b", []string{"Glob", "Read"}},
+		{"t-c", []string{"Bash"}},
+	} {
+		for i, tool := range turn.tools {
+			inv := model.Invocation{
+				ID: turn.id + strconv.Itoa(i), Source: "test", ToolName: tool, Timestamp: now,
+				TurnID: turn.id, TurnSequence: i + 1, TurnLength: i + 1,
+			}
+			if err := s.RecordInvocation(ctx, inv); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var got []string
+	opts := TurnOpts{Limit: 1}
+	for range 4 {
+		page, err := s.ListTurns(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		tr := page[0]
+		got = append(got, fmt.Sprintf("%s/%d: %s", tr.TurnID, tr.Length, tr.Tools))
+		opts.Cursor = TurnCursor(tr)
+	}
+	want := "[t-a/3: Grep → Read → Edit t-b/2: Glob → Read t-c/1: Bash]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("turn pages = %v, want %v", got, want)
+	}
+}
+
+func TestRemoteListPages(t *testing.T) {
+	local := newTestStore(t)
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ds := make([]model.Desire, 2*remotePageSize+10)
+	for i := range ds {
+		ds[i] = model.Desire{ID: fmt.Sprintf("d%05d", i), ToolName: "read_file", Error: "x", Timestamp: base.Add(time.Duration(i) * time.Second)}
+	}
+	if err := local.RecordDesires(ctx, ds); err != nil {
+		t.Fatal(err)
+	}
+
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		desires, err := local.ListDesires(r.Context(), ListOpts{Limit: limit, Cursor: r.URL.Query().Get("cursor")})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(NewPage(desires, limit, DesireCursor))
+	}))
+	t.Cleanup(ts.Close)
+	remote := NewRemote(ts.URL)
+
+	all, err := remote.ListDesires(ctx, ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(ds) || all[0].ID != ds[len(ds)-1].ID || all[len(all)-1].ID != "d00000" {
+		t.Fatalf("got %d desires, want %d in order", len(all), len(ds))
+	}
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3 pages", requests)
+	}
+
+	some, err := remote.ListDesires(ctx, ListOpts{Limit: remotePageSize + 5})
+	if err != nil || len(some) != remotePageSize+5 {
+		t.Errorf("limited list: %d desires, %v", len(some), err)
+	}
+}
+
+func TestRemoteListWithoutPagination(t *testing.T) {
+	// A server that predates pagination returns plain arrays and ignores
+	// the cursor; a full first page means the list may have been cut short.
+	var limits []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limits = append(limits, r.URL.Query().Get("limit"))
+		n := remotePageSize + 1
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l < n {
+			n = l
+		}
+		json.NewEncoder(w).Encode(make([]model.Recovery, n))
+	}))
+	t.Cleanup(ts.Close)
+
+	recs, err := NewRemote(ts.URL).ListRecoveries(context.Background(), RecoveryOpts{})
+	if err != nil || len(recs) != remotePageSize+1 {
+		t.Fatalf("got %d recoveries, %v", len(recs), err)
+	}
+	if fmt.Sprint(limits) != fmt.Sprintf("[%d ]", remotePageSize) {
+		t.Errorf("limits requested = %q", limits)
+	}
+}
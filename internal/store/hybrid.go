@@ -0,0 +1,632 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// pushBatch caps how many rows HybridStore.Sync sends per batch request.
+const pushBatch = 500
+
+// HybridStore writes to a local SQLite database and replicates to a team
+// server. Writes, alias and doc-mapping lookups, and tokens are served by
+// the embedded SQLiteStore, so hooks never wait on the network. Team-wide
+// reads (desires, paths, invocations, turns, recoveries, and their stats)
+// are served by the server, falling back to the local database when the
+// server cannot be reached.
+//
+// Sync pushes new local desires, invocations, and interventions to the
+// server and reconciles aliases and doc mappings in both directions.
+// Recoveries and retry chains are derived data and are not replicated; the
+// server derives its own from the invocations it receives, taking failed
+// calls' inputs from the desires pushed before them.
+type HybridStore struct {
+	*SQLiteStore
+	remote *RemoteStore
+}
+
+// NewHybrid returns a HybridStore over local and remote.
+func NewHybrid(local *SQLiteStore, remote *RemoteStore) *HybridStore {
+	return &HybridStore{SQLiteStore: local, remote: remote}
+}
+
+// Remote returns the server the store replicates to.
+func (h *HybridStore) Remote() *RemoteStore {
+	return h.remote
+}
+
+// remoteFirst returns the result of remote, or of local if the server
+// cannot be reached.
+func remoteFirst[T any](remote, local func() (T, error)) (T, error) {
+	v, err := remote()
+	if err != nil && Unavailable(err) {
+		return local()
+	}
+	return v, err
+}
+
+func (h *HybridStore) ListDesires(ctx context.Context, opts ListOpts) ([]model.Desire, error) {
+	return remoteFirst(
+		func() ([]model.Desire, error) { return h.remote.ListDesires(ctx, opts) },
+		func() ([]model.Desire, error) { return h.SQLiteStore.ListDesires(ctx, opts) })
+}
+
+func (h *HybridStore) GetPaths(ctx context.Context, opts PathOpts) ([]model.Path, error) {
+	return remoteFirst(
+		func() ([]model.Path, error) { return h.remote.GetPaths(ctx, opts) },
+		func() ([]model.Path, error) { return h.SQLiteStore.GetPaths(ctx, opts) })
+}
+
+func (h *HybridStore) PathTrends(ctx context.Context, opts TrendOpts) (*TrendResult, error) {
+	return remoteFirst(
+		func() (*TrendResult, error) { return h.remote.PathTrends(ctx, opts) },
+		func() (*TrendResult, error) { return h.SQLiteStore.PathTrends(ctx, opts) })
+}
+
+func (h *HybridStore) Stats(ctx context.Context) (Stats, error) {
+	return remoteFirst(
+		func() (Stats, error) { return h.remote.Stats(ctx) },
+		func() (Stats, error) { return h.SQLiteStore.Stats(ctx) })
+}
+
+func (h *HybridStore) InspectPath(ctx context.Context, opts InspectOpts) (*InspectResult, error) {
+	return remoteFirst(
+		func() (*InspectResult, error) { return h.remote.InspectPath(ctx, opts) },
+		func() (*InspectResult, error) { return h.SQLiteStore.InspectPath(ctx, opts) })
+}
+
+func (h *HybridStore) ListInvocations(ctx context.Context, opts InvocationOpts) ([]model.Invocation, error) {
+	return remoteFirst(
+		func() ([]model.Invocation, error) { return h.remote.ListInvocations(ctx, opts) },
+		func() ([]model.Invocation, error) { return h.SQLiteStore.ListInvocations(ctx, opts) })
+}
+
+func (h *HybridStore) InvocationStats(ctx context.Context) (InvocationStatsResult, error) {
+	return remoteFirst(
+		func() (InvocationStatsResult, error) { return h.remote.InvocationStats(ctx) },
+		func() (InvocationStatsResult, error) { return h.SQLiteStore.InvocationStats(ctx) })
+}
+
+func (h *HybridStore) ListTurns(ctx context.Context, opts TurnOpts) ([]TurnRow, error) {
+	return remoteFirst(
+		func() ([]TurnRow, error) { return h.remote.ListTurns(ctx, opts) },
+		func() ([]TurnRow, error) { return h.SQLiteStore.ListTurns(ctx, opts) })
+}
+
+func (h *HybridStore) TurnPatternStats(ctx context.Context, opts TurnOpts) ([]TurnPattern, error) {
+	return remoteFirst(
+		func() ([]TurnPattern, error) { return h.remote.TurnPatternStats(ctx, opts) },
+		func() ([]TurnPattern, error) { return h.SQLiteStore.TurnPatternStats(ctx, opts) })
+}
+
+func (h *HybridStore) ToolTurnStats(ctx context.Context, opts TurnOpts) ([]ToolTurnStat, error) {
+	return remoteFirst(
+		func() ([]ToolTurnStat, error) { return h.remote.ToolTurnStats(ctx, opts) },
+		func() ([]ToolTurnStat, error) { return h.SQLiteStore.ToolTurnStats(ctx, opts) })
+}
+
+func (h *HybridStore) ListRecoveries(ctx context.Context, opts RecoveryOpts) ([]model.Recovery, error) {
+	return remoteFirst(
+		func() ([]model.Recovery, error) { return h.remote.ListRecoveries(ctx, opts) },
+		func() ([]model.Recovery, error) { return h.SQLiteStore.ListRecoveries(ctx, opts) })
+}
+
+func (h *HybridStore) ListRetryChains(ctx context.Context, opts ChainOpts) ([]model.RetryChain, error) {
+	return remoteFirst(
+		func() ([]model.RetryChain, error) { return h.remote.ListRetryChains(ctx, opts) },
+		func() ([]model.RetryChain, error) { return h.SQLiteStore.ListRetryChains(ctx, opts) })
+}
+
+func (h *HybridStore) RecoveryStats(ctx context.Context) ([]model.RecoveryStat, error) {
+	return remoteFirst(
+		func() ([]model.RecoveryStat, error) { return h.remote.RecoveryStats(ctx) },
+		func() ([]model.RecoveryStat, error) { return h.SQLiteStore.RecoveryStats(ctx) })
+}
+
+func (h *HybridStore) StrugglingTools(ctx context.Context, opts StrugglingOpts) ([]model.StrugglingTool, error) {
+	return remoteFirst(
+		func() ([]model.StrugglingTool, error) { return h.remote.StrugglingTools(ctx, opts) },
+		func() ([]model.StrugglingTool, error) { return h.SQLiteStore.StrugglingTools(ctx, opts) })
+}
+
+func (h *HybridStore) InterventionStats(ctx context.Context, since time.Time) ([]model.InterventionStat, error) {
+	return remoteFirst(
+		func() ([]model.InterventionStat, error) { return h.remote.InterventionStats(ctx, since) },
+		func() ([]model.InterventionStat, error) { return h.SQLiteStore.InterventionStats(ctx, since) })
+}
+
+func (h *HybridStore) SourceToolCounts(ctx context.Context) ([]SourceToolCount, error) {
+	return remoteFirst(
+		func() ([]SourceToolCount, error) { return h.remote.SourceToolCounts(ctx) },
+		func() ([]SourceToolCount, error) { return h.SQLiteStore.SourceToolCounts(ctx) })
+}
+
+// DeleteAlias removes the alias locally and records a tombstone, so that
+// the next Sync deletes it on the server too.
+func (h *HybridStore) DeleteAlias(ctx context.Context, k model.AliasKey) (bool, error) {
+	a, err := h.SQLiteStore.GetAlias(ctx, k)
+	if err != nil || a == nil {
+		return false, err
+	}
+	deleted, err := h.SQLiteStore.DeleteAlias(ctx, k)
+	if err != nil || !deleted {
+		return deleted, err
+	}
+	if err := h.logAliasSync(ctx, model.AliasSync{Action: model.AliasSyncDelete, Local: a}, a.Key()); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// SetDocMapping writes the mapping to the server, then to the local
+// database. Doc mappings are shared team-wide, so the server is required.
+func (h *HybridStore) SetDocMapping(ctx context.Context, dm model.DocMapping) error {
+	if err := h.remote.SetDocMapping(ctx, dm); err != nil {
+		return err
+	}
+	return h.SQLiteStore.SetDocMapping(ctx, dm)
+}
+
+// DeleteDocMapping deletes the mapping on the server, then locally.
+func (h *HybridStore) DeleteDocMapping(ctx context.Context, id string) (bool, error) {
+	if _, err := h.remote.DeleteDocMapping(ctx, id); err != nil {
+		return false, err
+	}
+	return h.SQLiteStore.DeleteDocMapping(ctx, id)
+}
+
+// SyncResult reports what a HybridStore.Sync replicated.
+type SyncResult struct {
+	Desires        int `json:"desires_pushed"`
+	Invocations    int `json:"invocations_pushed"`
+	Interventions  int `json:"interventions_pushed"`
+	AliasesPulled  int `json:"aliases_pulled"`
+	AliasesPushed  int `json:"aliases_pushed"`
+	AliasesDeleted int `json:"aliases_deleted"` // local deletes applied on the server
+	AliasesRemoved int `json:"aliases_removed"` // server deletes applied locally
+	Conflicts      int `json:"alias_conflicts"`
+	DocMappings    int `json:"doc_mappings_pulled"`
+}
+
+// Sync replicates between the local database and the server:
+//
+//   - desires, invocations, and interventions recorded locally since the
+//     last Sync are pushed, in batches, and a per-table cursor advances
+//     after each batch the server accepts;
+//   - aliases are reconciled in both directions (see syncAliases);
+//   - doc mappings are pulled from the server.
+//
+// Sync stops at the first error. Everything replicated before it is kept,
+// and the next Sync resumes from there.
+func (h *HybridStore) Sync(ctx context.Context) (SyncResult, error) {
+	var res SyncResult
+	var err error
+	if res.Desires, err = pushRows(ctx, h, "desires", h.desiresAfter, h.remote.RecordDesires); err != nil {
+		return res, err
+	}
+	if res.Invocations, err = pushRows(ctx, h, "invocations", h.invocationsAfter, h.remote.RecordInvocations); err != nil {
+		return res, err
+	}
+	if res.Interventions, err = pushRows(ctx, h, "interventions", h.interventionsAfter, h.sendInterventions); err != nil {
+		return res, err
+	}
+	if err := h.syncAliases(ctx, &res); err != nil {
+		return res, err
+	}
+	if res.DocMappings, err = h.pullDocMappings(ctx); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// pushRows sends the rows of table added since its cursor, advancing the
+// cursor after each batch. fetch returns up to limit rows with a rowid
+// greater than after, and the rowid of the last one.
+func pushRows[T any](ctx context.Context, h *HybridStore, table string,
+	fetch func(ctx context.Context, after int64, limit int) ([]T, int64, error),
+	send func(ctx context.Context, rows []T) error) (int, error) {
+	cur, err := h.cursor(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+	after, _ := strconv.ParseInt(cur, 10, 64)
+	pushed := 0
+	for {
+		rows, last, err := fetch(ctx, after, pushBatch)
+		if err != nil {
+			return pushed, err
+		}
+		if len(rows) == 0 {
+			return pushed, nil
+		}
+		if err := send(ctx, rows); err != nil {
+			return pushed, fmt.Errorf("push %s: %w", table, err)
+		}
+		if err := h.setCursor(ctx, table, strconv.FormatInt(last, 10)); err != nil {
+			return pushed, err
+		}
+		pushed += len(rows)
+		after = last
+	}
+}
+
+// sendInterventions sends interventions one at a time; the server has no
+// batch endpoint for them. Ones it already has count as sent.
+func (h *HybridStore) sendInterventions(ctx context.Context, ivs []model.Intervention) error {
+	for _, iv := range ivs {
+		if err := h.remote.RecordIntervention(ctx, iv); err != nil && !errors.Is(err, ErrDuplicateID) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *HybridStore) desiresAfter(ctx context.Context, after int64, limit int) ([]model.Desire, int64, error) {
+	rows, err := h.db.QueryContext(ctx,
+		"SELECT "+desireColumns+", rowid FROM desires WHERE rowid > ? ORDER BY rowid LIMIT ?", after, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list desires to push: %w", err)
+	}
+	defer rows.Close()
+
+	var desires []model.Desire
+	last := after
+	for rows.Next() {
+		d, err := scanDesire(rows, &last)
+		if err != nil {
+			return nil, 0, err
+		}
+		desires = append(desires, d)
+	}
+	return desires, last, rows.Err()
+}
+
+func (h *HybridStore) invocationsAfter(ctx context.Context, after int64, limit int) ([]model.Invocation, int64, error) {
+	rows, err := h.db.QueryContext(ctx,
+		"SELECT "+invocationColumns+", rowid FROM invocations WHERE rowid > ? ORDER BY rowid LIMIT ?", after, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list invocations to push: %w", err)
+	}
+	defer rows.Close()
+
+	var invs []model.Invocation
+	last := after
+	for rows.Next() {
+		inv, err := scanInvocation(rows, &last)
+		if err != nil {
+			return nil, 0, err
+		}
+		invs = append(invs, inv)
+	}
+	return invs, last, rows.Err()
+}
+
+func (h *HybridStore) interventionsAfter(ctx context.Context, after int64, limit int) ([]model.Intervention, int64, error) {
+	rows, err := h.db.QueryContext(ctx,
+		`SELECT id, kind, tool_name, rule, match_kind, session_id, cwd, timestamp, client_id, rowid
+		 FROM interventions WHERE rowid > ? ORDER BY rowid LIMIT ?`, after, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list interventions to push: %w", err)
+	}
+	defer rows.Close()
+
+	var ivs []model.Intervention
+	last := after
+	for rows.Next() {
+		var iv model.Intervention
+		var sessionID, cwd sql.NullString
+		var ts string
+		if err := rows.Scan(&iv.ID, &iv.Kind, &iv.ToolName, &iv.Rule, &iv.MatchKind, &sessionID, &cwd, &ts, &iv.ClientID, &last); err != nil {
+			return nil, 0, fmt.Errorf("scan intervention: %w", err)
+		}
+		iv.SessionID = sessionID.String
+		iv.CWD = cwd.String
+		iv.Timestamp, _ = time.Parse(time.RFC3339Nano, ts)
+		ivs = append(ivs, iv)
+	}
+	return ivs, last, rows.Err()
+}
+
+// cursor returns the replication cursor called name for this server, or
+// "" if it has not been set.
+func (h *HybridStore) cursor(ctx context.Context, name string) (string, error) {
+	var v string
+	err := h.db.QueryRowContext(ctx,
+		`SELECT value FROM replication_cursors WHERE remote = ? AND name = ?`, h.remote.target(), name).Scan(&v)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read replication cursor: %w", err)
+	}
+	return v, nil
+}
+
+func (h *HybridStore) setCursor(ctx context.Context, name, value string) error {
+	_, err := h.db.ExecContext(ctx,
+		`INSERT INTO replication_cursors (remote, name, value, updated_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(remote, name) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		h.remote.target(), name, value, time.Now().UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("write replication cursor: %w", err)
+	}
+	return nil
+}
+
+// syncAliases reconciles aliases with the server. An alias's CreatedAt is
+// reset on every write, so it is the time of its last change; changes since
+// the previous sync are detected against it.
+//
+//   - An alias changed on one side only is copied to the other.
+//   - An alias changed on both sides is a conflict: the one written last
+//     wins (last-writer-wins).
+//   - An alias deleted locally (see DeleteAlias) is deleted on the server,
+//     unless the server's copy changed after the delete.
+//   - An alias that is only local and unchanged since the previous sync
+//     was deleted on the server, and is removed locally.
+//
+// Every change is recorded in the alias audit trail (AliasLog).
+func (h *HybridStore) syncAliases(ctx context.Context, res *SyncResult) error {
+	started := time.Now().UTC()
+	cur, err := h.cursor(ctx, "aliases")
+	if err != nil {
+		return err
+	}
+	var lastSync time.Time
+	if cur != "" {
+		lastSync, _ = time.Parse(time.RFC3339Nano, cur)
+	}
+
+	remote, err := h.remote.GetAliases(ctx)
+	if err != nil {
+		return fmt.Errorf("pull aliases: %w", err)
+	}
+	local, err := h.SQLiteStore.GetAliases(ctx)
+	if err != nil {
+		return err
+	}
+	localByKey := make(map[model.AliasKey]model.Alias, len(local))
+	for _, a := range local {
+		localByKey[a.Key()] = a
+	}
+	tombstones, err := h.tombstones(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range remote {
+		k := r.Key()
+		l, ok := localByKey[k]
+		delete(localByKey, k)
+		switch {
+		case !ok:
+			if del, found := tombstones[k]; found && del.After(r.CreatedAt) {
+				if _, err := h.remote.DeleteAlias(ctx, k); err != nil {
+					return fmt.Errorf("push alias delete: %w", err)
+				}
+				if err := h.logAliasSync(ctx, model.AliasSync{Action: model.AliasSyncPushDelete, Remote: &r}, k); err != nil {
+					return err
+				}
+				res.AliasesDeleted++
+				continue
+			}
+			if err := h.pullAlias(ctx, model.AliasSync{Action: model.AliasSyncPull, Remote: &r}, r, res); err != nil {
+				return err
+			}
+		case l.Same(r):
+			// In step.
+		default:
+			if err := h.resolveAlias(ctx, l, r, lastSync, res); err != nil {
+				return err
+			}
+		}
+	}
+
+	for k, l := range localByKey {
+		if l.CreatedAt.After(lastSync) {
+			if err := h.pushAlias(ctx, model.AliasSync{Action: model.AliasSyncPush, Local: &l}, l, res); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := h.SQLiteStore.DeleteAlias(ctx, k); err != nil {
+			return err
+		}
+		if err := h.logAliasSync(ctx, model.AliasSync{Action: model.AliasSyncRemove, Local: &l}, k); err != nil {
+			return err
+		}
+		res.AliasesRemoved++
+	}
+	return h.setCursor(ctx, "aliases", started.Format(time.RFC3339Nano))
+}
+
+// resolveAlias settles an alias that differs between the local database
+// and the server.
+func (h *HybridStore) resolveAlias(ctx context.Context, l, r model.Alias, lastSync time.Time, res *SyncResult) error {
+	localChanged := l.CreatedAt.After(lastSync)
+	remoteChanged := r.CreatedAt.After(lastSync)
+	entry := model.AliasSync{Local: &l, Remote: &r}
+
+	localWins := l.CreatedAt.After(r.CreatedAt)
+	switch {
+	case localChanged && remoteChanged:
+		entry.Action = model.AliasSyncConflict
+		entry.Winner = "remote"
+		if localWins {
+			entry.Winner = "local"
+		}
+		res.Conflicts++
+	case localChanged:
+		entry.Action = model.AliasSyncPush
+		localWins = true
+	case remoteChanged:
+		entry.Action = model.AliasSyncPull
+		localWins = false
+	default:
+		// Neither changed since the last sync, yet they differ: settle it by
+		// timestamp like a conflict, without counting one.
+		entry.Action = model.AliasSyncPull
+		if localWins {
+			entry.Action = model.AliasSyncPush
+		}
+	}
+	if localWins {
+		return h.pushAlias(ctx, entry, l, res)
+	}
+	return h.pullAlias(ctx, entry, r, res)
+}
+
+func (h *HybridStore) pushAlias(ctx context.Context, entry model.AliasSync, a model.Alias, res *SyncResult) error {
+	if err := h.remote.SetAlias(ctx, a); err != nil {
+		return fmt.Errorf("push alias: %w", err)
+	}
+	res.AliasesPushed++
+	return h.logAliasSync(ctx, entry, a.Key())
+}
+
+func (h *HybridStore) pullAlias(ctx context.Context, entry model.AliasSync, a model.Alias, res *SyncResult) error {
+	if err := h.putAlias(ctx, a); err != nil {
+		return err
+	}
+	res.AliasesPulled++
+	return h.logAliasSync(ctx, entry, a.Key())
+}
+
+// tombstones returns, for each alias deleted locally and not pushed since,
+// when it was deleted.
+func (h *HybridStore) tombstones(ctx context.Context) (map[model.AliasKey]time.Time, error) {
+	rows, err := h.db.QueryContext(ctx,
+		`SELECT from_name, tool, param, command, match_kind, scope_cwd, scope_remote, scope_source, action, timestamp
+		 FROM alias_sync_log WHERE remote = ? AND action IN (?, ?) ORDER BY id`,
+		h.remote.target(), model.AliasSyncDelete, model.AliasSyncPushDelete)
+	if err != nil {
+		return nil, fmt.Errorf("read alias tombstones: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[model.AliasKey]time.Time)
+	for rows.Next() {
+		var k model.AliasKey
+		var action, ts string
+		if err := rows.Scan(&k.From, &k.Tool, &k.Param, &k.Command, &k.MatchKind,
+			&k.ScopeCWD, &k.ScopeRemote, &k.ScopeSource, &action, &ts); err != nil {
+			return nil, fmt.Errorf("scan alias tombstone: %w", err)
+		}
+		if action == model.AliasSyncPushDelete {
+			delete(out, k)
+			continue
+		}
+		t, _ := time.Parse(time.RFC3339Nano, ts)
+		out[k] = t
+	}
+	return out, rows.Err()
+}
+
+// logAliasSync appends an entry to the alias audit trail.
+func (h *HybridStore) logAliasSync(ctx context.Context, e model.AliasSync, k model.AliasKey) error {
+	local, err := aliasJSON(e.Local)
+	if err != nil {
+		return err
+	}
+	remote, err := aliasJSON(e.Remote)
+	if err != nil {
+		return err
+	}
+	_, err = h.db.ExecContext(ctx,
+		`INSERT INTO alias_sync_log (remote, timestamp, action, winner, from_name, tool, param, command, match_kind,
+		                             scope_cwd, scope_remote, scope_source, local_alias, remote_alias)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		h.remote.target(), time.Now().UTC().Format(time.RFC3339Nano), e.Action, e.Winner,
+		k.From, k.Tool, k.Param, k.Command, k.MatchKind, k.ScopeCWD, k.ScopeRemote, k.ScopeSource, local, remote)
+	if err != nil {
+		return fmt.Errorf("log alias sync: %w", err)
+	}
+	return nil
+}
+
+func aliasJSON(a *model.Alias) (any, error) {
+	if a == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, fmt.Errorf("marshal alias: %w", err)
+	}
+	return string(data), nil
+}
+
+// AliasLog returns the most recent entries of the alias audit trail, newest
+// first. A limit of 0 returns all of them.
+func (h *HybridStore) AliasLog(ctx context.Context, limit int) ([]model.AliasSync, error) {
+	query := `SELECT id, timestamp, action, winner, from_name, tool, param, command, match_kind, local_alias, remote_alias
+		 FROM alias_sync_log WHERE remote = ? ORDER BY id DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := h.db.QueryContext(ctx, query, h.remote.target())
+	if err != nil {
+		return nil, fmt.Errorf("read alias log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.AliasSync
+	for rows.Next() {
+		var e model.AliasSync
+		var ts string
+		var local, remote sql.NullString
+		if err := rows.Scan(&e.ID, &ts, &e.Action, &e.Winner, &e.From, &e.Tool, &e.Param, &e.Command, &e.MatchKind, &local, &remote); err != nil {
+			return nil, fmt.Errorf("scan alias log: %w", err)
+		}
+		e.Timestamp, _ = time.Parse(time.RFC3339Nano, ts)
+		if local.Valid {
+			e.Local = new(model.Alias)
+			if err := json.Unmarshal([]byte(local.String), e.Local); err != nil {
+				return nil, fmt.Errorf("decode alias log: %w", err)
+			}
+		}
+		if remote.Valid {
+			e.Remote = new(model.Alias)
+			if err := json.Unmarshal([]byte(remote.String), e.Remote); err != nil {
+				return nil, fmt.Errorf("decode alias log: %w", err)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// pullDocMappings copies the server's doc mappings into the local database,
+// keeping local match counts. It returns how many mappings it wrote.
+func (h *HybridStore) pullDocMappings(ctx context.Context) (int, error) {
+	remote, err := h.remote.GetDocMappings(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("pull doc mappings: %w", err)
+	}
+	local, err := h.SQLiteStore.GetDocMappings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	have := make(map[string]model.DocMapping, len(local))
+	for _, dm := range local {
+		have[dm.ID] = dm
+	}
+	n := 0
+	for _, dm := range remote {
+		if l, ok := have[dm.ID]; ok && l.Pattern == dm.Pattern && l.Tool == dm.Tool &&
+			l.DocPath == dm.DocPath && l.DocExcerpt == dm.DocExcerpt {
+			continue
+		}
+		if err := h.SQLiteStore.SetDocMapping(ctx, dm); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// testHybrid returns a HybridStore and the SQLiteStore behind its server.
+func testHybrid(t *testing.T) (*HybridStore, *SQLiteStore) {
+	t.Helper()
+	server, err := New(filepath.Join(t.TempDir(), "server.db"))
+	if err != nil {
+		t.Fatalf("open server store: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	mux := http.NewServeMux()
+	registerRoutes(mux, server)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	local, err := New(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatalf("open local store: %v", err)
+	}
+	h := NewHybrid(local, NewRemote(ts.URL))
+	t.Cleanup(func() { h.Close() })
+	return h, server
+}
+
+func TestHybridSyncPushesNewRows(t *testing.T) {
+	h, server := testHybrid(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for _, id := range []string{"d1", "d2"} {
+		if err := h.RecordDesire(ctx, model.Desire{ID: id, ToolName: "read_file", Error: "unknown tool", Timestamp: now}); err != nil {
+			t.Fatalf("RecordDesire: %v", err)
+		}
+	}
+	if err := h.RecordInvocation(ctx, model.Invocation{ID: "i1", Source: "test", ToolName: "Read", Timestamp: now}); err != nil {
+		t.Fatalf("RecordInvocation: %v", err)
+	}
+	if err := h.RecordIntervention(ctx, model.Intervention{ID: "iv1", Kind: "block", ToolName: "read_file", Timestamp: now}); err != nil {
+		t.Fatalf("RecordIntervention: %v", err)
+	}
+
+	res, err := h.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.Desires != 2 || res.Invocations != 1 || res.Interventions != 1 {
+		t.Errorf("first sync = %+v, want 2 desires, 1 invocation, 1 intervention", res)
+	}
+	desires, err := server.ListDesires(ctx, ListOpts{})
+	if err != nil {
+		t.Fatalf("server ListDesires: %v", err)
+	}
+	if len(desires) != 2 {
+		t.Errorf("server has %d desires, want 2", len(desires))
+	}
+
+	// Only rows added since the last sync are pushed.
+	if err := h.RecordDesire(ctx, model.Desire{ID: "d3", ToolName: "read_file", Error: "unknown tool", Timestamp: now}); err != nil {
+		t.Fatalf("RecordDesire: %v", err)
+	}
+	res, err = h.Sync(ctx)
+	if err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if res.Desires != 1 || res.Invocations != 0 || res.Interventions != 0 {
+		t.Errorf("second sync = %+v, want only 1 desire", res)
+	}
+
+	// Team-wide reads come from the server.
+	st, err := h.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if st.TotalDesires != 3 {
+		t.Errorf("TotalDesires = %d, want 3", st.TotalDesires)
+	}
+}
+
+func TestHybridSyncAliases(t *testing.T) {
+	h, server := testHybrid(t)
+	ctx := context.Background()
+
+	// New on each side: copied to the other.
+	if err := h.SetAlias(ctx, model.Alias{From: "read_file", To: "Read"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetAlias(ctx, model.Alias{From: "write_file", To: "Write"}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := h.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.AliasesPushed != 1 || res.AliasesPulled != 1 {
+		t.Errorf("first sync = %+v, want 1 pushed and 1 pulled", res)
+	}
+	if a, _ := server.GetAlias(ctx, model.AliasKey{From: "read_file"}); a == nil || a.To != "Read" {
+		t.Errorf("server read_file = %+v, want -> Read", a)
+	}
+	if a, _ := h.GetAlias(ctx, model.AliasKey{From: "write_file"}); a == nil || a.To != "Write" {
+		t.Errorf("local write_file = %+v, want -> Write", a)
+	}
+
+	// A second sync with no changes does nothing.
+	res, err = h.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res != (SyncResult{}) {
+		t.Errorf("idle sync = %+v, want no changes", res)
+	}
+
+	// Changed on both sides: the later write wins.
+	if err := server.SetAlias(ctx, model.Alias{From: "read_file", To: "ReadServer"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := h.SetAlias(ctx, model.Alias{From: "read_file", To: "ReadLocal"}); err != nil {
+		t.Fatal(err)
+	}
+	res, err = h.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.Conflicts != 1 {
+		t.Errorf("Conflicts = %d, want 1", res.Conflicts)
+	}
+	if a, _ := server.GetAlias(ctx, model.AliasKey{From: "read_file"}); a == nil || a.To != "ReadLocal" {
+		t.Errorf("server read_file = %+v, want -> ReadLocal", a)
+	}
+
+	log, err := h.AliasLog(ctx, 1)
+	if err != nil {
+		t.Fatalf("AliasLog: %v", err)
+	}
+	if len(log) != 1 || log[0].Action != model.AliasSyncConflict || log[0].Winner != "local" {
+		t.Fatalf("latest log entry = %+v, want a conflict won by local", log)
+	}
+	if log[0].Local == nil || log[0].Local.To != "ReadLocal" || log[0].Remote == nil || log[0].Remote.To != "ReadServer" {
+		t.Errorf("conflict entry versions = %+v / %+v", log[0].Local, log[0].Remote)
+	}
+
+	// A local delete reaches the server; a server delete reaches local.
+	if _, err := h.DeleteAlias(ctx, model.AliasKey{From: "read_file"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.DeleteAlias(ctx, model.AliasKey{From: "write_file"}); err != nil {
+		t.Fatal(err)
+	}
+	res, err = h.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.AliasesDeleted != 1 || res.AliasesRemoved != 1 {
+		t.Errorf("delete sync = %+v, want 1 deleted and 1 removed", res)
+	}
+	if a, _ := server.GetAlias(ctx, model.AliasKey{From: "read_file"}); a != nil {
+		t.Errorf("server still has read_file: %+v", a)
+	}
+	if a, _ := h.GetAlias(ctx, model.AliasKey{From: "write_file"}); a != nil {
+		t.Errorf("local still has write_file: %+v", a)
+	}
+}
+
+func TestHybridSyncPullsDocMappings(t *testing.T) {
+	h, server := testHybrid(t)
+	ctx := context.Background()
+
+	if err := server.SetDocMapping(ctx, model.DocMapping{ID: "dm-1", Pattern: "Bash", DocPath: "docs/bash.md"}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := h.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.DocMappings != 1 {
+		t.Errorf("DocMappings = %d, want 1", res.DocMappings)
+	}
+	mappings, err := h.GetDocMappings(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mappings) != 1 || mappings[0].DocPath != "docs/bash.md" {
+		t.Errorf("local mappings = %+v", mappings)
+	}
+}
+
+func TestHybridReadsFallBackToLocal(t *testing.T) {
+	local, err := New(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close() // nothing listening
+	h := NewHybrid(local, NewRemote(ts.URL))
+	defer h.Close()
+	ctx := context.Background()
+
+	if err := h.RecordDesire(ctx, model.Desire{ID: "d1", ToolName: "read_file", Error: "unknown tool", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("RecordDesire: %v", err)
+	}
+	desires, err := h.ListDesires(ctx, ListOpts{})
+	if err != nil {
+		t.Fatalf("ListDesires: %v", err)
+	}
+	if len(desires) != 1 {
+		t.Errorf("got %d desires, want the 1 local one", len(desires))
+	}
+
+	// A failed sync keeps its cursor, so the rows are pushed later.
+	if _, err := h.Sync(ctx); err == nil {
+		t.Fatal("Sync with no server succeeded")
+	}
+	if cur, _ := h.cursor(ctx, "desires"); cur != "" {
+		t.Errorf("desires cursor = %q after failed sync, want unset", cur)
+	}
+}
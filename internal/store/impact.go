@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// impactEvent is one failed or successful call considered by the impact
+// score.
+type impactEvent struct {
+	tool    string
+	session string
+	at      time.Time
+}
+
+// orDefault returns w, or DefaultImpactWeights if w is zero. A non-positive
+// half-life falls back to the default one.
+func (w ImpactWeights) orDefault() ImpactWeights {
+	if w == (ImpactWeights{}) {
+		return DefaultImpactWeights
+	}
+	if w.HalfLifeDays <= 0 {
+		w.HalfLifeDays = DefaultImpactWeights.HalfLifeDays
+	}
+	return w
+}
+
+// impactScores computes each tool's distinct sessions and impact score
+// from its failures and the successful calls that break retry chains.
+// Failures without a session ID each count as a session of their own.
+func impactScores(failures, successes []impactEvent, w ImpactWeights, now time.Time) (sessions map[string]int, scores map[string]float64) {
+	w = w.orDefault()
+	type key struct{ tool, session string }
+	bySession := make(map[key][]time.Time)
+	for i, f := range failures {
+		k := key{f.tool, f.session}
+		if k.session == "" {
+			k.session = "\x00" + strconv.Itoa(i)
+		}
+		bySession[k] = append(bySession[k], f.at)
+	}
+	recovered := make(map[key][]time.Time)
+	for _, s := range successes {
+		k := key{s.tool, s.session}
+		if _, ok := bySession[k]; ok {
+			recovered[k] = append(recovered[k], s.at)
+		}
+	}
+
+	sessions = make(map[string]int)
+	scores = make(map[string]float64)
+	halfLife := w.HalfLifeDays * 24 * float64(time.Hour)
+	for k, fails := range bySession {
+		sort.Slice(fails, func(i, j int) bool { return fails[i].Before(fails[j]) })
+		oks := recovered[k]
+		sort.Slice(oks, func(i, j int) bool { return oks[i].Before(oks[j]) })
+
+		longest, chain, next := 0, 0, 0
+		for _, t := range fails {
+			// A success since the previous failure ends the chain.
+			for next < len(oks) && oks[next].Before(t) {
+				chain = 0
+				next++
+			}
+			chain++
+			longest = max(longest, chain)
+		}
+
+		age := float64(now.Sub(fails[len(fails)-1]))
+		decay := math.Pow(0.5, math.Max(age, 0)/halfLife)
+		sessions[k.tool]++
+		scores[k.tool] += decay * (w.Sessions + w.Retries*math.Log(float64(longest)))
+	}
+	for tool, sc := range scores {
+		scores[tool] = math.Round(sc*1000) / 1000
+	}
+	return sessions, scores
+}
+
+// scorePaths fills in Sessions and Impact for paths from the desires and
+// successful invocations recorded since since. Only the paths' own tools
+// are scanned.
+func (s *SQLiteStore) scorePaths(ctx context.Context, paths []model.Path, since time.Time, w ImpactWeights) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	cond := " AND tool_name IN (?" + strings.Repeat(", ?", len(paths)-1) + ")"
+	args := make([]any, 0, len(paths)+1)
+	for _, p := range paths {
+		args = append(args, p.Pattern)
+	}
+	if !since.IsZero() {
+		cond += " AND timestamp >= ?"
+		args = append(args, since.UTC().Format(time.RFC3339Nano))
+	}
+	failures, err := s.impactEvents(ctx, "SELECT tool_name, COALESCE(session_id, ''), timestamp FROM desires WHERE 1=1"+cond, args)
+	if err != nil {
+		return err
+	}
+	successes, err := s.impactEvents(ctx, "SELECT tool_name, instance_id, timestamp FROM invocations WHERE is_error = 0 AND instance_id != ''"+cond, args)
+	if err != nil {
+		return err
+	}
+	sessions, scores := impactScores(failures, successes, w, time.Now())
+	for i := range paths {
+		paths[i].Sessions = sessions[paths[i].Pattern]
+		paths[i].Impact = scores[paths[i].Pattern]
+	}
+	return nil
+}
+
+func (s *SQLiteStore) impactEvents(ctx context.Context, query string, args []any) ([]impactEvent, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("impact events: %w", err)
+	}
+	defer rows.Close()
+	var events []impactEvent
+	for rows.Next() {
+		var e impactEvent
+		var ts string
+		if err := rows.Scan(&e.tool, &e.session, &ts); err != nil {
+			return nil, fmt.Errorf("scan impact event: %w", err)
+		}
+		e.at, _ = time.Parse(time.RFC3339Nano, ts)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// sortPathsByImpact orders paths by impact score, then count, then pattern.
+func sortPathsByImpact(paths []model.Path) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		a, b := paths[i], paths[j]
+		if a.Impact != b.Impact {
+			return a.Impact > b.Impact
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Pattern < b.Pattern
+	})
+}
@@ -382,7 +382,7 @@ func TestEmptyDBAllOperations(t *testing.T) {
 	}
 
 	// DeleteAlias on empty DB.
-	deleted, err := s.DeleteAlias(ctx, "nonexistent", "", "", "", "")
+	deleted, err := s.DeleteAlias(ctx, model.AliasKey{From: "nonexistent"})
 	if err != nil {
 		t.Fatalf("DeleteAlias: %v", err)
 	}
@@ -169,6 +169,74 @@ func TestRecordInvocationMinimalFields(t *testing.T) {
 	}
 }
 
+func TestRecordInvocationTokens(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	inv := model.Invocation{
+		ID:               "tok-inv-1",
+		Source:           "claude-code",
+		ToolName:         "Bash",
+		Timestamp:        time.Now().UTC(),
+		InputTokens:      12,
+		OutputTokens:     340,
+		CacheReadTokens:  5600,
+		CacheWriteTokens: 780,
+		DurationMs:       1250,
+	}
+	if err := s.RecordInvocations(ctx, []model.Invocation{inv}); err != nil {
+		t.Fatalf("RecordInvocations: %v", err)
+	}
+
+	got, err := s.ListInvocations(ctx, InvocationOpts{})
+	if err != nil {
+		t.Fatalf("ListInvocations: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1, got %d", len(got))
+	}
+	g := got[0]
+	if g.InputTokens != 12 || g.OutputTokens != 340 || g.CacheReadTokens != 5600 || g.CacheWriteTokens != 780 {
+		t.Errorf("tokens = %d/%d/%d/%d, want 12/340/5600/780",
+			g.InputTokens, g.OutputTokens, g.CacheReadTokens, g.CacheWriteTokens)
+	}
+	if g.DurationMs != 1250 {
+		t.Errorf("DurationMs = %d, want 1250", g.DurationMs)
+	}
+}
+
+func TestListFilterByModel(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, m := range []string{"claude-sonnet-4-5", "cursor-fast", ""} {
+		id := fmt.Sprintf("m%d", i)
+		ts := base.Add(time.Duration(i) * time.Hour)
+		if err := s.RecordInvocation(ctx, model.Invocation{ID: id, Source: "cursor", ToolName: "Read", IsError: true, Timestamp: ts, Model: m}); err != nil {
+			t.Fatalf("RecordInvocation: %v", err)
+		}
+		if err := s.RecordDesire(ctx, model.Desire{ID: id, ToolName: "Read", Error: "not found", Timestamp: ts, Model: m}); err != nil {
+			t.Fatalf("RecordDesire: %v", err)
+		}
+	}
+
+	invs, err := s.ListInvocations(ctx, InvocationOpts{Model: "cursor-fast"})
+	if err != nil {
+		t.Fatalf("ListInvocations: %v", err)
+	}
+	if len(invs) != 1 || invs[0].ID != "m1" || invs[0].Model != "cursor-fast" {
+		t.Errorf("invocations = %+v, want m1 with model cursor-fast", invs)
+	}
+	desires, err := s.ListDesires(ctx, ListOpts{Model: "claude-sonnet-4-5"})
+	if err != nil {
+		t.Fatalf("ListDesires: %v", err)
+	}
+	if len(desires) != 1 || desires[0].ID != "m0" || desires[0].Model != "claude-sonnet-4-5" {
+		t.Errorf("desires = %+v, want m0 with model claude-sonnet-4-5", desires)
+	}
+}
+
 func TestListInvocationsFilterBySource(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,8 +12,10 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/events"
 	"github.com/scbrown/desire-path/internal/model"
 )
 
@@ -20,12 +23,19 @@ const (
 	defaultTimeout    = 30 * time.Second
 	maxRetries        = 3
 	retryBaseInterval = 100 * time.Millisecond
+
+	// remotePageSize is how many rows a list asks the server for at a time.
+	remotePageSize = 1000
 )
 
 // RemoteStore implements Store by forwarding requests over HTTP to a dp serve instance.
 type RemoteStore struct {
-	baseURL string
-	client  *http.Client
+	baseURL   string
+	token     string
+	workspace string
+	client    *http.Client
+	spool     *Spool
+	onSpool   func(err error)
 }
 
 // NewRemote creates a RemoteStore pointing at the given base URL (e.g., "http://localhost:7273").
@@ -38,6 +48,113 @@ func NewRemote(baseURL string) *RemoteStore {
 	}
 }
 
+// SetToken sets the API token sent as "Authorization: Bearer <token>" on
+// every request. An empty token sends no Authorization header.
+func (r *RemoteStore) SetToken(token string) {
+	r.token = token
+}
+
+// SetWorkspace makes every request go to the named workspace on the
+// server. An empty name, or "default", uses the server's default workspace.
+func (r *RemoteStore) SetWorkspace(name string) {
+	if name == model.DefaultWorkspace {
+		name = ""
+	}
+	r.workspace = name
+}
+
+// endpoint returns the URL of an /api/v1 path in the store's workspace.
+func (r *RemoteStore) endpoint(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/api/v1/"); ok && r.workspace != "" {
+		return r.baseURL + "/api/v1/w/" + url.PathEscape(r.workspace) + "/" + rest
+	}
+	return r.baseURL + path
+}
+
+// target identifies the server and workspace the store talks to.
+func (r *RemoteStore) target() string {
+	if r.workspace == "" {
+		return r.baseURL
+	}
+	return r.baseURL + "/api/v1/w/" + r.workspace
+}
+
+// authorize adds the bearer token, if any, to req.
+func (r *RemoteStore) authorize(req *http.Request) {
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+}
+
+// SetSpool makes the write methods (RecordDesire, RecordInvocation,
+// RecordIntervention, their batch forms, DetectAndRecordRecovery and
+// TrackRetryChain) append to sp instead of failing when the server cannot
+// be reached. After a successful write, anything already spooled is
+// replayed to the server. A nil spool turns this off.
+func (r *RemoteStore) SetSpool(sp *Spool) {
+	r.spool = sp
+}
+
+// SetSpoolNotice sets a function called with the delivery error each time
+// a write is spooled instead of sent.
+func (r *RemoteStore) SetSpoolNotice(fn func(err error)) {
+	r.onSpool = fn
+}
+
+// Sync replays the spool to the server. It returns an empty result if no
+// spool is set.
+func (r *RemoteStore) Sync(ctx context.Context) (ReplayResult, error) {
+	if r.spool == nil {
+		return ReplayResult{}, nil
+	}
+	// Replay through a copy without the spool, so that failed replays are
+	// reported rather than spooled again.
+	direct := *r
+	direct.spool = nil
+	return r.spool.Replay(ctx, &direct)
+}
+
+// opportunisticReplay bounds the replay that follows a successful write,
+// so that a hook never waits long on an old backlog; dp sync sends the
+// rest.
+const opportunisticReplay = 2 * time.Second
+
+// record posts body to path. If the server is unreachable and a spool is
+// set, items are spooled as kind and the write counts as done.
+func record[T any](ctx context.Context, r *RemoteStore, path, kind string, body any, items []T) error {
+	err := r.postJSON(ctx, path, body, nil)
+	if err == nil {
+		if r.spool != nil && !r.spool.empty() {
+			// Best-effort: deliver what was spooled earlier.
+			rctx, cancel := context.WithTimeout(ctx, opportunisticReplay)
+			_, _ = r.Sync(rctx)
+			cancel()
+		}
+		return nil
+	}
+	if r.spool == nil || !Unavailable(err) {
+		return err
+	}
+	if serr := appendAll(r.spool, kind, items); serr != nil {
+		return fmt.Errorf("%w (spooling failed: %v)", err, serr)
+	}
+	if r.onSpool != nil {
+		r.onSpool(err)
+	}
+	return nil
+}
+
+// Unavailable reports whether err means the server could not be reached or
+// could not handle the request for now, so the write may succeed later.
+func Unavailable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 || se.code == http.StatusTooManyRequests
+	}
+	var ue *url.Error
+	return errors.As(err, &ue) && ue.Op != "parse"
+}
+
 // isRetryable returns true for transient errors worth retrying.
 func isRetryable(err error) bool {
 	if err == nil {
@@ -60,7 +177,16 @@ func isRetryableStatus(code int) bool {
 }
 
 func (r *RemoteStore) RecordDesire(ctx context.Context, d model.Desire) error {
-	return r.postJSON(ctx, "/api/v1/desires", d, nil)
+	return record(ctx, r, "/api/v1/desires", spoolDesire, d, []model.Desire{d})
+}
+
+// RecordDesires sends the whole batch in one request; the server stores it
+// in a single transaction.
+func (r *RemoteStore) RecordDesires(ctx context.Context, ds []model.Desire) error {
+	if len(ds) == 0 {
+		return nil
+	}
+	return record(ctx, r, "/api/v1/desires/batch", spoolDesire, ds, ds)
 }
 
 func (r *RemoteStore) ListDesires(ctx context.Context, opts ListOpts) ([]model.Desire, error) {
@@ -74,14 +200,10 @@ func (r *RemoteStore) ListDesires(ctx context.Context, opts ListOpts) ([]model.D
 	if opts.ToolName != "" {
 		q.Set("tool", opts.ToolName)
 	}
-	if opts.Limit > 0 {
-		q.Set("limit", strconv.Itoa(opts.Limit))
-	}
-	var desires []model.Desire
-	if err := r.getJSON(ctx, "/api/v1/desires", q, &desires); err != nil {
-		return nil, err
+	if opts.Model != "" {
+		q.Set("model", opts.Model)
 	}
-	return desires, nil
+	return getPages[model.Desire](ctx, r, "/api/v1/desires", q, opts.Limit, opts.Cursor)
 }
 
 func (r *RemoteStore) GetPaths(ctx context.Context, opts PathOpts) ([]model.Path, error) {
@@ -92,6 +214,17 @@ func (r *RemoteStore) GetPaths(ctx context.Context, opts PathOpts) ([]model.Path
 	if !opts.Since.IsZero() {
 		q.Set("since", opts.Since.UTC().Format(time.RFC3339))
 	}
+	if opts.Sort != "" {
+		q.Set("sort", opts.Sort)
+	}
+	if w := opts.Weights; w != (ImpactWeights{}) {
+		q.Set("session_weight", strconv.FormatFloat(w.Sessions, 'g', -1, 64))
+		q.Set("retry_weight", strconv.FormatFloat(w.Retries, 'g', -1, 64))
+		q.Set("half_life_days", strconv.FormatFloat(w.HalfLifeDays, 'g', -1, 64))
+	}
+	if opts.Score {
+		q.Set("score", "true")
+	}
 	var paths []model.Path
 	if err := r.getJSON(ctx, "/api/v1/paths", q, &paths); err != nil {
 		return nil, err
@@ -99,28 +232,56 @@ func (r *RemoteStore) GetPaths(ctx context.Context, opts PathOpts) ([]model.Path
 	return paths, nil
 }
 
+func (r *RemoteStore) PathTrends(ctx context.Context, opts TrendOpts) (*TrendResult, error) {
+	q := url.Values{}
+	if opts.Bucket != "" {
+		q.Set("bucket", opts.Bucket)
+	}
+	if opts.Buckets > 0 {
+		q.Set("buckets", strconv.Itoa(opts.Buckets))
+	}
+	if !opts.Until.IsZero() {
+		q.Set("until", opts.Until.UTC().Format(time.RFC3339))
+	}
+	if opts.Top > 0 {
+		q.Set("top", strconv.Itoa(opts.Top))
+	}
+	var res TrendResult
+	if err := r.getJSON(ctx, "/api/v1/paths/trends", q, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 func (r *RemoteStore) SetAlias(ctx context.Context, a model.Alias) error {
 	return r.postJSON(ctx, "/api/v1/aliases", a, nil)
 }
 
-func (r *RemoteStore) GetAlias(ctx context.Context, from, tool, param, command, matchKind string) (*model.Alias, error) {
+// aliasURL returns the URL of the alias with key k.
+func (r *RemoteStore) aliasURL(k model.AliasKey) string {
 	q := url.Values{}
-	if tool != "" {
-		q.Set("tool", tool)
-	}
-	if param != "" {
-		q.Set("param", param)
-	}
-	if command != "" {
-		q.Set("command", command)
-	}
-	if matchKind != "" {
-		q.Set("match_kind", matchKind)
+	for name, v := range map[string]string{
+		"tool":         k.Tool,
+		"param":        k.Param,
+		"command":      k.Command,
+		"match_kind":   k.MatchKind,
+		"scope_cwd":    k.ScopeCWD,
+		"scope_remote": k.ScopeRemote,
+		"scope_source": k.ScopeSource,
+	} {
+		if v != "" {
+			q.Set(name, v)
+		}
 	}
-	u := r.baseURL + "/api/v1/aliases/" + url.PathEscape(from)
+	u := r.endpoint("/api/v1/aliases/" + url.PathEscape(k.From))
 	if len(q) > 0 {
 		u += "?" + q.Encode()
 	}
+	return u
+}
+
+func (r *RemoteStore) GetAlias(ctx context.Context, k model.AliasKey) (*model.Alias, error) {
+	u := r.aliasURL(k)
 	var lastErr error
 	for attempt := range maxRetries {
 		if attempt > 0 {
@@ -135,6 +296,7 @@ func (r *RemoteStore) GetAlias(ctx context.Context, from, tool, param, command,
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
+		r.authorize(req)
 		req.Header.Set("Accept", "application/json")
 		var resp *http.Response
 		resp, lastErr = r.client.Do(req)
@@ -172,24 +334,16 @@ func (r *RemoteStore) GetAliases(ctx context.Context) ([]model.Alias, error) {
 	return aliases, nil
 }
 
-func (r *RemoteStore) DeleteAlias(ctx context.Context, from, tool, param, command, matchKind string) (bool, error) {
-	q := url.Values{}
-	if tool != "" {
-		q.Set("tool", tool)
-	}
-	if param != "" {
-		q.Set("param", param)
-	}
-	if command != "" {
-		q.Set("command", command)
-	}
-	if matchKind != "" {
-		q.Set("match_kind", matchKind)
-	}
-	u := r.baseURL + "/api/v1/aliases/" + url.PathEscape(from)
-	if len(q) > 0 {
-		u += "?" + q.Encode()
+func (r *RemoteStore) GetToolAliases(ctx context.Context, from string) ([]model.Alias, error) {
+	var aliases []model.Alias
+	if err := r.getJSON(ctx, "/api/v1/aliases", url.Values{"from": {from}}, &aliases); err != nil {
+		return nil, err
 	}
+	return aliases, nil
+}
+
+func (r *RemoteStore) DeleteAlias(ctx context.Context, k model.AliasKey) (bool, error) {
+	u := r.aliasURL(k)
 	var lastErr error
 	for attempt := range maxRetries {
 		if attempt > 0 {
@@ -204,6 +358,7 @@ func (r *RemoteStore) DeleteAlias(ctx context.Context, from, tool, param, comman
 		if err != nil {
 			return false, fmt.Errorf("creating request: %w", err)
 		}
+		r.authorize(req)
 		var resp *http.Response
 		resp, lastErr = r.client.Do(req)
 		if lastErr != nil {
@@ -262,7 +417,16 @@ func (r *RemoteStore) InspectPath(ctx context.Context, opts InspectOpts) (*Inspe
 }
 
 func (r *RemoteStore) RecordInvocation(ctx context.Context, inv model.Invocation) error {
-	return r.postJSON(ctx, "/api/v1/invocations", inv, nil)
+	return record(ctx, r, "/api/v1/invocations", spoolInvocation, inv, []model.Invocation{inv})
+}
+
+// RecordInvocations sends the whole batch in one request; the server stores
+// it in a single transaction.
+func (r *RemoteStore) RecordInvocations(ctx context.Context, invs []model.Invocation) error {
+	if len(invs) == 0 {
+		return nil
+	}
+	return record(ctx, r, "/api/v1/invocations/batch", spoolInvocation, invs, invs)
 }
 
 func (r *RemoteStore) ListInvocations(ctx context.Context, opts InvocationOpts) ([]model.Invocation, error) {
@@ -279,17 +443,13 @@ func (r *RemoteStore) ListInvocations(ctx context.Context, opts InvocationOpts)
 	if opts.ToolName != "" {
 		q.Set("tool", opts.ToolName)
 	}
+	if opts.Model != "" {
+		q.Set("model", opts.Model)
+	}
 	if opts.ErrorsOnly {
 		q.Set("errors_only", "true")
 	}
-	if opts.Limit > 0 {
-		q.Set("limit", strconv.Itoa(opts.Limit))
-	}
-	var invocations []model.Invocation
-	if err := r.getJSON(ctx, "/api/v1/invocations", q, &invocations); err != nil {
-		return nil, err
-	}
-	return invocations, nil
+	return getPages[model.Invocation](ctx, r, "/api/v1/invocations", q, opts.Limit, opts.Cursor)
 }
 
 func (r *RemoteStore) InvocationStats(ctx context.Context) (InvocationStatsResult, error) {
@@ -314,14 +474,7 @@ func (r *RemoteStore) ListTurns(ctx context.Context, opts TurnOpts) ([]TurnRow,
 	if opts.Pattern != "" {
 		q.Set("pattern", opts.Pattern)
 	}
-	if opts.Limit > 0 {
-		q.Set("limit", strconv.Itoa(opts.Limit))
-	}
-	var turns []TurnRow
-	if err := r.getJSON(ctx, "/api/v1/turns", q, &turns); err != nil {
-		return nil, err
-	}
-	return turns, nil
+	return getPages[TurnRow](ctx, r, "/api/v1/turns", q, opts.Limit, opts.Cursor)
 }
 
 func (r *RemoteStore) TurnPatternStats(ctx context.Context, opts TurnOpts) ([]TurnPattern, error) {
@@ -361,22 +514,15 @@ func (r *RemoteStore) ToolTurnStats(ctx context.Context, opts TurnOpts) ([]ToolT
 }
 
 func (r *RemoteStore) DetectAndRecordRecovery(ctx context.Context, inv model.Invocation) error {
-	return r.postJSON(ctx, "/api/v1/recoveries/detect", inv, nil)
+	return record(ctx, r, "/api/v1/recoveries/detect", spoolRecovery, inv, []model.Invocation{inv})
 }
 
-func (r *RemoteStore) ListRecoveries(ctx context.Context, since time.Time, limit int) ([]model.Recovery, error) {
+func (r *RemoteStore) ListRecoveries(ctx context.Context, opts RecoveryOpts) ([]model.Recovery, error) {
 	q := url.Values{}
-	if !since.IsZero() {
-		q.Set("since", since.UTC().Format(time.RFC3339))
-	}
-	if limit > 0 {
-		q.Set("limit", strconv.Itoa(limit))
-	}
-	var recoveries []model.Recovery
-	if err := r.getJSON(ctx, "/api/v1/recoveries", q, &recoveries); err != nil {
-		return nil, err
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.UTC().Format(time.RFC3339))
 	}
-	return recoveries, nil
+	return getPages[model.Recovery](ctx, r, "/api/v1/recoveries", q, opts.Limit, opts.Cursor)
 }
 
 func (r *RemoteStore) RecoveryStats(ctx context.Context) ([]model.RecoveryStat, error) {
@@ -387,6 +533,36 @@ func (r *RemoteStore) RecoveryStats(ctx context.Context) ([]model.RecoveryStat,
 	return stats, nil
 }
 
+func (r *RemoteStore) TrackRetryChain(ctx context.Context, inv model.Invocation, input json.RawMessage) error {
+	body := ChainTrackRequest{Invocation: inv, ToolInput: input}
+	return record(ctx, r, "/api/v1/chains/track", spoolChain, body, []ChainTrackRequest{body})
+}
+
+func (r *RemoteStore) ListRetryChains(ctx context.Context, opts ChainOpts) ([]model.RetryChain, error) {
+	q := url.Values{}
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	}
+	if opts.ToolName != "" {
+		q.Set("tool", opts.ToolName)
+	}
+	if opts.SessionID != "" {
+		q.Set("session", opts.SessionID)
+	}
+	if opts.Outcome != "" {
+		q.Set("outcome", opts.Outcome)
+	}
+	return getPages[model.RetryChain](ctx, r, "/api/v1/chains", q, opts.Limit, opts.Cursor)
+}
+
+func (r *RemoteStore) SourceToolCounts(ctx context.Context) ([]SourceToolCount, error) {
+	var counts []SourceToolCount
+	if err := r.getJSON(ctx, "/api/v1/counts", nil, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
 func (r *RemoteStore) SetDocMapping(ctx context.Context, dm model.DocMapping) error {
 	return r.postJSON(ctx, "/api/v1/doc-mappings", dm, nil)
 }
@@ -400,11 +576,13 @@ func (r *RemoteStore) GetDocMappings(ctx context.Context) ([]model.DocMapping, e
 }
 
 func (r *RemoteStore) DeleteDocMapping(ctx context.Context, id string) (bool, error) {
-	err := r.postJSON(ctx, "/api/v1/doc-mappings/delete", map[string]string{"id": id}, nil)
-	if err != nil {
+	var resp struct {
+		Deleted bool `json:"deleted"`
+	}
+	if err := r.postJSON(ctx, "/api/v1/doc-mappings/delete", map[string]string{"id": id}, &resp); err != nil {
 		return false, err
 	}
-	return true, nil
+	return resp.Deleted, nil
 }
 
 func (r *RemoteStore) SuggestDocs(ctx context.Context, tool, errorText string) ([]model.DocMapping, error) {
@@ -447,15 +625,177 @@ func (r *RemoteStore) StrugglingTools(ctx context.Context, opts StrugglingOpts)
 	return tools, nil
 }
 
+func (r *RemoteStore) RecordIntervention(ctx context.Context, iv model.Intervention) error {
+	return record(ctx, r, "/api/v1/interventions", spoolIntervention, iv, []model.Intervention{iv})
+}
+
+func (r *RemoteStore) InterventionStats(ctx context.Context, since time.Time) ([]model.InterventionStat, error) {
+	q := url.Values{}
+	if !since.IsZero() {
+		q.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	var stats []model.InterventionStat
+	if err := r.getJSON(ctx, "/api/v1/interventions/stats", q, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// StreamEvents subscribes to the server's live event stream
+// (GET /api/v1/events) and calls fn for each event until ctx is cancelled,
+// the server closes the stream, or fn returns an error. It does not
+// reconnect; a dropped connection is reported as an error for which
+// Unavailable is true.
+func (r *RemoteStore) StreamEvents(ctx context.Context, f events.Filter, fn func(events.Event) error) error {
+	q := url.Values{}
+	if f.ToolName != "" {
+		q.Set("tool", f.ToolName)
+	}
+	if f.Source != "" {
+		q.Set("source", f.Source)
+	}
+	if f.SessionID != "" {
+		q.Set("session", f.SessionID)
+	}
+	if len(f.Types) > 0 {
+		q.Set("type", strings.Join(f.Types, ","))
+	}
+	u := r.endpoint("/api/v1/events")
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	r.authorize(req)
+	req.Header.Set("Accept", "text/event-stream")
+
+	// The stream is long-lived, so it must not inherit the client timeout.
+	streamClient := &http.Client{Transport: r.client.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("remote events: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return remoteError(resp)
+	}
+
+	// Minimal SSE parser: only "data:" lines matter; a blank line ends an event.
+	var data strings.Builder
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if data.Len() == 0 {
+				continue
+			}
+			var e events.Event
+			if err := json.Unmarshal([]byte(data.String()), &e); err != nil {
+				return fmt.Errorf("decoding event: %w", err)
+			}
+			data.Reset()
+			if err := fn(e); err != nil {
+				return err
+			}
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(v, " "))
+		}
+	}
+	if err := sc.Err(); err != nil && ctx.Err() == nil {
+		// The connection broke mid-stream: report it like any other
+		// transport failure, so Unavailable recognises it.
+		return fmt.Errorf("reading events: %w", &url.Error{Op: "Get", URL: u, Err: err})
+	}
+	return nil
+}
+
+// errTokensRemote is returned by the token methods: tokens live in the
+// server's own database and are managed there with 'dp serve token'.
+var errTokensRemote = errors.New("API tokens are managed on the server with 'dp serve token', not over the remote store")
+
+func (r *RemoteStore) CreateToken(ctx context.Context, t model.APIToken) error {
+	return errTokensRemote
+}
+
+func (r *RemoteStore) ListTokens(ctx context.Context) ([]model.APIToken, error) {
+	return nil, errTokensRemote
+}
+
+func (r *RemoteStore) RevokeToken(ctx context.Context, id string) (bool, error) {
+	return false, errTokensRemote
+}
+
+func (r *RemoteStore) AuthenticateToken(ctx context.Context, hash string) (*model.APIToken, error) {
+	return nil, errTokensRemote
+}
+
 // Close is a no-op for the remote store.
 func (r *RemoteStore) Close() error {
 	return nil
 }
 
+// getPages fetches a list page by page, starting after cursor, until the
+// server has no more rows or limit rows (0 means all) have been fetched.
+func getPages[T any](ctx context.Context, r *RemoteStore, path string, q url.Values, limit int, cursor string) ([]T, error) {
+	var all []T
+	for {
+		size := remotePageSize
+		if limit > 0 && limit-len(all) < size {
+			size = limit - len(all)
+		}
+		q.Set("limit", strconv.Itoa(size))
+		q.Set("cursor", cursor)
+		var raw json.RawMessage
+		if err := r.getJSON(ctx, path, q, &raw); err != nil {
+			return nil, err
+		}
+
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+			// A server without pagination ignores the cursor and returns
+			// a plain array. If that may have cut the list short, ask it
+			// for the whole list instead.
+			var items []T
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, fmt.Errorf("decoding response: %w", err)
+			}
+			if limit == 0 && len(items) == size {
+				q.Del("limit")
+				q.Del("cursor")
+				items = nil
+				if err := r.getJSON(ctx, path, q, &items); err != nil {
+					return nil, err
+				}
+			}
+			return items, nil
+		}
+
+		var page Page[T]
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+		all = append(all, page.Items...)
+		if page.NextCursor == "" || (limit > 0 && len(all) >= limit) {
+			return all, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
 // getJSON performs a GET request and decodes the JSON response into dst.
 // Transient network errors and 5xx responses are retried with exponential backoff.
 func (r *RemoteStore) getJSON(ctx context.Context, path string, query url.Values, dst any) error {
-	u := r.baseURL + path
+	u := r.endpoint(path)
 	if len(query) > 0 {
 		u += "?" + query.Encode()
 	}
@@ -473,6 +813,7 @@ func (r *RemoteStore) getJSON(ctx context.Context, path string, query url.Values
 		if err != nil {
 			return fmt.Errorf("creating request: %w", err)
 		}
+		r.authorize(req)
 		req.Header.Set("Accept", "application/json")
 		var resp *http.Response
 		resp, lastErr = r.client.Do(req)
@@ -505,7 +846,7 @@ func (r *RemoteStore) postJSON(ctx context.Context, path string, body any, dst a
 	if err != nil {
 		return fmt.Errorf("marshaling request: %w", err)
 	}
-	u := r.baseURL + path
+	u := r.endpoint(path)
 	var lastErr error
 	for attempt := range maxRetries {
 		if attempt > 0 {
@@ -520,6 +861,7 @@ func (r *RemoteStore) postJSON(ctx context.Context, path string, body any, dst a
 		if err != nil {
 			return fmt.Errorf("creating request: %w", err)
 		}
+		r.authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Accept", "application/json")
 		var resp *http.Response
@@ -548,6 +890,22 @@ func (r *RemoteStore) postJSON(ctx context.Context, path string, body any, dst a
 	return fmt.Errorf("remote request (after %d retries): %w", maxRetries, lastErr)
 }
 
+// statusError is an error response from the server.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("remote store (%d): %s", e.code, e.msg)
+}
+
+// Is makes a 409 Conflict match ErrDuplicateID: the server answers a write
+// whose ID it already has with 409.
+func (e *statusError) Is(target error) bool {
+	return target == ErrDuplicateID && e.code == http.StatusConflict
+}
+
 // remoteError reads an error response from the server and returns it as an error.
 func remoteError(resp *http.Response) error {
 	body, _ := io.ReadAll(resp.Body)
@@ -555,7 +913,7 @@ func remoteError(resp *http.Response) error {
 		Error string `json:"error"`
 	}
 	if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
-		return fmt.Errorf("remote store (%d): %s", resp.StatusCode, errResp.Error)
+		return &statusError{code: resp.StatusCode, msg: errResp.Error}
 	}
-	return fmt.Errorf("remote store (%d): %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	return &statusError{code: resp.StatusCode, msg: http.StatusText(resp.StatusCode)}
 }
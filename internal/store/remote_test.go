@@ -3,12 +3,14 @@ package store
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/events"
 	"github.com/scbrown/desire-path/internal/model"
 )
 
@@ -45,6 +47,17 @@ func registerRoutes(mux *http.ServeMux, s Store) {
 		w.WriteHeader(201)
 		json.NewEncoder(w).Encode(d)
 	})
+	mux.HandleFunc("POST /api/v1/desires/batch", func(w http.ResponseWriter, r *http.Request) {
+		var ds []model.Desire
+		json.NewDecoder(r.Body).Decode(&ds)
+		if err := s.RecordDesires(r.Context(), ds); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(201)
+		json.NewEncoder(w).Encode(map[string]int{"recorded": len(ds)})
+	})
 	mux.HandleFunc("GET /api/v1/desires", func(w http.ResponseWriter, r *http.Request) {
 		desires, err := s.ListDesires(r.Context(), ListOpts{})
 		if err != nil {
@@ -94,7 +107,7 @@ func registerRoutes(mux *http.ServeMux, s Store) {
 	})
 	mux.HandleFunc("GET /api/v1/aliases/{from}", func(w http.ResponseWriter, r *http.Request) {
 		from := r.PathValue("from")
-		alias, err := s.GetAlias(r.Context(), from, "", "", "", "")
+		alias, err := s.GetAlias(r.Context(), model.AliasKey{From: from})
 		if err != nil {
 			http.Error(w, err.Error(), 500)
 			return
@@ -109,7 +122,7 @@ func registerRoutes(mux *http.ServeMux, s Store) {
 	})
 	mux.HandleFunc("DELETE /api/v1/aliases/{from}", func(w http.ResponseWriter, r *http.Request) {
 		from := r.PathValue("from")
-		deleted, err := s.DeleteAlias(r.Context(), from, "", "", "", "")
+		deleted, err := s.DeleteAlias(r.Context(), model.AliasKey{From: from})
 		if err != nil {
 			http.Error(w, err.Error(), 500)
 			return
@@ -158,6 +171,17 @@ func registerRoutes(mux *http.ServeMux, s Store) {
 		w.WriteHeader(201)
 		json.NewEncoder(w).Encode(inv)
 	})
+	mux.HandleFunc("POST /api/v1/invocations/batch", func(w http.ResponseWriter, r *http.Request) {
+		var invs []model.Invocation
+		json.NewDecoder(r.Body).Decode(&invs)
+		if err := s.RecordInvocations(r.Context(), invs); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(201)
+		json.NewEncoder(w).Encode(map[string]int{"recorded": len(invs)})
+	})
 	mux.HandleFunc("GET /api/v1/invocations", func(w http.ResponseWriter, r *http.Request) {
 		invocations, err := s.ListInvocations(r.Context(), InvocationOpts{})
 		if err != nil {
@@ -179,6 +203,50 @@ func registerRoutes(mux *http.ServeMux, s Store) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(stats)
 	})
+	mux.HandleFunc("POST /api/v1/interventions", func(w http.ResponseWriter, r *http.Request) {
+		var iv model.Intervention
+		json.NewDecoder(r.Body).Decode(&iv)
+		if err := s.RecordIntervention(r.Context(), iv); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(201)
+		json.NewEncoder(w).Encode(iv)
+	})
+	mux.HandleFunc("POST /api/v1/chains/track", func(w http.ResponseWriter, r *http.Request) {
+		var req ChainTrackRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if err := s.TrackRetryChain(r.Context(), req.Invocation, req.ToolInput); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("POST /api/v1/doc-mappings", func(w http.ResponseWriter, r *http.Request) {
+		var dm model.DocMapping
+		json.NewDecoder(r.Body).Decode(&dm)
+		if err := s.SetDocMapping(r.Context(), dm); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(201)
+		json.NewEncoder(w).Encode(dm)
+	})
+	mux.HandleFunc("GET /api/v1/doc-mappings", func(w http.ResponseWriter, r *http.Request) {
+		mappings, err := s.GetDocMappings(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		if mappings == nil {
+			mappings = []model.DocMapping{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mappings)
+	})
 }
 
 func TestRemoteRecordAndListDesires(t *testing.T) {
@@ -235,6 +303,31 @@ func TestRemoteRecordAndListInvocations(t *testing.T) {
 	}
 }
 
+func TestRemoteRecordInvocations(t *testing.T) {
+	remote := testRemote(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	invs := []model.Invocation{
+		{ID: "rb-1", Source: "test", ToolName: "Read", Timestamp: now},
+		{ID: "rb-2", Source: "test", ToolName: "Bash", IsError: true, Timestamp: now},
+	}
+	if err := remote.RecordInvocations(ctx, invs); err != nil {
+		t.Fatalf("record invocations: %v", err)
+	}
+	if err := remote.RecordInvocations(ctx, nil); err != nil {
+		t.Fatalf("record empty batch: %v", err)
+	}
+
+	got, err := remote.ListInvocations(ctx, InvocationOpts{})
+	if err != nil {
+		t.Fatalf("list invocations: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d invocations, want 2", len(got))
+	}
+}
+
 func TestRemoteAliases(t *testing.T) {
 	remote := testRemote(t)
 	ctx := context.Background()
@@ -257,7 +350,7 @@ func TestRemoteAliases(t *testing.T) {
 	}
 
 	// Delete.
-	deleted, err := remote.DeleteAlias(ctx, "read_file", "", "", "", "")
+	deleted, err := remote.DeleteAlias(ctx, model.AliasKey{From: "read_file"})
 	if err != nil {
 		t.Fatalf("delete alias: %v", err)
 	}
@@ -266,7 +359,7 @@ func TestRemoteAliases(t *testing.T) {
 	}
 
 	// Delete nonexistent.
-	deleted, err = remote.DeleteAlias(ctx, "nonexistent", "", "", "", "")
+	deleted, err = remote.DeleteAlias(ctx, model.AliasKey{From: "nonexistent"})
 	if err != nil {
 		t.Fatalf("delete nonexistent: %v", err)
 	}
@@ -280,7 +373,7 @@ func TestRemoteGetAlias(t *testing.T) {
 	ctx := context.Background()
 
 	// Get nonexistent.
-	alias, err := remote.GetAlias(ctx, "nonexistent", "", "", "", "")
+	alias, err := remote.GetAlias(ctx, model.AliasKey{From: "nonexistent"})
 	if err != nil {
 		t.Fatalf("get nonexistent: %v", err)
 	}
@@ -292,7 +385,7 @@ func TestRemoteGetAlias(t *testing.T) {
 	if err := remote.SetAlias(ctx, model.Alias{From: "read_file", To: "Read"}); err != nil {
 		t.Fatalf("set alias: %v", err)
 	}
-	alias, err = remote.GetAlias(ctx, "read_file", "", "", "", "")
+	alias, err = remote.GetAlias(ctx, model.AliasKey{From: "read_file"})
 	if err != nil {
 		t.Fatalf("get alias: %v", err)
 	}
@@ -331,6 +424,64 @@ func TestRemoteRetryOnServerError(t *testing.T) {
 	}
 }
 
+func TestRemoteSendsToken(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode([]model.Desire{})
+	}))
+	t.Cleanup(ts.Close)
+
+	ctx := context.Background()
+	remote := NewRemote(ts.URL)
+	// Only the headers matter here; response decoding errors are ignored.
+	_, _ = remote.ListDesires(ctx, ListOpts{})
+	remote.SetToken("dp_secret")
+	_ = remote.RecordDesire(ctx, model.Desire{ID: "d1", ToolName: "x"})
+	_, _ = remote.DeleteAlias(ctx, model.AliasKey{From: "x"})
+
+	want := []string{"", "Bearer dp_secret", "Bearer dp_secret"}
+	if len(got) != len(want) {
+		t.Fatalf("requests = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d Authorization = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRemoteStreamEvents(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": connected\n\n")
+		fmt.Fprint(w, "id: d1\nevent: desire\ndata: {\"type\":\"desire\",\"tool_name\":\"Read\",\"desire\":{\"id\":\"d1\",\"tool_name\":\"Read\"}}\n\n")
+		fmt.Fprint(w, ": ping\n\n")
+		fmt.Fprint(w, "event: recovery\ndata: {\"type\":\"recovery\",\"tool_name\":\"Read\"}\n\n")
+	}))
+	t.Cleanup(ts.Close)
+
+	var got []events.Event
+	remote := NewRemote(ts.URL)
+	err := remote.StreamEvents(context.Background(), events.Filter{ToolName: "Read", Types: []string{"desire", "recovery"}}, func(e events.Event) error {
+		got = append(got, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamEvents: %v", err)
+	}
+	if query != "tool=Read&type=desire%2Crecovery" {
+		t.Errorf("query = %q", query)
+	}
+	if len(got) != 2 || got[0].Desire == nil || got[0].Desire.ID != "d1" || got[1].Type != "recovery" {
+		t.Errorf("events = %+v", got)
+	}
+}
+
 func TestRemoteStats(t *testing.T) {
 	remote := testRemote(t)
 	ctx := context.Background()
@@ -368,6 +519,14 @@ func TestRemotePaths(t *testing.T) {
 	if len(paths) != 0 {
 		t.Errorf("got %d paths, want 0", len(paths))
 	}
+
+	paths, err = remote.GetPaths(ctx, PathOpts{Sort: PathSortImpact, Weights: ImpactWeights{Sessions: 1, Retries: 0, HalfLifeDays: 3}})
+	if err != nil {
+		t.Fatalf("get paths by impact: %v", err)
+	}
+	if len(paths) != 0 {
+		t.Errorf("got %d paths, want 0", len(paths))
+	}
 }
 
 func TestRemoteInspectPath(t *testing.T) {
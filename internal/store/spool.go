@@ -0,0 +1,366 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// Spool entry kinds.
+const (
+	spoolDesire       = "desire"
+	spoolInvocation   = "invocation"
+	spoolIntervention = "intervention"
+	spoolRecovery     = "recovery" // an invocation to run recovery detection on
+	spoolChain        = "chain"    // a ChainTrackRequest
+)
+
+const (
+	spoolExt     = ".ndjson"
+	replayingExt = ".replaying"
+	replayLock   = "replay.lock"
+
+	// staleReplayLock is how old a replay lock must be before it is assumed
+	// to belong to a process that died mid-replay.
+	staleReplayLock = 10 * time.Minute
+
+	// replayBatch caps how many consecutive desires or invocations are
+	// sent in one batch request during replay.
+	replayBatch = 500
+)
+
+// spoolEntry is one line of a spool file.
+type spoolEntry struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Spool is an on-disk queue of writes that a RemoteStore could not deliver.
+// Each Spool appends to its own NDJSON file in dir, named by creation time
+// so that files sort in the order they were started. Replay sends the
+// writes on in that order.
+type Spool struct {
+	dir string
+
+	mu   sync.Mutex
+	file string // this Spool's file name, chosen on first append
+}
+
+// NewSpool returns a Spool that keeps its files in dir. The directory is
+// created on first use.
+func NewSpool(dir string) *Spool {
+	return &Spool{dir: dir}
+}
+
+// Dir returns the spool directory.
+func (sp *Spool) Dir() string {
+	return sp.dir
+}
+
+// appendAll spools the elements of items (a slice) as entries of kind.
+func appendAll[T any](sp *Spool, kind string, items []T) error {
+	var buf bytes.Buffer
+	for _, it := range items {
+		data, err := json.Marshal(it)
+		if err != nil {
+			return fmt.Errorf("marshal spool entry: %w", err)
+		}
+		line, err := json.Marshal(spoolEntry{Kind: kind, Data: data})
+		if err != nil {
+			return fmt.Errorf("marshal spool entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if err := os.MkdirAll(sp.dir, 0o700); err != nil {
+		return fmt.Errorf("create spool dir: %w", err)
+	}
+	if sp.file == "" {
+		sp.file = fmt.Sprintf("%020d-%d%s", time.Now().UnixNano(), os.Getpid(), spoolExt)
+	}
+	f, err := os.OpenFile(filepath.Join(sp.dir, sp.file), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open spool: %w", err)
+	}
+	// One write per call keeps concurrent appends from interleaving lines.
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("write spool: %w", err)
+	}
+	return f.Close()
+}
+
+// SpoolStatus summarises the writes waiting in a spool.
+type SpoolStatus struct {
+	Dir     string `json:"dir"`
+	Files   int    `json:"files"`
+	Entries int    `json:"entries"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// Status counts the spooled writes. A missing directory is an empty spool.
+func (sp *Spool) Status() (SpoolStatus, error) {
+	st := SpoolStatus{Dir: sp.dir}
+	names, err := sp.pending()
+	if err != nil {
+		return st, err
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(sp.dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // replayed meanwhile
+			}
+			return st, fmt.Errorf("read spool: %w", err)
+		}
+		st.Files++
+		st.Bytes += int64(len(data))
+		st.Entries += bytes.Count(data, []byte("\n"))
+	}
+	return st, nil
+}
+
+// empty reports whether nothing is waiting to be replayed.
+func (sp *Spool) empty() bool {
+	names, err := sp.pending()
+	return err == nil && len(names) == 0
+}
+
+// pending returns the spool files waiting to be replayed, oldest first.
+// Files left mid-replay by a process that died are included.
+func (sp *Spool) pending() ([]string, error) {
+	entries, err := os.ReadDir(sp.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read spool dir: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		n := e.Name()
+		if !e.IsDir() && (strings.HasSuffix(n, spoolExt) || strings.HasSuffix(n, spoolExt+replayingExt)) {
+			names = append(names, n)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return strings.TrimSuffix(names[i], replayingExt) < strings.TrimSuffix(names[j], replayingExt)
+	})
+	return names, nil
+}
+
+// ReplayResult reports what a Replay delivered.
+type ReplayResult struct {
+	Replayed int `json:"replayed"` // writes delivered (or found already stored)
+	Skipped  int `json:"skipped"`  // unreadable lines, dropped
+}
+
+// Replay sends spooled writes to s in the order they were recorded,
+// removing each file once it has been delivered. It stops at the first
+// write that fails, leaving that write and everything after it spooled.
+//
+// Writes that the target already has are treated as delivered, so a write
+// that reached the server before its response was lost is not sent twice.
+// Only one process replays a spool at a time; if another is already doing
+// so, Replay returns an empty result.
+func (sp *Spool) Replay(ctx context.Context, s Store) (ReplayResult, error) {
+	var res ReplayResult
+	names, err := sp.pending()
+	if err != nil || len(names) == 0 {
+		return res, err
+	}
+
+	unlock, ok, err := sp.lock()
+	if err != nil || !ok {
+		return res, err
+	}
+	defer unlock()
+
+	// Re-list under the lock: another replay may have just finished.
+	names, err = sp.pending()
+	if err != nil {
+		return res, err
+	}
+	for _, name := range names {
+		if err := sp.replayFile(ctx, s, name, &res); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// lock takes the replay lock. It reports false if another process holds it.
+func (sp *Spool) lock() (unlock func(), ok bool, err error) {
+	path := filepath.Join(sp.dir, replayLock)
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, true, nil
+		}
+		if !os.IsExist(err) {
+			return nil, false, fmt.Errorf("lock spool: %w", err)
+		}
+		info, statErr := os.Stat(path)
+		if statErr != nil || time.Since(info.ModTime()) < staleReplayLock {
+			return nil, false, nil
+		}
+		os.Remove(path) // stale: its owner died mid-replay
+	}
+	return nil, false, nil
+}
+
+// replayFile delivers one spool file. The file is first renamed so that a
+// writer still appending to it starts a fresh file instead; whatever could
+// not be delivered is put back ahead of anything written since.
+func (sp *Spool) replayFile(ctx context.Context, s Store, name string, res *ReplayResult) error {
+	base := strings.TrimSuffix(name, replayingExt)
+	path := filepath.Join(sp.dir, base)
+	claimed := path + replayingExt
+	if name == base {
+		if err := os.Rename(path, claimed); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("claim spool file: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(claimed)
+	if err != nil {
+		return fmt.Errorf("read spool: %w", err)
+	}
+	var lines [][]byte
+	var entries []spoolEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), maxSpoolLine)
+	for sc.Scan() {
+		var e spoolEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil || e.Kind == "" {
+			res.Skipped++
+			continue
+		}
+		lines = append(lines, append([]byte(nil), sc.Bytes()...))
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read spool %s: %w", base, err)
+	}
+
+	for i := 0; i < len(entries); {
+		n, err := sendEntries(ctx, s, entries[i:])
+		if err != nil {
+			if rerr := restore(path, lines[i:]); rerr != nil {
+				return fmt.Errorf("%w (restoring spool: %v)", err, rerr)
+			}
+			os.Remove(claimed)
+			return err
+		}
+		if n == 0 {
+			res.Skipped++ // unknown kind or undecodable data
+			n = 1
+		} else {
+			res.Replayed += n
+		}
+		i += n
+	}
+	return os.Remove(claimed)
+}
+
+// maxSpoolLine is the longest spool line Replay reads.
+const maxSpoolLine = 10 << 20
+
+// sendEntries delivers the first entry of es, together with any directly
+// following entries of the same kind that can go in the same batch. It
+// returns how many entries were delivered, or 0 if the first entry is
+// unusable and should be skipped.
+func sendEntries(ctx context.Context, s Store, es []spoolEntry) (int, error) {
+	run := 1
+	for run < len(es) && run < replayBatch && es[run].Kind == es[0].Kind {
+		run++
+	}
+	switch es[0].Kind {
+	case spoolDesire:
+		ds, ok := decodeAll[model.Desire](es[:run])
+		if !ok {
+			return 0, nil
+		}
+		return len(ds), s.RecordDesires(ctx, ds)
+	case spoolInvocation:
+		invs, ok := decodeAll[model.Invocation](es[:run])
+		if !ok {
+			return 0, nil
+		}
+		return len(invs), s.RecordInvocations(ctx, invs)
+	case spoolIntervention:
+		var iv model.Intervention
+		if json.Unmarshal(es[0].Data, &iv) != nil {
+			return 0, nil
+		}
+		if err := s.RecordIntervention(ctx, iv); err != nil && !errors.Is(err, ErrDuplicateID) {
+			return 0, err
+		}
+		return 1, nil
+	case spoolRecovery:
+		var inv model.Invocation
+		if json.Unmarshal(es[0].Data, &inv) != nil {
+			return 0, nil
+		}
+		return 1, s.DetectAndRecordRecovery(ctx, inv)
+	case spoolChain:
+		var req ChainTrackRequest
+		if json.Unmarshal(es[0].Data, &req) != nil {
+			return 0, nil
+		}
+		return 1, s.TrackRetryChain(ctx, req.Invocation, req.ToolInput)
+	}
+	return 0, nil
+}
+
+// decodeAll decodes the data of es, stopping before the first entry that
+// does not decode. It reports false if not even the first one does.
+func decodeAll[T any](es []spoolEntry) ([]T, bool) {
+	var out []T
+	for _, e := range es {
+		var v T
+		if json.Unmarshal(e.Data, &v) != nil {
+			break
+		}
+		out = append(out, v)
+	}
+	return out, len(out) > 0
+}
+
+// restore writes lines back to path, ahead of anything appended to path
+// since it was claimed.
+func restore(path string, lines [][]byte) error {
+	var buf bytes.Buffer
+	for _, l := range lines {
+		buf.Write(l)
+		buf.WriteByte('\n')
+	}
+	newer, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	buf.Write(newer)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+func TestRemoteSpoolsWhenUnreachable(t *testing.T) {
+	local := newTestStore(t)
+	ctx := context.Background()
+
+	var down atomic.Bool
+	mux := http.NewServeMux()
+	registerRoutes(mux, local)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/api/v1/interventions" {
+			http.Error(w, "rejected", http.StatusBadRequest)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	sp := NewSpool(filepath.Join(t.TempDir(), "spool"))
+	remote := NewRemote(ts.URL)
+	remote.SetSpool(sp)
+	var notices int
+	remote.SetSpoolNotice(func(error) { notices++ })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	down.Store(true)
+	for _, id := range []string{"d-1", "d-2"} {
+		if err := remote.RecordDesire(ctx, model.Desire{ID: id, ToolName: "read_file", Error: "unknown tool", Timestamp: now}); err != nil {
+			t.Fatalf("RecordDesire while down: %v", err)
+		}
+	}
+	inv := model.Invocation{ID: "inv-1", Source: "test", InstanceID: "s1", ToolName: "Read", IsError: true, Error: "no such file", Timestamp: now}
+	if err := remote.RecordInvocation(ctx, inv); err != nil {
+		t.Fatalf("RecordInvocation while down: %v", err)
+	}
+	if err := remote.TrackRetryChain(ctx, inv, nil); err != nil {
+		t.Fatalf("TrackRetryChain while down: %v", err)
+	}
+	st, err := sp.Status()
+	if err != nil || st.Entries != 4 || st.Files != 1 {
+		t.Fatalf("status = %+v, %v; want 4 entries in 1 file", st, err)
+	}
+	if notices != 4 {
+		t.Errorf("notices = %d, want 4", notices)
+	}
+
+	// The next successful write delivers the spool too.
+	down.Store(false)
+	if err := remote.RecordDesire(ctx, model.Desire{ID: "d-3", ToolName: "grep", Error: "unknown tool", Timestamp: now}); err != nil {
+		t.Fatalf("RecordDesire: %v", err)
+	}
+	if st, _ := sp.Status(); st.Entries != 0 || st.Files != 0 {
+		t.Errorf("status after replay = %+v, want empty", st)
+	}
+	desires, _ := local.ListDesires(ctx, ListOpts{})
+	invs, _ := local.ListInvocations(ctx, InvocationOpts{})
+	chains, _ := local.ListRetryChains(ctx, ChainOpts{})
+	if len(desires) != 3 || len(invs) != 1 || len(chains) != 1 {
+		t.Errorf("server has %d desires, %d invocations and %d chains, want 3, 1 and 1", len(desires), len(invs), len(chains))
+	}
+
+	// Reads are not spooled, and neither are writes the server rejects.
+	down.Store(true)
+	if _, err := remote.Stats(ctx); err == nil {
+		t.Error("Stats while down: expected error")
+	}
+	down.Store(false)
+	if err := remote.RecordIntervention(ctx, model.Intervention{ID: "iv-1", Kind: "deny", ToolName: "Bash", Timestamp: now}); err == nil {
+		t.Error("rejected RecordIntervention: expected error")
+	}
+	if st, _ := sp.Status(); st.Entries != 0 {
+		t.Errorf("spooled %d entries, want 0", st.Entries)
+	}
+}
+
+// flakyStore fails every write once failAfter writes have succeeded.
+type flakyStore struct {
+	Store
+	failAfter int
+	writes    int
+}
+
+func (f *flakyStore) write() error {
+	if f.writes >= f.failAfter {
+		return errors.New("server unreachable")
+	}
+	f.writes++
+	return nil
+}
+
+func (f *flakyStore) RecordDesires(ctx context.Context, ds []model.Desire) error {
+	if err := f.write(); err != nil {
+		return err
+	}
+	return f.Store.RecordDesires(ctx, ds)
+}
+
+func (f *flakyStore) RecordInvocations(ctx context.Context, invs []model.Invocation) error {
+	if err := f.write(); err != nil {
+		return err
+	}
+	return f.Store.RecordInvocations(ctx, invs)
+}
+
+func (f *flakyStore) RecordIntervention(ctx context.Context, iv model.Intervention) error {
+	if err := f.write(); err != nil {
+		return err
+	}
+	return f.Store.RecordIntervention(ctx, iv)
+}
+
+func TestSpoolReplayInOrder(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "spool")
+	sp := NewSpool(dir)
+
+	now := time.Now().UTC()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(appendAll(sp, spoolDesire, []model.Desire{
+		{ID: "d-1", ToolName: "a", Error: "x", Timestamp: now},
+		{ID: "d-2", ToolName: "b", Error: "x", Timestamp: now},
+	}))
+	must(appendAll(sp, spoolIntervention, []model.Intervention{{ID: "iv-1", Kind: "deny", ToolName: "Bash", Timestamp: now}}))
+	must(appendAll(sp, spoolInvocation, []model.Invocation{{ID: "inv-1", Source: "test", ToolName: "Read", Timestamp: now}}))
+
+	// The desire batch and the intervention go through; the invocation fails.
+	res, err := sp.Replay(ctx, &flakyStore{Store: s, failAfter: 2})
+	if err == nil {
+		t.Fatal("expected replay error")
+	}
+	if res.Replayed != 3 {
+		t.Errorf("replayed %d, want 3", res.Replayed)
+	}
+	if st, _ := sp.Status(); st.Entries != 1 || st.Files != 1 {
+		t.Fatalf("status = %+v, want the invocation left", st)
+	}
+
+	// A later write lands in a new file, behind what is left.
+	must(appendAll(NewSpool(dir), spoolDesire, []model.Desire{{ID: "d-3", ToolName: "c", Error: "x", Timestamp: now}}))
+	// Garbage is skipped rather than blocking the spool forever.
+	must(os.WriteFile(filepath.Join(dir, "00000000000000000000-1.ndjson"), []byte("not json\n"), 0o600))
+
+	res, err = sp.Replay(ctx, s)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if res.Replayed != 2 || res.Skipped != 1 {
+		t.Errorf("result = %+v, want 2 replayed, 1 skipped", res)
+	}
+	if st, _ := sp.Status(); st.Files != 0 {
+		t.Errorf("status = %+v, want empty", st)
+	}
+
+	// Replaying writes the store already has is harmless.
+	must(appendAll(sp, spoolDesire, []model.Desire{{ID: "d-1", ToolName: "a", Error: "x", Timestamp: now}}))
+	must(appendAll(sp, spoolIntervention, []model.Intervention{{ID: "iv-1", Kind: "deny", ToolName: "Bash", Timestamp: now}}))
+	if res, err := sp.Replay(ctx, s); err != nil || res.Replayed != 2 {
+		t.Errorf("replaying duplicates: %+v, %v", res, err)
+	}
+
+	desires, _ := s.ListDesires(ctx, ListOpts{})
+	invs, _ := s.ListInvocations(ctx, InvocationOpts{})
+	if len(desires) != 3 || len(invs) != 1 {
+		t.Errorf("store has %d desires and %d invocations, want 3 and 1", len(desires), len(invs))
+	}
+}
+
+func TestSpoolReplayLocked(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	sp := NewSpool(dir)
+	if err := appendAll(sp, spoolDesire, []model.Desire{{ID: "d-1", ToolName: "a", Error: "x", Timestamp: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	lock := filepath.Join(dir, replayLock)
+	if err := os.WriteFile(lock, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestStore(t)
+	if res, err := sp.Replay(context.Background(), s); err != nil || res.Replayed != 0 {
+		t.Errorf("replay while locked: %+v, %v", res, err)
+	}
+
+	// A lock left by a process that died is taken over.
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := sp.Replay(context.Background(), s); err != nil || res.Replayed != 1 {
+		t.Errorf("replay with stale lock: %+v, %v", res, err)
+	}
+}
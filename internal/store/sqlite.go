@@ -9,18 +9,31 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/events"
 	"github.com/scbrown/desire-path/internal/model"
 
 	_ "modernc.org/sqlite"
 )
 
-const schemaVersion = 7
+const schemaVersion = 18
 
 // SQLiteStore implements Store using a local SQLite database.
 type SQLiteStore struct {
-	db *sql.DB
+	db  *sql.DB
+	bus *events.Bus
+
+	tokMu      sync.Mutex // guards tokActive and tokChecked
+	tokActive  bool       // cached HasActiveTokens result
+	tokChecked time.Time  // when tokActive was read; zero means not cached
+}
+
+// SetEventBus makes the store publish recorded desires, invocations, and
+// detected recoveries to b. A nil bus disables publishing.
+func (s *SQLiteStore) SetEventBus(b *events.Bus) {
+	s.bus = b
 }
 
 // New opens (or creates) a SQLite database at dbPath.
@@ -32,12 +45,17 @@ func New(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("create data dir %s: %w", dir, err)
 	}
 
-	db, err := sql.Open("sqlite", dbPath+"?_journal_mode=WAL&_busy_timeout=5000")
+	// modernc.org/sqlite takes pragmas as _pragma=name(value). The busy
+	// timeout lets concurrent readers (dp tail -f) and hook writers wait on
+	// each other instead of failing with SQLITE_BUSY.
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
 
-	// Single connection for WAL mode simplicity.
+	// SQLite allows one writer at a time, so a single connection
+	// serialises this process's queries and transactions instead of
+	// having pooled connections contend for the database lock.
 	db.SetMaxOpenConns(1)
 
 	s := &SQLiteStore{db: db}
@@ -107,6 +125,72 @@ func (s *SQLiteStore) migrate() error {
 		}
 	}
 
+	if ver < 8 {
+		if err := s.migrateV8(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 9 {
+		if err := s.migrateV9(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 10 {
+		if err := s.migrateV10(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 11 {
+		if err := s.migrateV11(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 12 {
+		if err := s.migrateV12(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 13 {
+		if err := s.migrateV13(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 14 {
+		if err := s.migrateV14(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 15 {
+		if err := s.migrateV15(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 16 {
+		if err := s.migrateV16(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 17 {
+		if err := s.migrateV17(); err != nil {
+			return err
+		}
+	}
+
+	if ver < 18 {
+		if err := s.migrateV18(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -252,27 +336,31 @@ func (s *SQLiteStore) migrateV6() error {
 
 // DetectAndRecordRecovery checks if a successful invocation represents a recovery
 // from a previous failure pattern. If the tool had recent desires (failures) but
-// this invocation succeeded, record a recovery event.
+// this invocation succeeded, record a recovery event. Only desires from before
+// the invocation count, so the result does not depend on whether later
+// failures were stored first, as they are by a batch.
 func (s *SQLiteStore) DetectAndRecordRecovery(ctx context.Context, inv model.Invocation) error {
 	if inv.IsError {
 		return nil // Only successes can be recoveries
 	}
 
-	// Check if this tool had any recent desires (failures) in the last 7 days
-	var desireID string
+	// Check if this tool had any recent desires (failures) in the 7 days
+	// before the invocation
+	ts := inv.Timestamp.UTC().Format(time.RFC3339Nano)
+	var desireID, desireTS string
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id FROM desires WHERE tool_name = ? AND timestamp > datetime(?, '-7 days') ORDER BY timestamp DESC LIMIT 1`,
-		inv.ToolName, inv.Timestamp.UTC().Format(time.RFC3339Nano),
-	).Scan(&desireID)
+		`SELECT id, timestamp FROM desires WHERE tool_name = ? AND timestamp > datetime(?, '-7 days') AND timestamp < ? ORDER BY timestamp DESC LIMIT 1`,
+		inv.ToolName, ts, ts,
+	).Scan(&desireID, &desireTS)
 	if err != nil {
 		return nil // No recent failures — not a recovery
 	}
 
-	// Check we haven't already recorded a recovery for this tool since the last failure
+	// Check we haven't already recorded a recovery for this tool since that failure
 	var existingCount int
 	err = s.db.QueryRowContext(ctx,
-		`SELECT COUNT(*) FROM recoveries WHERE tool_name = ? AND timestamp > (SELECT MAX(timestamp) FROM desires WHERE tool_name = ?)`,
-		inv.ToolName, inv.ToolName,
+		`SELECT COUNT(*) FROM recoveries WHERE tool_name = ? AND timestamp > ?`,
+		inv.ToolName, desireTS,
 	).Scan(&existingCount)
 	if err == nil && existingCount > 0 {
 		return nil // Already recorded recovery for this failure window
@@ -280,28 +368,45 @@ func (s *SQLiteStore) DetectAndRecordRecovery(ctx context.Context, inv model.Inv
 
 	// Record the recovery
 	id := inv.ID + "-recovery"
-	_, err = s.db.ExecContext(ctx,
+	res, err := s.db.ExecContext(ctx,
 		`INSERT OR IGNORE INTO recoveries (id, tool_name, desire_id, timestamp) VALUES (?, ?, ?, ?)`,
-		id, inv.ToolName, desireID, inv.Timestamp.UTC().Format(time.RFC3339Nano),
+		id, inv.ToolName, desireID, ts,
 	)
 	if err != nil {
 		return fmt.Errorf("insert recovery: %w", err)
 	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		s.bus.Publish(events.FromRecovery(model.Recovery{
+			ID:        id,
+			ToolName:  inv.ToolName,
+			DesireID:  desireID,
+			Timestamp: inv.Timestamp,
+		}, &inv))
+	}
 	return nil
 }
 
-// ListRecoveries returns recovery events matching the filter options.
-func (s *SQLiteStore) ListRecoveries(ctx context.Context, since time.Time, limit int) ([]model.Recovery, error) {
+// ListRecoveries returns recovery events matching the filter options,
+// newest first.
+func (s *SQLiteStore) ListRecoveries(ctx context.Context, opts RecoveryOpts) ([]model.Recovery, error) {
 	query := "SELECT id, tool_name, desire_id, timestamp FROM recoveries WHERE 1=1"
 	var args []any
-	if !since.IsZero() {
+	if !opts.Since.IsZero() {
 		query += " AND timestamp >= ?"
-		args = append(args, since.UTC().Format(time.RFC3339Nano))
+		args = append(args, opts.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if opts.Cursor != "" {
+		cond, cargs, err := afterTime(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query += cond
+		args = append(args, cargs...)
 	}
-	query += " ORDER BY timestamp DESC"
-	if limit > 0 {
+	query += " ORDER BY timestamp DESC, id DESC"
+	if opts.Limit > 0 {
 		query += " LIMIT ?"
-		args = append(args, limit)
+		args = append(args, opts.Limit)
 	}
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -346,11 +451,81 @@ func (s *SQLiteStore) RecoveryStats(ctx context.Context) ([]model.RecoveryStat,
 	return results, rows.Err()
 }
 
-// RecordDesire persists a single failed tool call.
-func (s *SQLiteStore) RecordDesire(ctx context.Context, d model.Desire) error {
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO desires (id, tool_name, tool_input, error, category, source, session_id, cwd, timestamp, metadata)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+// SourceToolCounts returns desire and invocation totals grouped by source
+// and tool name, ordered by source then tool.
+func (s *SQLiteStore) SourceToolCounts(ctx context.Context) ([]SourceToolCount, error) {
+	type key struct{ source, tool string }
+	counts := make(map[key]*SourceToolCount)
+	get := func(source, tool string) *SourceToolCount {
+		k := key{source, tool}
+		c, ok := counts[k]
+		if !ok {
+			c = &SourceToolCount{Source: source, ToolName: tool}
+			counts[k] = c
+		}
+		return c
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT COALESCE(source, ''), tool_name, COUNT(*) FROM desires GROUP BY 1, 2`)
+	if err != nil {
+		return nil, fmt.Errorf("query desire counts: %w", err)
+	}
+	for rows.Next() {
+		var source, tool string
+		var n int
+		if err := rows.Scan(&source, &tool, &n); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan desire count: %w", err)
+		}
+		get(source, tool).Desires = n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx,
+		`SELECT source, tool_name, COUNT(*), SUM(CASE WHEN is_error = 1 THEN 1 ELSE 0 END)
+		FROM invocations GROUP BY 1, 2`)
+	if err != nil {
+		return nil, fmt.Errorf("query invocation counts: %w", err)
+	}
+	for rows.Next() {
+		var source, tool string
+		var n, errs int
+		if err := rows.Scan(&source, &tool, &n, &errs); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan invocation count: %w", err)
+		}
+		c := get(source, tool)
+		c.Invocations = n
+		c.InvocationErrors = errs
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]SourceToolCount, 0, len(counts))
+	for _, c := range counts {
+		results = append(results, *c)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Source != results[j].Source {
+			return results[i].Source < results[j].Source
+		}
+		return results[i].ToolName < results[j].ToolName
+	})
+	return results, nil
+}
+
+const insertDesireSQL = `INSERT INTO desires (id, tool_name, tool_input, error, category, source, session_id, cwd, timestamp, metadata, client_id, model)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// desireArgs returns the insertDesireSQL arguments for d.
+func desireArgs(d model.Desire) []any {
+	return []any{
 		d.ID,
 		d.ToolName,
 		nullableJSON(d.ToolInput),
@@ -361,16 +536,86 @@ func (s *SQLiteStore) RecordDesire(ctx context.Context, d model.Desire) error {
 		nullableString(d.CWD),
 		d.Timestamp.UTC().Format(time.RFC3339Nano),
 		nullableJSON(d.Metadata),
-	)
+		d.ClientID,
+		d.Model,
+	}
+}
+
+// RecordDesire persists a single failed tool call.
+func (s *SQLiteStore) RecordDesire(ctx context.Context, d model.Desire) error {
+	if _, err := s.db.ExecContext(ctx, insertDesireSQL, desireArgs(d)...); err != nil {
+		return fmt.Errorf("insert desire: %w", duplicateErr(err))
+	}
+	s.bus.Publish(events.FromDesire(d))
+	return nil
+}
+
+// RecordDesires persists a batch of desires in a single transaction, skipping
+// IDs that are already stored.
+func (s *SQLiteStore) RecordDesires(ctx context.Context, ds []model.Desire) error {
+	if len(ds) == 0 {
+		return nil
+	}
+	var inserted []model.Desire
+	err := s.inTx(ctx, insertDesireSQL+skipDuplicates, func(stmt *sql.Stmt) error {
+		for _, d := range ds {
+			res, err := stmt.ExecContext(ctx, desireArgs(d)...)
+			if err != nil {
+				return fmt.Errorf("insert desire %s: %w", d.ID, err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				inserted = append(inserted, d)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, d := range inserted {
+		s.bus.Publish(events.FromDesire(d))
+	}
+	return nil
+}
+
+// skipDuplicates makes an insert ignore rows whose ID is already stored, so
+// batch writes can be replayed safely.
+const skipDuplicates = ` ON CONFLICT(id) DO NOTHING`
+
+// duplicateErr returns ErrDuplicateID if err is a primary-key violation,
+// and err otherwise.
+func duplicateErr(err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrDuplicateID
+	}
+	return err
+}
+
+// inTx prepares query inside a transaction and passes it to fn, committing
+// if fn succeeds and rolling back otherwise.
+func (s *SQLiteStore) inTx(ctx context.Context, query string, fn func(*sql.Stmt) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
-		return fmt.Errorf("insert desire: %w", err)
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+	if err := fn(stmt); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
 	}
 	return nil
 }
 
 // ListDesires returns desires matching the given filter options.
 func (s *SQLiteStore) ListDesires(ctx context.Context, opts ListOpts) ([]model.Desire, error) {
-	query := "SELECT id, tool_name, tool_input, error, category, source, session_id, cwd, timestamp, metadata FROM desires WHERE 1=1"
+	query := "SELECT " + desireColumns + " FROM desires WHERE 1=1"
 	var args []any
 
 	if !opts.Since.IsZero() {
@@ -389,7 +634,19 @@ func (s *SQLiteStore) ListDesires(ctx context.Context, opts ListOpts) ([]model.D
 		query += " AND category = ?"
 		args = append(args, opts.Category)
 	}
-	query += " ORDER BY timestamp DESC"
+	if opts.Model != "" {
+		query += " AND model = ?"
+		args = append(args, opts.Model)
+	}
+	if opts.Cursor != "" {
+		cond, cargs, err := afterTime(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query += cond
+		args = append(args, cargs...)
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
 	if opts.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", opts.Limit)
 	}
@@ -402,41 +659,61 @@ func (s *SQLiteStore) ListDesires(ctx context.Context, opts ListOpts) ([]model.D
 
 	var desires []model.Desire
 	for rows.Next() {
-		var d model.Desire
-		var toolInput, category, source, sessionID, cwd, ts, metadata sql.NullString
-		if err := rows.Scan(&d.ID, &d.ToolName, &toolInput, &d.Error, &category, &source, &sessionID, &cwd, &ts, &metadata); err != nil {
-			return nil, fmt.Errorf("scan desire: %w", err)
-		}
-		d.Category = category.String
-		d.Source = source.String
-		d.SessionID = sessionID.String
-		d.CWD = cwd.String
-		if toolInput.Valid && toolInput.String != "" {
-			d.ToolInput = []byte(toolInput.String)
-		}
-		if metadata.Valid && metadata.String != "" {
-			d.Metadata = []byte(metadata.String)
-		}
-		t, err := time.Parse(time.RFC3339Nano, ts.String)
+		d, err := scanDesire(rows)
 		if err != nil {
-			return nil, fmt.Errorf("parse timestamp %q: %w", ts.String, err)
+			return nil, err
 		}
-		d.Timestamp = t
 		desires = append(desires, d)
 	}
 	return desires, rows.Err()
 }
 
-// GetPaths returns aggregated desire patterns ranked by frequency.
+// desireColumns lists the desire columns in the order scanDesire reads them.
+const desireColumns = "id, tool_name, tool_input, error, category, source, session_id, cwd, timestamp, metadata, client_id, model"
+
+// scanDesire reads a row of desireColumns, followed by any extra columns,
+// which are scanned into extra.
+func scanDesire(sc interface{ Scan(...any) error }, extra ...any) (model.Desire, error) {
+	var d model.Desire
+	var toolInput, category, source, sessionID, cwd, ts, metadata sql.NullString
+	dest := append([]any{&d.ID, &d.ToolName, &toolInput, &d.Error, &category, &source, &sessionID, &cwd, &ts, &metadata, &d.ClientID, &d.Model}, extra...)
+	if err := sc.Scan(dest...); err != nil {
+		return d, fmt.Errorf("scan desire: %w", err)
+	}
+	d.Category = category.String
+	d.Source = source.String
+	d.SessionID = sessionID.String
+	d.CWD = cwd.String
+	if toolInput.Valid && toolInput.String != "" {
+		d.ToolInput = []byte(toolInput.String)
+	}
+	if metadata.Valid && metadata.String != "" {
+		d.Metadata = []byte(metadata.String)
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts.String)
+	if err != nil {
+		return d, fmt.Errorf("parse timestamp %q: %w", ts.String, err)
+	}
+	d.Timestamp = t
+	return d, nil
+}
+
+// GetPaths returns aggregated desire patterns ranked by frequency, or by
+// impact score when opts.Sort is PathSortImpact. Sessions and Impact are
+// only filled in when sorting by impact or when opts.Score is set.
 func (s *SQLiteStore) GetPaths(ctx context.Context, opts PathOpts) ([]model.Path, error) {
+	byImpact := opts.Sort == PathSortImpact
+	if !byImpact && opts.Sort != "" && opts.Sort != PathSortCount {
+		return nil, fmt.Errorf("unknown path sort %q: want %s or %s", opts.Sort, PathSortCount, PathSortImpact)
+	}
 	query := `SELECT
 		d.tool_name,
 		COUNT(*) as cnt,
 		MIN(d.timestamp) as first_seen,
 		MAX(d.timestamp) as last_seen,
-		a.to_name
-	FROM desires d
-	LEFT JOIN aliases a ON a.from_name = d.tool_name AND a.tool = '' AND a.param = ''`
+		(SELECT to_name FROM aliases
+		 WHERE from_name = d.tool_name AND tool = '' AND param = '' `+unscopedFirst+` LIMIT 1)
+	FROM desires d`
 
 	var args []any
 	if !opts.Since.IsZero() {
@@ -444,7 +721,7 @@ func (s *SQLiteStore) GetPaths(ctx context.Context, opts PathOpts) ([]model.Path
 		args = append(args, opts.Since.UTC().Format(time.RFC3339Nano))
 	}
 	query += " GROUP BY d.tool_name ORDER BY cnt DESC"
-	if opts.Top > 0 {
+	if opts.Top > 0 && !byImpact {
 		query += fmt.Sprintf(" LIMIT %d", opts.Top)
 	}
 
@@ -470,16 +747,136 @@ func (s *SQLiteStore) GetPaths(ctx context.Context, opts PathOpts) ([]model.Path
 		}
 		paths = append(paths, p)
 	}
-	return paths, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if !byImpact && !opts.Score {
+		return paths, nil
+	}
+	if err := s.scorePaths(ctx, paths, opts.Since, opts.Weights); err != nil {
+		return nil, err
+	}
+	if byImpact {
+		sortPathsByImpact(paths)
+		if opts.Top > 0 && len(paths) > opts.Top {
+			paths = paths[:opts.Top]
+		}
+	}
+	return paths, nil
 }
 
-// SetAlias creates or updates an alias or parameter correction rule.
+// PathTrends counts each path's desires per bucket over the current window
+// and the one before it. Paths are ordered by how much they grew, so the
+// ones getting worse come first and the ones that shrank most, such as
+// disappeared ones, last.
+func (s *SQLiteStore) PathTrends(ctx context.Context, opts TrendOpts) (*TrendResult, error) {
+	size, err := BucketSize(opts.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	res := &TrendResult{Bucket: opts.Bucket, Buckets: opts.Buckets, Until: opts.Until.UTC()}
+	if res.Bucket == "" {
+		res.Bucket = BucketDay
+	}
+	if res.Buckets <= 0 {
+		res.Buckets = 7
+	}
+	if res.Until.IsZero() {
+		res.Until = time.Now().UTC()
+	}
+	window := time.Duration(res.Buckets) * size
+	res.Start = res.Until.Add(-window)
+	res.PreviousStart = res.Start.Add(-window)
+
+	// Widen the range by a second: stored timestamps compare as text, so
+	// fractional seconds can sort just outside an exact bound. Each row is
+	// bucketed by its parsed time below.
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT tool_name, timestamp FROM desires WHERE timestamp >= ? AND timestamp <= ?",
+		res.PreviousStart.Add(-time.Second).Format(time.RFC3339Nano),
+		res.Until.Add(time.Second).Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, fmt.Errorf("path trends: %w", err)
+	}
+	defer rows.Close()
+
+	byPattern := make(map[string]*PathTrend)
+	for rows.Next() {
+		var name, ts string
+		if err := rows.Scan(&name, &ts); err != nil {
+			return nil, fmt.Errorf("scan path trend: %w", err)
+		}
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil || t.Before(res.PreviousStart) || !t.Before(res.Until) {
+			continue
+		}
+		p := byPattern[name]
+		if p == nil {
+			p = &PathTrend{Pattern: name, Counts: make([]int, res.Buckets)}
+			byPattern[name] = p
+		}
+		if t.Before(res.Start) {
+			p.Previous++
+			continue
+		}
+		p.Current++
+		p.Counts[int(t.Sub(res.Start)/size)]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res.Paths = make([]PathTrend, 0, len(byPattern))
+	for _, p := range byPattern {
+		p.Delta = p.Current - p.Previous
+		switch {
+		case p.Previous == 0:
+			p.Status = TrendNew
+		case p.Current == 0:
+			p.Status = TrendGone
+		case p.Delta > 0:
+			p.Status = TrendUp
+		case p.Delta < 0:
+			p.Status = TrendDown
+		default:
+			p.Status = TrendFlat
+		}
+		res.Paths = append(res.Paths, *p)
+	}
+	sort.Slice(res.Paths, func(i, j int) bool {
+		a, b := res.Paths[i], res.Paths[j]
+		if a.Delta != b.Delta {
+			return a.Delta > b.Delta
+		}
+		if a.Current != b.Current {
+			return a.Current > b.Current
+		}
+		return a.Pattern < b.Pattern
+	})
+	if opts.Top > 0 && len(res.Paths) > opts.Top {
+		res.Paths = res.Paths[:opts.Top]
+	}
+	return res, nil
+}
+
+// SetAlias creates or updates an alias or parameter correction rule. Its
+// created_at is reset on every write, so it records the last change.
 func (s *SQLiteStore) SetAlias(ctx context.Context, a model.Alias) error {
+	a.CreatedAt = time.Now()
+	return s.putAlias(ctx, a)
+}
+
+// putAlias writes a as is, keeping its CreatedAt.
+func (s *SQLiteStore) putAlias(ctx context.Context, a model.Alias) error {
 	_, err := s.db.ExecContext(ctx,
-		`INSERT OR REPLACE INTO aliases (from_name, to_name, tool, param, command, match_kind, message, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT OR REPLACE INTO aliases (from_name, to_name, tool, param, command, match_kind, message, created_at,
+		                                  scope_cwd, scope_remote, scope_source, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		a.From, a.To, a.Tool, a.Param, a.Command, a.MatchKind, a.Message,
-		time.Now().UTC().Format(time.RFC3339Nano),
+		a.CreatedAt.UTC().Format(time.RFC3339Nano),
+		a.ScopeCWD, a.ScopeRemote, a.ScopeSource, formatOptionalTime(a.ExpiresAt),
 	)
 	if err != nil {
 		return fmt.Errorf("set alias: %w", err)
@@ -488,14 +885,15 @@ func (s *SQLiteStore) SetAlias(ctx context.Context, a model.Alias) error {
 }
 
 // GetAlias returns a single alias by its composite key, or nil if not found.
-func (s *SQLiteStore) GetAlias(ctx context.Context, from, tool, param, command, matchKind string) (*model.Alias, error) {
+func (s *SQLiteStore) GetAlias(ctx context.Context, k model.AliasKey) (*model.Alias, error) {
 	var a model.Alias
-	var createdAt string
+	var createdAt, expiresAt string
 	err := s.db.QueryRowContext(ctx,
-		`SELECT from_name, to_name, tool, param, command, match_kind, message, created_at
-		 FROM aliases WHERE from_name = ? AND tool = ? AND param = ? AND command = ? AND match_kind = ?`,
-		from, tool, param, command, matchKind,
-	).Scan(&a.From, &a.To, &a.Tool, &a.Param, &a.Command, &a.MatchKind, &a.Message, &createdAt)
+		`SELECT from_name, to_name, tool, param, command, match_kind, message, created_at,
+		        scope_cwd, scope_remote, scope_source, expires_at
+		 FROM aliases WHERE `+aliasKeyWhere, aliasKeyArgs(k)...,
+	).Scan(&a.From, &a.To, &a.Tool, &a.Param, &a.Command, &a.MatchKind, &a.Message, &createdAt,
+		&a.ScopeCWD, &a.ScopeRemote, &a.ScopeSource, &expiresAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -503,13 +901,54 @@ func (s *SQLiteStore) GetAlias(ctx context.Context, from, tool, param, command,
 		return nil, fmt.Errorf("get alias: %w", err)
 	}
 	a.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	a.ExpiresAt = parseOptionalTime(expiresAt)
 	return &a, nil
 }
 
+// unscopedFirst orders a tool-name alias's variants so that the unscoped
+// one, which applies everywhere, comes first.
+const unscopedFirst = `ORDER BY scope_cwd || scope_remote || scope_source != ''`
+
+// aliasKeyWhere matches the aliases row with the key given by aliasKeyArgs.
+const aliasKeyWhere = `from_name = ? AND tool = ? AND param = ? AND command = ? AND match_kind = ?
+	AND scope_cwd = ? AND scope_remote = ? AND scope_source = ?`
+
+func aliasKeyArgs(k model.AliasKey) []any {
+	return []any{k.From, k.Tool, k.Param, k.Command, k.MatchKind, k.ScopeCWD, k.ScopeRemote, k.ScopeSource}
+}
+
+// GetToolAliases returns the tool-name aliases for from, one per scope.
+func (s *SQLiteStore) GetToolAliases(ctx context.Context, from string) ([]model.Alias, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT from_name, to_name, tool, param, command, match_kind, message, created_at,
+		        scope_cwd, scope_remote, scope_source, expires_at
+		 FROM aliases WHERE from_name = ? AND tool = '' AND param = ''
+		 ORDER BY scope_cwd, scope_remote, scope_source`, from)
+	if err != nil {
+		return nil, fmt.Errorf("get tool aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var aliases []model.Alias
+	for rows.Next() {
+		var a model.Alias
+		var createdAt, expiresAt string
+		if err := rows.Scan(&a.From, &a.To, &a.Tool, &a.Param, &a.Command, &a.MatchKind, &a.Message, &createdAt,
+			&a.ScopeCWD, &a.ScopeRemote, &a.ScopeSource, &expiresAt); err != nil {
+			return nil, fmt.Errorf("scan alias: %w", err)
+		}
+		a.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		a.ExpiresAt = parseOptionalTime(expiresAt)
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
 // GetAliases returns all configured aliases and parameter correction rules.
 func (s *SQLiteStore) GetAliases(ctx context.Context) ([]model.Alias, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT from_name, to_name, tool, param, command, match_kind, message, created_at
+		`SELECT from_name, to_name, tool, param, command, match_kind, message, created_at,
+		        scope_cwd, scope_remote, scope_source, expires_at
 		 FROM aliases ORDER BY tool, command, param, from_name`)
 	if err != nil {
 		return nil, fmt.Errorf("get aliases: %w", err)
@@ -519,21 +958,21 @@ func (s *SQLiteStore) GetAliases(ctx context.Context) ([]model.Alias, error) {
 	var aliases []model.Alias
 	for rows.Next() {
 		var a model.Alias
-		var createdAt string
-		if err := rows.Scan(&a.From, &a.To, &a.Tool, &a.Param, &a.Command, &a.MatchKind, &a.Message, &createdAt); err != nil {
+		var createdAt, expiresAt string
+		if err := rows.Scan(&a.From, &a.To, &a.Tool, &a.Param, &a.Command, &a.MatchKind, &a.Message, &createdAt,
+			&a.ScopeCWD, &a.ScopeRemote, &a.ScopeSource, &expiresAt); err != nil {
 			return nil, fmt.Errorf("scan alias: %w", err)
 		}
 		a.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		a.ExpiresAt = parseOptionalTime(expiresAt)
 		aliases = append(aliases, a)
 	}
 	return aliases, rows.Err()
 }
 
 // DeleteAlias removes an alias by its composite key. Returns true if deleted.
-func (s *SQLiteStore) DeleteAlias(ctx context.Context, from, tool, param, command, matchKind string) (bool, error) {
-	res, err := s.db.ExecContext(ctx,
-		`DELETE FROM aliases WHERE from_name = ? AND tool = ? AND param = ? AND command = ? AND match_kind = ?`,
-		from, tool, param, command, matchKind)
+func (s *SQLiteStore) DeleteAlias(ctx context.Context, k model.AliasKey) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM aliases WHERE `+aliasKeyWhere, aliasKeyArgs(k)...)
 	if err != nil {
 		return false, fmt.Errorf("delete alias: %w", err)
 	}
@@ -547,7 +986,8 @@ func (s *SQLiteStore) DeleteAlias(ctx context.Context, from, tool, param, comman
 // GetRulesForTool returns all parameter correction rules for a specific tool.
 func (s *SQLiteStore) GetRulesForTool(ctx context.Context, tool string) ([]model.Alias, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT from_name, to_name, tool, param, command, match_kind, message, created_at
+		`SELECT from_name, to_name, tool, param, command, match_kind, message, created_at,
+		        scope_cwd, scope_remote, scope_source, expires_at
 		 FROM aliases WHERE tool = ? ORDER BY command, param, from_name`, tool)
 	if err != nil {
 		return nil, fmt.Errorf("get rules for tool: %w", err)
@@ -557,11 +997,13 @@ func (s *SQLiteStore) GetRulesForTool(ctx context.Context, tool string) ([]model
 	var rules []model.Alias
 	for rows.Next() {
 		var a model.Alias
-		var createdAt string
-		if err := rows.Scan(&a.From, &a.To, &a.Tool, &a.Param, &a.Command, &a.MatchKind, &a.Message, &createdAt); err != nil {
+		var createdAt, expiresAt string
+		if err := rows.Scan(&a.From, &a.To, &a.Tool, &a.Param, &a.Command, &a.MatchKind, &a.Message, &createdAt,
+			&a.ScopeCWD, &a.ScopeRemote, &a.ScopeSource, &expiresAt); err != nil {
 			return nil, fmt.Errorf("scan rule: %w", err)
 		}
 		a.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		a.ExpiresAt = parseOptionalTime(expiresAt)
 		rules = append(rules, a)
 	}
 	return rules, rows.Err()
@@ -700,7 +1142,7 @@ func (s *SQLiteStore) InspectPath(ctx context.Context, opts InspectOpts) (*Inspe
 	if !hasWildcard {
 		var aliasTo sql.NullString
 		_ = s.db.QueryRowContext(ctx,
-			"SELECT to_name FROM aliases WHERE from_name = ? AND tool = '' AND param = ''", opts.Pattern,
+			"SELECT to_name FROM aliases WHERE from_name = ? AND tool = '' AND param = '' "+unscopedFirst+" LIMIT 1", opts.Pattern,
 		).Scan(&aliasTo)
 		if aliasTo.Valid {
 			result.AliasTo = aliasTo.String
@@ -767,14 +1209,22 @@ func (s *SQLiteStore) InspectPath(ctx context.Context, opts InspectOpts) (*Inspe
 		return nil, err
 	}
 
+	// Retry chains share the tool_name and timestamp columns.
+	result.Chains, err = s.chainSummary(ctx, strings.TrimPrefix(where, "WHERE "), args, topN)
+	if err != nil {
+		return nil, err
+	}
+
 	return &result, nil
 }
 
-// RecordInvocation persists a single tool invocation.
-func (s *SQLiteStore) RecordInvocation(ctx context.Context, inv model.Invocation) error {
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO invocations (id, source, instance_id, host_id, tool_name, is_error, error, cwd, timestamp, metadata, turn_id, turn_sequence, turn_length)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+const insertInvocationSQL = `INSERT INTO invocations (id, source, instance_id, host_id, tool_name, is_error, error, cwd, timestamp, metadata, turn_id, turn_sequence, turn_length, client_id,
+		input_tokens, output_tokens, cache_read_tokens, cache_write_tokens, model, duration_ms)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// invocationArgs returns the insertInvocationSQL arguments for inv.
+func invocationArgs(inv model.Invocation) []any {
+	return []any{
 		inv.ID,
 		inv.Source,
 		nullableString(inv.InstanceID),
@@ -788,16 +1238,56 @@ func (s *SQLiteStore) RecordInvocation(ctx context.Context, inv model.Invocation
 		inv.TurnID,
 		inv.TurnSequence,
 		inv.TurnLength,
-	)
+		inv.ClientID,
+		inv.InputTokens,
+		inv.OutputTokens,
+		inv.CacheReadTokens,
+		inv.CacheWriteTokens,
+		inv.Model,
+		inv.DurationMs,
+	}
+}
+
+// RecordInvocation persists a single tool invocation.
+func (s *SQLiteStore) RecordInvocation(ctx context.Context, inv model.Invocation) error {
+	if _, err := s.db.ExecContext(ctx, insertInvocationSQL, invocationArgs(inv)...); err != nil {
+		return fmt.Errorf("insert invocation: %w", duplicateErr(err))
+	}
+	s.bus.Publish(events.FromInvocation(inv))
+	return nil
+}
+
+// RecordInvocations persists a batch of invocations in a single transaction,
+// skipping IDs that are already stored.
+func (s *SQLiteStore) RecordInvocations(ctx context.Context, invs []model.Invocation) error {
+	if len(invs) == 0 {
+		return nil
+	}
+	var inserted []model.Invocation
+	err := s.inTx(ctx, insertInvocationSQL+skipDuplicates, func(stmt *sql.Stmt) error {
+		for _, inv := range invs {
+			res, err := stmt.ExecContext(ctx, invocationArgs(inv)...)
+			if err != nil {
+				return fmt.Errorf("insert invocation %s: %w", inv.ID, err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				inserted = append(inserted, inv)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("insert invocation: %w", err)
+		return err
+	}
+	for _, inv := range inserted {
+		s.bus.Publish(events.FromInvocation(inv))
 	}
 	return nil
 }
 
 // ListInvocations returns invocations matching the given filter options.
 func (s *SQLiteStore) ListInvocations(ctx context.Context, opts InvocationOpts) ([]model.Invocation, error) {
-	query := "SELECT id, source, instance_id, host_id, tool_name, is_error, error, cwd, timestamp, metadata, turn_id, turn_sequence, turn_length FROM invocations WHERE 1=1"
+	query := "SELECT " + invocationColumns + " FROM invocations WHERE 1=1"
 	var args []any
 
 	if !opts.Since.IsZero() {
@@ -816,10 +1306,22 @@ func (s *SQLiteStore) ListInvocations(ctx context.Context, opts InvocationOpts)
 		query += " AND tool_name = ?"
 		args = append(args, opts.ToolName)
 	}
+	if opts.Model != "" {
+		query += " AND model = ?"
+		args = append(args, opts.Model)
+	}
 	if opts.ErrorsOnly {
 		query += " AND is_error = 1"
 	}
-	query += " ORDER BY timestamp DESC"
+	if opts.Cursor != "" {
+		cond, cargs, err := afterTime(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query += cond
+		args = append(args, cargs...)
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
 	if opts.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", opts.Limit)
 	}
@@ -832,30 +1334,47 @@ func (s *SQLiteStore) ListInvocations(ctx context.Context, opts InvocationOpts)
 
 	var invocations []model.Invocation
 	for rows.Next() {
-		var inv model.Invocation
-		var instanceID, hostID, errStr, cwd, ts, metadata sql.NullString
-		var isError int
-		if err := rows.Scan(&inv.ID, &inv.Source, &instanceID, &hostID, &inv.ToolName, &isError, &errStr, &cwd, &ts, &metadata, &inv.TurnID, &inv.TurnSequence, &inv.TurnLength); err != nil {
-			return nil, fmt.Errorf("scan invocation: %w", err)
-		}
-		inv.InstanceID = instanceID.String
-		inv.HostID = hostID.String
-		inv.IsError = isError != 0
-		inv.Error = errStr.String
-		inv.CWD = cwd.String
-		if metadata.Valid && metadata.String != "" {
-			inv.Metadata = []byte(metadata.String)
-		}
-		t, err := time.Parse(time.RFC3339Nano, ts.String)
+		inv, err := scanInvocation(rows)
 		if err != nil {
-			return nil, fmt.Errorf("parse timestamp %q: %w", ts.String, err)
+			return nil, err
 		}
-		inv.Timestamp = t
 		invocations = append(invocations, inv)
 	}
 	return invocations, rows.Err()
 }
 
+// invocationColumns lists the invocation columns in the order
+// scanInvocation reads them.
+const invocationColumns = "id, source, instance_id, host_id, tool_name, is_error, error, cwd, timestamp, metadata, turn_id, turn_sequence, turn_length, client_id, " +
+	"input_tokens, output_tokens, cache_read_tokens, cache_write_tokens, model, duration_ms"
+
+// scanInvocation reads a row of invocationColumns, followed by any extra
+// columns, which are scanned into extra.
+func scanInvocation(sc interface{ Scan(...any) error }, extra ...any) (model.Invocation, error) {
+	var inv model.Invocation
+	var instanceID, hostID, errStr, cwd, ts, metadata sql.NullString
+	var isError int
+	dest := append([]any{&inv.ID, &inv.Source, &instanceID, &hostID, &inv.ToolName, &isError, &errStr, &cwd, &ts, &metadata, &inv.TurnID, &inv.TurnSequence, &inv.TurnLength, &inv.ClientID,
+		&inv.InputTokens, &inv.OutputTokens, &inv.CacheReadTokens, &inv.CacheWriteTokens, &inv.Model, &inv.DurationMs}, extra...)
+	if err := sc.Scan(dest...); err != nil {
+		return inv, fmt.Errorf("scan invocation: %w", err)
+	}
+	inv.InstanceID = instanceID.String
+	inv.HostID = hostID.String
+	inv.IsError = isError != 0
+	inv.Error = errStr.String
+	inv.CWD = cwd.String
+	if metadata.Valid && metadata.String != "" {
+		inv.Metadata = []byte(metadata.String)
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts.String)
+	if err != nil {
+		return inv, fmt.Errorf("parse timestamp %q: %w", ts.String, err)
+	}
+	inv.Timestamp = t
+	return inv, nil
+}
+
 // InvocationStats returns summary statistics about stored invocations.
 func (s *SQLiteStore) InvocationStats(ctx context.Context) (InvocationStatsResult, error) {
 	var st InvocationStatsResult
@@ -937,8 +1456,8 @@ func (s *SQLiteStore) InvocationStats(ctx context.Context) (InvocationStatsResul
 	return st, nil
 }
 
-// ListTurns returns turns (grouped invocations) matching the given options.
-// Each TurnRow represents one turn with its tool sequence.
+// ListTurns returns turns (grouped invocations) matching the given options,
+// longest first. Each TurnRow represents one turn with its tool sequence.
 func (s *SQLiteStore) ListTurns(ctx context.Context, opts TurnOpts) ([]TurnRow, error) {
 	// Use a subquery ordered by turn_sequence to guarantee GROUP_CONCAT
 	// produces tools in the correct execution order.
@@ -957,11 +1476,24 @@ func (s *SQLiteStore) ListTurns(ctx context.Context, opts TurnOpts) ([]TurnRow,
 		where += " AND instance_id = ?"
 		args = append(args, opts.SessionID)
 	}
+	// A turn's early rows carry the length it had when they were
+	// recorded, so a turn's length is its longest, and the keyset is
+	// applied to the grouped turns rather than to their rows.
+	var having string
+	if opts.Cursor != "" {
+		k, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		having = " HAVING MAX(turn_length) < ? OR (MAX(turn_length) = ? AND turn_id > ?)"
+		args = append(args, k.Length, k.Length, k.ID)
+	}
 
-	query := fmt.Sprintf(`SELECT turn_id, instance_id, turn_length, GROUP_CONCAT(tool_name, ' → ')
+	query := fmt.Sprintf(`SELECT turn_id, instance_id, MAX(turn_length), GROUP_CONCAT(tool_name, ' → ')
 		FROM (SELECT * FROM invocations %s ORDER BY turn_sequence)
-		GROUP BY turn_id ORDER BY turn_length DESC`, where)
-	if opts.Limit > 0 {
+		GROUP BY turn_id%s ORDER BY MAX(turn_length) DESC, turn_id`, where, having)
+	// A pattern is matched after the query, so the limit is applied then.
+	if opts.Limit > 0 && opts.Pattern == "" {
 		query += fmt.Sprintf(" LIMIT %d", opts.Limit)
 	}
 
@@ -983,6 +1515,9 @@ func (s *SQLiteStore) ListTurns(ctx context.Context, opts TurnOpts) ([]TurnRow,
 			continue
 		}
 		turns = append(turns, tr)
+		if opts.Limit > 0 && len(turns) == opts.Limit {
+			break
+		}
 	}
 	return turns, rows.Err()
 }
@@ -1349,6 +1884,269 @@ func (s *SQLiteStore) StrugglingTools(ctx context.Context, opts StrugglingOpts)
 	return results, nil
 }
 
+func (s *SQLiteStore) migrateV8() error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS interventions (
+			id         TEXT PRIMARY KEY,
+			kind       TEXT NOT NULL,
+			tool_name  TEXT NOT NULL,
+			rule       TEXT NOT NULL DEFAULT '',
+			match_kind TEXT NOT NULL DEFAULT '',
+			session_id TEXT,
+			cwd        TEXT,
+			timestamp  TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_interventions_kind ON interventions(kind)`,
+		`CREATE INDEX IF NOT EXISTS idx_interventions_timestamp ON interventions(timestamp)`,
+		`UPDATE schema_version SET version = 8`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v8: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) migrateV9() error {
+	stmts := []string{
+		`ALTER TABLE aliases ADD COLUMN scope_cwd TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE aliases ADD COLUMN scope_remote TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE aliases ADD COLUMN scope_source TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE aliases ADD COLUMN expires_at TEXT NOT NULL DEFAULT ''`,
+		`UPDATE schema_version SET version = 9`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v9: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) migrateV10() error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS api_tokens (
+			id         TEXT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			scope      TEXT NOT NULL,
+			hash       TEXT NOT NULL UNIQUE,
+			created_at TEXT NOT NULL,
+			last_used  TEXT NOT NULL DEFAULT '',
+			revoked_at TEXT NOT NULL DEFAULT ''
+		)`,
+		`ALTER TABLE desires ADD COLUMN client_id TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE invocations ADD COLUMN client_id TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE interventions ADD COLUMN client_id TEXT NOT NULL DEFAULT ''`,
+		`UPDATE schema_version SET version = 10`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v10: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) migrateV11() error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS replication_cursors (
+			remote     TEXT NOT NULL,
+			name       TEXT NOT NULL,
+			value      TEXT NOT NULL,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (remote, name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS alias_sync_log (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			remote       TEXT NOT NULL,
+			timestamp    TEXT NOT NULL,
+			action       TEXT NOT NULL,
+			winner       TEXT NOT NULL DEFAULT '',
+			from_name    TEXT NOT NULL,
+			tool         TEXT NOT NULL DEFAULT '',
+			param        TEXT NOT NULL DEFAULT '',
+			command      TEXT NOT NULL DEFAULT '',
+			match_kind   TEXT NOT NULL DEFAULT '',
+			local_alias  TEXT,
+			remote_alias TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_alias_sync_log_key ON alias_sync_log(from_name, tool, param, command, match_kind)`,
+		`UPDATE schema_version SET version = 11`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v11: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) migrateV12() error {
+	stmts := []string{
+		`ALTER TABLE api_tokens ADD COLUMN workspace TEXT NOT NULL DEFAULT ''`,
+		`UPDATE schema_version SET version = 12`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v12: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) migrateV14() error {
+	stmts := []string{
+		`ALTER TABLE invocations ADD COLUMN input_tokens INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE invocations ADD COLUMN output_tokens INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE invocations ADD COLUMN cache_read_tokens INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE invocations ADD COLUMN cache_write_tokens INTEGER NOT NULL DEFAULT 0`,
+		`UPDATE schema_version SET version = 14`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v14: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) migrateV15() error {
+	stmts := []string{
+		`ALTER TABLE desires ADD COLUMN model TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE invocations ADD COLUMN model TEXT NOT NULL DEFAULT ''`,
+		`UPDATE schema_version SET version = 15`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v15: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) migrateV16() error {
+	stmts := []string{
+		`ALTER TABLE invocations ADD COLUMN duration_ms INTEGER NOT NULL DEFAULT 0`,
+		`UPDATE schema_version SET version = 16`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v16: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) migrateV18() error {
+	stmts := []string{
+		// Recreate aliases with the scope in the primary key, so that
+		// scoped variants of the same rule can coexist.
+		`CREATE TABLE IF NOT EXISTS aliases_v18 (
+			from_name    TEXT NOT NULL,
+			to_name      TEXT NOT NULL,
+			tool         TEXT NOT NULL DEFAULT '',
+			param        TEXT NOT NULL DEFAULT '',
+			command      TEXT NOT NULL DEFAULT '',
+			match_kind   TEXT NOT NULL DEFAULT '',
+			message      TEXT NOT NULL DEFAULT '',
+			created_at   TEXT NOT NULL,
+			scope_cwd    TEXT NOT NULL DEFAULT '',
+			scope_remote TEXT NOT NULL DEFAULT '',
+			scope_source TEXT NOT NULL DEFAULT '',
+			expires_at   TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (from_name, tool, param, command, match_kind, scope_cwd, scope_remote, scope_source)
+		)`,
+		`INSERT OR IGNORE INTO aliases_v18 (from_name, to_name, tool, param, command, match_kind, message, created_at,
+		                                     scope_cwd, scope_remote, scope_source, expires_at)
+			SELECT from_name, to_name, tool, param, command, match_kind, message, created_at,
+			       scope_cwd, scope_remote, scope_source, expires_at FROM aliases`,
+		`DROP TABLE aliases`,
+		`ALTER TABLE aliases_v18 RENAME TO aliases`,
+		`CREATE INDEX IF NOT EXISTS idx_aliases_tool_command ON aliases(tool, command)`,
+		`ALTER TABLE alias_sync_log ADD COLUMN scope_cwd TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE alias_sync_log ADD COLUMN scope_remote TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE alias_sync_log ADD COLUMN scope_source TEXT NOT NULL DEFAULT ''`,
+		`UPDATE schema_version SET version = 18`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate v18: %w", err)
+		}
+	}
+	return nil
+}
+
+// formatOptionalTime encodes an optional timestamp for storage ("" = unset).
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseOptionalTime decodes a timestamp stored by formatOptionalTime.
+func parseOptionalTime(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// RecordIntervention persists a single pave-check action.
+func (s *SQLiteStore) RecordIntervention(ctx context.Context, iv model.Intervention) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO interventions (id, kind, tool_name, rule, match_kind, session_id, cwd, timestamp, client_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		iv.ID,
+		iv.Kind,
+		iv.ToolName,
+		iv.Rule,
+		iv.MatchKind,
+		nullableString(iv.SessionID),
+		nullableString(iv.CWD),
+		iv.Timestamp.UTC().Format(time.RFC3339Nano),
+		iv.ClientID,
+	)
+	if err != nil {
+		return fmt.Errorf("insert intervention: %w", duplicateErr(err))
+	}
+	return nil
+}
+
+// InterventionStats returns intervention counts per kind and tool.
+func (s *SQLiteStore) InterventionStats(ctx context.Context, since time.Time) ([]model.InterventionStat, error) {
+	query := `SELECT kind, tool_name, COUNT(*) AS cnt, MAX(timestamp) FROM interventions WHERE 1=1`
+	var args []any
+	if !since.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, since.UTC().Format(time.RFC3339Nano))
+	}
+	query += " GROUP BY kind, tool_name ORDER BY cnt DESC, kind, tool_name"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query intervention stats: %w", err)
+	}
+	defer rows.Close()
+
+	var results []model.InterventionStat
+	for rows.Next() {
+		var st model.InterventionStat
+		var ts string
+		if err := rows.Scan(&st.Kind, &st.ToolName, &st.Count, &ts); err != nil {
+			return nil, fmt.Errorf("scan intervention stat: %w", err)
+		}
+		st.LastSeen, _ = time.Parse(time.RFC3339Nano, ts)
+		results = append(results, st)
+	}
+	return results, rows.Err()
+}
+
 // Close releases the database connection.
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
@@ -1410,3 +2208,121 @@ func nullableJSON(data []byte) any {
 	}
 	return string(data)
 }
+
+// CreateToken persists a new API token.
+func (s *SQLiteStore) CreateToken(ctx context.Context, t model.APIToken) error {
+	if t.Hash == "" {
+		return fmt.Errorf("insert token: hash is required")
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO api_tokens (id, name, scope, hash, created_at, workspace) VALUES (?, ?, ?, ?, ?, ?)`,
+		t.ID, t.Name, t.Scope, t.Hash, t.CreatedAt.UTC().Format(time.RFC3339Nano), t.Workspace,
+	)
+	if err != nil {
+		return fmt.Errorf("insert token: %w", err)
+	}
+	s.forgetActiveTokens()
+	return nil
+}
+
+// ListTokens returns all API tokens, newest first.
+func (s *SQLiteStore) ListTokens(ctx context.Context) ([]model.APIToken, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, name, scope, hash, created_at, last_used, revoked_at, workspace FROM api_tokens ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("query tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []model.APIToken
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// RevokeToken marks the token with the given ID as revoked.
+func (s *SQLiteStore) RevokeToken(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at = ''`,
+		time.Now().UTC().Format(time.RFC3339Nano), id)
+	if err != nil {
+		return false, fmt.Errorf("revoke token: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("revoke token: %w", err)
+	}
+	s.forgetActiveTokens()
+	return n > 0, nil
+}
+
+// activeTokensTTL is how long HasActiveTokens trusts its cached answer.
+// Creating or revoking a token through the store clears the cache at once;
+// the TTL covers tokens managed by another process, such as
+// 'dp serve token' against a running server's database.
+var activeTokensTTL = 5 * time.Second
+
+// HasActiveTokens reports whether any unrevoked token exists. The answer is
+// cached, since the server asks on every request without a token.
+func (s *SQLiteStore) HasActiveTokens(ctx context.Context) (bool, error) {
+	s.tokMu.Lock()
+	defer s.tokMu.Unlock()
+	if !s.tokChecked.IsZero() && time.Since(s.tokChecked) < activeTokensTTL {
+		return s.tokActive, nil
+	}
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM api_tokens WHERE revoked_at = ''`).Scan(&n); err != nil {
+		return false, fmt.Errorf("count tokens: %w", err)
+	}
+	s.tokActive, s.tokChecked = n > 0, time.Now()
+	return s.tokActive, nil
+}
+
+func (s *SQLiteStore) forgetActiveTokens() {
+	s.tokMu.Lock()
+	s.tokChecked = time.Time{}
+	s.tokMu.Unlock()
+}
+
+// AuthenticateToken looks up an active token by hash and stamps last_used.
+func (s *SQLiteStore) AuthenticateToken(ctx context.Context, hash string) (*model.APIToken, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, name, scope, hash, created_at, last_used, revoked_at, workspace FROM api_tokens WHERE hash = ? AND revoked_at = ''`,
+		hash)
+	t, err := scanToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE api_tokens SET last_used = ? WHERE id = ?`,
+		now.Format(time.RFC3339Nano), t.ID); err != nil {
+		return nil, fmt.Errorf("touch token: %w", err)
+	}
+	t.LastUsed = &now
+	return &t, nil
+}
+
+// scanToken reads an api_tokens row from a *sql.Row or *sql.Rows.
+func scanToken(sc interface{ Scan(...any) error }) (model.APIToken, error) {
+	var t model.APIToken
+	var createdAt, lastUsed, revokedAt string
+	if err := sc.Scan(&t.ID, &t.Name, &t.Scope, &t.Hash, &createdAt, &lastUsed, &revokedAt, &t.Workspace); err != nil {
+		if err == sql.ErrNoRows {
+			return t, err
+		}
+		return t, fmt.Errorf("scan token: %w", err)
+	}
+	t.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	t.LastUsed = parseOptionalTime(lastUsed)
+	t.RevokedAt = parseOptionalTime(revokedAt)
+	return t, nil
+}
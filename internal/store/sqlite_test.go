@@ -3,12 +3,16 @@ package store
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/scbrown/desire-path/internal/events"
 	"github.com/scbrown/desire-path/internal/model"
 )
 
@@ -583,6 +587,123 @@ func TestGetPathsEmpty(t *testing.T) {
 	}
 }
 
+func TestImpactScores(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return now.Add(time.Duration(-h) * time.Hour) }
+
+	var failures, successes []impactEvent
+	// runaway: 40 failures in one session, never recovered.
+	for i := 0; i < 40; i++ {
+		failures = append(failures, impactEvent{"runaway", "s1", at(40 - i)})
+	}
+	// spread: one failure in each of 15 sessions.
+	for i := 0; i < 15; i++ {
+		failures = append(failures, impactEvent{"spread", "s" + strconv.Itoa(i), at(1)})
+	}
+	// chained: 4 failures in one session, broken into 2+2 by a success.
+	for _, h := range []int{10, 9, 7, 6} {
+		failures = append(failures, impactEvent{"chained", "c", at(h)})
+	}
+	successes = append(successes, impactEvent{"chained", "c", at(8)}, impactEvent{"chained", "other", at(8)})
+	// anonymous: failures without a session each count as one.
+	failures = append(failures, impactEvent{"anon", "", at(1)}, impactEvent{"anon", "", at(1)})
+
+	w := ImpactWeights{Sessions: 1, Retries: 1, HalfLifeDays: 1000}
+	sessions, scores := impactScores(failures, successes, w, now)
+	if sessions["runaway"] != 1 || sessions["spread"] != 15 || sessions["chained"] != 1 || sessions["anon"] != 2 {
+		t.Errorf("sessions = %v", sessions)
+	}
+	if scores["spread"] <= scores["runaway"] {
+		t.Errorf("spread (%v) should outrank runaway (%v)", scores["spread"], scores["runaway"])
+	}
+	// Longest chain is 2, not 4: 1 + ln 2.
+	if got, want := scores["chained"], 1+math.Log(2); math.Abs(got-want) > 0.01 {
+		t.Errorf("chained = %v, want %v", got, want)
+	}
+
+	// Decay: a session a half-life ago counts half as much.
+	old := []impactEvent{{"t", "s", now.Add(-7 * 24 * time.Hour)}}
+	_, scores = impactScores(old, nil, ImpactWeights{Sessions: 1, HalfLifeDays: 7}, now)
+	if scores["t"] != 0.5 {
+		t.Errorf("decayed score = %v, want 0.5", scores["t"])
+	}
+
+	// Zero weights use the defaults.
+	_, scores = impactScores(old, nil, ImpactWeights{}, now)
+	if scores["t"] != 0.5 {
+		t.Errorf("default-weight score = %v, want 0.5", scores["t"])
+	}
+}
+
+func TestGetPathsSortImpact(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	// loop fails 6 times in one session; spread once in each of 3.
+	for i := 0; i < 6; i++ {
+		d := model.Desire{ID: "l" + strconv.Itoa(i), ToolName: "loop", SessionID: "s0", Error: "e", Timestamp: now.Add(-time.Duration(i) * time.Minute)}
+		if err := s.RecordDesire(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		d := model.Desire{ID: "s" + strconv.Itoa(i), ToolName: "spread", SessionID: "s" + strconv.Itoa(i), Error: "e", Timestamp: now}
+		if err := s.RecordDesire(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := s.GetPaths(ctx, PathOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths[0].Pattern != "loop" || paths[0].Sessions != 0 || paths[0].Impact != 0 {
+		t.Fatalf("by count without Score = %+v, want unscored", paths)
+	}
+
+	paths, err = s.GetPaths(ctx, PathOpts{Score: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths[0].Pattern != "loop" || paths[0].Sessions != 1 || paths[1].Sessions != 3 {
+		t.Fatalf("by count = %+v", paths)
+	}
+	if paths[0].Impact == 0 || paths[1].Impact <= paths[0].Impact {
+		t.Errorf("impact scores = %v, %v; spread should score higher", paths[0].Impact, paths[1].Impact)
+	}
+
+	// Scoring only the top path must give it the same score.
+	top, err := s.GetPaths(ctx, PathOpts{Score: true, Top: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].Sessions != paths[0].Sessions || top[0].Impact != paths[0].Impact {
+		t.Errorf("top 1 = %+v, want %+v", top, paths[0])
+	}
+
+	// A window covering every desire must not change the scores.
+	windowed, err := s.GetPaths(ctx, PathOpts{Sort: PathSortImpact, Since: now.Add(-24 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windowed) != 2 || windowed[0].Pattern != "spread" || windowed[0].Sessions != 3 || windowed[0].Impact != paths[1].Impact {
+		t.Errorf("with Since = %+v, want spread scored %v first", windowed, paths[1].Impact)
+	}
+
+	paths, err = s.GetPaths(ctx, PathOpts{Sort: PathSortImpact, Top: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0].Pattern != "spread" {
+		t.Errorf("by impact = %+v, want spread only", paths)
+	}
+
+	if _, err := s.GetPaths(ctx, PathOpts{Sort: "bogus"}); err == nil {
+		t.Error("expected error for unknown sort")
+	}
+}
+
 func TestGetAliasesEmpty(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
@@ -787,7 +908,7 @@ func TestDeleteAlias(t *testing.T) {
 		t.Fatalf("SetAlias: %v", err)
 	}
 
-	deleted, err := s.DeleteAlias(ctx, "read_file", "", "", "", "")
+	deleted, err := s.DeleteAlias(ctx, model.AliasKey{From: "read_file"})
 	if err != nil {
 		t.Fatalf("DeleteAlias: %v", err)
 	}
@@ -812,7 +933,7 @@ func TestGetAlias(t *testing.T) {
 		t.Fatalf("SetAlias: %v", err)
 	}
 
-	alias, err := s.GetAlias(ctx, "read_file", "", "", "", "")
+	alias, err := s.GetAlias(ctx, model.AliasKey{From: "read_file"})
 	if err != nil {
 		t.Fatalf("GetAlias: %v", err)
 	}
@@ -828,7 +949,7 @@ func TestGetAliasNotFound(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
-	alias, err := s.GetAlias(ctx, "nonexistent", "", "", "", "")
+	alias, err := s.GetAlias(ctx, model.AliasKey{From: "nonexistent"})
 	if err != nil {
 		t.Fatalf("GetAlias: %v", err)
 	}
@@ -841,7 +962,7 @@ func TestDeleteAliasNotFound(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
-	deleted, err := s.DeleteAlias(ctx, "nonexistent", "", "", "", "")
+	deleted, err := s.DeleteAlias(ctx, model.AliasKey{From: "nonexistent"})
 	if err != nil {
 		t.Fatalf("DeleteAlias: %v", err)
 	}
@@ -1104,5 +1225,453 @@ func TestRecordDesireCategoryRoundTrip(t *testing.T) {
 	}
 }
 
+func TestInterventionStats(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	ivs := []model.Intervention{
+		{ID: "iv-1", Kind: model.InterventionDeny, ToolName: "Bash", Rule: "rm -rf /", MatchKind: "deny", Timestamp: now.Add(-2 * time.Hour)},
+		{ID: "iv-2", Kind: model.InterventionDeny, ToolName: "Bash", Rule: "rm -rf /", MatchKind: "deny", Timestamp: now.Add(-time.Hour)},
+		{ID: "iv-3", Kind: model.InterventionCorrect, ToolName: "Bash", Rule: "grep", MatchKind: "command", Timestamp: now.Add(-48 * time.Hour)},
+	}
+	for _, iv := range ivs {
+		if err := s.RecordIntervention(ctx, iv); err != nil {
+			t.Fatalf("RecordIntervention: %v", err)
+		}
+	}
+
+	stats, err := s.InterventionStats(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("InterventionStats: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 groups, got %d: %+v", len(stats), stats)
+	}
+	if stats[0].Kind != model.InterventionDeny || stats[0].Count != 2 {
+		t.Errorf("first group: got %+v, want deny x2", stats[0])
+	}
+	if !stats[0].LastSeen.Equal(now.Add(-time.Hour)) {
+		t.Errorf("last seen: got %v, want %v", stats[0].LastSeen, now.Add(-time.Hour))
+	}
+
+	recent, err := s.InterventionStats(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("InterventionStats since: %v", err)
+	}
+	if len(recent) != 1 || recent[0].Kind != model.InterventionDeny {
+		t.Errorf("expected only deny group within 24h, got %+v", recent)
+	}
+}
+
+func TestRecordBatches(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bus := events.NewBus()
+	s.SetEventBus(bus)
+	ch, cancel := bus.Subscribe(events.Filter{})
+	defer cancel()
+
+	now := time.Now().UTC()
+	ds := []model.Desire{
+		{ID: "d-1", ToolName: "read_file", Error: "unknown tool", Timestamp: now},
+		{ID: "d-2", ToolName: "grep", Error: "unknown tool", Timestamp: now},
+	}
+	if err := s.RecordDesires(ctx, ds); err != nil {
+		t.Fatalf("RecordDesires: %v", err)
+	}
+	invs := []model.Invocation{
+		{ID: "inv-1", Source: "test", ToolName: "Read", Timestamp: now},
+		{ID: "inv-2", Source: "test", ToolName: "Bash", Timestamp: now},
+	}
+	if err := s.RecordInvocations(ctx, invs); err != nil {
+		t.Fatalf("RecordInvocations: %v", err)
+	}
+	if err := s.RecordInvocations(ctx, nil); err != nil {
+		t.Fatalf("RecordInvocations(nil): %v", err)
+	}
+	if len(ch) != 4 {
+		t.Errorf("published %d events, want 4", len(ch))
+	}
+
+	// IDs already stored are skipped, so replaying a batch is safe.
+	replay := []model.Invocation{
+		{ID: "inv-3", Source: "test", ToolName: "Read", Timestamp: now},
+		{ID: "inv-1", Source: "test", ToolName: "Read", Timestamp: now},
+	}
+	if err := s.RecordInvocations(ctx, replay); err != nil {
+		t.Fatalf("RecordInvocations replay: %v", err)
+	}
+	if err := s.RecordDesire(ctx, ds[0]); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("RecordDesire with a stored ID: err = %v, want ErrDuplicateID", err)
+	}
+	if len(ch) != 5 {
+		t.Errorf("published %d events after replay, want 5", len(ch))
+	}
+
+	gotD, err := s.ListDesires(ctx, ListOpts{})
+	if err != nil || len(gotD) != 2 {
+		t.Errorf("desires = %d (%v), want 2", len(gotD), err)
+	}
+	gotI, err := s.ListInvocations(ctx, InvocationOpts{})
+	if err != nil || len(gotI) != 3 {
+		t.Errorf("invocations = %d (%v), want 3", len(gotI), err)
+	}
+}
+
+func TestSourceToolCounts(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	for i, d := range []model.Desire{
+		{ToolName: "read_file", Source: "claude-code"},
+		{ToolName: "read_file", Source: "claude-code"},
+		{ToolName: "grep"},
+	} {
+		d.ID = fmt.Sprintf("d-%d", i)
+		d.Error = "unknown tool"
+		d.Timestamp = now
+		if err := s.RecordDesire(ctx, d); err != nil {
+			t.Fatalf("RecordDesire: %v", err)
+		}
+	}
+	for i, inv := range []model.Invocation{
+		{ToolName: "Bash", Source: "claude-code"},
+		{ToolName: "Bash", Source: "claude-code", IsError: true},
+		{ToolName: "read_file", Source: "claude-code", IsError: true},
+	} {
+		inv.ID = fmt.Sprintf("inv-%d", i)
+		inv.Timestamp = now
+		if err := s.RecordInvocation(ctx, inv); err != nil {
+			t.Fatalf("RecordInvocation: %v", err)
+		}
+	}
+
+	got, err := s.SourceToolCounts(ctx)
+	if err != nil {
+		t.Fatalf("SourceToolCounts: %v", err)
+	}
+	want := []SourceToolCount{
+		{Source: "", ToolName: "grep", Desires: 1},
+		{Source: "claude-code", ToolName: "Bash", Invocations: 2, InvocationErrors: 1},
+		{Source: "claude-code", ToolName: "read_file", Desires: 2, Invocations: 1, InvocationErrors: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSetAliasScope(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	exp := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := model.Alias{
+		From: "make", To: "just", Tool: "Bash", Param: "command", Command: "make", MatchKind: "command",
+		ScopeCWD: "/src/app", ScopeRemote: "acme/app", ScopeSource: "claude-code", ExpiresAt: &exp,
+	}
+	if err := s.SetAlias(ctx, in); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+
+	got, err := s.GetAlias(ctx, in.Key())
+	if err != nil {
+		t.Fatalf("GetAlias: %v", err)
+	}
+	if got == nil {
+		t.Fatal("expected alias, got nil")
+	}
+	if got.ScopeCWD != "/src/app" || got.ScopeRemote != "acme/app" || got.ScopeSource != "claude-code" {
+		t.Errorf("scope: got %+v", got)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(exp) {
+		t.Errorf("expires_at: got %v, want %v", got.ExpiresAt, exp)
+	}
+
+	rules, err := s.GetRulesForTool(ctx, "Bash")
+	if err != nil {
+		t.Fatalf("GetRulesForTool: %v", err)
+	}
+	if len(rules) != 1 || rules[0].ScopeCWD != "/src/app" || rules[0].ExpiresAt == nil {
+		t.Errorf("rules: got %+v", rules)
+	}
+
+	// Unscoped aliases read back with no scope.
+	if err := s.SetAlias(ctx, model.Alias{From: "read_file", To: "Read"}); err != nil {
+		t.Fatalf("SetAlias: %v", err)
+	}
+	plain, err := s.GetAlias(ctx, model.AliasKey{From: "read_file"})
+	if err != nil {
+		t.Fatalf("GetAlias: %v", err)
+	}
+	if plain.IsScoped() {
+		t.Errorf("expected unscoped alias, got %+v", plain)
+	}
+}
+
+func TestSetAliasScopedVariants(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	just := model.Alias{
+		From: "make", To: "just", Tool: "Bash", Param: "command", Command: "make", MatchKind: "command",
+		ScopeRemote: "acme/app",
+	}
+	task := just
+	task.To, task.ScopeRemote = "task", "acme/tools"
+	for _, a := range []model.Alias{just, task} {
+		if err := s.SetAlias(ctx, a); err != nil {
+			t.Fatalf("SetAlias: %v", err)
+		}
+	}
+
+	rules, err := s.GetRulesForTool(ctx, "Bash")
+	if err != nil {
+		t.Fatalf("GetRulesForTool: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2: %+v", len(rules), rules)
+	}
+	for _, want := range []model.Alias{just, task} {
+		got, err := s.GetAlias(ctx, want.Key())
+		if err != nil {
+			t.Fatalf("GetAlias: %v", err)
+		}
+		if got == nil || got.To != want.To {
+			t.Errorf("GetAlias(%s): got %+v, want To=%s", want.ScopeRemote, got, want.To)
+		}
+	}
+	if got, _ := s.GetAlias(ctx, just.Key().Unscoped()); got != nil {
+		t.Errorf("unscoped key: got %+v, want nil", got)
+	}
+
+	deleted, err := s.DeleteAlias(ctx, just.Key())
+	if err != nil || !deleted {
+		t.Fatalf("DeleteAlias: %v, %v", deleted, err)
+	}
+	if got, _ := s.GetAlias(ctx, task.Key()); got == nil || got.To != "task" {
+		t.Errorf("other variant after delete: got %+v", got)
+	}
+}
+
+func TestGetPathsScopedAliasVariants(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for _, a := range []model.Alias{
+		{From: "read_file", To: "View", ScopeSource: "cursor"},
+		{From: "read_file", To: "Read"},
+	} {
+		if err := s.SetAlias(ctx, a); err != nil {
+			t.Fatalf("SetAlias: %v", err)
+		}
+	}
+	for _, id := range []string{"d1", "d2"} {
+		if err := s.RecordDesire(ctx, model.Desire{ID: id, ToolName: "read_file", Error: "unknown tool", Timestamp: time.Now()}); err != nil {
+			t.Fatalf("RecordDesire: %v", err)
+		}
+	}
+
+	paths, err := s.GetPaths(ctx, PathOpts{})
+	if err != nil {
+		t.Fatalf("GetPaths: %v", err)
+	}
+	if len(paths) != 1 || paths[0].Count != 2 || paths[0].AliasTo != "Read" {
+		t.Errorf("paths = %+v, want read_file counted once with the unscoped alias", paths)
+	}
+}
+
+func TestGetToolAliases(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for _, a := range []model.Alias{
+		{From: "read_file", To: "Read"},
+		{From: "read_file", To: "View", ScopeSource: "cursor"},
+		{From: "write_file", To: "Write"},
+		{From: "read_file", To: "x", Tool: "Bash", Param: "command", Command: "read_file", MatchKind: "command"},
+	} {
+		if err := s.SetAlias(ctx, a); err != nil {
+			t.Fatalf("SetAlias: %v", err)
+		}
+	}
+	got, err := s.GetToolAliases(ctx, "read_file")
+	if err != nil {
+		t.Fatalf("GetToolAliases: %v", err)
+	}
+	if len(got) != 2 || got[0].To != "Read" || got[1].To != "View" {
+		t.Errorf("got %+v, want the unscoped and cursor read_file aliases", got)
+	}
+}
+
+func TestAPITokens(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	tok := model.APIToken{ID: "t1", Name: "alice-laptop", Scope: model.ScopeWrite, Hash: "abc", CreatedAt: time.Now()}
+	if err := s.CreateToken(ctx, tok); err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	if err := s.CreateToken(ctx, model.APIToken{ID: "t2", Name: "x", Scope: model.ScopeRead}); err == nil {
+		t.Error("expected error creating token without hash")
+	}
+
+	got, err := s.AuthenticateToken(ctx, "abc")
+	if err != nil {
+		t.Fatalf("AuthenticateToken: %v", err)
+	}
+	if got == nil || got.Name != "alice-laptop" || got.Scope != model.ScopeWrite || got.LastUsed == nil {
+		t.Fatalf("AuthenticateToken = %+v", got)
+	}
+	if got, _ := s.AuthenticateToken(ctx, "nope"); got != nil {
+		t.Errorf("unknown hash authenticated: %+v", got)
+	}
+
+	ok, err := s.RevokeToken(ctx, "t1")
+	if err != nil || !ok {
+		t.Fatalf("RevokeToken = %v, %v", ok, err)
+	}
+	if ok, _ := s.RevokeToken(ctx, "t1"); ok {
+		t.Error("revoking twice should report false")
+	}
+	if got, _ := s.AuthenticateToken(ctx, "abc"); got != nil {
+		t.Errorf("revoked token authenticated: %+v", got)
+	}
+
+	tokens, err := s.ListTokens(ctx)
+	if err != nil {
+		t.Fatalf("ListTokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].RevokedAt == nil || tokens[0].LastUsed == nil {
+		t.Errorf("ListTokens = %+v", tokens)
+	}
+}
+
+func TestHasActiveTokens(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	s, err := New(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	if ok, err := s.HasActiveTokens(ctx); err != nil || ok {
+		t.Fatalf("no tokens: HasActiveTokens = %v, %v", ok, err)
+	}
+	// Creating and revoking through the store clear the cached answer.
+	tok := model.APIToken{ID: "t1", Name: "ci", Scope: model.ScopeRead, Hash: "abc", CreatedAt: time.Now()}
+	if err := s.CreateToken(ctx, tok); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.HasActiveTokens(ctx); !ok {
+		t.Error("after CreateToken: HasActiveTokens = false")
+	}
+	if _, err := s.RevokeToken(ctx, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.HasActiveTokens(ctx); ok {
+		t.Error("after RevokeToken: HasActiveTokens = true")
+	}
+
+	// A token created by another process is seen once the cache expires.
+	other, err := New(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := other.CreateToken(ctx, model.APIToken{ID: "t2", Name: "cli", Scope: model.ScopeRead, Hash: "def", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.HasActiveTokens(ctx); ok {
+		t.Error("within TTL: HasActiveTokens = true, want the cached false")
+	}
+	defer func(d time.Duration) { activeTokensTTL = d }(activeTokensTTL)
+	activeTokensTTL = 0
+	if ok, _ := s.HasActiveTokens(ctx); !ok {
+		t.Error("after TTL: HasActiveTokens = false")
+	}
+}
+
+func TestClientIDRoundTrip(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := s.RecordDesire(ctx, model.Desire{ID: "d1", ToolName: "read_file", Timestamp: now, ClientID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordInvocation(ctx, model.Invocation{ID: "i1", Source: "test", ToolName: "Read", Timestamp: now, ClientID: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	desires, err := s.ListDesires(ctx, ListOpts{})
+	if err != nil || len(desires) != 1 || desires[0].ClientID != "alice" {
+		t.Errorf("desires = %+v, %v", desires, err)
+	}
+	invs, err := s.ListInvocations(ctx, InvocationOpts{})
+	if err != nil || len(invs) != 1 || invs[0].ClientID != "bob" {
+		t.Errorf("invocations = %+v, %v", invs, err)
+	}
+}
+
+func TestPathTrends(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	until := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	// Windows of 2 days: current is [Mar 6, Mar 8), previous is [Mar 4, Mar 6).
+	for i, d := range []struct {
+		tool string
+		ago  time.Duration
+	}{
+		{"read_file", time.Hour},       // current, bucket 1
+		{"read_file", time.Hour},       // current, bucket 1
+		{"read_file", day + time.Hour}, // current, bucket 0
+		{"read_file", 3 * day},         // previous
+		{"grep", time.Hour},            // current only: new
+		{"old_tool", 3 * day},          // previous only: gone
+		{"flat", time.Hour},            // one in each window
+		{"flat", 3 * day},
+		{"ancient", 10 * day},  // before both windows
+		{"future", -time.Hour}, // after until
+	} {
+		desire := model.Desire{ID: fmt.Sprintf("d%d", i), ToolName: d.tool, Error: "x", Timestamp: until.Add(-d.ago)}
+		if err := s.RecordDesire(ctx, desire); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := s.PathTrends(ctx, TrendOpts{Buckets: 2, Until: until})
+	if err != nil {
+		t.Fatalf("PathTrends: %v", err)
+	}
+	if res.Bucket != BucketDay || !res.Start.Equal(until.Add(-2*day)) || !res.PreviousStart.Equal(until.Add(-4*day)) {
+		t.Errorf("window = %s %s..%s", res.Bucket, res.PreviousStart, res.Start)
+	}
+	var got []string
+	for _, p := range res.Paths {
+		got = append(got, fmt.Sprintf("%s:%v:%d:%+d:%s", p.Pattern, p.Counts, p.Previous, p.Delta, p.Status))
+	}
+	want := "[read_file:[1 2]:1:+2:up grep:[0 1]:0:+1:new flat:[0 1]:1:+0:flat old_tool:[0 0]:1:-1:gone]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("paths =\n%v\nwant\n%s", got, want)
+	}
+
+	res, err = s.PathTrends(ctx, TrendOpts{Buckets: 2, Until: until, Top: 2})
+	if err != nil || len(res.Paths) != 2 {
+		t.Errorf("top 2: %+v, %v", res, err)
+	}
+	if _, err := s.PathTrends(ctx, TrendOpts{Bucket: "month"}); err == nil {
+		t.Error("unknown bucket should fail")
+	}
+}
+
 // Verify SQLiteStore satisfies the Store interface at compile time.
 var _ Store = (*SQLiteStore)(nil)
@@ -3,34 +3,56 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/scbrown/desire-path/internal/model"
 )
 
+// ErrDuplicateID is returned (wrapped) when a single record is written with
+// an ID that is already stored.
+var ErrDuplicateID = errors.New("duplicate ID")
+
 // Store is the persistence interface for desires, paths, and aliases.
 type Store interface {
-	// RecordDesire persists a single failed tool call.
+	// RecordDesire persists a single failed tool call. It returns an error
+	// wrapping ErrDuplicateID if a desire with the same ID exists.
 	RecordDesire(ctx context.Context, d model.Desire) error
 
-	// ListDesires returns desires matching the given filter options.
+	// RecordDesires persists a batch of desires atomically: either all are
+	// stored or none are. Desires whose ID is already stored are skipped,
+	// so a batch can safely be sent again.
+	RecordDesires(ctx context.Context, ds []model.Desire) error
+
+	// ListDesires returns desires matching the given filter options, newest
+	// first.
 	ListDesires(ctx context.Context, opts ListOpts) ([]model.Desire, error)
 
 	// GetPaths returns aggregated desire patterns ranked by frequency.
 	GetPaths(ctx context.Context, opts PathOpts) ([]model.Path, error)
 
+	// PathTrends compares each path's desires in the current window with
+	// the window before it, bucket by bucket.
+	PathTrends(ctx context.Context, opts TrendOpts) (*TrendResult, error)
+
 	// SetAlias creates or updates an alias or parameter correction rule.
 	SetAlias(ctx context.Context, alias model.Alias) error
 
 	// GetAlias returns a single alias by its composite key, or nil if not found.
-	// For tool-name aliases, pass empty strings for tool, param, command, matchKind.
-	GetAlias(ctx context.Context, from, tool, param, command, matchKind string) (*model.Alias, error)
+	// For tool-name aliases, leave Tool, Param, Command and MatchKind empty;
+	// for unscoped aliases, leave the scope fields empty.
+	GetAlias(ctx context.Context, key model.AliasKey) (*model.Alias, error)
+
+	// GetToolAliases returns the tool-name aliases for from, one per scope.
+	GetToolAliases(ctx context.Context, from string) ([]model.Alias, error)
 
 	// GetAliases returns all configured aliases and parameter correction rules.
 	GetAliases(ctx context.Context) ([]model.Alias, error)
 
 	// DeleteAlias removes an alias by its composite key. Returns true if deleted.
-	DeleteAlias(ctx context.Context, from, tool, param, command, matchKind string) (bool, error)
+	DeleteAlias(ctx context.Context, key model.AliasKey) (bool, error)
 
 	// GetRulesForTool returns all parameter correction rules for a specific tool.
 	// Only returns rules where Tool is non-empty (not tool-name aliases).
@@ -45,6 +67,11 @@ type Store interface {
 	// RecordInvocation persists a single tool invocation.
 	RecordInvocation(ctx context.Context, inv model.Invocation) error
 
+	// RecordInvocations persists a batch of invocations atomically: either
+	// all are stored or none are. Invocations whose ID is already stored
+	// are skipped, so a batch can safely be sent again.
+	RecordInvocations(ctx context.Context, invs []model.Invocation) error
+
 	// ListInvocations returns invocations matching the given filter options.
 	ListInvocations(ctx context.Context, opts InvocationOpts) ([]model.Invocation, error)
 
@@ -65,11 +92,19 @@ type Store interface {
 	DetectAndRecordRecovery(ctx context.Context, inv model.Invocation) error
 
 	// ListRecoveries returns recovery events, optionally filtered by time.
-	ListRecoveries(ctx context.Context, since time.Time, limit int) ([]model.Recovery, error)
+	ListRecoveries(ctx context.Context, opts RecoveryOpts) ([]model.Recovery, error)
 
 	// RecoveryStats returns aggregated recovery counts per tool.
 	RecoveryStats(ctx context.Context) ([]model.RecoveryStat, error)
 
+	// TrackRetryChain folds an invocation, called with the given tool
+	// input, into its session's retry chains: a failure starts or extends
+	// a chain and a success closes it as recovered.
+	TrackRetryChain(ctx context.Context, inv model.Invocation, input json.RawMessage) error
+
+	// ListRetryChains returns retry chains, newest first.
+	ListRetryChains(ctx context.Context, opts ChainOpts) ([]model.RetryChain, error)
+
 	// SetDocMapping creates or updates a doc mapping.
 	SetDocMapping(ctx context.Context, dm model.DocMapping) error
 
@@ -88,6 +123,31 @@ type Store interface {
 	// StrugglingTools returns tools with high failure rates.
 	StrugglingTools(ctx context.Context, opts StrugglingOpts) ([]model.StrugglingTool, error)
 
+	// RecordIntervention persists a single pave-check action (block, correct, deny).
+	RecordIntervention(ctx context.Context, iv model.Intervention) error
+
+	// InterventionStats returns intervention counts per kind and tool,
+	// optionally filtered by time.
+	InterventionStats(ctx context.Context, since time.Time) ([]model.InterventionStat, error)
+
+	// SourceToolCounts returns desire and invocation totals grouped by
+	// source and tool name.
+	SourceToolCounts(ctx context.Context) ([]SourceToolCount, error)
+
+	// CreateToken persists a new API token. The token's Hash must be set.
+	CreateToken(ctx context.Context, t model.APIToken) error
+
+	// ListTokens returns all API tokens, including revoked ones, newest first.
+	ListTokens(ctx context.Context) ([]model.APIToken, error)
+
+	// RevokeToken revokes the token with the given ID. Returns true if an
+	// active token was revoked.
+	RevokeToken(ctx context.Context, id string) (bool, error)
+
+	// AuthenticateToken returns the active token with the given hash and
+	// records its use, or nil if no active token matches.
+	AuthenticateToken(ctx context.Context, hash string) (*model.APIToken, error)
+
 	// Close releases any resources held by the store.
 	Close() error
 }
@@ -98,13 +158,101 @@ type ListOpts struct {
 	Source   string    // Filter by source (e.g., "claude-code").
 	ToolName string    // Filter by tool name.
 	Category string    // Filter by category (e.g., "env-need").
+	Model    string    // Filter by model (e.g., "claude-sonnet-4-5").
 	Limit    int       // Maximum results; 0 means no limit.
+	Cursor   string    // Continue after this position (see DesireCursor).
 }
 
 // PathOpts controls filtering for GetPaths.
 type PathOpts struct {
-	Top   int       // Maximum paths to return; 0 means no limit.
-	Since time.Time // Only aggregate desires after this time.
+	Top     int           // Maximum paths to return; 0 means no limit.
+	Since   time.Time     // Only aggregate desires after this time.
+	Sort    string        // PathSortCount (the default) or PathSortImpact.
+	Weights ImpactWeights // Impact score weights; zero means DefaultImpactWeights.
+	Score   bool          // Fill in Sessions and Impact even when sorting by count.
+}
+
+// Path orderings for GetPaths.
+const (
+	PathSortCount  = "count"  // most desires first
+	PathSortImpact = "impact" // highest impact score first
+)
+
+// ImpactWeights tunes the impact score GetPaths gives each path. Every
+// session that hit the path adds
+//
+//	decay × (Sessions + Retries × ln(longest retry chain))
+//
+// where decay halves every HalfLifeDays since the session's last failure,
+// and a retry chain is a run of failures in one session not broken by a
+// successful call to the same tool. A path hit once in each of many
+// sessions therefore outranks one that a single runaway session hit many
+// times.
+type ImpactWeights struct {
+	Sessions     float64 `json:"sessions"`
+	Retries      float64 `json:"retries"`
+	HalfLifeDays float64 `json:"half_life_days"`
+}
+
+// DefaultImpactWeights are used when PathOpts.Weights is zero.
+var DefaultImpactWeights = ImpactWeights{Sessions: 1, Retries: 0.5, HalfLifeDays: 7}
+
+// Trend buckets for PathTrends.
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+	BucketWeek = "week"
+)
+
+// Trend statuses compare a path's current window with the previous one.
+const (
+	TrendNew  = "new"  // no desires in the previous window
+	TrendGone = "gone" // no desires in the current window
+	TrendUp   = "up"
+	TrendDown = "down"
+	TrendFlat = "flat"
+)
+
+// TrendOpts controls PathTrends. The current window is the Buckets buckets
+// ending at Until; the previous window is the same length before it.
+type TrendOpts struct {
+	Bucket  string    // "hour", "day", or "week"; empty means "day".
+	Buckets int       // Buckets per window; 0 means 7.
+	Until   time.Time // End of the current window; zero means now.
+	Top     int       // Maximum paths, biggest growth first; 0 means no limit.
+}
+
+// BucketSize returns the length of the named trend bucket.
+func BucketSize(bucket string) (time.Duration, error) {
+	switch bucket {
+	case BucketHour:
+		return time.Hour, nil
+	case BucketDay, "":
+		return 24 * time.Hour, nil
+	case BucketWeek:
+		return 7 * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("invalid bucket %q: use hour, day, or week", bucket)
+}
+
+// TrendResult holds PathTrends output.
+type TrendResult struct {
+	Bucket        string      `json:"bucket"`
+	Buckets       int         `json:"buckets"`
+	PreviousStart time.Time   `json:"previous_start"` // start of the previous window
+	Start         time.Time   `json:"start"`          // start of the current window
+	Until         time.Time   `json:"until"`
+	Paths         []PathTrend `json:"paths"`
+}
+
+// PathTrend is one path's desire counts in the current and previous window.
+type PathTrend struct {
+	Pattern  string `json:"pattern"`
+	Counts   []int  `json:"counts"` // per bucket of the current window, oldest first
+	Current  int    `json:"current"`
+	Previous int    `json:"previous"`
+	Delta    int    `json:"delta"`  // Current - Previous
+	Status   string `json:"status"` // "new", "gone", "up", "down", or "flat"
 }
 
 // NameCount pairs a name (tool name or source) with its occurrence count.
@@ -122,14 +270,15 @@ type InspectOpts struct {
 
 // InspectResult holds detailed inspection data for a tool name pattern.
 type InspectResult struct {
-	Pattern   string      `json:"pattern"`
-	Total     int         `json:"total"`
-	FirstSeen time.Time   `json:"first_seen"`
-	LastSeen  time.Time   `json:"last_seen"`
-	AliasTo   string      `json:"alias_to,omitempty"`
-	Histogram []DateCount `json:"histogram"`
-	TopInputs []NameCount `json:"top_inputs"`
-	TopErrors []NameCount `json:"top_errors"`
+	Pattern   string        `json:"pattern"`
+	Total     int           `json:"total"`
+	FirstSeen time.Time     `json:"first_seen"`
+	LastSeen  time.Time     `json:"last_seen"`
+	AliasTo   string        `json:"alias_to,omitempty"`
+	Histogram []DateCount   `json:"histogram"`
+	TopInputs []NameCount   `json:"top_inputs"`
+	TopErrors []NameCount   `json:"top_errors"`
+	Chains    *ChainSummary `json:"chains,omitempty"`
 }
 
 // DateCount pairs a date string with a count for histogram display.
@@ -157,8 +306,10 @@ type InvocationOpts struct {
 	Source     string    // Filter by source plugin name.
 	InstanceID string    // Filter by instance ID.
 	ToolName   string    // Filter by tool name.
+	Model      string    // Filter by model.
 	ErrorsOnly bool      // Only return invocations with errors.
 	Limit      int       // Maximum results; 0 means no limit.
+	Cursor     string    // Continue after this position (see InvocationCursor).
 }
 
 // InvocationStatsResult holds summary statistics about stored invocations.
@@ -174,6 +325,15 @@ type InvocationStatsResult struct {
 	Latest      time.Time      `json:"latest"`
 }
 
+// SourceToolCount holds all-time totals for one source and tool name.
+type SourceToolCount struct {
+	Source           string `json:"source"`
+	ToolName         string `json:"tool_name"`
+	Desires          int    `json:"desires"`
+	Invocations      int    `json:"invocations"`
+	InvocationErrors int    `json:"invocation_errors"`
+}
+
 // TurnOpts controls filtering for turn-related queries.
 type TurnOpts struct {
 	MinLength int       // Minimum turn length (tool call count).
@@ -181,6 +341,42 @@ type TurnOpts struct {
 	SessionID string    // Filter by session ID.
 	Pattern   string    // Filter by abstract pattern (e.g. "Grep → Read{2+} → Edit").
 	Limit     int       // Maximum results; 0 means no limit.
+	Cursor    string    // ListTurns only: continue after this position (see TurnCursor).
+}
+
+// RecoveryOpts controls filtering for ListRecoveries.
+type RecoveryOpts struct {
+	Since  time.Time // Only recoveries after this time.
+	Limit  int       // Maximum results; 0 means no limit.
+	Cursor string    // Continue after this position (see RecoveryCursor).
+}
+
+// ChainOpts controls filtering for ListRetryChains.
+type ChainOpts struct {
+	Since     time.Time // Only chains started after this time.
+	ToolName  string    // Filter by tool name.
+	SessionID string    // Filter by session.
+	Outcome   string    // Filter by outcome (model.ChainOpen, ChainRecovered, or ChainAbandoned).
+	Limit     int       // Maximum results; 0 means no limit.
+	Cursor    string    // Continue after this position (see ChainCursor).
+}
+
+// ChainTrackRequest is the body of POST /api/v1/chains/track: an invocation
+// and the tool input it was called with.
+type ChainTrackRequest struct {
+	Invocation model.Invocation `json:"invocation"`
+	ToolInput  json.RawMessage  `json:"tool_input,omitempty"`
+}
+
+// ChainSummary aggregates the retry chains of an inspected pattern.
+type ChainSummary struct {
+	Total         int         `json:"total"`
+	Recovered     int         `json:"recovered"`
+	Abandoned     int         `json:"abandoned"`
+	Open          int         `json:"open"`
+	AvgAttempts   float64     `json:"avg_attempts"`
+	AvgRecoveryMs int64       `json:"avg_recovery_ms"`     // mean elapsed time of recovered chains
+	TopFixes      []NameCount `json:"top_fixes,omitempty"` // most common input diffs that recovered
 }
 
 // TurnRow represents a single turn with its tool sequence.
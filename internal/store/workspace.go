@@ -0,0 +1,114 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/scbrown/desire-path/internal/model"
+)
+
+// ErrNoWorkspace is returned (wrapped) when a workspace has not been created.
+var ErrNoWorkspace = errors.New("no such workspace")
+
+// Workspaces manages the databases of the workspaces dp serve hosts besides
+// its default one. Each workspace is a separate SQLite file, dir/<name>.db,
+// so workspaces share nothing: not desires, aliases, nor doc mappings.
+type Workspaces struct {
+	dir string
+}
+
+// NewWorkspaces returns a Workspaces that keeps its databases in dir. The
+// directory is created with the first workspace.
+func NewWorkspaces(dir string) *Workspaces {
+	return &Workspaces{dir: dir}
+}
+
+// Dir returns the workspace directory.
+func (w *Workspaces) Dir() string {
+	return w.dir
+}
+
+// WorkspaceInfo describes a workspace database.
+type WorkspaceInfo struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+func (w *Workspaces) path(name string) string {
+	return filepath.Join(w.dir, name+".db")
+}
+
+// checkName rejects names that cannot be workspaces. The default workspace
+// is the server's own database and is not managed here.
+func checkName(name string) error {
+	if name == model.DefaultWorkspace {
+		return fmt.Errorf("workspace %q is the server's own database", name)
+	}
+	if !model.ValidWorkspace(name) {
+		return fmt.Errorf("invalid workspace name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// Create creates the database for a new workspace.
+func (w *Workspaces) Create(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if _, err := os.Stat(w.path(name)); err == nil {
+		return fmt.Errorf("workspace %q already exists", name)
+	}
+	if err := os.MkdirAll(w.dir, 0o700); err != nil {
+		return fmt.Errorf("create workspace dir: %w", err)
+	}
+	s, err := New(w.path(name))
+	if err != nil {
+		return fmt.Errorf("create workspace %q: %w", name, err)
+	}
+	return s.Close()
+}
+
+// Open opens the database of an existing workspace. It returns an error
+// wrapping ErrNoWorkspace if the workspace has not been created.
+func (w *Workspaces) Open(name string) (*SQLiteStore, error) {
+	if err := checkName(name); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoWorkspace, err)
+	}
+	if _, err := os.Stat(w.path(name)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %q", ErrNoWorkspace, name)
+		}
+		return nil, fmt.Errorf("open workspace %q: %w", name, err)
+	}
+	return New(w.path(name))
+}
+
+// List returns the created workspaces, sorted by name.
+func (w *Workspaces) List() ([]WorkspaceInfo, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read workspace dir: %w", err)
+	}
+	var out []WorkspaceInfo
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".db")
+		if !ok || e.IsDir() || checkName(name) != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // removed meanwhile
+		}
+		out = append(out, WorkspaceInfo{Name: name, Path: w.path(name), Bytes: info.Size()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
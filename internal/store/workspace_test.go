@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspacesCreateListOpen(t *testing.T) {
+	ws := NewWorkspaces(filepath.Join(t.TempDir(), "workspaces"))
+
+	list, err := ws.List()
+	if err != nil || len(list) != 0 {
+		t.Fatalf("List before any workspace = %v, %v", list, err)
+	}
+	if _, err := ws.Open("team-a"); !errors.Is(err, ErrNoWorkspace) {
+		t.Fatalf("Open missing workspace: err = %v, want ErrNoWorkspace", err)
+	}
+
+	for _, name := range []string{"team-b", "team-a"} {
+		if err := ws.Create(name); err != nil {
+			t.Fatalf("Create(%q): %v", name, err)
+		}
+	}
+	if err := ws.Create("team-a"); err == nil {
+		t.Error("creating an existing workspace should fail")
+	}
+
+	list, err = ws.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "team-a" || list[1].Name != "team-b" {
+		t.Fatalf("List = %+v, want team-a, team-b", list)
+	}
+	if list[0].Bytes == 0 || list[0].Path != filepath.Join(ws.Dir(), "team-a.db") {
+		t.Errorf("team-a = %+v", list[0])
+	}
+
+	s, err := ws.Open("team-a")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	if _, err := s.ListDesires(context.Background(), ListOpts{}); err != nil {
+		t.Errorf("ListDesires on workspace: %v", err)
+	}
+}
+
+func TestWorkspacesInvalidNames(t *testing.T) {
+	ws := NewWorkspaces(t.TempDir())
+	for _, name := range []string{"", "default", "Team", "-a", "a/b", "../x", "a b"} {
+		if err := ws.Create(name); err == nil {
+			t.Errorf("Create(%q) should fail", name)
+		}
+		if _, err := ws.Open(name); !errors.Is(err, ErrNoWorkspace) {
+			t.Errorf("Open(%q): err = %v, want ErrNoWorkspace", name, err)
+		}
+	}
+}
+
+func TestRemoteWorkspaceEndpoint(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.Path)
+		json.NewEncoder(w).Encode([]any{})
+	}))
+	t.Cleanup(ts.Close)
+
+	r := NewRemote(ts.URL)
+	ctx := context.Background()
+	r.ListDesires(ctx, ListOpts{})
+	r.SetWorkspace("team-a")
+	r.ListDesires(ctx, ListOpts{})
+	r.SetWorkspace("default")
+	r.ListDesires(ctx, ListOpts{})
+
+	want := []string{"/api/v1/desires", "/api/v1/w/team-a/desires", "/api/v1/desires"}
+	if len(got) != len(want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("path %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+	r.SetWorkspace("team-a")
+	if r.target() != ts.URL+"/api/v1/w/team-a" {
+		t.Errorf("target = %q", r.target())
+	}
+}
@@ -18,6 +18,7 @@ type Turn struct {
 	StartedAt  time.Time
 	DurationMs int       // from turn_duration system event, 0 if absent
 	Steps      []Step    // tool calls in execution order
+	Usage      Usage     // all assistant messages in the turn, with or without tool calls
 }
 
 // Step represents one tool invocation within a turn.
@@ -29,6 +30,52 @@ type Step struct {
 	IsParallel bool            // true if fired concurrently with adjacent steps
 	IsError    bool
 	Error      string
+	Usage      Usage  // share of the usage of the assistant message that made the call
+	Model      string // model of the assistant message that made the call
+	StartedAt  time.Time
+	DurationMs int // from the tool_use to its tool_result, 0 if unknown
+}
+
+// Usage counts the tokens of one or more API responses.
+type Usage struct {
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+	CacheReadTokens  int `json:"cache_read_input_tokens"`
+	CacheWriteTokens int `json:"cache_creation_input_tokens"`
+}
+
+// Total returns the number of tokens of every kind in u.
+func (u Usage) Total() int {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// Add returns the sum of u and v.
+func (u Usage) Add(v Usage) Usage {
+	return Usage{
+		InputTokens:      u.InputTokens + v.InputTokens,
+		OutputTokens:     u.OutputTokens + v.OutputTokens,
+		CacheReadTokens:  u.CacheReadTokens + v.CacheReadTokens,
+		CacheWriteTokens: u.CacheWriteTokens + v.CacheWriteTokens,
+	}
+}
+
+// split divides u into n shares that add up to u, the first share taking
+// any remainder.
+func (u Usage) split(n int) []Usage {
+	shares := make([]Usage, n)
+	for i := range shares {
+		shares[i] = Usage{
+			InputTokens:      u.InputTokens / n,
+			OutputTokens:     u.OutputTokens / n,
+			CacheReadTokens:  u.CacheReadTokens / n,
+			CacheWriteTokens: u.CacheWriteTokens / n,
+		}
+	}
+	shares[0].InputTokens += u.InputTokens % n
+	shares[0].OutputTokens += u.OutputTokens % n
+	shares[0].CacheReadTokens += u.CacheReadTokens % n
+	shares[0].CacheWriteTokens += u.CacheWriteTokens % n
+	return shares
 }
 
 // event is the minimal JSONL event shape we need for parsing.
@@ -50,8 +97,11 @@ type event struct {
 
 // messageEnvelope is the shape of the message field on user/assistant events.
 type messageEnvelope struct {
+	ID      string            `json:"id,omitempty"`
+	Model   string            `json:"model,omitempty"`
 	Role    string            `json:"role"`
 	Content json.RawMessage   `json:"content"`
+	Usage   *Usage            `json:"usage,omitempty"`
 }
 
 // contentBlock represents one block in an assistant message's content array.
@@ -129,6 +179,7 @@ func Parse(r io.Reader) ([]Turn, error) {
 			if currentTurn == nil {
 				continue
 			}
+			msgID, msgModel := currentTurn.recordMessage(e)
 			// Check for tool_use content blocks.
 			block, err := extractToolUse(e)
 			if err != nil || block == nil {
@@ -140,6 +191,9 @@ func Parse(r io.Reader) ([]Turn, error) {
 				input:     block.Input,
 				parentUUID: parentOf(e),
 				uuid:      e.UUID,
+				messageID: msgID,
+				model:     msgModel,
+				startedAt: e.Timestamp,
 			}
 			currentTurn.steps = append(currentTurn.steps, step)
 
@@ -161,11 +215,11 @@ func Parse(r io.Reader) ([]Turn, error) {
 		turns = append(turns, currentTurn.build(sessionID, turnIndex))
 	}
 
-	// Enrich steps with error info from tool results.
+	// Enrich steps with error and timing info from tool results.
 	for ti := range turns {
 		for si := range turns[ti].Steps {
 			step := &turns[ti].Steps[si]
-			enrichStepError(step, toolResults)
+			enrichStepResult(step, toolResults)
 		}
 	}
 
@@ -177,6 +231,37 @@ type turnBuilder struct {
 	startedAt  time.Time
 	durationMs int
 	steps      []pendingStep
+
+	// usage holds each assistant message's usage by message ID, in the
+	// order the messages first appeared.
+	usage    map[string]Usage
+	messages []string
+}
+
+// recordMessage notes the usage of assistant event e and returns its
+// message ID and model. Claude Code writes one event per content block, each
+// repeating the message's usage, so a message is counted once with its
+// latest usage. Events without a message ID are their own message.
+func (tb *turnBuilder) recordMessage(e *event) (id, model string) {
+	var env messageEnvelope
+	if json.Unmarshal(e.Message, &env) != nil {
+		return e.UUID, ""
+	}
+	id = env.ID
+	if id == "" {
+		id = e.UUID
+	}
+	if env.Usage == nil {
+		return id, env.Model
+	}
+	if tb.usage == nil {
+		tb.usage = make(map[string]Usage)
+	}
+	if _, ok := tb.usage[id]; !ok {
+		tb.messages = append(tb.messages, id)
+	}
+	tb.usage[id] = *env.Usage
+	return id, env.Model
 }
 
 // pendingStep holds step data before finalization.
@@ -186,6 +271,9 @@ type pendingStep struct {
 	input      json.RawMessage
 	parentUUID string
 	uuid       string
+	messageID  string
+	model      string
+	startedAt  time.Time
 }
 
 func (tb *turnBuilder) build(sessionID string, index int) Turn {
@@ -196,6 +284,8 @@ func (tb *turnBuilder) build(sessionID string, index int) Turn {
 			ToolUseID: ps.toolUseID,
 			Input:     ps.input,
 			Sequence:  i,
+			Model:     ps.model,
+			StartedAt: ps.startedAt,
 		}
 	}
 
@@ -203,12 +293,29 @@ func (tb *turnBuilder) build(sessionID string, index int) Turn {
 	// (no intervening tool_result) are parallel.
 	detectParallelism(tb.steps, steps)
 
+	// Split each message's usage among the tool calls it made.
+	calls := make(map[string][]int)
+	for i, ps := range tb.steps {
+		calls[ps.messageID] = append(calls[ps.messageID], i)
+	}
+	var total Usage
+	for _, id := range tb.messages {
+		u := tb.usage[id]
+		total = total.Add(u)
+		if idx := calls[id]; len(idx) > 0 {
+			for j, share := range u.split(len(idx)) {
+				steps[idx[j]].Usage = share
+			}
+		}
+	}
+
 	return Turn{
 		SessionID:  sessionID,
 		Index:      index,
 		StartedAt:  tb.startedAt,
 		DurationMs: tb.durationMs,
 		Steps:      steps,
+		Usage:      total,
 	}
 }
 
@@ -226,8 +333,9 @@ func detectParallelism(pending []pendingStep, steps []Step) {
 	}
 }
 
-// enrichStepError looks up the tool_result for a step and sets IsError/Error.
-func enrichStepError(step *Step, toolResults map[string]*event) {
+// enrichStepResult looks up the tool_result for a step and sets IsError,
+// Error and DurationMs.
+func enrichStepResult(step *Step, toolResults map[string]*event) {
 	// The toolResults map is keyed by sourceToolAssistantUUID → result event.
 	// Each tool_result user event contains a tool_use_id linking it back to
 	// the original tool_use. We scan all results to find the matching one.
@@ -242,6 +350,9 @@ func enrichStepError(step *Step, toolResults map[string]*event) {
 					step.IsError = true
 					step.Error = block.Content
 				}
+				if !step.StartedAt.IsZero() && resultEvt.Timestamp.After(step.StartedAt) {
+					step.DurationMs = int(resultEvt.Timestamp.Sub(step.StartedAt).Milliseconds())
+				}
 				return
 			}
 		}
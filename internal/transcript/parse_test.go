@@ -359,3 +359,52 @@ func TestParseStepInput(t *testing.T) {
 		t.Errorf("Input %s should contain file_path", got)
 	}
 }
+
+func TestParseUsage(t *testing.T) {
+	f := mustOpen(t, "testdata/usage.jsonl")
+	turns, err := Parse(f)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(turns) != 1 {
+		t.Fatalf("got %d turns, want 1", len(turns))
+	}
+	turn := turns[0]
+	if len(turn.Steps) != 3 {
+		t.Fatalf("got %d steps, want 3", len(turn.Steps))
+	}
+
+	for i, step := range turn.Steps {
+		if step.Model != "claude-opus-4-6" {
+			t.Errorf("step %d Model = %q, want claude-opus-4-6", i, step.Model)
+		}
+		// From the tool_use to its tool_result.
+		if want := []int{1000, 1000, 2000}[i]; step.DurationMs != want {
+			t.Errorf("step %d DurationMs = %d, want %d", i, step.DurationMs, want)
+		}
+	}
+
+	// msg_01 is counted once, with the usage of its last event.
+	want := Usage{InputTokens: 10, OutputTokens: 120, CacheWriteTokens: 2000}
+	if got := turn.Steps[0].Usage; got != want {
+		t.Errorf("step 0 Usage = %+v, want %+v", got, want)
+	}
+	// msg_02 made two calls, which share its usage.
+	want0 := Usage{InputTokens: 2, OutputTokens: 41, CacheReadTokens: 1005, CacheWriteTokens: 50}
+	want1 := Usage{InputTokens: 1, OutputTokens: 40, CacheReadTokens: 1005, CacheWriteTokens: 50}
+	if got := turn.Steps[1].Usage; got != want0 {
+		t.Errorf("step 1 Usage = %+v, want %+v", got, want0)
+	}
+	if got := turn.Steps[2].Usage; got != want1 {
+		t.Errorf("step 2 Usage = %+v, want %+v", got, want1)
+	}
+
+	// The turn includes the final text-only message.
+	wantTurn := Usage{InputTokens: 15, OutputTokens: 205, CacheReadTokens: 4210, CacheWriteTokens: 2100}
+	if turn.Usage != wantTurn {
+		t.Errorf("turn Usage = %+v, want %+v", turn.Usage, wantTurn)
+	}
+	if turn.Usage.Total() != 6530 {
+		t.Errorf("turn Usage.Total() = %d, want 6530", turn.Usage.Total())
+	}
+}